@@ -8,6 +8,7 @@ import (
 	"userclouds.com/infra/logtransports"
 	"userclouds.com/infra/namespace/region"
 	"userclouds.com/infra/namespace/universe"
+	"userclouds.com/infra/secret/provider"
 	"userclouds.com/infra/service"
 	"userclouds.com/infra/ucdb"
 	"userclouds.com/infra/ucerr"
@@ -28,6 +29,7 @@ type Config struct {
 	FeatureFlagConfig *featureflags.Config `yaml:"featureflags,omitempty" json:"featureflags,omitempty" validate:"allownil"`
 	Sentry            *ucsentry.Config     `yaml:"sentry,omitempty" json:"sentry,omitempty" validate:"allownil"`
 	Tracing           *uctrace.Config      `yaml:"tracing,omitempty" json:"tracing,omitempty" validate:"allownil"`
+	Secrets           *provider.Config     `yaml:"secrets,omitempty" json:"secrets,omitempty" validate:"allownil"`
 
 	// CheckAttribute specific config
 	CheckAttributeServiceMap map[uuid.UUID][]RegionalCheckAttributeConfig `yaml:"check_attribute_service_map,omitempty" json:"check_attribute_service_map,omitempty"` // for authz service to read
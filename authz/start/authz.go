@@ -7,6 +7,7 @@ import (
 	"userclouds.com/authz/routes"
 	"userclouds.com/infra/logtransports"
 	serviceNamespace "userclouds.com/infra/namespace/service"
+	"userclouds.com/infra/secret"
 	"userclouds.com/infra/service"
 	"userclouds.com/infra/uclog"
 	_ "userclouds.com/internal/apiclient/routing" // turn on localhost routing for jsonclient
@@ -23,6 +24,9 @@ func RunAuthZ() {
 	if err != nil {
 		uclog.Fatalf(ctx, "LoadAuthzConfig failed: %v", err)
 	}
+	if err := secret.Configure(cfg.Secrets); err != nil {
+		uclog.Fatalf(ctx, "failed to configure the secret provider: %v", err)
+	}
 
 	ts, err := m2m.GetM2MTokenSource(ctx, cfg.ConsoleTenantID)
 	if err != nil {
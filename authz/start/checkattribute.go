@@ -12,6 +12,7 @@ import (
 	"userclouds.com/infra/logtransports"
 	"userclouds.com/infra/namespace/region"
 	serviceNamespace "userclouds.com/infra/namespace/service"
+	"userclouds.com/infra/secret"
 	"userclouds.com/infra/service"
 	"userclouds.com/infra/uclog"
 	_ "userclouds.com/internal/apiclient/routing" // turn on localhost routing for jsonclient
@@ -34,6 +35,9 @@ func RunCheckAttribute() {
 	if err != nil {
 		uclog.Fatalf(ctx, "LoadCheckAttributeConfig failed: %v", err)
 	}
+	if err := secret.Configure(cfg.Secrets); err != nil {
+		uclog.Fatalf(ctx, "failed to configure the secret provider: %v", err)
+	}
 
 	ts, err := m2m.GetM2MTokenSource(ctx, cfg.ConsoleTenantID)
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 
 	"userclouds.com/infra/namespace/universe"
+	ucsecret "userclouds.com/infra/secret"
 	"userclouds.com/infra/ucerr"
 	"userclouds.com/infra/uclog"
 )
@@ -56,6 +57,11 @@ func (sc *secretCopier) getSecretName(secret types.SecretListEntry) (string, err
 	if !sc.renameSecrets {
 		return sn, nil
 	}
+	if p, err := ucsecret.ParsePath(sn); err == nil && string(p.Universe) == sc.sourceAccount {
+		p.Universe = sc.targetAccount
+		return p.String(), nil
+	}
+	// fall back to a plain substitution for secrets that predate the userclouds/<universe> layout
 	newName := strings.Replace(sn, string(sc.sourceAccount), string(sc.targetAccount), 1)
 	if newName == sn {
 		return "", ucerr.Errorf("failed to rename secret %s", sn)
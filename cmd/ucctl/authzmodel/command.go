@@ -0,0 +1,222 @@
+package authzmodel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"userclouds.com/authz"
+	"userclouds.com/cmd/ucctl/config"
+	"userclouds.com/cmd/ucctl/synctenant"
+	"userclouds.com/infra/jsonclient"
+	"userclouds.com/infra/logtransports"
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/uclog"
+)
+
+const (
+	DefaultClientSecretVar = "UC_CLIENT_SECRET"
+
+	CompileUsage = "compile"
+	CompileShort = "Compile an RBAC model into authz types and apply them"
+	CompileLong  = `Compile an RBAC model (-f) of resource types, roles and permissions into authz object types
+and edge types, and insert the ones missing from the tenant.  Types that already exist with the
+same name are reused.  With --dry-run the compiled types are printed instead of applied.
+
+Example model:
+
+  resource_types:
+  - name: folder
+    roles:
+    - name: viewer
+      permissions: [read]
+    - name: editor
+      permissions: [write]
+      includes: [viewer]
+  - name: document
+    parent: folder
+    roles:
+    - name: viewer
+      permissions: [read]`
+)
+
+// Command holds the options shared by all of the authz subcommands.
+type Command struct {
+	URL             string
+	ClientID        string
+	ClientSecretVar string
+	Verbose         bool
+}
+
+// CompileCommand compiles an RBAC model and applies it to a tenant.
+type CompileCommand struct {
+	*Command
+	File   string
+	DryRun bool
+}
+
+// CompileCommand returns the compile subcommand.
+func (c *Command) CompileCommand() *cobra.Command {
+	cc := &CompileCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   CompileUsage,
+		Short: CompileShort,
+		Long:  CompileLong,
+		Args:  cobra.NoArgs,
+		RunE:  cc.RunE,
+	}
+
+	cmd.Flags().StringVarP(&cc.File, "file", "f", "", "model definition file")
+	cmd.Flags().BoolVarP(&cc.DryRun, "dry-run", "", false, "print the compiled types instead of applying them")
+	return cmd
+}
+
+func (c *CompileCommand) RunE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	logLevel := uclog.LogLevelInfo
+	if c.Verbose {
+		logLevel = uclog.LogLevelDebug
+	}
+
+	logtransports.InitLoggerAndTransportsForTools(ctx, logLevel, logLevel, "authz-compile")
+	defer logtransports.Close()
+
+	if err := c.compile(ctx, cmd); err != nil {
+		uclog.Errorf(ctx, "%v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *CompileCommand) compile(ctx context.Context, cmd *cobra.Command) error {
+	if c.File == "" {
+		return fmt.Errorf("model file is required")
+	}
+
+	bs, err := os.ReadFile(c.File)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", c.File, err)
+	}
+
+	var model Model
+	if err := yaml.UnmarshalStrict(bs, &model); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", c.File, err)
+	}
+
+	compiled, err := model.Compile()
+	if err != nil {
+		return fmt.Errorf("failed to compile %s: %v", c.File, err)
+	}
+
+	if c.DryRun {
+		out, err := yaml.Marshal(compiled)
+		if err != nil {
+			return fmt.Errorf("failed to marshal compiled model: %v", err)
+		}
+
+		_, err = cmd.OutOrStdout().Write(out)
+		return err
+	}
+
+	azc, err := c.authzClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	dst := synctenant.NewResources()
+	if err := dst.GetTypes(ctx, azc); err != nil {
+		return fmt.Errorf("failed to get authz types from %s: %v", c.URL, err)
+	}
+
+	desired := synctenant.NewResources()
+	for _, ot := range compiled.ObjectTypes {
+		desired.AddObjectType(ot)
+	}
+	for _, et := range compiled.EdgeTypes {
+		desired.AddEdgeType(et)
+	}
+	desired.MatchTypesByName(dst)
+
+	insert := synctenant.NewResources()
+	insert.Diff(ctx, desired, dst)
+	if err := insert.Insert(ctx, azc); err != nil {
+		return fmt.Errorf("failed to apply authz types to %s: %v", c.URL, err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Applied %d object types and %d edge types\n", len(compiled.ObjectTypes), len(compiled.EdgeTypes))
+	return nil
+}
+
+// authzClient returns an authz client for the tenant, filling in the connection options
+// that weren't set by flags from the current context.
+func (c *Command) authzClient(ctx context.Context) (*authz.Client, error) {
+	url, clientID, clientSecret := c.URL, c.ClientID, os.Getenv(c.ClientSecretVar)
+	var client *http.Client
+	var opts []jsonclient.Option
+	if uc := config.CurrentContext(ctx); uc != nil {
+		// the context's connection settings only apply to its tenant
+		if url == "" || url == uc.URL {
+			var err error
+			if client, opts, err = uc.ClientOptions(); err != nil {
+				return nil, err
+			}
+		}
+
+		// the context's cached tokens are used when connecting to its tenant with its client
+		if (url == "" || url == uc.URL) && (clientID == "" || clientID == uc.ClientID) {
+			ts := config.FromContext(ctx).TokenSource(ctx, clientSecret)
+			return authz.NewClient(uc.URL, append(organizationOptions(ctx), authz.JSONClient(append(opts, jsonclient.TokenSource(ts))...))...)
+		}
+
+		if url == "" {
+			url = uc.URL
+		}
+
+		if clientID == "" {
+			clientID = uc.ClientID
+		}
+
+		if clientSecret == "" {
+			s, err := uc.ResolveClientSecret(ctx)
+			if err != nil {
+				return nil, err
+			}
+			clientSecret = s
+		}
+	}
+
+	if url == "" || clientID == "" || clientSecret == "" {
+		return nil, fmt.Errorf("tenant URL, client id and client secret are required")
+	}
+
+	tokenURL, err := config.TokenURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, jsonclient.TokenSource(oidc.ClientCredentialsTokenSource{
+		TokenURL:     tokenURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		HTTPClient:   client,
+	}))
+
+	return authz.NewClient(url, append(organizationOptions(ctx), authz.JSONClient(opts...))...)
+}
+
+// organizationOptions returns the authz options scoping API calls to the organization of
+// --as-organization, if it is set.
+func organizationOptions(ctx context.Context) []authz.Option {
+	orgID := config.AsOrganization(ctx)
+	if orgID.IsNil() {
+		return nil
+	}
+
+	return []authz.Option{authz.OrganizationID(orgID)}
+}
@@ -0,0 +1,212 @@
+package authzmodel
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/authz"
+	"userclouds.com/infra/ucdb"
+)
+
+// idNamespace is used to derive stable IDs from type names so that compiling the same
+// model twice produces the same object and edge types.
+var idNamespace = uuid.Must(uuid.FromString("4f0a4c61-8d1e-4a44-9a3c-3d6b8e6f0d52"))
+
+// Model is a role based access control model.  Each resource type has a set of roles,
+// and each role grants a set of permissions on resources of that type to the subjects
+// that hold it.
+type Model struct {
+	// SubjectType is the object type that is granted roles, defaulting to _user.
+	SubjectType   string         `json:"subject_type"`
+	ResourceTypes []ResourceType `json:"resource_types"`
+}
+
+// ResourceType is a type of resource that roles are granted on.
+type ResourceType struct {
+	Name string `json:"name"`
+
+	// Parent is the resource type that contains resources of this type.  Permissions
+	// held on the parent that are also defined on this type propagate to the children.
+	Parent string `json:"parent,omitempty"`
+	Roles  []Role `json:"roles"`
+}
+
+// Role grants permissions on a resource type.  A role also grants the permissions of
+// the roles it includes.
+type Role struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions,omitempty"`
+	Includes    []string `json:"includes,omitempty"`
+}
+
+// Compiled is the set of authz types generated from a model.
+type Compiled struct {
+	ObjectTypes []authz.ObjectType `json:"object_types"`
+	EdgeTypes   []authz.EdgeType   `json:"edge_types"`
+}
+
+// Compile generates the object types and edge types for the model.  Each resource type
+// becomes an object type, each role becomes an edge type from the subject type to the
+// resource type with a direct attribute per permission, and each parent becomes an edge
+// type from the parent to the child that propagates the shared permissions.
+func (m Model) Compile() (*Compiled, error) {
+	if len(m.ResourceTypes) == 0 {
+		return nil, fmt.Errorf("model has no resource types")
+	}
+
+	var compiled Compiled
+
+	subjectTypeID := authz.UserObjectTypeID
+	if m.SubjectType != "" && m.SubjectType != authz.ObjectTypeUser {
+		subjectTypeID = typeID("object", m.SubjectType)
+		compiled.ObjectTypes = append(compiled.ObjectTypes, authz.ObjectType{
+			BaseModel: ucdb.NewBaseWithID(subjectTypeID),
+			TypeName:  m.SubjectType,
+		})
+	}
+
+	resourceTypes := map[string]ResourceType{}
+	for _, rt := range m.ResourceTypes {
+		if rt.Name == "" {
+			return nil, fmt.Errorf("resource type name can't be empty")
+		}
+
+		if _, ok := resourceTypes[rt.Name]; ok || rt.Name == m.SubjectType {
+			return nil, fmt.Errorf("duplicate resource type %s", rt.Name)
+		}
+
+		resourceTypes[rt.Name] = rt
+		compiled.ObjectTypes = append(compiled.ObjectTypes, authz.ObjectType{
+			BaseModel: ucdb.NewBaseWithID(typeID("object", rt.Name)),
+			TypeName:  rt.Name,
+		})
+	}
+
+	for _, rt := range m.ResourceTypes {
+		permissions, err := rt.permissions()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, role := range rt.Roles {
+			name := fmt.Sprintf("%s_%s", rt.Name, role.Name)
+			var attrs authz.Attributes
+			for _, p := range permissions[role.Name] {
+				attrs = append(attrs, authz.Attribute{Name: p, Direct: true})
+			}
+
+			compiled.EdgeTypes = append(compiled.EdgeTypes, authz.EdgeType{
+				BaseModel:          ucdb.NewBaseWithID(typeID("edge", name)),
+				TypeName:           name,
+				SourceObjectTypeID: subjectTypeID,
+				TargetObjectTypeID: typeID("object", rt.Name),
+				Attributes:         attrs,
+			})
+		}
+
+		if rt.Parent == "" {
+			continue
+		}
+
+		parent, ok := resourceTypes[rt.Parent]
+		if !ok {
+			return nil, fmt.Errorf("resource type %s has unknown parent %s", rt.Name, rt.Parent)
+		}
+
+		parentPermissions, err := parent.permissions()
+		if err != nil {
+			return nil, err
+		}
+
+		var attrs authz.Attributes
+		for _, p := range allPermissions(parentPermissions) {
+			if slices.Contains(allPermissions(permissions), p) {
+				attrs = append(attrs, authz.Attribute{Name: p, Propagate: true})
+			}
+		}
+
+		name := fmt.Sprintf("%s_contains_%s", parent.Name, rt.Name)
+		compiled.EdgeTypes = append(compiled.EdgeTypes, authz.EdgeType{
+			BaseModel:          ucdb.NewBaseWithID(typeID("edge", name)),
+			TypeName:           name,
+			SourceObjectTypeID: typeID("object", parent.Name),
+			TargetObjectTypeID: typeID("object", rt.Name),
+			Attributes:         attrs,
+		})
+	}
+
+	return &compiled, nil
+}
+
+// permissions returns the permissions granted by each role, including the permissions
+// of included roles.
+func (rt ResourceType) permissions() (map[string][]string, error) {
+	roles := map[string]Role{}
+	for _, role := range rt.Roles {
+		if role.Name == "" {
+			return nil, fmt.Errorf("resource type %s has a role with no name", rt.Name)
+		}
+
+		if _, ok := roles[role.Name]; ok {
+			return nil, fmt.Errorf("resource type %s has duplicate role %s", rt.Name, role.Name)
+		}
+
+		roles[role.Name] = role
+	}
+
+	permissions := map[string][]string{}
+	var resolve func(name string, visiting []string) ([]string, error)
+	resolve = func(name string, visiting []string) ([]string, error) {
+		if perms, ok := permissions[name]; ok {
+			return perms, nil
+		}
+
+		if slices.Contains(visiting, name) {
+			return nil, fmt.Errorf("resource type %s has a cycle in role %s", rt.Name, name)
+		}
+
+		role, ok := roles[name]
+		if !ok {
+			return nil, fmt.Errorf("resource type %s has unknown role %s", rt.Name, name)
+		}
+
+		perms := slices.Clone(role.Permissions)
+		for _, included := range role.Includes {
+			includedPerms, err := resolve(included, append(visiting, name))
+			if err != nil {
+				return nil, err
+			}
+			perms = append(perms, includedPerms...)
+		}
+
+		slices.Sort(perms)
+		perms = slices.Compact(perms)
+		permissions[name] = perms
+		return perms, nil
+	}
+
+	for _, role := range rt.Roles {
+		if _, err := resolve(role.Name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return permissions, nil
+}
+
+// allPermissions returns the sorted set of permissions granted by any role.
+func allPermissions(permissions map[string][]string) []string {
+	var all []string
+	for _, perms := range permissions {
+		all = append(all, perms...)
+	}
+
+	slices.Sort(all)
+	return slices.Compact(all)
+}
+
+func typeID(kind, name string) uuid.UUID {
+	return uuid.NewV5(idNamespace, fmt.Sprintf("%s/%s", kind, name))
+}
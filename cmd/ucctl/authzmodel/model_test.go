@@ -0,0 +1,74 @@
+package authzmodel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/authz"
+)
+
+func TestModel_Compile(t *testing.T) {
+	model := Model{
+		ResourceTypes: []ResourceType{
+			{
+				Name: "folder",
+				Roles: []Role{
+					{Name: "viewer", Permissions: []string{"read"}},
+					{Name: "editor", Permissions: []string{"write"}, Includes: []string{"viewer"}},
+				},
+			},
+			{
+				Name:   "document",
+				Parent: "folder",
+				Roles:  []Role{{Name: "viewer", Permissions: []string{"read"}}},
+			},
+		},
+	}
+
+	compiled, err := model.Compile()
+	assert.NoError(t, err)
+	assert.Len(t, compiled.ObjectTypes, 2)
+	assert.Len(t, compiled.EdgeTypes, 4)
+
+	edgeTypes := map[string]authz.EdgeType{}
+	for _, et := range compiled.EdgeTypes {
+		edgeTypes[et.TypeName] = et
+	}
+
+	editor := edgeTypes["folder_editor"]
+	assert.Equal(t, authz.UserObjectTypeID, editor.SourceObjectTypeID)
+	assert.Equal(t, authz.Attributes{{Name: "read", Direct: true}, {Name: "write", Direct: true}}, editor.Attributes)
+
+	contains := edgeTypes["folder_contains_document"]
+	assert.Equal(t, typeID("object", "folder"), contains.SourceObjectTypeID)
+	assert.Equal(t, typeID("object", "document"), contains.TargetObjectTypeID)
+	assert.Equal(t, authz.Attributes{{Name: "read", Propagate: true}}, contains.Attributes)
+
+	again, err := model.Compile()
+	assert.NoError(t, err)
+	assert.Equal(t, compiled, again, "compiling should be deterministic")
+}
+
+func TestModel_CompileErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		model Model
+	}{
+		{"no resource types", Model{}},
+		{"unknown parent", Model{ResourceTypes: []ResourceType{{Name: "doc", Parent: "folder"}}}},
+		{"unknown role", Model{ResourceTypes: []ResourceType{{Name: "doc", Roles: []Role{{Name: "a", Includes: []string{"b"}}}}}}},
+		{"role cycle", Model{ResourceTypes: []ResourceType{{Name: "doc", Roles: []Role{
+			{Name: "a", Includes: []string{"b"}},
+			{Name: "b", Includes: []string{"a"}},
+		}}}}},
+		{"duplicate resource type", Model{ResourceTypes: []ResourceType{{Name: "doc"}, {Name: "doc"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.model.Compile()
+			assert.Error(t, err)
+		})
+	}
+}
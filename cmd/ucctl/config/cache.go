@@ -0,0 +1,46 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	DefaultCacheDir = "cache"
+)
+
+// cachedNames is a list of names cached for a context.
+type cachedNames struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Names     []string  `json:"names"`
+}
+
+// CachedNames returns a list of names for a context, such as the object types of its
+// tenant used to complete a command line.  The result of fetch is cached next to the
+// config file and reused for up to ttl, so that repeated completions stay fast.  The
+// cache is best effort, failing to read or write it only means fetch is called.
+func CachedNames(configPath, contextName, kind string, ttl time.Duration, fetch func() ([]string, error)) ([]string, error) {
+	path := filepath.Join(filepath.Dir(PrimaryPath(configPath)), DefaultCacheDir, contextName, kind+".json")
+
+	if bs, err := os.ReadFile(path); err == nil {
+		var cached cachedNames
+		if err := json.Unmarshal(bs, &cached); err == nil && time.Since(cached.FetchedAt) < ttl {
+			return cached.Names, nil
+		}
+	}
+
+	names, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	if bs, err := json.Marshal(cachedNames{FetchedAt: time.Now().UTC(), Names: names}); err == nil {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+			_ = os.WriteFile(path, bs, 0600)
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_CachedNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	fetches := 0
+	fetch := func() ([]string, error) {
+		fetches++
+		return []string{"_user", "_group"}, nil
+	}
+
+	names, err := CachedNames(path, "prod", "object_types", time.Minute, fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"_user", "_group"}, names)
+
+	names, err = CachedNames(path, "prod", "object_types", time.Minute, fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"_user", "_group"}, names)
+	assert.Equal(t, 1, fetches)
+
+	// other contexts and expired entries are fetched again
+	_, err = CachedNames(path, "staging", "object_types", time.Minute, fetch)
+	assert.NoError(t, err)
+	_, err = CachedNames(path, "prod", "object_types", 0, fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, fetches)
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret"
+)
+
+func TestConfig_ClientSecret(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("TEST_UC_CLIENT_SECRET", "from-env")
+
+	cfg := &Config{}
+	cfg.SetContext(Context{Name: "prod", URL: "https://prod.example.com", ClientID: "id", ClientSecret: *secret.FromLocation("env://TEST_UC_CLIENT_SECRET")})
+	cfg.SetContext(Context{Name: "legacy", ClientSecret: *secret.FromLocation("plaintext")})
+	cfg.SetContext(Context{Name: "none"})
+	assert.NoError(t, cfg.Save(path))
+
+	bs, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), "client_secret: env://TEST_UC_CLIENT_SECRET")
+
+	loaded, err := Load(path)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"prod", "from-env"},
+		{"legacy", "plaintext"},
+		{"none", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, err := loaded.Context(tt.name)
+			assert.NoError(t, err)
+
+			s, err := uc.ResolveClientSecret(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.secret, s)
+		})
+	}
+}
+
+func TestConfig_RenameContext(t *testing.T) {
+	cfg := &Config{CurrentContext: "prod"}
+	cfg.SetContext(Context{Name: "prod", URL: "https://prod.example.com"})
+	cfg.SetContext(Context{Name: "staging"})
+
+	assert.Error(t, cfg.RenameContext("missing", "other"))
+	assert.Error(t, cfg.RenameContext("prod", "staging"))
+	assert.Error(t, cfg.RenameContext("prod", ""))
+
+	assert.NoError(t, cfg.RenameContext("prod", "production"))
+	assert.Equal(t, "production", cfg.CurrentContext)
+	uc, err := cfg.Context("production")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://prod.example.com", uc.URL)
+	_, err = cfg.Context("prod")
+	assert.Error(t, err)
+
+	assert.NoError(t, cfg.RenameContext("staging", "stage"))
+	assert.Equal(t, "production", cfg.CurrentContext)
+}
+
+func TestConfig_LoadPathList(t *testing.T) {
+	dir := t.TempDir()
+	personal := filepath.Join(dir, "personal.yaml")
+	shared := filepath.Join(dir, "shared.yaml")
+	paths := personal + string(filepath.ListSeparator) + shared
+
+	assert.NoError(t, os.WriteFile(shared, []byte(`current_context: prod
+defaults:
+  min_ucctl_version: v1.2.0
+contexts:
+- name: prod
+  url: https://prod.example.com
+  client_id: shared
+- name: staging
+  url: https://staging.example.com
+  client_id: shared
+`), 0600))
+	assert.NoError(t, os.WriteFile(personal, []byte(`contexts:
+- name: staging
+  url: https://staging.example.com
+  client_id: mine
+- name: dev
+  url: http://localhost
+  client_id: dev
+`), 0600))
+
+	cfg, err := Load(paths)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", cfg.CurrentContext)
+	assert.Equal(t, "v1.2.0", cfg.Defaults.MinUCCTLVersion)
+	assert.Len(t, cfg.Contexts, 3)
+
+	// the first file wins
+	staging, err := cfg.Context("staging")
+	assert.NoError(t, err)
+	assert.Equal(t, "mine", staging.ClientID)
+
+	assert.Error(t, cfg.DeleteContext("prod"))
+	assert.Error(t, cfg.RenameContext("prod", "production"))
+
+	// only the settings that differ from the shared file are saved to the personal file
+	cfg.CurrentContext = "dev"
+	cfg.SetContext(Context{Name: "local", URL: "http://localhost:3333"})
+	assert.NoError(t, cfg.Save(paths))
+
+	personalOnly, err := Load(personal)
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", personalOnly.CurrentContext)
+	assert.Empty(t, personalOnly.Defaults.MinUCCTLVersion)
+	var names []string
+	for _, uc := range personalOnly.Contexts {
+		names = append(names, uc.Name)
+	}
+	assert.Equal(t, []string{"staging", "dev", "local"}, names)
+
+	assert.Equal(t, TokenPath(personal, "prod"), TokenPath(paths, "prod"))
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration that is written to the config file as a string such as
+// "30s" or "1m30s".
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(bs []byte) error {
+	var s string
+	if err := json.Unmarshal(bs, &s); err != nil {
+		return fmt.Errorf("invalid duration %s, expected a string like 30s: %v", bs, err)
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %s: %v", s, err)
+	}
+
+	if v < 0 {
+		return fmt.Errorf("invalid duration %s, must not be negative", s)
+	}
+
+	*d = Duration(v)
+	return nil
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+const (
+	// PassphraseVar is the environment variable holding the passphrase of an encrypted
+	// config file.  Without it, the passphrase is prompted for on the terminal.
+	PassphraseVar = "UC_CONFIG_PASSPHRASE"
+
+	// encryptedHeader starts an encrypted config file, followed by the base64 encoded
+	// salt, nonce and ciphertext.
+	encryptedHeader = "# ucctl encrypted config v1\n"
+
+	saltSize = 16
+	keySize  = 32
+
+	// scrypt parameters recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// encryption holds the key an encrypted config file is saved with.
+type encryption struct {
+	salt []byte
+	key  []byte
+}
+
+// newEncryption derives a key from the passphrase.  A new salt is generated if salt is nil.
+func newEncryption(passphrase string, salt []byte) (*encryption, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase is required")
+	}
+
+	if salt == nil {
+		salt = make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %v", err)
+		}
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+
+	return &encryption{salt: salt, key: key}, nil
+}
+
+func (e *encryption) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(e.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (e *encryption) encrypt(plaintext []byte) ([]byte, error) {
+	aead, err := e.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	sealed := append(append([]byte{}, e.salt...), nonce...)
+	sealed = aead.Seal(sealed, nonce, plaintext, nil)
+
+	var out bytes.Buffer
+	out.WriteString(encryptedHeader)
+	out.WriteString(base64.StdEncoding.EncodeToString(sealed))
+	out.WriteString("\n")
+	return out.Bytes(), nil
+}
+
+// isEncrypted returns true if bs is an encrypted config file.
+func isEncrypted(bs []byte) bool {
+	return bytes.HasPrefix(bs, []byte(encryptedHeader))
+}
+
+// decrypt decrypts an encrypted config file, returning the encryption to save it with.
+func decrypt(path string, bs []byte, passphrase string) ([]byte, *encryption, error) {
+	sealed, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(bs[len(encryptedHeader):])))
+	if err != nil || len(sealed) < saltSize {
+		return nil, nil, fmt.Errorf("%s is not a valid encrypted config", path)
+	}
+
+	e, err := newEncryption(passphrase, sealed[:saltSize])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aead, err := e.aead()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sealed = sealed[saltSize:]
+	if len(sealed) < aead.NonceSize() {
+		return nil, nil, fmt.Errorf("%s is not a valid encrypted config", path)
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt %s, the passphrase is wrong", path)
+	}
+
+	return plaintext, e, nil
+}
+
+// Encrypted returns true if the config is saved encrypted.
+func (c *Config) Encrypted() bool {
+	return c.encryption != nil
+}
+
+// Encrypt makes the config be saved encrypted with a key derived from the passphrase.
+func (c *Config) Encrypt(passphrase string) error {
+	e, err := newEncryption(passphrase, nil)
+	if err != nil {
+		return err
+	}
+
+	c.encryption = e
+	return nil
+}
+
+// Decrypt makes the config be saved in plaintext.
+func (c *Config) Decrypt() {
+	c.encryption = nil
+}
+
+// ReadPassphrase returns the passphrase from UC_CONFIG_PASSPHRASE, or prompts for it on
+// the terminal.
+func ReadPassphrase(prompt string) (string, error) {
+	if v := os.Getenv(PassphraseVar); v != "" {
+		return v, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("%s must be set when stdin is not a terminal", PassphraseVar)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: ", prompt)
+	bs, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %v", err)
+	}
+
+	return string(bs), nil
+}
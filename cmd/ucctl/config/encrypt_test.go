@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret"
+)
+
+func TestConfig_Encrypt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv(PassphraseVar, "correct horse")
+
+	cfg := &Config{CurrentContext: "prod"}
+	cfg.SetContext(Context{Name: "prod", ClientID: "id", ClientSecret: *secret.FromLocation("plaintext-secret")})
+	assert.NoError(t, cfg.Encrypt("correct horse"))
+	assert.NoError(t, cfg.Save(path))
+
+	bs, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.True(t, isEncrypted(bs))
+	assert.NotContains(t, string(bs), "plaintext-secret")
+
+	loaded, err := Load(path)
+	assert.NoError(t, err)
+	assert.True(t, loaded.Encrypted())
+	uc, err := loaded.Context("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, "plaintext-secret", uc.ClientSecret.Location())
+
+	t.Setenv(PassphraseVar, "wrong")
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "passphrase is wrong")
+
+	// saving a decrypted config writes plaintext again
+	loaded.Decrypt()
+	assert.NoError(t, loaded.Save(path))
+	plain, err := Load(path)
+	assert.NoError(t, err)
+	assert.False(t, plain.Encrypted())
+}
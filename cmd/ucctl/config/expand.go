@@ -0,0 +1,203 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// varRef matches a ${VAR} reference to an environment variable in a context setting, or
+// an escaped $${VAR} that is kept as ${VAR}.
+var varRef = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandString replaces the ${VAR} references in s with the values of the environment
+// variables, returning the names of the variables that aren't set.
+func expandString(s string) (string, []string) {
+	var unset []string
+	out := varRef.ReplaceAllStringFunc(s, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+
+		name := varRef.FindStringSubmatch(ref)[1]
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			unset = append(unset, name)
+		}
+		return v
+	})
+
+	return out, unset
+}
+
+// expandContext returns a copy of the context with the ${VAR} references in its settings
+// replaced by the values of the environment variables, and whether it had any.  The
+// context name is never expanded.
+func expandContext(uc Context) (Context, bool, error) {
+	fields, err := contextFields(uc)
+	if err != nil {
+		return uc, false, err
+	}
+
+	var expanded bool
+	var unset []string
+	for k, v := range fields {
+		s, ok := v.(string)
+		if !ok || k == "name" || !varRef.MatchString(s) {
+			continue
+		}
+
+		var u []string
+		fields[k], u = expandString(s)
+		unset = append(unset, u...)
+		expanded = true
+	}
+
+	if !expanded {
+		return uc, false, nil
+	}
+
+	bs, err := json.Marshal(fields)
+	if err != nil {
+		return uc, false, fmt.Errorf("failed to marshal context %s: %v", uc.Name, err)
+	}
+
+	var out Context
+	if err := json.Unmarshal(bs, &out); err != nil {
+		return uc, false, fmt.Errorf("invalid value for a setting of context %s after expanding environment variables: %v", uc.Name, err)
+	}
+	out.unset = unset
+
+	return out, true, nil
+}
+
+// expand replaces the ${VAR} references in the settings of the contexts, keeping their raw
+// form so that the references rather than their values are saved.
+func (c *Config) expand() error {
+	for i, uc := range c.Contexts {
+		expanded, ok, err := expandContext(uc)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if c.raw == nil {
+			c.raw = map[string]Context{}
+		}
+		c.raw[uc.Name] = uc
+		c.Contexts[i] = expanded
+	}
+
+	return nil
+}
+
+// unexpanded returns a copy of the config in which the context settings that are unchanged
+// since they were loaded are replaced by their raw form, so that saving writes the ${VAR}
+// references rather than the values of the environment variables, e.g. credentials.
+func (c *Config) unexpanded() (*Config, error) {
+	if len(c.raw) == 0 {
+		return c, nil
+	}
+
+	out := *c
+	out.Contexts = make([]Context, len(c.Contexts))
+	for i, uc := range c.Contexts {
+		raw, ok := c.raw[uc.Name]
+		if !ok {
+			out.Contexts[i] = uc
+			continue
+		}
+
+		unexpanded, err := unexpandContext(raw, uc)
+		if err != nil {
+			return nil, err
+		}
+		out.Contexts[i] = unexpanded
+	}
+
+	return &out, nil
+}
+
+// RawContext returns the named context with the settings that reference environment
+// variables in their ${VAR} form, e.g. for exporting it.
+func (c *Config) RawContext(name string) (Context, error) {
+	uc, err := c.Context(name)
+	if err != nil {
+		return Context{}, err
+	}
+
+	raw, ok := c.raw[name]
+	if !ok {
+		return *uc, nil
+	}
+
+	return unexpandContext(raw, *uc)
+}
+
+// unexpandContext returns a copy of the context with the settings that still have the
+// expanded value of the raw context replaced by their raw form.
+func unexpandContext(raw, uc Context) (Context, error) {
+	rawFields, err := contextFields(raw)
+	if err != nil {
+		return uc, err
+	}
+
+	fields, err := contextFields(uc)
+	if err != nil {
+		return uc, err
+	}
+
+	for k, v := range rawFields {
+		s, ok := v.(string)
+		if !ok || k == "name" || !varRef.MatchString(s) {
+			continue
+		}
+
+		if expanded, _ := expandString(s); fields[k] == expanded {
+			fields[k] = s
+		}
+	}
+
+	bs, err := json.Marshal(fields)
+	if err != nil {
+		return uc, fmt.Errorf("failed to marshal context %s: %v", uc.Name, err)
+	}
+
+	var out Context
+	if err := json.Unmarshal(bs, &out); err != nil {
+		return uc, fmt.Errorf("failed to unmarshal context %s: %v", uc.Name, err)
+	}
+
+	return out, nil
+}
+
+// contextFields returns the settings of a context by their JSON names.
+func contextFields(uc Context) (map[string]any, error) {
+	bs, err := json.Marshal(uc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal context %s: %v", uc.Name, err)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(bs, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal context %s: %v", uc.Name, err)
+	}
+
+	return fields, nil
+}
+
+// checkUnset returns an error if a setting of the context references an environment
+// variable that isn't set.  It is checked when connecting to the tenant rather than when
+// loading, so that other contexts can be used and the context can still be changed.
+func (c *Context) checkUnset() error {
+	if len(c.unset) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("context %s references environment variables that are not set: %s", c.Name, strings.Join(c.unset, ", "))
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Group returns the contexts of the named group, checking that they all exist.
+func (c *Config) Group(name string) ([]*Context, error) {
+	members, ok := c.Groups[name]
+	if !ok {
+		return nil, fmt.Errorf("context group %s not found", name)
+	}
+
+	if len(members) == 0 {
+		return nil, fmt.Errorf("context group %s has no contexts", name)
+	}
+
+	out := make([]*Context, 0, len(members))
+	for _, member := range members {
+		uc, err := c.Context(member)
+		if err != nil {
+			return nil, fmt.Errorf("context group %s: %v", name, err)
+		}
+		out = append(out, uc)
+	}
+
+	return out, nil
+}
+
+// GroupNames returns the names of the groups in order.
+func (c *Config) GroupNames() []string {
+	names := make([]string, 0, len(c.Groups))
+	for name := range c.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetGroup sets the contexts of the named group, replacing any existing group with the
+// same name.
+func (c *Config) SetGroup(name string, members []string) error {
+	if name == "" {
+		return fmt.Errorf("context group name is required")
+	}
+
+	if len(members) == 0 {
+		return fmt.Errorf("context group %s needs at least one context", name)
+	}
+
+	for i, member := range members {
+		if _, err := c.Context(member); err != nil {
+			return err
+		}
+
+		if slices.Contains(members[:i], member) {
+			return fmt.Errorf("context %s is listed more than once", member)
+		}
+	}
+
+	if c.Groups == nil {
+		c.Groups = map[string][]string{}
+	}
+	c.Groups[name] = slices.Clone(members)
+	return nil
+}
+
+// DeleteGroup removes the named group.  The contexts of the group are left unchanged.
+func (c *Config) DeleteGroup(name string) error {
+	if c.base != nil {
+		if _, ok := c.base.Groups[name]; ok {
+			return fmt.Errorf("context group %s is defined in another file of %s and can only be changed there", name, ConfigVar)
+		}
+	}
+
+	if _, ok := c.Groups[name]; !ok {
+		return fmt.Errorf("context group %s not found", name)
+	}
+
+	delete(c.Groups, name)
+	return nil
+}
+
+// renameGroupMember updates the groups when a context is renamed, or removes it from the
+// groups if newName is empty.
+func (c *Config) renameGroupMember(oldName, newName string) {
+	for name, members := range c.Groups {
+		out := make([]string, 0, len(members))
+		for _, member := range members {
+			if member != oldName {
+				out = append(out, member)
+			} else if newName != "" {
+				out = append(out, newName)
+			}
+		}
+		c.Groups[name] = out
+	}
+}
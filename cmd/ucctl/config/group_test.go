@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Groups(t *testing.T) {
+	cfg := &Config{}
+	cfg.SetContext(Context{Name: "eu-1"})
+	cfg.SetContext(Context{Name: "eu-2"})
+	cfg.SetContext(Context{Name: "us-1"})
+
+	assert.Error(t, cfg.SetGroup("eu", nil))
+	assert.Error(t, cfg.SetGroup("eu", []string{"eu-1", "missing"}))
+	assert.Error(t, cfg.SetGroup("eu", []string{"eu-1", "eu-1"}))
+	assert.NoError(t, cfg.SetGroup("eu", []string{"eu-1", "eu-2"}))
+	assert.NoError(t, cfg.SetGroup("all", []string{"eu-1", "eu-2", "us-1"}))
+	assert.Equal(t, []string{"all", "eu"}, cfg.GroupNames())
+
+	members, err := cfg.Group("eu")
+	assert.NoError(t, err)
+	assert.Len(t, members, 2)
+	assert.Equal(t, "eu-2", members[1].Name)
+
+	_, err = cfg.Group("missing")
+	assert.Error(t, err)
+
+	// groups follow renamed and deleted contexts
+	assert.NoError(t, cfg.RenameContext("eu-1", "eu-west-1"))
+	assert.NoError(t, cfg.DeleteContext("eu-2"))
+	assert.Equal(t, []string{"eu-west-1"}, cfg.Groups["eu"])
+	assert.Equal(t, []string{"eu-west-1", "us-1"}, cfg.Groups["all"])
+
+	assert.NoError(t, cfg.DeleteGroup("eu"))
+	assert.Error(t, cfg.DeleteGroup("eu"))
+	assert.Equal(t, []string{"all"}, cfg.GroupNames())
+}
+
+func TestConfig_GroupsPathList(t *testing.T) {
+	dir := t.TempDir()
+	personal := filepath.Join(dir, "personal.yaml")
+	shared := filepath.Join(dir, "shared.yaml")
+	paths := personal + string(filepath.ListSeparator) + shared
+
+	assert.NoError(t, os.WriteFile(shared, []byte(`contexts:
+- name: eu-1
+- name: eu-2
+groups:
+  eu: [eu-1, eu-2]
+`), 0600))
+
+	cfg, err := Load(paths)
+	assert.NoError(t, err)
+	members, err := cfg.Group("eu")
+	assert.NoError(t, err)
+	assert.Len(t, members, 2)
+
+	// shared groups can't be deleted, and only personal groups are saved to the first file
+	assert.Error(t, cfg.DeleteGroup("eu"))
+	assert.NoError(t, cfg.SetGroup("mine", []string{"eu-1"}))
+	assert.NoError(t, cfg.Save(paths))
+
+	bs, err := os.ReadFile(personal)
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), "mine:")
+	assert.NotContains(t, string(bs), "eu:")
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"userclouds.com/infra/secret"
+)
+
+const (
+	// InClusterContextName is the name of the context built when running in Kubernetes.
+	InClusterContextName = "in-cluster"
+
+	// DefaultInClusterSecretsDir is where the tenant secret is expected to be mounted.
+	DefaultInClusterSecretsDir = "/var/run/secrets/userclouds"
+
+	// Environment variables read by InClusterContext.  The tenant URL and client ID can
+	// also be mounted as the url and client_id files of the secrets directory.
+	InClusterSecretsDirVar   = "UC_SECRETS_DIR"
+	InClusterURLVar          = "UC_TENANT_URL"
+	InClusterClientIDVar     = "UC_CLIENT_ID"
+	InClusterClientSecretVar = "UC_CLIENT_SECRET"
+	InClusterSecretLocVar    = "UC_CLIENT_SECRET_LOCATION"
+
+	kubernetesServiceHostVar  = "KUBERNETES_SERVICE_HOST"
+	inClusterURLFile          = "url"
+	inClusterClientIDFile     = "client_id"
+	inClusterClientSecretFile = "client_secret"
+)
+
+// InClusterContext builds a context from the environment when ucctl runs in a Kubernetes
+// pod, e.g. as a Job, so that it can be used without a config file.  The tenant URL and
+// client ID come from UC_TENANT_URL and UC_CLIENT_ID or from files of the same name in the
+// mounted secrets directory.  The client secret is, in order, the secret location in
+// UC_CLIENT_SECRET_LOCATION (e.g. kube://secrets/<namespace>:<name>), the
+// UC_CLIENT_SECRET environment variable, or the mounted client_secret file.  It returns
+// nil if ucctl isn't running in Kubernetes or the tenant URL isn't set.
+func InClusterContext() (*Context, error) {
+	if os.Getenv(kubernetesServiceHostVar) == "" {
+		return nil, nil
+	}
+
+	dir := os.Getenv(InClusterSecretsDirVar)
+	if dir == "" {
+		dir = DefaultInClusterSecretsDir
+	}
+
+	url, err := envOrFile(InClusterURLVar, filepath.Join(dir, inClusterURLFile))
+	if err != nil || url == "" {
+		return nil, err
+	}
+
+	clientID, err := envOrFile(InClusterClientIDVar, filepath.Join(dir, inClusterClientIDFile))
+	if err != nil {
+		return nil, err
+	}
+
+	uc := &Context{Name: InClusterContextName, URL: url, ClientID: clientID}
+	if loc := os.Getenv(InClusterSecretLocVar); loc != "" {
+		uc.ClientSecret = *secret.FromLocation(loc)
+	} else if os.Getenv(InClusterClientSecretVar) != "" {
+		uc.ClientSecret = *secret.FromLocation("env://" + InClusterClientSecretVar)
+	} else {
+		s, err := envOrFile("", filepath.Join(dir, inClusterClientSecretFile))
+		if err != nil {
+			return nil, err
+		}
+		uc.ClientSecret = *secret.FromLocation(s)
+	}
+
+	return uc, nil
+}
+
+// envOrFile returns the value of the environment variable, or the trimmed contents of the
+// file if it isn't set.  A missing file is an empty value.
+func envOrFile(name, path string) (string, error) {
+	if v := os.Getenv(name); name != "" && v != "" {
+		return v, nil
+	}
+
+	bs, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	return strings.TrimSpace(string(bs)), nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_InClusterContext(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	t.Setenv(InClusterSecretsDirVar, dir)
+	t.Setenv(InClusterURLVar, "")
+	t.Setenv(InClusterClientIDVar, "")
+	t.Setenv(InClusterClientSecretVar, "")
+	t.Setenv(InClusterSecretLocVar, "")
+
+	t.Setenv(kubernetesServiceHostVar, "")
+	uc, err := InClusterContext()
+	assert.NoError(t, err)
+	assert.Nil(t, uc)
+
+	// in a pod without a tenant URL there is no context either
+	t.Setenv(kubernetesServiceHostVar, "10.0.0.1")
+	uc, err = InClusterContext()
+	assert.NoError(t, err)
+	assert.Nil(t, uc)
+
+	// mounted secret files
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "url"), []byte("https://prod.example.com\n"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "client_id"), []byte("id\n"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "client_secret"), []byte("mounted\n"), 0600))
+	uc, err = InClusterContext()
+	assert.NoError(t, err)
+	assert.Equal(t, InClusterContextName, uc.Name)
+	assert.Equal(t, "https://prod.example.com", uc.URL)
+	assert.Equal(t, "id", uc.ClientID)
+	s, err := uc.ResolveClientSecret(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "mounted", s)
+
+	// environment variables take precedence over the files
+	t.Setenv(InClusterURLVar, "https://staging.example.com")
+	t.Setenv(InClusterClientSecretVar, "from-env")
+	uc, err = InClusterContext()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://staging.example.com", uc.URL)
+	s, err = uc.ResolveClientSecret(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "from-env", s)
+
+	t.Setenv(InClusterSecretLocVar, "kube://secrets/userclouds:client-secret")
+	uc, err = InClusterContext()
+	assert.NoError(t, err)
+	assert.Equal(t, "kube://secrets/userclouds:client-secret", uc.ClientSecret.Location())
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// lockTimeout is how long Save waits for another ucctl saving the same config file.
+	lockTimeout = 10 * time.Second
+
+	// staleLockAge is the age after which a lock is assumed to be left over from a ucctl
+	// that was killed while saving, since saving only takes milliseconds.
+	staleLockAge = time.Minute
+
+	lockRetryInterval = 50 * time.Millisecond
+
+	// noFile is the revision of a config file that doesn't exist.
+	noFile = "none"
+)
+
+// ErrConflict is returned by Save if the config file was changed by another ucctl after
+// the config was loaded, since saving would discard the other change.
+var ErrConflict = errors.New("config file was changed by another ucctl")
+
+// lock acquires an advisory lock on the file at path, which is held by creating a lock
+// file next to it.  Every ucctl takes the lock before saving, so a lock file works on
+// every OS without relying on flock.
+func lock(path string) (unlock func(), err error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock %s: %v", path, err)
+		}
+
+		if fi, err := os.Stat(lockPath); err == nil && time.Since(fi.ModTime()) > staleLockAge {
+			_ = os.Remove(lockPath)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the lock on %s, remove %s if no other ucctl is running", path, lockPath)
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// revision identifies the contents of a config file, to detect changes made by another
+// ucctl.
+func revision(bs []byte) string {
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:])
+}
+
+// fileRevision returns the revision of the file at path.
+func fileRevision(path string) (string, error) {
+	bs, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return noFile, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	return revision(bs), nil
+}
+
+// writeFile atomically replaces the file at path, so that a reader never sees a partially
+// written config.
+func writeFile(path string, bs []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(bs); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+
+	// CreateTemp already creates the file readable only by the user
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_SaveConflict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	a, err := Load(path)
+	assert.NoError(t, err)
+	b, err := Load(path)
+	assert.NoError(t, err)
+
+	a.SetContext(Context{Name: "a"})
+	assert.NoError(t, a.Save(path))
+
+	// b was loaded before a was saved, so saving it would drop context a
+	b.SetContext(Context{Name: "b"})
+	assert.ErrorIs(t, b.Save(path), ErrConflict)
+
+	// a config can be saved again after saving it
+	a.SetContext(Context{Name: "c"})
+	assert.NoError(t, a.Save(path))
+
+	b, err = Load(path)
+	assert.NoError(t, err)
+	b.SetContext(Context{Name: "b"})
+	assert.NoError(t, b.Save(path))
+
+	loaded, err := Load(path)
+	assert.NoError(t, err)
+	assert.Len(t, loaded.Contexts, 3)
+
+	// a lock left by a killed ucctl doesn't block saving forever
+	lockPath := path + ".lock"
+	assert.NoError(t, os.WriteFile(lockPath, nil, 0600))
+	old := time.Now().Add(-2 * staleLockAge)
+	assert.NoError(t, os.Chtimes(lockPath, old, old))
+	loaded.SetContext(Context{Name: "d"})
+	assert.NoError(t, loaded.Save(path))
+	assert.NoFileExists(t, lockPath)
+}
+
+func TestConfig_SaveConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// like a ucctl invocation that is run again after a conflict
+			for {
+				cfg, err := Load(path)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				cfg.SetContext(Context{Name: fmt.Sprintf("context-%d", i)})
+				err = cfg.Save(path)
+				if errors.Is(err, ErrConflict) {
+					continue
+				}
+				assert.NoError(t, err)
+				return
+			}
+		}()
+	}
+	wg.Wait()
+
+	cfg, err := Load(path)
+	assert.NoError(t, err)
+	assert.Len(t, cfg.Contexts, 10)
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+)
+
+// CurrentVersion is the version of the config file layout written by this ucctl.  Bump
+// it, and add a migration, whenever a change to the layout would break older configs.
+const CurrentVersion = 1
+
+// migration upgrades a config file from one version to the next.  It works on the raw
+// file contents, since the old layout may not parse into the current Config.
+type migration func(raw map[string]any) error
+
+// migrations[i] upgrades a config from version i to version i+1.
+var migrations = []migration{
+	// configs written before the version field existed have the same layout as version 1
+	func(raw map[string]any) error { return nil },
+}
+
+// migrate upgrades the contents of the config file at path to the current version, so
+// that it can be parsed into a Config.  The upgraded config is written the next time
+// it is saved.
+func migrate(path string, bs []byte) ([]byte, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(bs, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if raw == nil {
+		raw = map[string]any{}
+	}
+
+	version, err := rawVersion(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	if version > CurrentVersion {
+		return nil, fmt.Errorf("%s has config version %d, which is newer than the version %d supported by this ucctl, please upgrade ucctl", path, version, CurrentVersion)
+	}
+
+	if version == CurrentVersion {
+		return bs, nil
+	}
+
+	for v := version; v < CurrentVersion; v++ {
+		if err := migrations[v](raw); err != nil {
+			return nil, fmt.Errorf("failed to migrate %s from config version %d to %d: %v", path, v, v+1, err)
+		}
+	}
+	raw["version"] = CurrentVersion
+
+	bs, err = yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal migrated config %s: %v", path, err)
+	}
+
+	return bs, nil
+}
+
+// rawVersion returns the version of a raw config, which is 0 for configs written before
+// the version field existed.
+func rawVersion(raw map[string]any) (int, error) {
+	v, ok := raw["version"]
+	if !ok || v == nil {
+		return 0, nil
+	}
+
+	// numbers are decoded as JSON numbers
+	f, ok := v.(float64)
+	if !ok || f < 0 || f != float64(int(f)) {
+		return 0, fmt.Errorf("invalid config version %v", v)
+	}
+
+	return int(f), nil
+}
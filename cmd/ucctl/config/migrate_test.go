@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Migrate(t *testing.T) {
+	dir := t.TempDir()
+
+	// configs written before the version field existed are upgraded on load
+	path := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("current_context: prod\ncontexts:\n- name: prod\n  url: https://prod.example.com\n  client_id: id\n"), 0600))
+
+	cfg, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, CurrentVersion, cfg.Version)
+	assert.Equal(t, "prod", cfg.CurrentContext)
+
+	uc, err := cfg.Context("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://prod.example.com", uc.URL)
+
+	assert.NoError(t, cfg.Save(path))
+	bs, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), fmt.Sprintf("version: %d", CurrentVersion))
+
+	// empty and missing files are fine too
+	empty := filepath.Join(dir, "empty.yaml")
+	assert.NoError(t, os.WriteFile(empty, nil, 0600))
+	_, err = Load(empty)
+	assert.NoError(t, err)
+
+	_, err = Load(filepath.Join(dir, "missing.yaml"))
+	assert.NoError(t, err)
+
+	// configs from a newer ucctl aren't silently misread
+	newer := filepath.Join(dir, "newer.yaml")
+	assert.NoError(t, os.WriteFile(newer, []byte(fmt.Sprintf("version: %d\n", CurrentVersion+1)), 0600))
+	_, err = Load(newer)
+	assert.ErrorContains(t, err, "please upgrade ucctl")
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	assert.NoError(t, os.WriteFile(invalid, []byte("version: one\n"), 0600))
+	_, err = Load(invalid)
+	assert.ErrorContains(t, err, "invalid config version")
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gofrs/uuid"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// ContextVar is the environment variable selecting the context for an invocation,
+	// overridden by --context.
+	ContextVar = "UC_CONTEXT"
+)
+
+// State is the loaded config, which is attached to the command context by the root
+// command so that subcommands don't need to load it themselves.
+type State struct {
+	Path    string
+	Config  *Config
+	Current *Context
+
+	// AsOrganizationID is the organization that API calls are scoped to where supported,
+	// set with --as-organization.  It is nil if API calls aren't scoped.
+	AsOrganizationID uuid.UUID
+}
+
+// StateFromFlags loads the config named by the --config flag, or UC_CONFIG if the flag
+// wasn't set, and selects the context named by --context or UC_CONTEXT, falling back to
+// the config's current context.  The selection only applies to this invocation, the
+// config's current context is left unchanged.  In a Kubernetes pod with no context
+// selected, the in-cluster context is used.  The request settings of the context are
+// overridden by the --request-timeout, --retries and --retry-backoff flags.  Only an
+// interactive load prompts for the passphrase of an encrypted config.
+func StateFromFlags(cmd *cobra.Command, interactive bool) (*State, error) {
+	path, err := PathFromFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := load(path, interactive)
+	if err != nil {
+		return nil, err
+	}
+
+	return SelectContext(cmd, path, cfg)
+}
+
+// SelectContext returns the state of a config that was already loaded from path, selecting
+// the context like StateFromFlags.
+func SelectContext(cmd *cobra.Command, path string, cfg *Config) (*State, error) {
+	current, err := cfg.Current()
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := cmd.Flags().GetString("context")
+	if name == "" {
+		name = os.Getenv(ContextVar)
+	}
+	if name != "" {
+		if current, err = cfg.Context(name); err != nil {
+			return nil, err
+		}
+	}
+
+	if current == nil {
+		if current, err = InClusterContext(); err != nil {
+			return nil, err
+		}
+	}
+
+	if current != nil {
+		if current, err = overrideRequestSettings(cmd, current); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &State{Path: path, Config: cfg, Current: current}
+	if org, _ := cmd.Flags().GetString("as-organization"); org != "" {
+		if s.AsOrganizationID, err = uuid.FromString(org); err != nil {
+			return nil, fmt.Errorf("invalid --as-organization %s: %v", org, err)
+		}
+	}
+
+	return s, nil
+}
+
+// overrideRequestSettings returns a copy of the context with the request settings set by
+// flags, so that the overrides aren't saved with the config.
+func overrideRequestSettings(cmd *cobra.Command, uc *Context) (*Context, error) {
+	flags := cmd.Flags()
+	if !flags.Changed("request-timeout") && !flags.Changed("retries") && !flags.Changed("retry-backoff") {
+		return uc, nil
+	}
+
+	out := *uc
+	if flags.Changed("request-timeout") {
+		timeout, err := flags.GetDuration("request-timeout")
+		if err != nil {
+			return nil, err
+		}
+		out.Timeout = Duration(timeout)
+	}
+
+	if flags.Changed("retries") {
+		retries, err := flags.GetInt("retries")
+		if err != nil {
+			return nil, err
+		}
+		out.Retries = retries
+	}
+
+	if flags.Changed("retry-backoff") {
+		backoff, err := flags.GetDuration("retry-backoff")
+		if err != nil {
+			return nil, err
+		}
+		out.RetryBackoff = Duration(backoff)
+	}
+
+	if err := out.validateRequestSettings(); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// PathFromFlags returns the config path set by the --config flag, or UC_CONFIG if the
+// flag wasn't set.
+func PathFromFlags(cmd *cobra.Command) (string, error) {
+	path, _ := cmd.Flags().GetString("config")
+	if v := os.Getenv(ConfigVar); v != "" && !cmd.Flags().Changed("config") {
+		path = v
+	}
+
+	if path == "" {
+		return "", fmt.Errorf("config file path is required")
+	}
+
+	return path, nil
+}
+
+type stateKey struct{}
+
+// WithState returns a copy of ctx holding the state.
+func WithState(ctx context.Context, s *State) context.Context {
+	return context.WithValue(ctx, stateKey{}, s)
+}
+
+// FromContext returns the state attached to ctx, or nil if there is none.
+func FromContext(ctx context.Context) *State {
+	s, _ := ctx.Value(stateKey{}).(*State)
+	return s
+}
+
+// AsOrganization returns the organization that API calls are scoped to with
+// --as-organization, or uuid.Nil if there is none.
+func AsOrganization(ctx context.Context) uuid.UUID {
+	if s := FromContext(ctx); s != nil {
+		return s.AsOrganizationID
+	}
+
+	return uuid.Nil
+}
+
+// CurrentContext returns the current context attached to ctx, or nil if there is none.
+func CurrentContext(ctx context.Context) *Context {
+	if s := FromContext(ctx); s != nil {
+		return s.Current
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/ucjwt"
+)
+
+const (
+	DefaultTokenDir = "tokens"
+
+	// tokenKeyFile holds the key the cached tokens are encrypted with.
+	tokenKeyFile = ".key"
+	tokenKeySize = 32
+
+	// tokenExpirySkew is how long before it expires a cached token stops being used, so
+	// that it doesn't expire in the middle of a command.
+	tokenExpirySkew = 30 * time.Second
+)
+
+// Token is an access token cached for a context, either by ucctl login or by a command
+// connecting to the context's tenant.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// NewToken returns the token to cache for a token response.  The expiry comes from
+// expires_in, falling back to the exp claim of the access token.
+func NewToken(resp *oidc.TokenResponse) (*Token, error) {
+	t := &Token{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+	}
+
+	if resp.ExpiresIn > 0 {
+		t.ExpiresAt = time.Now().UTC().Add(time.Duration(resp.ExpiresIn) * time.Second)
+		return t, nil
+	}
+
+	claims, err := ucjwt.ParseUCClaimsUnverified(resp.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse access token: %v", err)
+	}
+	if claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("access token has no expiration time")
+	}
+	t.ExpiresAt = claims.ExpiresAt.UTC()
+
+	return t, nil
+}
+
+// Valid returns true if the token can still be used at now.
+func (t *Token) Valid(now time.Time) bool {
+	return t != nil && t.AccessToken != "" && now.Add(tokenExpirySkew).Before(t.ExpiresAt)
+}
+
+// TokenPath returns the path of the cached token for the named context, which is kept
+// next to the config file, or the first file of a path list.
+func TokenPath(configPath, name string) string {
+	return filepath.Join(filepath.Dir(PrimaryPath(configPath)), DefaultTokenDir, name+".token")
+}
+
+// LoadToken reads the cached token for the named context.  A missing token is not an
+// error, nil is returned instead.  Neither is a token that can't be decrypted, e.g.
+// because the key was deleted, since the token will simply be replaced.
+func LoadToken(configPath, name string) (*Token, error) {
+	path := TokenPath(configPath, name)
+	bs, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	aead, err := tokenCipher(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bs) < aead.NonceSize() {
+		return nil, nil
+	}
+
+	plaintext, err := aead.Open(nil, bs[:aead.NonceSize()], bs[aead.NonceSize():], []byte(name))
+	if err != nil {
+		return nil, nil
+	}
+
+	var t Token
+	if err := json.Unmarshal(plaintext, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	return &t, nil
+}
+
+// SaveToken encrypts and caches the token for the named context.  Like the config file,
+// it is only readable by the user.
+func SaveToken(configPath, name string, t *Token) error {
+	bs, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token: %v", err)
+	}
+
+	aead, err := tokenCipher(configPath)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	// the context name is authenticated so a token can't be swapped into another context
+	path := TokenPath(configPath, name)
+	if err := os.WriteFile(path, aead.Seal(nonce, nonce, bs, []byte(name)), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// DeleteToken removes the cached token for the named context, if there is one.
+func DeleteToken(configPath, name string) error {
+	path := TokenPath(configPath, name)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// RenameToken moves the cached token of a context to its new name, if it has one.
+func RenameToken(configPath, oldName, newName string) error {
+	t, err := LoadToken(configPath, oldName)
+	if err != nil {
+		return err
+	}
+
+	if t != nil {
+		// tokens are encrypted with the context name so they are saved again rather than moved
+		if err := SaveToken(configPath, newName, t); err != nil {
+			return err
+		}
+	}
+
+	return DeleteToken(configPath, oldName)
+}
+
+// tokenCipher returns the cipher used to encrypt cached tokens, generating its key the
+// first time it is used.
+func tokenCipher(configPath string) (cipher.AEAD, error) {
+	dir := filepath.Join(filepath.Dir(PrimaryPath(configPath)), DefaultTokenDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+
+	path := filepath.Join(dir, tokenKeyFile)
+	key, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key = make([]byte, tokenKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate token key: %v", err)
+		}
+
+		if err := os.WriteFile(path, key, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %v", path, err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	if len(key) != tokenKeySize {
+		return nil, fmt.Errorf("token key %s is invalid, delete it to generate a new one", path)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token cipher: %v", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token cipher: %v", err)
+	}
+
+	return aead, nil
+}
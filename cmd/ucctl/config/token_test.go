@@ -0,0 +1,109 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/secret"
+)
+
+func TestConfig_Token(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	now := time.Now().UTC()
+
+	missing, err := LoadToken(path, "prod")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+	assert.False(t, missing.Valid(now))
+
+	assert.NoError(t, SaveToken(path, "prod", &Token{AccessToken: "valid", ExpiresAt: now.Add(time.Hour)}))
+
+	info, err := os.Stat(TokenPath(path, "prod"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	bs, err := os.ReadFile(TokenPath(path, "prod"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(bs), "valid")
+
+	loaded, err := LoadToken(path, "prod")
+	assert.NoError(t, err)
+	assert.Equal(t, "valid", loaded.AccessToken)
+	assert.True(t, loaded.Valid(now))
+	assert.False(t, loaded.Valid(now.Add(time.Hour)))
+
+	// a token copied to another context can't be decrypted
+	assert.NoError(t, os.WriteFile(TokenPath(path, "staging"), bs, 0600))
+	swapped, err := LoadToken(path, "staging")
+	assert.NoError(t, err)
+	assert.Nil(t, swapped)
+
+	assert.NoError(t, RenameToken(path, "prod", "production"))
+	renamed, err := LoadToken(path, "production")
+	assert.NoError(t, err)
+	assert.Equal(t, "valid", renamed.AccessToken)
+
+	assert.NoError(t, DeleteToken(path, "production"))
+	assert.NoError(t, DeleteToken(path, "production"))
+	deleted, err := LoadToken(path, "production")
+	assert.NoError(t, err)
+	assert.Nil(t, deleted)
+}
+
+func TestConfig_TokenSource(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	var grants []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		grants = append(grants, r.PostForm.Get("grant_type"))
+
+		accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}).SignedString([]byte("test"))
+		assert.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		assert.NoError(t, json.NewEncoder(w).Encode(oidc.TokenResponse{AccessToken: accessToken}))
+	}))
+	defer srv.Close()
+
+	uc := &Context{Name: "prod", URL: srv.URL, ClientID: "id", ClientSecret: *secret.FromLocation("plaintext")}
+	state := &State{Path: path, Current: uc}
+
+	first, err := state.TokenSource(ctx, "").GetToken()
+	assert.NoError(t, err)
+
+	// a new invocation reuses the cached token
+	second, err := state.TokenSource(ctx, "").GetToken()
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, []string{"client_credentials"}, grants)
+
+	// an expired token with a refresh token is refreshed
+	assert.NoError(t, SaveToken(path, "prod", &Token{AccessToken: "expired", RefreshToken: "refresh", ExpiresAt: time.Now().Add(-time.Minute)}))
+	_, err = state.TokenSource(ctx, "").GetToken()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"client_credentials", "refresh_token"}, grants)
+
+	refreshed, err := LoadToken(path, "prod")
+	assert.NoError(t, err)
+	assert.Equal(t, "refresh", refreshed.RefreshToken)
+
+	// without a valid token or a client secret the user has to log in
+	assert.NoError(t, DeleteToken(path, "prod"))
+	noSecret := &State{Path: path, Current: &Context{Name: "prod", URL: srv.URL, ClientID: "id"}}
+	_, err = noSecret.TokenSource(ctx, "").GetToken()
+	assert.ErrorContains(t, err, "ucctl login")
+}
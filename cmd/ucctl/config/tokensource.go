@@ -0,0 +1,181 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/uclog"
+)
+
+// TokenSource is an oidc.TokenSource for a context that caches its tokens on disk, so that
+// consecutive ucctl invocations reuse a token rather than requesting a new one each time.
+// An expired token is refreshed with its refresh token if it has one, e.g. after a device
+// login, and is otherwise replaced using the client credentials grant.
+type TokenSource struct {
+	ctx          context.Context
+	configPath   string
+	uc           *Context
+	clientSecret string
+
+	mu     sync.Mutex
+	token  *Token
+	client *http.Client
+}
+
+// TokenSource returns a token source for the current context, or nil if no context is
+// selected.  clientSecret overrides the context's client secret if it is set.
+func (s *State) TokenSource(ctx context.Context, clientSecret string) *TokenSource {
+	if s == nil || s.Current == nil {
+		return nil
+	}
+
+	return &TokenSource{
+		ctx:          ctx,
+		configPath:   s.Path,
+		uc:           s.Current,
+		clientSecret: clientSecret,
+	}
+}
+
+// TokenURL returns the token endpoint of a tenant.
+func TokenURL(tenantURL string) (string, error) {
+	u, err := url.Parse(tenantURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid tenant URL %s: %v", tenantURL, err)
+	}
+	u.Path = "/oidc/token"
+
+	return u.String(), nil
+}
+
+// GetToken implements oidc.TokenSource.  It is safe to call concurrently.
+func (ts *TokenSource) GetToken() (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	now := time.Now().UTC()
+	if ts.token.Valid(now) {
+		return ts.token.AccessToken, nil
+	}
+
+	// the cache is best effort, e.g. the home directory of a Kubernetes Job may not be
+	// writable
+	cached, err := LoadToken(ts.configPath, ts.uc.Name)
+	if err != nil {
+		uclog.Warningf(ts.ctx, "failed to read the cached token of context %s: %v", ts.uc.Name, err)
+		cached = nil
+	}
+
+	if cached.Valid(now) {
+		ts.token = cached
+		return cached.AccessToken, nil
+	}
+
+	if ts.client == nil {
+		if ts.client, err = ts.uc.HTTPClient(); err != nil {
+			return "", err
+		}
+	}
+
+	var t *Token
+	if cached != nil && cached.RefreshToken != "" {
+		if t, err = ts.refresh(cached.RefreshToken); err != nil {
+			uclog.Debugf(ts.ctx, "failed to refresh the token of context %s: %v", ts.uc.Name, err)
+		}
+	}
+
+	if t == nil {
+		if t, err = ts.clientCredentials(); err != nil {
+			return "", err
+		}
+	}
+
+	if err := SaveToken(ts.configPath, ts.uc.Name, t); err != nil {
+		uclog.Warningf(ts.ctx, "failed to cache the token of context %s: %v", ts.uc.Name, err)
+	}
+	ts.token = t
+
+	return t.AccessToken, nil
+}
+
+// clientCredentials gets a new token with the client secret.
+func (ts *TokenSource) clientCredentials() (*Token, error) {
+	clientSecret := ts.clientSecret
+	if clientSecret == "" {
+		s, err := ts.uc.ResolveClientSecret(ts.ctx)
+		if err != nil {
+			return nil, err
+		}
+		clientSecret = s
+	}
+
+	if clientSecret == "" {
+		return nil, fmt.Errorf("context %s has no client secret or valid token, set a client secret or run ucctl login", ts.uc.Name)
+	}
+
+	tokenURL, err := TokenURL(ts.uc.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	cc := oidc.ClientCredentialsTokenSource{
+		TokenURL:     tokenURL,
+		ClientID:     ts.uc.ClientID,
+		ClientSecret: clientSecret,
+		HTTPClient:   ts.client,
+	}
+	accessToken, err := cc.GetToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a token from %s: %v", tokenURL, err)
+	}
+
+	return NewToken(&oidc.TokenResponse{AccessToken: accessToken})
+}
+
+// refresh exchanges a refresh token for a new token.
+func (ts *TokenSource) refresh(refreshToken string) (*Token, error) {
+	tokenURL, err := TokenURL(ts.uc.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+	form.Set("client_id", ts.uc.ClientID)
+
+	req, err := http.NewRequestWithContext(ts.ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var tresp oidc.TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tresp); err != nil {
+		return nil, fmt.Errorf("failed to parse response from %s: %v", tokenURL, err)
+	}
+
+	if resp.StatusCode != http.StatusOK || tresp.ErrorType != "" {
+		return nil, fmt.Errorf("unexpected response from %s: %s %s %s", tokenURL, resp.Status, tresp.ErrorType, tresp.ErrorDesc)
+	}
+
+	// the refresh token is kept if the server didn't rotate it
+	if tresp.RefreshToken == "" {
+		tresp.RefreshToken = refreshToken
+	}
+
+	return NewToken(&tresp)
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"userclouds.com/infra/jsonclient"
+)
+
+// DefaultRetryBackoff is the pause between retries of a context that sets retries but not
+// retry_backoff.
+const DefaultRetryBackoff = 500 * time.Millisecond
+
+// TLSConfig returns the TLS settings of the context, or nil if it uses the defaults.
+func (c *Context) TLSConfig() (*tls.Config, error) {
+	if c.CACert == "" && c.ClientCert == "" && c.ClientKey == "" && !c.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// only set for contexts that explicitly opt in, e.g. for local testing
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CACert != "" {
+		bs, err := os.ReadFile(c.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the CA certificate of context %s: %v", c.Name, err)
+		}
+
+		// the private CA is trusted in addition to the system roots, so that a context can
+		// still reach public endpoints
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(bs) {
+			return nil, fmt.Errorf("no certificates found in the CA certificate %s of context %s", c.CACert, c.Name)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.ClientCert != "" || c.ClientKey != "" {
+		if c.ClientCert == "" || c.ClientKey == "" {
+			return nil, fmt.Errorf("context %s needs both client_cert and client_key for a client certificate", c.Name)
+		}
+
+		cert, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the client certificate of context %s: %v", c.Name, err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// Proxy returns the proxy URL of the context, or nil if it uses the proxy set by the
+// environment, e.g. HTTPS_PROXY.
+func (c *Context) Proxy() (*url.URL, error) {
+	if c.ProxyURL == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(c.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL of context %s: %v", c.Name, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL of context %s, expected an http, https or socks5 URL", c.Name)
+	}
+
+	return u, nil
+}
+
+// HTTPClient returns the HTTP client used to connect to the context's tenant, which
+// applies the context's TLS, proxy and timeout settings.
+func (c *Context) HTTPClient() (*http.Client, error) {
+	if err := c.checkUnset(); err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := c.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig == nil && proxy == nil && c.Timeout == 0 {
+		return http.DefaultClient, nil
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsConfig
+
+	// the context's proxy overrides the environment's, and its credentials are sent to
+	// the proxy if it requires authentication
+	if proxy != nil {
+		t.Proxy = http.ProxyURL(proxy)
+	}
+
+	return &http.Client{Transport: t, Timeout: time.Duration(c.Timeout)}, nil
+}
+
+// validateRequestSettings checks the timeout and retry settings of the context.
+func (c *Context) validateRequestSettings() error {
+	if c.Timeout < 0 || c.RetryBackoff < 0 {
+		return fmt.Errorf("the timeout and retry backoff of context %s must not be negative", c.Name)
+	}
+
+	if c.Retries < 0 {
+		return fmt.Errorf("the retries of context %s must not be negative", c.Name)
+	}
+
+	return nil
+}
+
+// ClientOptions returns the HTTP client for the context's tenant along with the jsonclient
+// options applying the context's connection settings, which include the client.  The
+// client is returned for requests that aren't made with jsonclient, e.g. for tokens.
+func (c *Context) ClientOptions() (*http.Client, []jsonclient.Option, error) {
+	if err := c.validateRequestSettings(); err != nil {
+		return nil, nil, err
+	}
+
+	client, err := c.HTTPClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := []jsonclient.Option{jsonclient.HTTPClient(client)}
+	if c.Retries > 0 {
+		backoff := time.Duration(c.RetryBackoff)
+		if backoff == 0 {
+			backoff = DefaultRetryBackoff
+		}
+		opts = append(opts, jsonclient.RetryPolicy(c.Retries+1, backoff))
+	}
+
+	return client, opts, nil
+}
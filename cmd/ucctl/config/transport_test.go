@@ -0,0 +1,119 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_HTTPClient(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, os.WriteFile(caCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600))
+
+	get := func(uc *Context) error {
+		client, err := uc.HTTPClient()
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	// the test server's certificate is only trusted with the CA certificate
+	assert.Error(t, get(&Context{Name: "default"}))
+	assert.NoError(t, get(&Context{Name: "ca", CACert: caCert}))
+	assert.NoError(t, get(&Context{Name: "insecure", InsecureSkipVerify: true}))
+
+	uc := &Context{Name: "default"}
+	client, err := uc.HTTPClient()
+	assert.NoError(t, err)
+	assert.Equal(t, http.DefaultClient, client)
+
+	_, err = (&Context{Name: "missing", CACert: filepath.Join(t.TempDir(), "missing.pem")}).TLSConfig()
+	assert.Error(t, err)
+
+	invalid := filepath.Join(t.TempDir(), "invalid.pem")
+	assert.NoError(t, os.WriteFile(invalid, []byte("not a certificate"), 0600))
+	_, err = (&Context{Name: "invalid", CACert: invalid}).TLSConfig()
+	assert.ErrorContains(t, err, "no certificates found")
+
+	_, err = (&Context{Name: "cert", ClientCert: caCert}).TLSConfig()
+	assert.ErrorContains(t, err, "needs both client_cert and client_key")
+}
+
+func TestConfig_Proxy(t *testing.T) {
+	var proxied, auth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		auth = r.Header.Get("Proxy-Authorization")
+	}))
+	defer proxy.Close()
+
+	u, err := url.Parse(proxy.URL)
+	assert.NoError(t, err)
+	u.User = url.UserPassword("user", "password")
+
+	// the context's proxy wins over the environment's
+	t.Setenv("HTTP_PROXY", "http://unused.example.com:3128")
+	uc := &Context{Name: "proxied", ProxyURL: u.String()}
+	client, err := uc.HTTPClient()
+	assert.NoError(t, err)
+
+	resp, err := client.Get("http://tenant.example.com/.well-known/openid-configuration")
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, "http://tenant.example.com/.well-known/openid-configuration", proxied)
+	assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("user:password")), auth)
+
+	for _, invalid := range []string{"proxy.example.com:3128", "ftp://proxy.example.com", "http://"} {
+		_, err := (&Context{Name: "invalid", ProxyURL: invalid}).HTTPClient()
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestConfig_RequestSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := &Config{}
+	cfg.SetContext(Context{Name: "slow", Timeout: Duration(90 * time.Second), Retries: 3, RetryBackoff: Duration(2 * time.Second)})
+	assert.NoError(t, cfg.Save(path))
+
+	bs, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), "timeout: 1m30s")
+	assert.Contains(t, string(bs), "retry_backoff: 2s")
+
+	loaded, err := Load(path)
+	assert.NoError(t, err)
+	uc, err := loaded.Context("slow")
+	assert.NoError(t, err)
+	assert.Equal(t, Duration(90*time.Second), uc.Timeout)
+	assert.Equal(t, 3, uc.Retries)
+	assert.Equal(t, Duration(2*time.Second), uc.RetryBackoff)
+
+	client, opts, err := uc.ClientOptions()
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Second, client.Timeout)
+	assert.Len(t, opts, 2)
+
+	_, _, err = (&Context{Name: "negative", Retries: -1}).ClientOptions()
+	assert.Error(t, err)
+
+	assert.NoError(t, os.WriteFile(path, []byte("contexts:\n- name: invalid\n  timeout: soon\n"), 0600))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "invalid duration")
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"golang.org/x/mod/semver"
+	"sigs.k8s.io/yaml"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// Problem is an issue found in a config file by Validate.
+type Problem struct {
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Path, p.Message)
+}
+
+// Validate checks the config files of path for unknown fields, missing required fields and
+// invalid settings, without connecting to any tenant.  Unlike Load, it reports every
+// problem rather than stopping at the first one.  The merged config is returned if all of
+// the files parse, so that the caller can check its contexts further.  An error is only
+// returned if a file can't be read at all.
+func Validate(path string, interactive bool) (*Config, []Problem, error) {
+	paths := filepath.SplitList(path)
+	if len(paths) == 0 {
+		return nil, nil, fmt.Errorf("config file path is required")
+	}
+
+	var problems []Problem
+	readPassphrase := passphraseReader(interactive)
+	merged := &Config{}
+	parsed := true
+	for _, p := range paths {
+		bs, _, _, err := readFile(p, readPassphrase)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		report := func(format string, args ...any) {
+			problems = append(problems, Problem{Path: p, Message: fmt.Sprintf(format, args...)})
+		}
+
+		cfg := validateFile(bs, report)
+		if cfg == nil {
+			parsed = false
+			continue
+		}
+		merged.merge(cfg)
+	}
+
+	if !parsed {
+		return nil, problems, nil
+	}
+
+	// references to contexts may be satisfied by any of the files
+	report := func(format string, args ...any) {
+		problems = append(problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if merged.CurrentContext != "" {
+		if _, err := merged.Context(merged.CurrentContext); err != nil {
+			report("current context %s is not defined, run ucctl context use to select another one", merged.CurrentContext)
+		}
+	}
+
+	for _, name := range merged.GroupNames() {
+		for _, member := range merged.Groups[name] {
+			if _, err := merged.Context(member); err != nil {
+				report("group %s references context %s, which is not defined", name, member)
+			}
+		}
+	}
+
+	return merged, problems, nil
+}
+
+// validateFile checks the contents of a single config file, returning the parsed config or
+// nil if it doesn't parse.
+func validateFile(bs []byte, report func(format string, args ...any)) *Config {
+	var raw map[string]any
+	if err := yaml.Unmarshal(bs, &raw); err != nil {
+		report("%v", err)
+		return nil
+	}
+
+	checkFields(raw, reflect.TypeOf(Config{}), "", report)
+	if defaults, ok := raw["defaults"].(map[string]any); ok {
+		checkFields(defaults, reflect.TypeOf(Defaults{}), "in defaults ", report)
+	}
+	if contexts, ok := raw["contexts"].([]any); ok {
+		for i, v := range contexts {
+			if fields, ok := v.(map[string]any); ok {
+				checkFields(fields, reflect.TypeOf(Context{}), fmt.Sprintf("in context %s ", contextLabel(fields, i)), report)
+			}
+		}
+	}
+
+	// unknown fields were reported above, so only type errors remain
+	var cfg Config
+	if err := yaml.Unmarshal(bs, &cfg); err != nil {
+		report("%v", err)
+		return nil
+	}
+
+	if err := cfg.expand(); err != nil {
+		report("%v", err)
+		return nil
+	}
+
+	if cfg.Defaults.MinUCCTLVersion != "" && !semver.IsValid(cfg.Defaults.MinUCCTLVersion) {
+		report("invalid min_ucctl_version %s in defaults, expected a version like v1.2.3", cfg.Defaults.MinUCCTLVersion)
+	}
+
+	seen := map[string]bool{}
+	for i := range cfg.Contexts {
+		uc := &cfg.Contexts[i]
+		if uc.Name != "" {
+			if seen[uc.Name] {
+				report("context %s is defined more than once, only the first definition is used", uc.Name)
+			}
+			seen[uc.Name] = true
+		}
+
+		// settings that reference unset environment variables are reported as such rather
+		// than as missing
+		required := *uc
+		if raw, ok := cfg.raw[uc.Name]; ok {
+			required = raw
+		}
+		validateContext(uc, required, contextLabel(map[string]any{"name": uc.Name}, i), report)
+	}
+
+	return &cfg
+}
+
+// validateContext checks the settings of a context, and that its required settings are set
+// before environment variables are expanded.
+func validateContext(uc *Context, required Context, label string, report func(format string, args ...any)) {
+	if uc.Name == "" {
+		report("context %s has no name", label)
+	}
+
+	// contexts without a name can't be fixed with ucctl context set
+	fix := func(flag string) string {
+		if uc.Name == "" {
+			return ""
+		}
+		return fmt.Sprintf(", set it with ucctl context set %s %s", uc.Name, flag)
+	}
+
+	if required.URL == "" {
+		report("context %s has no url%s", label, fix("--url"))
+	} else if u, err := url.Parse(uc.URL); uc.URL != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		report("context %s has invalid url %s, expected an http or https URL", label, uc.URL)
+	}
+
+	if required.ClientID == "" {
+		report("context %s has no client_id%s", label, fix("--client-id"))
+	}
+
+	if err := uc.ClientSecret.Validate(); err != nil {
+		report("context %s has an invalid client_secret location: %s", label, ucerr.FirstLine(err))
+	}
+
+	if uc.MinUCCTLVersion != "" && !semver.IsValid(uc.MinUCCTLVersion) {
+		report("context %s has invalid min_ucctl_version %s, expected a version like v1.2.3", label, uc.MinUCCTLVersion)
+	}
+
+	for _, id := range []struct{ field, value string }{{"tenant_id", uc.TenantID}, {"default_organization_id", uc.DefaultOrganizationID}} {
+		if id.value == "" {
+			continue
+		}
+		if _, err := uuid.FromString(id.value); err != nil {
+			report("context %s has invalid %s %s: %v", label, id.field, id.value, err)
+		}
+	}
+
+	for _, check := range []func() error{uc.checkUnset, uc.validateRequestSettings, func() error {
+		_, err := uc.TLSConfig()
+		return err
+	}, func() error {
+		_, err := uc.Proxy()
+		return err
+	}} {
+		if err := check(); err != nil {
+			report("%v", err)
+		}
+	}
+}
+
+// contextLabel names a context in problems, falling back to its position for contexts
+// without a name.
+func contextLabel(fields map[string]any, i int) string {
+	if name, ok := fields["name"].(string); ok && name != "" {
+		return name
+	}
+
+	return fmt.Sprintf("#%d", i+1)
+}
+
+// checkFields reports the fields of raw that t doesn't have, suggesting the known field
+// that was probably meant.
+func checkFields(raw map[string]any, t reflect.Type, where string, report func(format string, args ...any)) {
+	known := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			known[name] = true
+		}
+	}
+
+	var unknown []string
+	for name := range raw {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+
+	for _, name := range unknown {
+		msg := fmt.Sprintf("unknown field %s %s", name, where)
+		for k := range known {
+			if normalizeField(k) == normalizeField(name) {
+				msg += fmt.Sprintf("(did you mean %s?)", k)
+				break
+			}
+		}
+		report("%s", strings.TrimSpace(msg))
+	}
+}
+
+// normalizeField folds the case and separators of a field name, to catch e.g. clientID
+// or client-id for client_id.
+func normalizeField(name string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+}
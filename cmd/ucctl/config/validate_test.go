@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("UC_TEST_UNSET", "")
+	os.Unsetenv("UC_TEST_UNSET")
+
+	valid := filepath.Join(dir, "valid.yaml")
+	assert.NoError(t, os.WriteFile(valid, []byte("current_context: prod\ncontexts:\n- name: prod\n  url: https://prod.example.com\n  client_id: id\n  client_secret: env://UC_CLIENT_SECRET\n"), 0600))
+
+	cfg, problems, err := Validate(valid, false)
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+	assert.Len(t, cfg.Contexts, 1)
+
+	// every problem is reported, not just the first one
+	invalid := filepath.Join(dir, "invalid.yaml")
+	assert.NoError(t, os.WriteFile(invalid, []byte(`current_context: missing
+contexts:
+- name: prod
+  url: prod.example.com
+  clientID: id
+  retries: -1
+- name: staging
+  url: https://staging.example.com
+  client_id: ${UC_TEST_UNSET}
+  default_organization_id: not-a-uuid
+groups:
+  eu: [prod, gone]
+`), 0600))
+
+	cfg, problems, err = Validate(invalid, false)
+	assert.NoError(t, err)
+	assert.NotNil(t, cfg)
+
+	var messages []string
+	for _, p := range problems {
+		assert.Equal(t, invalid, p.Path)
+		messages = append(messages, p.Message)
+	}
+	all := strings.Join(messages, "\n")
+	assert.Contains(t, all, "unknown field clientID in context prod (did you mean client_id?)")
+	assert.Contains(t, all, "context prod has invalid url prod.example.com")
+	assert.Contains(t, all, "context prod has no client_id, set it with ucctl context set prod --client-id")
+	assert.Contains(t, all, "the retries of context prod must not be negative")
+	assert.Contains(t, all, "context staging references environment variables that are not set: UC_TEST_UNSET")
+	assert.NotContains(t, all, "context staging has no client_id")
+	assert.Contains(t, all, "context staging has invalid default_organization_id not-a-uuid")
+	assert.Contains(t, all, "current context missing is not defined")
+	assert.Contains(t, all, "group eu references context gone, which is not defined")
+
+	// references may be satisfied by another file of a path list
+	shared := filepath.Join(dir, "shared.yaml")
+	assert.NoError(t, os.WriteFile(shared, []byte("contexts:\n- name: dev\n  url: https://dev.example.com\n  client_id: id\n"), 0600))
+	personal := filepath.Join(dir, "personal.yaml")
+	assert.NoError(t, os.WriteFile(personal, []byte("current_context: dev\n"), 0600))
+
+	_, problems, err = Validate(personal+string(filepath.ListSeparator)+shared, false)
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+
+	// type errors stop the checks of the file
+	broken := filepath.Join(dir, "broken.yaml")
+	assert.NoError(t, os.WriteFile(broken, []byte("contexts:\n- name: prod\n  retries: many\n"), 0600))
+	cfg, problems, err = Validate(broken, false)
+	assert.NoError(t, err)
+	assert.Nil(t, cfg)
+	assert.Len(t, problems, 1)
+}
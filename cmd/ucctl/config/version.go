@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+
+	"golang.org/x/mod/semver"
+)
+
+// DevVersion is the version reported by builds that weren't stamped with a release
+// version.  Version pins are not enforced for these builds.
+const DevVersion = "dev"
+
+// MinVersion returns the minimum ucctl version required by the context, falling back
+// to the config defaults.
+func (c *Config) MinVersion(ctx *Context) string {
+	if ctx != nil && ctx.MinUCCTLVersion != "" {
+		return ctx.MinUCCTLVersion
+	}
+
+	return c.Defaults.MinUCCTLVersion
+}
+
+// CheckVersion returns an error if version is older than the minimum ucctl version
+// required by the context.
+func (c *Config) CheckVersion(ctx *Context, version string) error {
+	minVersion := c.MinVersion(ctx)
+	if minVersion == "" || version == DevVersion {
+		return nil
+	}
+
+	if !semver.IsValid(minVersion) {
+		return fmt.Errorf("invalid min_ucctl_version %s, expected a version like v1.2.3", minVersion)
+	}
+
+	if !semver.IsValid(version) {
+		return fmt.Errorf("ucctl version %s can't be compared to min_ucctl_version %s", version, minVersion)
+	}
+
+	if semver.Compare(version, minVersion) < 0 {
+		name := "config defaults"
+		if ctx != nil && ctx.MinUCCTLVersion != "" {
+			name = fmt.Sprintf("context %s", ctx.Name)
+		}
+		return fmt.Errorf("ucctl %s is older than %s required by %s, please upgrade", version, minVersion, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_CheckVersion(t *testing.T) {
+	cfg := &Config{Defaults: Defaults{MinUCCTLVersion: "v1.2.0"}}
+	pinned := &Context{Name: "prod", MinUCCTLVersion: "v1.4.0"}
+	unpinned := &Context{Name: "dev"}
+
+	tests := []struct {
+		name    string
+		ctx     *Context
+		version string
+		valid   bool
+	}{
+		{"no context uses defaults", nil, "v1.2.0", true},
+		{"older than defaults", unpinned, "v1.1.9", false},
+		{"context overrides defaults", pinned, "v1.3.0", false},
+		{"newer than context", pinned, "v1.4.1", true},
+		{"dev build", pinned, DevVersion, true},
+		{"unparseable version", pinned, "1.4.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cfg.CheckVersion(tt.ctx, tt.version)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	assert.NoError(t, (&Config{}).CheckVersion(unpinned, "v0.0.1"), "no pin should pass")
+}
@@ -0,0 +1,157 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"userclouds.com/infra/jsonclient"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/internal/companyconfig"
+)
+
+const (
+	DefaultSessionVar = "UC_CONSOLE_SESSION"
+
+	// sessionCookieName must match the cookie set by the console when logging in.
+	sessionCookieName = "auth-session-id"
+)
+
+// Client is a minimal client for the console API.  The console API only accepts
+// browser sessions, so requests are authenticated with the console session cookie.
+type Client struct {
+	client *jsonclient.Client
+}
+
+// NewClient returns a console client for the console at url using the session ID.
+func NewClient(url, sessionID string) *Client {
+	return &Client{
+		client: jsonclient.New(url, jsonclient.Cookie(http.Cookie{Name: sessionCookieName, Value: sessionID})),
+	}
+}
+
+// CreateCompany creates and provisions a company.  The session user becomes an admin of
+// the company.
+func (c *Client) CreateCompany(ctx context.Context, company companyconfig.Company) (*companyconfig.Company, error) {
+	req := struct {
+		Company companyconfig.Company `json:"company"`
+	}{Company: company}
+
+	var resp struct {
+		companyconfig.Company
+	}
+	if err := c.client.Post(ctx, "/api/companies", req, &resp); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &resp.Company, nil
+}
+
+// InviteUserToCompany emails an invite to join the company with the company role.
+func (c *Client) InviteUserToCompany(ctx context.Context, companyID uuid.UUID, emails []string, companyRole string) error {
+	req := struct {
+		InviteeEmails string                    `json:"invitee_emails"`
+		CompanyRole   string                    `json:"company_role"`
+		TenantRoles   companyconfig.TenantRoles `json:"tenant_roles"`
+	}{
+		InviteeEmails: strings.Join(emails, ","),
+		CompanyRole:   companyRole,
+		TenantRoles:   companyconfig.TenantRoles{},
+	}
+
+	path := fmt.Sprintf("/api/companies/%s/actions/inviteuser", companyID)
+	return ucerr.Wrap(c.client.Post(ctx, path, req, nil))
+}
+
+// tenantResponse mirrors the console response for a single tenant, we only need the
+// embedded tenant.
+type tenantResponse struct {
+	companyconfig.Tenant
+}
+
+func tenantsPath(companyID uuid.UUID) string {
+	return fmt.Sprintf("/api/companies/%s/tenants", companyID)
+}
+
+func tenantPath(companyID, tenantID uuid.UUID) string {
+	return fmt.Sprintf("%s/%s", tenantsPath(companyID), tenantID)
+}
+
+// CreateTenant asks the console to create and provision a tenant.  Provisioning happens
+// asynchronously, use WaitForTenant to wait for it to complete.
+func (c *Client) CreateTenant(ctx context.Context, tenant companyconfig.Tenant) (*companyconfig.Tenant, error) {
+	req := struct {
+		Tenant companyconfig.Tenant `json:"tenant"`
+	}{Tenant: tenant}
+
+	var resp tenantResponse
+	if err := c.client.Post(ctx, tenantsPath(tenant.CompanyID), req, &resp); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &resp.Tenant, nil
+}
+
+// GetTenant returns a tenant of the company.
+func (c *Client) GetTenant(ctx context.Context, companyID, tenantID uuid.UUID) (*companyconfig.Tenant, error) {
+	var resp tenantResponse
+	if err := c.client.Get(ctx, tenantPath(companyID, tenantID), &resp); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &resp.Tenant, nil
+}
+
+// ListTenants returns the tenants of the company.
+func (c *Client) ListTenants(ctx context.Context, companyID uuid.UUID) ([]companyconfig.Tenant, error) {
+	var resp []tenantResponse
+	if err := c.client.Get(ctx, tenantsPath(companyID), &resp); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	tenants := make([]companyconfig.Tenant, 0, len(resp))
+	for _, t := range resp {
+		tenants = append(tenants, t.Tenant)
+	}
+
+	return tenants, nil
+}
+
+// DeleteTenant deprovisions and deletes a tenant of the company.
+func (c *Client) DeleteTenant(ctx context.Context, companyID, tenantID uuid.UUID) error {
+	return ucerr.Wrap(c.client.Delete(ctx, tenantPath(companyID, tenantID), nil))
+}
+
+// WaitForTenant polls the tenant until provisioning has finished or the context is done.
+func (c *Client) WaitForTenant(ctx context.Context, companyID, tenantID uuid.UUID, interval time.Duration) (*companyconfig.Tenant, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		tenant, err := c.GetTenant(ctx, companyID, tenantID)
+		// the tenant may not be visible until the worker has picked up the create message
+		if err != nil && !jsonclient.IsHTTPNotFound(err) {
+			return nil, ucerr.Wrap(err)
+		}
+
+		if tenant != nil {
+			if tenant.State.IsFailed() {
+				return nil, ucerr.Errorf("tenant %s failed to provision", tenantID)
+			}
+
+			if tenant.State.IsActive() {
+				return tenant, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ucerr.Errorf("timed out waiting for tenant %s: %v", tenantID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
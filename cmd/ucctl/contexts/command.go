@@ -0,0 +1,45 @@
+package contexts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+	"userclouds.com/infra/logtransports"
+	"userclouds.com/infra/uclog"
+)
+
+// Command holds the options shared by all of the context subcommands.
+type Command struct {
+	Verbose bool
+}
+
+// run initializes logging and then calls fn with the loaded config.  Errors are logged
+// here since the root command silences them.
+func (c *Command) run(cmd *cobra.Command, name string, fn func(ctx context.Context, state *config.State) error) error {
+	ctx := cmd.Context()
+
+	logLevel := uclog.LogLevelInfo
+	if c.Verbose {
+		logLevel = uclog.LogLevelDebug
+	}
+
+	logtransports.InitLoggerAndTransportsForTools(ctx, logLevel, logLevel, name)
+	defer logtransports.Close()
+
+	state := config.FromContext(ctx)
+	if state == nil {
+		err := fmt.Errorf("config was not loaded")
+		uclog.Errorf(ctx, "%v", err)
+		return err
+	}
+
+	if err := fn(ctx, state); err != nil {
+		uclog.Errorf(ctx, "%v", err)
+		return err
+	}
+
+	return nil
+}
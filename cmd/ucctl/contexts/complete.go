@@ -0,0 +1,62 @@
+package contexts
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+)
+
+// CompleteName completes a context name, e.g. for the --context flag.
+func CompleteName(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	path, err := config.PathFromFlags(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cfg, err := config.LoadNonInteractive(path)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, uc := range cfg.Contexts {
+		if strings.HasPrefix(uc.Name, toComplete) {
+			names = append(names, uc.Name)
+		}
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeNameArg completes the context name argument of a subcommand.
+func completeNameArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return CompleteName(cmd, args, toComplete)
+}
+
+// CompleteGroup completes a context group name, e.g. for the --context-group flag.
+func CompleteGroup(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	path, err := config.PathFromFlags(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cfg, err := config.LoadNonInteractive(path)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, name := range cfg.GroupNames() {
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,62 @@
+package contexts
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+)
+
+const (
+	CurrentUsage = "current"
+	CurrentShort = "Print the current context"
+	CurrentLong  = `Print the name of the current context, or with --url or --client-id the tenant URL or client
+ID of the current context, for shell scripts and prompts.  The context selected with --context
+or UC_CONTEXT is printed if there is one.  Nothing else is written to stdout, and the command
+fails if no context is selected.`
+)
+
+// CurrentCommand prints the current context.
+type CurrentCommand struct {
+	*Command
+	URL      bool
+	ClientID bool
+}
+
+// CurrentCommand returns the current subcommand.
+func (c *Command) CurrentCommand() *cobra.Command {
+	cc := &CurrentCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   CurrentUsage,
+		Short: CurrentShort,
+		Long:  CurrentLong,
+		Args:  cobra.NoArgs,
+		RunE:  cc.RunE,
+	}
+
+	cmd.Flags().BoolVarP(&cc.URL, "url", "", false, "print the tenant URL of the current context")
+	cmd.Flags().BoolVarP(&cc.ClientID, "client-id", "", false, "print the client ID of the current context")
+	cmd.MarkFlagsMutuallyExclusive("url", "client-id")
+	return cmd
+}
+
+// RunE doesn't log like the other subcommands, since the tool logger writes to stdout.
+func (c *CurrentCommand) RunE(cmd *cobra.Command, args []string) error {
+	uc := config.CurrentContext(cmd.Context())
+	if uc == nil {
+		err := fmt.Errorf("no context selected")
+		fmt.Fprintln(cmd.ErrOrStderr(), err)
+		return err
+	}
+
+	value := uc.Name
+	if c.URL {
+		value = uc.URL
+	} else if c.ClientID {
+		value = uc.ClientID
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), value)
+	return nil
+}
@@ -0,0 +1,69 @@
+package contexts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+	"userclouds.com/infra/secret/provider/keyring"
+	"userclouds.com/infra/uclog"
+)
+
+const (
+	DeleteUsage = "delete NAME"
+	DeleteShort = "Delete a context"
+	DeleteLong  = `Delete a context.  If it is the current context, no context will be selected.  A client
+secret stored in the OS keyring and the token cached by ucctl login are deleted as well.`
+)
+
+// DeleteCommand deletes a context.
+type DeleteCommand struct {
+	*Command
+}
+
+// DeleteCommand returns the delete subcommand.
+func (c *Command) DeleteCommand() *cobra.Command {
+	d := &DeleteCommand{Command: c}
+	return &cobra.Command{
+		Use:               DeleteUsage,
+		Short:             DeleteShort,
+		Long:              DeleteLong,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeNameArg,
+		RunE:              d.RunE,
+	}
+}
+
+func (c *DeleteCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "context-delete", func(ctx context.Context, state *config.State) error {
+		name := args[0]
+		uc, err := state.Config.Context(name)
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(uc.ClientSecret.Location(), keyring.Prefix) {
+			if err := uc.ClientSecret.Delete(ctx); err != nil {
+				uclog.Warningf(ctx, "failed to delete the client secret of context %s from the keyring: %v", name, err)
+			}
+		}
+
+		if err := state.Config.DeleteContext(name); err != nil {
+			return err
+		}
+
+		if err := config.DeleteToken(state.Path, name); err != nil {
+			return err
+		}
+
+		if err := state.Config.Save(state.Path); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Deleted context %s\n", name)
+		return nil
+	})
+}
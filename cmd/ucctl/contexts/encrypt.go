@@ -0,0 +1,101 @@
+package contexts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+)
+
+const (
+	EncryptUsage = "encrypt"
+	EncryptShort = "Encrypt the config file"
+	EncryptLong  = `Encrypt the config file with a passphrase, so that the client secrets it holds are not
+stored in plaintext.  The passphrase is read from the UC_CONFIG_PASSPHRASE environment
+variable, or prompted for on the terminal, whenever ucctl loads the config.  Running encrypt
+on an encrypted config changes its passphrase.`
+	DecryptUsage = "decrypt"
+	DecryptShort = "Decrypt the config file"
+	DecryptLong  = `Decrypt the config file, storing it in plaintext again.`
+)
+
+// EncryptCommand encrypts the config file.
+type EncryptCommand struct {
+	*Command
+}
+
+// EncryptCommand returns the encrypt subcommand.
+func (c *Command) EncryptCommand() *cobra.Command {
+	e := &EncryptCommand{Command: c}
+	return &cobra.Command{
+		Use:   EncryptUsage,
+		Short: EncryptShort,
+		Long:  EncryptLong,
+		Args:  cobra.NoArgs,
+		RunE:  e.RunE,
+	}
+}
+
+func (c *EncryptCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "context-encrypt", func(ctx context.Context, state *config.State) error {
+		passphrase, err := config.ReadPassphrase("New passphrase")
+		if err != nil {
+			return err
+		}
+
+		confirm, err := config.ReadPassphrase("Confirm passphrase")
+		if err != nil {
+			return err
+		}
+
+		if passphrase != confirm {
+			return fmt.Errorf("passphrases don't match")
+		}
+
+		if err := state.Config.Encrypt(passphrase); err != nil {
+			return err
+		}
+
+		if err := state.Config.Save(state.Path); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Encrypted %s\n", config.PrimaryPath(state.Path))
+		return nil
+	})
+}
+
+// DecryptCommand decrypts the config file.
+type DecryptCommand struct {
+	*Command
+}
+
+// DecryptCommand returns the decrypt subcommand.
+func (c *Command) DecryptCommand() *cobra.Command {
+	d := &DecryptCommand{Command: c}
+	return &cobra.Command{
+		Use:   DecryptUsage,
+		Short: DecryptShort,
+		Long:  DecryptLong,
+		Args:  cobra.NoArgs,
+		RunE:  d.RunE,
+	}
+}
+
+func (c *DecryptCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "context-decrypt", func(ctx context.Context, state *config.State) error {
+		if !state.Config.Encrypted() {
+			return fmt.Errorf("%s is not encrypted", config.PrimaryPath(state.Path))
+		}
+
+		state.Config.Decrypt()
+		if err := state.Config.Save(state.Path); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Decrypted %s\n", config.PrimaryPath(state.Path))
+		return nil
+	})
+}
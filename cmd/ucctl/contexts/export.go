@@ -0,0 +1,97 @@
+package contexts
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"userclouds.com/cmd/ucctl/config"
+	"userclouds.com/infra/secret"
+	"userclouds.com/infra/uclog"
+)
+
+const (
+	ExportUsage = "export NAME"
+	ExportShort = "Export a context"
+	ExportLong  = `Export a context as YAML, to stdout or to a file (-f), so that it can be shared and
+added to another config with ucctl context import.
+
+With --redact the client secret and any proxy password are left out, which should be used
+unless the client secret is a location that makes sense on other machines, e.g.
+env://UC_CLIENT_SECRET.`
+)
+
+// ExportCommand exports a context.
+type ExportCommand struct {
+	*Command
+	File   string
+	Redact bool
+}
+
+// ExportCommand returns the export subcommand.
+func (c *Command) ExportCommand() *cobra.Command {
+	e := &ExportCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:               ExportUsage,
+		Short:             ExportShort,
+		Long:              ExportLong,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeNameArg,
+		RunE:              e.RunE,
+	}
+
+	cmd.Flags().StringVarP(&e.File, "file", "f", "", "file to write the context to, defaults to stdout")
+	cmd.Flags().BoolVarP(&e.Redact, "redact", "", false, "leave out the client secret and proxy password")
+	return cmd
+}
+
+func (c *ExportCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "context-export", func(ctx context.Context, state *config.State) error {
+		name := args[0]
+		// settings referencing environment variables are exported as ${VAR}
+		uc, err := state.Config.RawContext(name)
+		if err != nil {
+			return err
+		}
+
+		if c.Redact {
+			uc.ClientSecret = secret.String{}
+			uc.ProxyURL = redactProxyURL(uc.ProxyURL)
+		} else if !uc.ClientSecret.IsEmpty() && !uc.ClientSecret.HasPrefix() {
+			uclog.Warningf(ctx, "the exported context %s includes its plaintext client secret, use --redact to leave it out", name)
+		}
+
+		bs, err := yaml.Marshal(uc)
+		if err != nil {
+			return fmt.Errorf("failed to marshal context %s: %v", name, err)
+		}
+
+		if c.File == "" {
+			_, err := cmd.OutOrStdout().Write(bs)
+			return err
+		}
+
+		if err := os.WriteFile(c.File, bs, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %v", c.File, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Exported context %s to %s\n", name, c.File)
+		return nil
+	})
+}
+
+// redactProxyURL removes the password from a proxy URL, keeping the user so that it is
+// clear which credentials the proxy needs.
+func redactProxyURL(proxyURL string) string {
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.User == nil {
+		return proxyURL
+	}
+
+	u.User = url.User(u.User.Username())
+	return u.String()
+}
@@ -0,0 +1,164 @@
+package contexts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+)
+
+const (
+	GroupUsage = "group [COMMAND]"
+	GroupShort = "Manage context groups"
+	GroupLong  = `Manage context groups.  A group is a named list of contexts, e.g. the tenants of a region,
+and commands that connect to a tenant run against each context of the group with
+--context-group, followed by a summary of the contexts that failed.`
+
+	GroupListUsage = "list"
+	GroupListShort = "List the context groups"
+	GroupListLong  = `List the context groups and their contexts.`
+
+	GroupSetUsage = "set NAME CONTEXT..."
+	GroupSetShort = "Create or update a context group"
+	GroupSetLong  = `Create or update a context group, replacing its contexts with the ones given in order.`
+
+	GroupDeleteUsage = "delete NAME"
+	GroupDeleteShort = "Delete a context group"
+	GroupDeleteLong  = `Delete a context group.  The contexts of the group are not deleted.`
+)
+
+// GroupCommand returns the group subcommand.
+func (c *Command) GroupCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   GroupUsage,
+		Short: GroupShort,
+		Long:  GroupLong,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(c.GroupListCommand())
+	cmd.AddCommand(c.GroupSetCommand())
+	cmd.AddCommand(c.GroupDeleteCommand())
+	return cmd
+}
+
+// GroupListCommand lists the context groups.
+type GroupListCommand struct {
+	*Command
+}
+
+// GroupListCommand returns the group list subcommand.
+func (c *Command) GroupListCommand() *cobra.Command {
+	l := &GroupListCommand{Command: c}
+	return &cobra.Command{
+		Use:   GroupListUsage,
+		Short: GroupListShort,
+		Long:  GroupListLong,
+		Args:  cobra.NoArgs,
+		RunE:  l.RunE,
+	}
+}
+
+func (c *GroupListCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "context-group-list", func(ctx context.Context, state *config.State) error {
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tCONTEXTS")
+		for _, name := range state.Config.GroupNames() {
+			fmt.Fprintf(w, "%s\t%s\n", name, strings.Join(state.Config.Groups[name], ","))
+		}
+
+		return w.Flush()
+	})
+}
+
+// GroupSetCommand creates or updates a context group.
+type GroupSetCommand struct {
+	*Command
+}
+
+// GroupSetCommand returns the group set subcommand.
+func (c *Command) GroupSetCommand() *cobra.Command {
+	s := &GroupSetCommand{Command: c}
+	return &cobra.Command{
+		Use:               GroupSetUsage,
+		Short:             GroupSetShort,
+		Long:              GroupSetLong,
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: completeGroupSetArgs,
+		RunE:              s.RunE,
+	}
+}
+
+func (c *GroupSetCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "context-group-set", func(ctx context.Context, state *config.State) error {
+		name := args[0]
+		if err := state.Config.SetGroup(name, args[1:]); err != nil {
+			return err
+		}
+
+		if err := state.Config.Save(state.Path); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Saved context group %s\n", name)
+		return nil
+	})
+}
+
+// GroupDeleteCommand deletes a context group.
+type GroupDeleteCommand struct {
+	*Command
+}
+
+// GroupDeleteCommand returns the group delete subcommand.
+func (c *Command) GroupDeleteCommand() *cobra.Command {
+	d := &GroupDeleteCommand{Command: c}
+	return &cobra.Command{
+		Use:               GroupDeleteUsage,
+		Short:             GroupDeleteShort,
+		Long:              GroupDeleteLong,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeGroupArg,
+		RunE:              d.RunE,
+	}
+}
+
+func (c *GroupDeleteCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "context-group-delete", func(ctx context.Context, state *config.State) error {
+		name := args[0]
+		if err := state.Config.DeleteGroup(name); err != nil {
+			return err
+		}
+
+		if err := state.Config.Save(state.Path); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Deleted context group %s\n", name)
+		return nil
+	})
+}
+
+// completeGroupArg completes the group name argument of a subcommand.
+func completeGroupArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return CompleteGroup(cmd, args, toComplete)
+}
+
+// completeGroupSetArgs completes the group name and then the context names of group set.
+func completeGroupSetArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return CompleteGroup(cmd, args, toComplete)
+	}
+
+	return CompleteName(cmd, args, toComplete)
+}
@@ -0,0 +1,104 @@
+package contexts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"userclouds.com/cmd/ucctl/config"
+)
+
+const (
+	ImportUsage = "import"
+	ImportShort = "Import a context"
+	ImportLong  = `Import a context exported with ucctl context export from a file (-f), or from stdin with
+-f -.  The context keeps its exported name unless --name is set, and an existing context with
+the same name is only replaced with --overwrite.  A redacted context has no client secret,
+set one with ucctl context set --client-secret or use ucctl login.`
+)
+
+// ImportCommand imports a context.
+type ImportCommand struct {
+	*Command
+	File      string
+	Name      string
+	Overwrite bool
+	Use       bool
+}
+
+// ImportCommand returns the import subcommand.
+func (c *Command) ImportCommand() *cobra.Command {
+	i := &ImportCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   ImportUsage,
+		Short: ImportShort,
+		Long:  ImportLong,
+		Args:  cobra.NoArgs,
+		RunE:  i.RunE,
+	}
+
+	cmd.Flags().StringVarP(&i.File, "file", "f", "", "file to read the context from, - for stdin")
+	cmd.Flags().StringVarP(&i.Name, "name", "", "", "name of the imported context, defaults to the exported name")
+	cmd.Flags().BoolVarP(&i.Overwrite, "overwrite", "", false, "replace an existing context with the same name")
+	cmd.Flags().BoolVarP(&i.Use, "use", "", false, "make the imported context the current context")
+	return cmd
+}
+
+func (c *ImportCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "context-import", func(ctx context.Context, state *config.State) error {
+		if c.File == "" {
+			return fmt.Errorf("context file is required")
+		}
+
+		var bs []byte
+		var err error
+		if c.File == "-" {
+			bs, err = io.ReadAll(cmd.InOrStdin())
+		} else {
+			bs, err = os.ReadFile(c.File)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", c.File, err)
+		}
+
+		var uc config.Context
+		if err := yaml.UnmarshalStrict(bs, &uc); err != nil {
+			return fmt.Errorf("failed to parse %s: %v", c.File, err)
+		}
+
+		if c.Name != "" {
+			uc.Name = c.Name
+		}
+
+		if uc.Name == "" {
+			return fmt.Errorf("context name is required, use --name")
+		}
+
+		if _, err := state.Config.Context(uc.Name); err == nil {
+			if !c.Overwrite {
+				return fmt.Errorf("context %s already exists, use --overwrite to replace it", uc.Name)
+			}
+
+			// the cached token belongs to the replaced context
+			if err := config.DeleteToken(state.Path, uc.Name); err != nil {
+				return err
+			}
+		}
+
+		state.Config.SetContext(uc)
+		if c.Use || state.Config.CurrentContext == "" {
+			state.Config.CurrentContext = uc.Name
+		}
+
+		if err := state.Config.Save(state.Path); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Imported context %s\n", uc.Name)
+		return nil
+	})
+}
@@ -0,0 +1,177 @@
+package contexts
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+	"userclouds.com/cmd/ucctl/prompt"
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/secret"
+)
+
+const (
+	InitUsage = "init [NAME]"
+	InitShort = "Set up a context interactively"
+	InitLong  = `Set up a context interactively, prompting for the tenant URL, client ID and client secret.
+The client secret can be saved in the OS keyring, referenced by a secret location such as
+env://UC_CLIENT_SECRET, or stored in the config file.  The credentials are checked by
+exchanging them for a token before the context is saved.`
+
+	secretStorageKeyring  = "keyring"
+	secretStorageLocation = "location"
+	secretStorageConfig   = "config"
+)
+
+// InitCommand sets up a context interactively.
+type InitCommand struct {
+	*Command
+}
+
+// InitCommand returns the init subcommand.
+func (c *Command) InitCommand() *cobra.Command {
+	i := &InitCommand{Command: c}
+	return &cobra.Command{
+		Use:   InitUsage,
+		Short: InitShort,
+		Long:  InitLong,
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  i.RunE,
+	}
+}
+
+func (c *InitCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "context-init", func(ctx context.Context, state *config.State) error {
+		p := prompt.New(cmd.InOrStdin(), cmd.ErrOrStderr())
+
+		var name string
+		if len(args) > 0 {
+			name = args[0]
+		} else {
+			var err error
+			if name, err = p.String("Context name", "default", prompt.NonEmpty); err != nil {
+				return err
+			}
+		}
+
+		uc := config.Context{Name: name}
+		if existing, err := state.Config.Context(name); err == nil {
+			overwrite, err := p.Choice(fmt.Sprintf("Context %s exists, overwrite it?", name), []string{"yes", "no"}, "no")
+			if err != nil {
+				return err
+			}
+			if overwrite != "yes" {
+				return fmt.Errorf("context %s was not changed", name)
+			}
+			uc = *existing
+		}
+
+		var err error
+		if uc.URL, err = p.String("Tenant URL", uc.URL, validateTenantURL); err != nil {
+			return err
+		}
+
+		if uc.ClientID, err = p.String("Client ID", uc.ClientID, prompt.NonEmpty); err != nil {
+			return err
+		}
+
+		storage, err := p.Choice("Store the client secret in", []string{secretStorageKeyring, secretStorageLocation, secretStorageConfig}, secretStorageKeyring)
+		if err != nil {
+			return err
+		}
+
+		var clientSecret string
+		if storage == secretStorageLocation {
+			loc, err := p.String("Client secret location", "env://UC_CLIENT_SECRET", prompt.NonEmpty)
+			if err != nil {
+				return err
+			}
+
+			uc.ClientSecret = *secret.FromLocation(loc)
+			if clientSecret, err = uc.ResolveClientSecret(ctx); err != nil {
+				return err
+			}
+		} else if clientSecret, err = p.Password("Client secret"); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "Checking the credentials with %s...\n", uc.URL)
+		if err := checkCredentials(uc.URL, uc.ClientID, clientSecret); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "  %v\n", err)
+			save, err := p.Choice("Save the context anyway?", []string{"yes", "no"}, "no")
+			if err != nil {
+				return err
+			}
+			if save != "yes" {
+				return fmt.Errorf("context %s was not saved", name)
+			}
+		}
+
+		switch storage {
+		case secretStorageKeyring:
+			cs, err := saveToKeyring(ctx, name, clientSecret)
+			if err != nil {
+				return err
+			}
+			uc.ClientSecret = *cs
+		case secretStorageConfig:
+			uc.ClientSecret = *secret.FromLocation(clientSecret)
+		}
+
+		// a cached token belongs to the previous credentials
+		if err := config.DeleteToken(state.Path, name); err != nil {
+			return err
+		}
+
+		state.Config.SetContext(uc)
+		if state.Config.CurrentContext != name {
+			use := "yes"
+			if state.Config.CurrentContext != "" {
+				if use, err = p.Choice("Make it the current context?", []string{"yes", "no"}, "yes"); err != nil {
+					return err
+				}
+			}
+			if use == "yes" {
+				state.Config.CurrentContext = name
+			}
+		}
+
+		if err := state.Config.Save(state.Path); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Saved context %s\n", name)
+		return nil
+	})
+}
+
+func validateTenantURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("enter an http or https URL, e.g. https://mytenant.tenant.userclouds.com")
+	}
+
+	return nil
+}
+
+// checkCredentials exchanges the client credentials for a token.
+func checkCredentials(tenantURL, clientID, clientSecret string) error {
+	tokenURL, err := config.TokenURL(tenantURL)
+	if err != nil {
+		return err
+	}
+
+	ts := oidc.ClientCredentialsTokenSource{
+		TokenURL:     tokenURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+	}
+	if _, err := ts.GetToken(); err != nil {
+		return fmt.Errorf("failed to get a token: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package contexts
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+)
+
+const (
+	ListUsage = "list"
+	ListShort = "List the configured contexts"
+	ListLong  = `List the configured contexts.  The current context is marked with a *.`
+)
+
+// ListCommand lists the configured contexts.
+type ListCommand struct {
+	*Command
+}
+
+// ListCommand returns the list subcommand.
+func (c *Command) ListCommand() *cobra.Command {
+	l := &ListCommand{Command: c}
+	return &cobra.Command{
+		Use:   ListUsage,
+		Short: ListShort,
+		Long:  ListLong,
+		Args:  cobra.NoArgs,
+		RunE:  l.RunE,
+	}
+}
+
+func (c *ListCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "context-list", func(ctx context.Context, state *config.State) error {
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "CURRENT\tNAME\tURL\tCLIENT ID")
+		for _, uc := range state.Config.Contexts {
+			current := ""
+			if uc.Name == state.Config.CurrentContext {
+				current = "*"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", current, uc.Name, uc.URL, uc.ClientID)
+		}
+
+		return w.Flush()
+	})
+}
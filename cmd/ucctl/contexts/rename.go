@@ -0,0 +1,57 @@
+package contexts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+)
+
+const (
+	RenameUsage = "rename OLD NEW"
+	RenameShort = "Rename a context"
+	RenameLong  = `Rename a context.  If it is the current context, the current context is updated to the
+new name.`
+)
+
+// RenameCommand renames a context.
+type RenameCommand struct {
+	*Command
+}
+
+// RenameCommand returns the rename subcommand.
+func (c *Command) RenameCommand() *cobra.Command {
+	r := &RenameCommand{Command: c}
+	return &cobra.Command{
+		Use:               RenameUsage,
+		Short:             RenameShort,
+		Long:              RenameLong,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeNameArg,
+		RunE:              r.RunE,
+	}
+}
+
+func (c *RenameCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "context-rename", func(ctx context.Context, state *config.State) error {
+		oldName, newName := args[0], args[1]
+		if err := state.Config.RenameContext(oldName, newName); err != nil {
+			return err
+		}
+
+		if err := state.Config.Save(state.Path); err != nil {
+			return err
+		}
+
+		if oldName != newName {
+			if err := config.RenameToken(state.Path, oldName, newName); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Renamed context %s to %s\n", oldName, newName)
+		return nil
+	})
+}
@@ -0,0 +1,224 @@
+package contexts
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/authz"
+	"userclouds.com/cmd/ucctl/config"
+	"userclouds.com/infra/jsonclient"
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/pagination"
+)
+
+const (
+	TestUsage = "test [NAME]"
+	TestShort = "Test the connection to the tenant of a context"
+	TestLong  = `Test the connection to the tenant of a context, the current context if no name is given.
+Three checks are run in order and reported with their latency:
+
+  connect  the tenant's OpenID configuration can be fetched
+  token    a token is issued for the context's client credentials
+  read     object types can be listed with the token
+
+A context without a client secret uses the valid token cached by ucctl login, if it has one.`
+
+	DefaultTestTimeout = 10 * time.Second
+)
+
+// TestCommand tests the connection to the tenant of a context.
+type TestCommand struct {
+	*Command
+	Timeout time.Duration
+}
+
+// testResult is the outcome of a single check.
+type testResult struct {
+	name    string
+	err     error
+	latency time.Duration
+	detail  string
+}
+
+// TestCommand returns the test subcommand.
+func (c *Command) TestCommand() *cobra.Command {
+	t := &TestCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:               TestUsage,
+		Short:             TestShort,
+		Long:              TestLong,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeNameArg,
+		RunE:              t.RunE,
+	}
+
+	cmd.Flags().DurationVarP(&t.Timeout, "timeout", "", DefaultTestTimeout, "timeout of each check, defaults to the context's timeout if it sets one")
+	return cmd
+}
+
+func (c *TestCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "context-test", func(ctx context.Context, state *config.State) error {
+		uc := state.Current
+		if len(args) > 0 {
+			var err error
+			if uc, err = state.Config.Context(args[0]); err != nil {
+				return err
+			}
+		}
+		if uc == nil {
+			return fmt.Errorf("no context selected")
+		}
+
+		if uc.URL == "" {
+			return fmt.Errorf("context %s has no tenant URL", uc.Name)
+		}
+
+		// the context's request timeout applies to the checks unless --timeout is set
+		if !cmd.Flags().Changed("timeout") && uc.Timeout > 0 {
+			c.Timeout = time.Duration(uc.Timeout)
+		}
+
+		results := c.test(ctx, state, uc)
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "CHECK\tSTATUS\tLATENCY\tDETAIL")
+		var failed error
+		for _, r := range results {
+			status, detail := "ok", r.detail
+			if r.err != nil {
+				status, detail = "failed", r.err.Error()
+				failed = fmt.Errorf("context %s failed the %s check", uc.Name, r.name)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.name, status, r.latency.Round(time.Millisecond), detail)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		return failed
+	})
+}
+
+// test runs the checks, stopping at the first one that fails since the later ones
+// depend on it.
+func (c *TestCommand) test(ctx context.Context, state *config.State, uc *config.Context) []testResult {
+	var results []testResult
+	check := func(name string, fn func(ctx context.Context) (string, error)) bool {
+		ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+
+		start := time.Now()
+		detail, err := fn(ctx)
+		results = append(results, testResult{name: name, err: err, latency: time.Since(start), detail: detail})
+		return err == nil
+	}
+
+	var client *http.Client
+	var opts []jsonclient.Option
+	if !check("connect", func(ctx context.Context) (string, error) {
+		var err error
+		if client, opts, err = uc.ClientOptions(); err != nil {
+			return "", err
+		}
+		return connect(ctx, client, uc.URL)
+	}) {
+		return results
+	}
+
+	var token string
+	if !check("token", func(ctx context.Context) (string, error) {
+		var detail string
+		var err error
+		token, detail, err = issueToken(ctx, client, state, uc)
+		return detail, err
+	}) {
+		return results
+	}
+
+	check("read", func(ctx context.Context) (string, error) {
+		azc, err := authz.NewClient(uc.URL, authz.JSONClient(append(opts, jsonclient.HeaderAuthBearer(token))...))
+		if err != nil {
+			return "", err
+		}
+
+		resp, err := azc.ListObjectTypesPaginated(ctx, authz.Pagination(pagination.Limit(1)))
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("listed %d object type(s)", len(resp.Data)), nil
+	})
+
+	return results
+}
+
+// connect fetches the tenant's OpenID configuration.
+func connect(ctx context.Context, client *http.Client, tenantURL string) (string, error) {
+	u, err := url.JoinPath(tenantURL, "/.well-known/openid-configuration")
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected response %s", resp.Status)
+	}
+
+	return tenantURL, nil
+}
+
+// issueToken exchanges the context's client credentials for a token, bypassing the token
+// cache so that the credentials themselves are tested.
+func issueToken(ctx context.Context, client *http.Client, state *config.State, uc *config.Context) (string, string, error) {
+	clientSecret, err := uc.ResolveClientSecret(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	if clientSecret == "" {
+		t, err := config.LoadToken(state.Path, uc.Name)
+		if err != nil {
+			return "", "", err
+		}
+
+		if !t.Valid(time.Now().UTC()) {
+			return "", "", fmt.Errorf("context has no client secret or valid token, run ucctl login")
+		}
+
+		return t.AccessToken, fmt.Sprintf("using the token cached by ucctl login, expires at %s", t.ExpiresAt.Local().Format(time.RFC1123)), nil
+	}
+
+	tokenURL, err := config.TokenURL(uc.URL)
+	if err != nil {
+		return "", "", err
+	}
+
+	ts := oidc.ClientCredentialsTokenSource{
+		TokenURL:     tokenURL,
+		ClientID:     uc.ClientID,
+		ClientSecret: clientSecret,
+		HTTPClient:   client,
+	}
+	token, err := ts.GetToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	return token, fmt.Sprintf("issued for client %s", uc.ClientID), nil
+}
@@ -0,0 +1,51 @@
+package contexts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+)
+
+const (
+	UseUsage = "use NAME"
+	UseShort = "Set the current context"
+	UseLong  = `Set the current context used by commands that connect to a tenant.`
+)
+
+// UseCommand sets the current context.
+type UseCommand struct {
+	*Command
+}
+
+// UseCommand returns the use subcommand.
+func (c *Command) UseCommand() *cobra.Command {
+	u := &UseCommand{Command: c}
+	return &cobra.Command{
+		Use:               UseUsage,
+		Short:             UseShort,
+		Long:              UseLong,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeNameArg,
+		RunE:              u.RunE,
+	}
+}
+
+func (c *UseCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "context-use", func(ctx context.Context, state *config.State) error {
+		name := args[0]
+		if _, err := state.Config.Context(name); err != nil {
+			return err
+		}
+
+		state.Config.CurrentContext = name
+		if err := state.Config.Save(state.Path); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Switched to context %s\n", name)
+		return nil
+	})
+}
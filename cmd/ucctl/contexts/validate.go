@@ -0,0 +1,108 @@
+package contexts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+)
+
+const (
+	ValidateUsage = "validate"
+	ValidateShort = "Check the config file for problems"
+	ValidateLong  = `Check the config file, or each file of a path list, for unknown fields, missing required
+fields and invalid settings such as client secret locations, certificate files and proxy URLs.
+Unlike other commands, which stop at the first problem, every problem found is printed.
+
+With --online, the tenant URL of each context is also checked to be reachable.  The command
+fails if any problem is found.`
+)
+
+// ValidateCommand checks the config file for problems.
+type ValidateCommand struct {
+	*Command
+	Online  bool
+	Timeout time.Duration
+}
+
+// ValidateCommand returns the validate subcommand.
+func (c *Command) ValidateCommand() *cobra.Command {
+	v := &ValidateCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   ValidateUsage,
+		Short: ValidateShort,
+		Long:  ValidateLong,
+		Args:  cobra.NoArgs,
+		RunE:  v.RunE,
+	}
+
+	cmd.Flags().BoolVarP(&v.Online, "online", "", false, "check that the tenant URL of each context is reachable")
+	cmd.Flags().DurationVarP(&v.Timeout, "timeout", "", DefaultTestTimeout, "timeout of each online check")
+	return cmd
+}
+
+// RunE reads the config itself since the root command doesn't load an invalid config, and
+// doesn't log like the other subcommands so that only the problems are printed.
+func (c *ValidateCommand) RunE(cmd *cobra.Command, args []string) error {
+	path, err := config.PathFromFlags(cmd)
+	if err != nil {
+		fmt.Fprintln(cmd.ErrOrStderr(), err)
+		return err
+	}
+
+	cfg, problems, err := config.Validate(path, true)
+	if err != nil {
+		fmt.Fprintln(cmd.ErrOrStderr(), err)
+		return err
+	}
+
+	if cfg != nil && c.Online {
+		problems = append(problems, c.checkOnline(cmd.Context(), path, cfg)...)
+	}
+
+	out := cmd.OutOrStdout()
+	for _, p := range problems {
+		fmt.Fprintln(out, p)
+	}
+
+	if len(problems) > 0 {
+		err := fmt.Errorf("found %d problem(s) in %s", len(problems), path)
+		fmt.Fprintln(cmd.ErrOrStderr(), err)
+		return err
+	}
+
+	fmt.Fprintf(out, "%s is valid\n", path)
+	return nil
+}
+
+// checkOnline checks that the tenant of each context can be reached with its connection
+// settings.  Contexts whose settings are invalid were already reported and are skipped.
+func (c *ValidateCommand) checkOnline(ctx context.Context, path string, cfg *config.Config) []config.Problem {
+	var problems []config.Problem
+	for i := range cfg.Contexts {
+		uc := &cfg.Contexts[i]
+		if uc.Name == "" || uc.URL == "" {
+			continue
+		}
+
+		client, err := uc.HTTPClient()
+		if err != nil {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+		_, err = connect(ctx, client, uc.URL)
+		cancel()
+		if err != nil {
+			problems = append(problems, config.Problem{
+				Path:    path,
+				Message: fmt.Sprintf("context %s can't reach %s: %v, check the url and proxy_url", uc.Name, uc.URL, err),
+			})
+		}
+	}
+
+	return problems
+}
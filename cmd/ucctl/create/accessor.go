@@ -0,0 +1,158 @@
+package create
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/idp/policy"
+	"userclouds.com/idp/userstore"
+)
+
+const (
+	AccessorUsage = "accessor"
+	AccessorShort = "Create a userstore accessor"
+	AccessorLong  = `Create a userstore accessor from a YAML or JSON file (-f), or from flags for simple cases.
+Columns are specified with repeated --column flags in the form <column>[:<transformer>], and columns,
+purposes, transformers and access policies may be referenced by name or ID.  Flags override the
+corresponding values in the file.`
+
+	DefaultAccessorSelector = "{id} = ANY (?)"
+)
+
+// AccessorCommand creates a userstore accessor.
+type AccessorCommand struct {
+	*Command
+	File          string
+	Name          string
+	Description   string
+	Columns       []string
+	Purposes      []string
+	Selector      string
+	AccessPolicy  string
+	IsAuditLogged bool
+}
+
+// AccessorCommand returns the accessor subcommand.
+func (c *Command) AccessorCommand() *cobra.Command {
+	a := &AccessorCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   AccessorUsage,
+		Short: AccessorShort,
+		Long:  AccessorLong,
+		Args:  cobra.NoArgs,
+		RunE:  a.RunE,
+	}
+
+	cmd.Flags().StringVarP(&a.File, "file", "f", "", "accessor definition file")
+	cmd.Flags().StringVarP(&a.Name, "name", "", "", "accessor name")
+	cmd.Flags().StringVarP(&a.Description, "description", "", "", "accessor description")
+	cmd.Flags().StringArrayVarP(&a.Columns, "column", "", nil, "column in the form <column>[:<transformer>]")
+	cmd.Flags().StringArrayVarP(&a.Purposes, "purpose", "", nil, "purpose name or ID")
+	cmd.Flags().StringVarP(&a.Selector, "selector", "", DefaultAccessorSelector, "selector where clause")
+	cmd.Flags().StringVarP(&a.AccessPolicy, "access-policy", "", "", "access policy name or ID (defaults to allow all)")
+	cmd.Flags().BoolVarP(&a.IsAuditLogged, "audit-logged", "", false, "audit log each execution of the accessor")
+	return cmd
+}
+
+func (c *AccessorCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "create-accessor", func(ctx context.Context) error {
+		accessor, err := c.accessor(cmd)
+		if err != nil {
+			return err
+		}
+
+		if err := accessor.Validate(); err != nil {
+			return fmt.Errorf("invalid accessor %s: %v", accessor.Name, err)
+		}
+
+		idpc, err := c.idpClient()
+		if err != nil {
+			return err
+		}
+
+		created, err := idpc.CreateAccessor(ctx, *accessor)
+		if err != nil {
+			return fmt.Errorf("failed to create accessor %s: %v", accessor.Name, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created accessor %s (%s) version %d\n", created.Name, created.ID, created.Version)
+		return nil
+	})
+}
+
+// accessor builds the accessor from the definition file (if any) and the flags.
+func (c *AccessorCommand) accessor(cmd *cobra.Command) (*userstore.Accessor, error) {
+	accessor := &userstore.Accessor{}
+	if c.File != "" {
+		if err := readFile(c.File, accessor); err != nil {
+			return nil, err
+		}
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("name") {
+		accessor.Name = c.Name
+	}
+
+	if flags.Changed("description") {
+		accessor.Description = c.Description
+	}
+
+	if flags.Changed("column") {
+		columns, err := parseColumnOutputs(c.Columns)
+		if err != nil {
+			return nil, err
+		}
+		accessor.Columns = columns
+	}
+
+	if flags.Changed("purpose") {
+		accessor.Purposes = nil
+		for _, p := range c.Purposes {
+			accessor.Purposes = append(accessor.Purposes, resourceID(p))
+		}
+	}
+
+	if flags.Changed("selector") || accessor.SelectorConfig.WhereClause == "" {
+		accessor.SelectorConfig.WhereClause = c.Selector
+	}
+
+	if flags.Changed("access-policy") {
+		accessor.AccessPolicy = resourceID(c.AccessPolicy)
+	} else if accessor.AccessPolicy.Validate() != nil {
+		accessor.AccessPolicy = userstore.ResourceID{ID: policy.AccessPolicyAllowAll.ID}
+	}
+
+	if flags.Changed("audit-logged") {
+		accessor.IsAuditLogged = c.IsAuditLogged
+	}
+
+	return accessor, nil
+}
+
+// parseColumnOutputs turns a list of <column>[:<transformer>] values into column output
+// configs, defaulting to the passthrough transformer.
+func parseColumnOutputs(values []string) ([]userstore.ColumnOutputConfig, error) {
+	var columns []userstore.ColumnOutputConfig
+	for _, v := range values {
+		column, transformer, _ := strings.Cut(v, ":")
+		if column == "" {
+			return nil, fmt.Errorf("invalid column %s, expected <column>[:<transformer>]", v)
+		}
+
+		coc := userstore.ColumnOutputConfig{
+			Column:      resourceID(column),
+			Transformer: userstore.ResourceID{ID: policy.TransformerPassthrough.ID},
+		}
+		if transformer != "" {
+			coc.Transformer = resourceID(transformer)
+		}
+
+		columns = append(columns, coc)
+	}
+
+	return columns, nil
+}
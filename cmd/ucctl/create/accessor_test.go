@@ -0,0 +1,67 @@
+package create
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/idp/policy"
+	"userclouds.com/idp/userstore"
+)
+
+func TestParseColumnOutputs(t *testing.T) {
+	columnID := uuid.Must(uuid.NewV4())
+	transformerID := uuid.Must(uuid.NewV4())
+	passthrough := userstore.ResourceID{ID: policy.TransformerPassthrough.ID}
+
+	tests := []struct {
+		name    string
+		values  []string
+		columns []userstore.ColumnOutputConfig
+		err     string
+	}{
+		{"none", nil, nil, ""},
+		{
+			"defaults to passthrough",
+			[]string{"email"},
+			[]userstore.ColumnOutputConfig{{Column: userstore.ResourceID{Name: "email"}, Transformer: passthrough}},
+			"",
+		},
+		{
+			"empty transformer defaults to passthrough",
+			[]string{"email:"},
+			[]userstore.ColumnOutputConfig{{Column: userstore.ResourceID{Name: "email"}, Transformer: passthrough}},
+			"",
+		},
+		{
+			"transformer names",
+			[]string{"email:EmailToID", "name"},
+			[]userstore.ColumnOutputConfig{
+				{Column: userstore.ResourceID{Name: "email"}, Transformer: userstore.ResourceID{Name: "EmailToID"}},
+				{Column: userstore.ResourceID{Name: "name"}, Transformer: passthrough},
+			},
+			"",
+		},
+		{
+			"IDs",
+			[]string{columnID.String() + ":" + transformerID.String()},
+			[]userstore.ColumnOutputConfig{{Column: userstore.ResourceID{ID: columnID}, Transformer: userstore.ResourceID{ID: transformerID}}},
+			"",
+		},
+		{"missing column", []string{":EmailToID"}, nil, "invalid column :EmailToID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, err := parseColumnOutputs(tt.values)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.columns, columns)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package create
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/idp/userstore"
+)
+
+const (
+	ColumnUsage = "column"
+	ColumnShort = "Create a userstore column"
+	ColumnLong  = `Create a userstore column.  The data type, default transformer and access policy may be
+referenced by name or ID.`
+
+	DefaultColumnTable = "users"
+)
+
+// ColumnCommand creates a userstore column.
+type ColumnCommand struct {
+	*Command
+	Name               string
+	Table              string
+	Type               string
+	IsArray            bool
+	Indexed            bool
+	Unique             bool
+	SearchIndexed      bool
+	DefaultValue       string
+	DefaultTransformer string
+	AccessPolicy       string
+}
+
+// ColumnCommand returns the column subcommand.
+func (c *Command) ColumnCommand() *cobra.Command {
+	col := &ColumnCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   ColumnUsage,
+		Short: ColumnShort,
+		Long:  ColumnLong,
+		Args:  cobra.NoArgs,
+		RunE:  col.RunE,
+	}
+
+	cmd.Flags().StringVarP(&col.Name, "name", "", "", "column name")
+	cmd.Flags().StringVarP(&col.Table, "table", "", DefaultColumnTable, "table name")
+	cmd.Flags().StringVarP(&col.Type, "type", "", "", "data type name or ID (e.g. string, email, phonenumber)")
+	cmd.Flags().BoolVarP(&col.IsArray, "array", "", false, "column is an array")
+	cmd.Flags().BoolVarP(&col.Indexed, "indexed", "", false, "index the column")
+	cmd.Flags().BoolVarP(&col.Unique, "unique", "", false, "index the column and require unique values")
+	cmd.Flags().BoolVarP(&col.SearchIndexed, "search-indexed", "", false, "add the column to the search index")
+	cmd.Flags().StringVarP(&col.DefaultValue, "default-value", "", "", "default value")
+	cmd.Flags().StringVarP(&col.DefaultTransformer, "default-transformer", "", "", "default transformer name or ID")
+	cmd.Flags().StringVarP(&col.AccessPolicy, "access-policy", "", "", "access policy name or ID")
+	return cmd
+}
+
+func (c *ColumnCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "create-column", func(ctx context.Context) error {
+		if c.Name == "" {
+			return fmt.Errorf("column name is required")
+		}
+
+		if c.Type == "" {
+			return fmt.Errorf("column data type is required")
+		}
+
+		column := userstore.Column{
+			Table:         c.Table,
+			Name:          c.Name,
+			DataType:      resourceID(c.Type),
+			IsArray:       c.IsArray,
+			DefaultValue:  c.DefaultValue,
+			SearchIndexed: c.SearchIndexed,
+			IndexType:     userstore.ColumnIndexTypeNone,
+		}
+
+		switch {
+		case c.Unique:
+			column.IndexType = userstore.ColumnIndexTypeUnique
+		case c.Indexed:
+			column.IndexType = userstore.ColumnIndexTypeIndexed
+		}
+
+		if c.DefaultTransformer != "" {
+			column.DefaultTransformer = resourceID(c.DefaultTransformer)
+		}
+
+		if c.AccessPolicy != "" {
+			column.AccessPolicy = resourceID(c.AccessPolicy)
+		}
+
+		idpc, err := c.idpClient()
+		if err != nil {
+			return err
+		}
+
+		created, err := idpc.CreateColumn(ctx, column)
+		if err != nil {
+			return fmt.Errorf("failed to create column %s: %v", c.Name, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created column %s (%s)\n", created.Name, created.ID)
+		return nil
+	})
+}
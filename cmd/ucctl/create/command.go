@@ -0,0 +1,338 @@
+package create
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"userclouds.com/authz"
+	"userclouds.com/cmd/ucctl/config"
+	"userclouds.com/idp"
+	"userclouds.com/idp/userstore"
+	"userclouds.com/infra/jsonclient"
+	"userclouds.com/infra/logtransports"
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/plex"
+)
+
+const (
+	DefaultClientSecretVar = "UC_CLIENT_SECRET"
+
+	// completionCacheTTL is how long names fetched from the tenant for completions are reused.
+	completionCacheTTL = time.Minute
+)
+
+// Command holds the options shared by all of the create subcommands.
+type Command struct {
+	URL             string
+	ClientID        string
+	ClientSecretVar string
+	Verbose         bool
+
+	// tokens caches the tokens of the current context, and is only set when the
+	// connection options match the context.
+	tokens *config.TokenSource
+
+	// defaultOrganizationID is the default organization of the current context, used by
+	// subcommands when their organization flag isn't set.
+	defaultOrganizationID string
+
+	// transport is the context whose connection settings apply, which is only set when
+	// connecting to the context's tenant.
+	transport *config.Context
+
+	// asOrganizationID is the organization that API calls are scoped to with
+	// --as-organization.
+	asOrganizationID uuid.UUID
+}
+
+// run initializes logging, validates the connection options and then calls fn.  Errors
+// are logged here since the root command silences them.
+func (c *Command) run(cmd *cobra.Command, name string, fn func(ctx context.Context) error) error {
+	return c.runWithValidation(cmd, name, func(ctx context.Context) error {
+		c.applyContext(ctx, config.FromContext(ctx))
+		return c.validate()
+	}, fn)
+}
+
+// runWithValidation is run for subcommands that don't connect to a tenant and need to
+// validate their own options.
+func (c *Command) runWithValidation(cmd *cobra.Command, name string, validate, fn func(ctx context.Context) error) error {
+	ctx := cmd.Context()
+
+	logLevel := uclog.LogLevelInfo
+	if c.Verbose {
+		logLevel = uclog.LogLevelDebug
+	}
+
+	logtransports.InitLoggerAndTransportsForTools(ctx, logLevel, logLevel, name)
+	defer logtransports.Close()
+
+	if err := validate(ctx); err != nil {
+		uclog.Errorf(ctx, "%v", err)
+		return err
+	}
+
+	if err := fn(ctx); err != nil {
+		uclog.Errorf(ctx, "%v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *Command) validate() error {
+	if c.URL == "" {
+		return fmt.Errorf("tenant URL is required")
+	}
+
+	if c.ClientID == "" {
+		return fmt.Errorf("client id is required")
+	}
+
+	if os.Getenv(c.ClientSecretVar) == "" && c.tokens == nil {
+		return fmt.Errorf("client secret is not set")
+	}
+
+	return nil
+}
+
+// applyContext fills in the connection options that weren't set by flags from the
+// current context.  Commands connecting to the context's tenant with its client use the
+// context's cached tokens, so the client secret is only needed when there is no valid
+// token.
+func (c *Command) applyContext(ctx context.Context, s *config.State) {
+	if s == nil {
+		return
+	}
+
+	c.asOrganizationID = s.AsOrganizationID
+	if s.Current == nil {
+		return
+	}
+
+	uc := s.Current
+	if (c.URL == "" || c.URL == uc.URL) && (c.ClientID == "" || c.ClientID == uc.ClientID) {
+		c.tokens = s.TokenSource(ctx, os.Getenv(c.ClientSecretVar))
+	}
+
+	// the default organization and connection settings only apply to the context's tenant
+	if c.URL == "" || c.URL == uc.URL {
+		c.defaultOrganizationID = uc.DefaultOrganizationID
+		c.transport = uc
+	}
+
+	if c.URL == "" {
+		c.URL = uc.URL
+	}
+
+	if c.ClientID == "" {
+		c.ClientID = uc.ClientID
+	}
+}
+
+// clientOptions returns the jsonclient options for the tenant.  The token source uses the
+// cached tokens of the current context if it applies and the client credentials grant
+// otherwise.
+func (c *Command) clientOptions() ([]jsonclient.Option, error) {
+	var client *http.Client
+	var opts []jsonclient.Option
+	if c.transport != nil {
+		var err error
+		if client, opts, err = c.transport.ClientOptions(); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.tokens != nil {
+		return append(opts, jsonclient.TokenSource(c.tokens)), nil
+	}
+
+	tokenURL, err := config.TokenURL(c.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(opts, jsonclient.TokenSource(oidc.ClientCredentialsTokenSource{
+		TokenURL:     tokenURL,
+		ClientID:     c.ClientID,
+		ClientSecret: os.Getenv(c.ClientSecretVar),
+		HTTPClient:   client,
+	})), nil
+}
+
+// authzClient returns an authz client for the tenant.
+func (c *Command) authzClient() (*authz.Client, error) {
+	opts, err := c.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	azOpts := []authz.Option{authz.JSONClient(opts...)}
+	if !c.asOrganizationID.IsNil() {
+		azOpts = append(azOpts, authz.OrganizationID(c.asOrganizationID))
+	}
+
+	return authz.NewClient(c.URL, azOpts...)
+}
+
+// idpClient returns an idp (userstore and tokenizer) client for the tenant.
+func (c *Command) idpClient() (*idp.Client, error) {
+	opts, err := c.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	idpOpts := []idp.Option{idp.JSONClient(opts...)}
+	if !c.asOrganizationID.IsNil() {
+		idpOpts = append(idpOpts, idp.OrganizationID(c.asOrganizationID))
+	}
+
+	return idp.NewClient(c.URL, idpOpts...)
+}
+
+// mgmtClient returns an idp management client for the tenant.
+func (c *Command) mgmtClient() (*idp.ManagementClient, error) {
+	opts, err := c.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return idp.NewManagementClient(c.URL, opts...)
+}
+
+// plexClient returns a plex client for the tenant.
+func (c *Command) plexClient() (*plex.Client, error) {
+	opts, err := c.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return plex.NewClient(c.URL, opts...), nil
+}
+
+// completeObjectTypes completes object type names fetched from the tenant.  Names are
+// cached per context, so only completions against the selected context's tenant are
+// cached.
+func (c *Command) completeObjectTypes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx := cmd.Context()
+
+	// the root command doesn't load the config for completions
+	state, err := config.StateFromFlags(cmd, false)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	c.applyContext(ctx, state)
+	if err := c.validate(); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	fetch := func() ([]string, error) {
+		azc, err := c.authzClient()
+		if err != nil {
+			return nil, err
+		}
+
+		ots, err := azc.ListObjectTypes(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, 0, len(ots))
+		for _, ot := range ots {
+			names = append(names, ot.TypeName)
+		}
+		return names, nil
+	}
+
+	var names []string
+	if c.tokens != nil {
+		names, err = config.CachedNames(state.Path, state.Current.Name, "object_types", completionCacheTTL, fetch)
+	} else {
+		names, err = fetch()
+	}
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var matches []string
+	for _, name := range names {
+		if strings.HasPrefix(name, toComplete) {
+			matches = append(matches, name)
+		}
+	}
+
+	return matches, cobra.ShellCompDirectiveNoFileComp
+}
+
+// readFile reads a YAML or JSON resource definition from a file into v.  Resources
+// are decoded using their JSON field names.
+func readFile(path string, v any) error {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	if err := yaml.UnmarshalStrict(bs, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// resourceID turns a name or ID into a userstore resource ID.
+func resourceID(nameOrID string) userstore.ResourceID {
+	if id, err := uuid.FromString(nameOrID); err == nil {
+		return userstore.ResourceID{ID: id}
+	}
+
+	return userstore.ResourceID{Name: nameOrID}
+}
+
+// parseID parses an optional UUID flag value, returning uuid.Nil if it is empty.
+func parseID(flag, value string) (uuid.UUID, error) {
+	if value == "" {
+		return uuid.Nil, nil
+	}
+
+	id, err := uuid.FromString(value)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid %s %s: %v", flag, value, err)
+	}
+
+	return id, nil
+}
+
+// organizationOrDefault returns the value of an organization flag, or if the flag wasn't
+// set the organization of --as-organization or the default organization of the current
+// context.
+func (c *Command) organizationOrDefault(cmd *cobra.Command, flag, value string) string {
+	if cmd.Flags().Changed(flag) {
+		return value
+	}
+
+	if !c.asOrganizationID.IsNil() {
+		return c.asOrganizationID.String()
+	}
+
+	return c.defaultOrganizationID
+}
+
+// organizationOptions returns the authz options scoping a request to an organization,
+// if one was specified.
+func organizationOptions(orgID uuid.UUID) []authz.Option {
+	if orgID.IsNil() {
+		return nil
+	}
+
+	return []authz.Option{authz.OrganizationID(orgID)}
+}
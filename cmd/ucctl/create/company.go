@@ -0,0 +1,84 @@
+package create
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/authz/ucauthz"
+	"userclouds.com/infra/ucdb"
+	"userclouds.com/internal/companyconfig"
+)
+
+const (
+	CompanyUsage = "company"
+	CompanyShort = "Create and provision a company"
+	CompanyLong  = `Create and provision a company through the console API.  The console user creating the
+company becomes its admin, and additional admins can be invited by email with --admin-email.  The
+console API is authenticated with a console session ID, read from the environment variable named
+by --session.`
+)
+
+// CompanyCommand creates a company.
+type CompanyCommand struct {
+	*Command
+	ConsoleOptions
+	Name        string
+	Type        string
+	AdminEmails []string
+}
+
+// CompanyCommand returns the company subcommand.
+func (c *Command) CompanyCommand() *cobra.Command {
+	co := &CompanyCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   CompanyUsage,
+		Short: CompanyShort,
+		Long:  CompanyLong,
+		Args:  cobra.NoArgs,
+		RunE:  co.RunE,
+	}
+
+	co.addFlags(cmd)
+	cmd.Flags().StringVarP(&co.Name, "name", "", "", "company name")
+	cmd.Flags().StringVarP(&co.Type, "type", "", string(companyconfig.CompanyTypeCustomer), "company type")
+	cmd.Flags().StringArrayVarP(&co.AdminEmails, "admin-email", "", nil, "email of a user to invite as a company admin")
+	return cmd
+}
+
+func (c *CompanyCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.runWithValidation(cmd, "create-company", func(context.Context) error { return c.ConsoleOptions.validate() }, func(ctx context.Context) error {
+		if c.Name == "" {
+			return fmt.Errorf("company name is required")
+		}
+
+		var companyType companyconfig.CompanyType
+		if err := companyType.UnmarshalText([]byte(c.Type)); err != nil {
+			return fmt.Errorf("invalid company type %s: %v", c.Type, err)
+		}
+
+		cc := c.consoleClient()
+		company, err := cc.CreateCompany(ctx, companyconfig.Company{
+			BaseModel: ucdb.NewBase(),
+			Name:      c.Name,
+			Type:      companyType,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create company %s: %v", c.Name, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created company %s (%s)\n", company.Name, company.ID)
+
+		if len(c.AdminEmails) == 0 {
+			return nil
+		}
+
+		if err := cc.InviteUserToCompany(ctx, company.ID, c.AdminEmails, ucauthz.AdminRole); err != nil {
+			return fmt.Errorf("company %s was created but admins could not be invited: %v", company.ID, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Invited %d admins to company %s\n", len(c.AdminEmails), company.Name)
+		return nil
+	})
+}
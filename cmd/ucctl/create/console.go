@@ -0,0 +1,49 @@
+package create
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gofrs/uuid"
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/console"
+)
+
+// ConsoleOptions holds the options of the create subcommands that go through the console
+// API instead of a tenant.
+type ConsoleOptions struct {
+	ConsoleURL string
+	SessionVar string
+}
+
+func (o *ConsoleOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ConsoleURL, "console-url", "", "", "console URL")
+	cmd.Flags().StringVarP(&o.SessionVar, "session", "", console.DefaultSessionVar, "console session")
+}
+
+func (o *ConsoleOptions) validate() error {
+	if o.ConsoleURL == "" {
+		return fmt.Errorf("console URL is required")
+	}
+
+	if os.Getenv(o.SessionVar) == "" {
+		return fmt.Errorf("console session is not set")
+	}
+
+	return nil
+}
+
+// consoleClient returns a client for the console API.
+func (o *ConsoleOptions) consoleClient() *console.Client {
+	return console.NewClient(o.ConsoleURL, os.Getenv(o.SessionVar))
+}
+
+// parseRequiredID parses a required UUID flag value.
+func parseRequiredID(flag, value string) (uuid.UUID, error) {
+	if value == "" {
+		return uuid.Nil, fmt.Errorf("%s is required", flag)
+	}
+
+	return parseID(flag, value)
+}
@@ -0,0 +1,87 @@
+package create
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	EdgeUsage = "edge"
+	EdgeShort = "Create an authz edge"
+	EdgeLong  = `Create an authz edge between two objects.  The edge type may be specified by name or ID and an
+explicit --id can be provided for reproducible provisioning.`
+)
+
+// EdgeCommand creates an authz edge.
+type EdgeCommand struct {
+	*Command
+	Source string
+	Target string
+	Type   string
+	ID     string
+}
+
+// EdgeCommand returns the edge subcommand.
+func (c *Command) EdgeCommand() *cobra.Command {
+	e := &EdgeCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   EdgeUsage,
+		Short: EdgeShort,
+		Long:  EdgeLong,
+		Args:  cobra.NoArgs,
+		RunE:  e.RunE,
+	}
+
+	cmd.Flags().StringVarP(&e.Source, "source", "", "", "source object ID")
+	cmd.Flags().StringVarP(&e.Target, "target", "", "", "target object ID")
+	cmd.Flags().StringVarP(&e.Type, "type", "", "", "edge type name or ID")
+	cmd.Flags().StringVarP(&e.ID, "id", "", "", "edge ID (generated if not set)")
+	return cmd
+}
+
+func (c *EdgeCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "create-edge", func(ctx context.Context) error {
+		if c.Source == "" || c.Target == "" {
+			return fmt.Errorf("source and target object IDs are required")
+		}
+
+		if c.Type == "" {
+			return fmt.Errorf("edge type is required")
+		}
+
+		id, err := parseID("edge id", c.ID)
+		if err != nil {
+			return err
+		}
+
+		sourceID, err := parseID("source object id", c.Source)
+		if err != nil {
+			return err
+		}
+
+		targetID, err := parseID("target object id", c.Target)
+		if err != nil {
+			return err
+		}
+
+		azc, err := c.authzClient()
+		if err != nil {
+			return err
+		}
+
+		typeID, err := edgeTypeID(ctx, azc, c.Type)
+		if err != nil {
+			return err
+		}
+
+		e, err := azc.CreateEdge(ctx, id, sourceID, targetID, typeID)
+		if err != nil {
+			return fmt.Errorf("failed to create edge %s -> %s: %v", sourceID, targetID, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created edge %s -> %s (%s)\n", sourceID, targetID, e.ID)
+		return nil
+	})
+}
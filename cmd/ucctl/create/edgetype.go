@@ -0,0 +1,151 @@
+package create
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/spf13/cobra"
+
+	"userclouds.com/authz"
+)
+
+const (
+	EdgeTypeUsage = "edge-type"
+	EdgeTypeShort = "Create an authz edge type"
+	EdgeTypeLong  = `Create an authz edge type between a source and target object type.  Attributes are specified
+with repeated --attribute flags in the form <name>:<direct|inherit|propagate>, and the same attribute name
+may be repeated to combine flags (e.g. --attribute read:direct --attribute read:inherit).`
+)
+
+// EdgeTypeCommand creates an authz edge type.
+type EdgeTypeCommand struct {
+	*Command
+	Name           string
+	ID             string
+	SourceType     string
+	TargetType     string
+	Attributes     []string
+	OrganizationID string
+}
+
+// EdgeTypeCommand returns the edge-type subcommand.
+func (c *Command) EdgeTypeCommand() *cobra.Command {
+	et := &EdgeTypeCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   EdgeTypeUsage,
+		Short: EdgeTypeShort,
+		Long:  EdgeTypeLong,
+		Args:  cobra.NoArgs,
+		RunE:  et.RunE,
+	}
+
+	cmd.Flags().StringVarP(&et.Name, "name", "", "", "edge type name")
+	cmd.Flags().StringVarP(&et.ID, "id", "", "", "edge type ID (generated if not set)")
+	cmd.Flags().StringVarP(&et.SourceType, "source-type", "", "", "source object type name or ID")
+	cmd.Flags().StringVarP(&et.TargetType, "target-type", "", "", "target object type name or ID")
+	_ = cmd.RegisterFlagCompletionFunc("source-type", c.completeObjectTypes)
+	_ = cmd.RegisterFlagCompletionFunc("target-type", c.completeObjectTypes)
+	cmd.Flags().StringArrayVarP(&et.Attributes, "attribute", "", nil, "attribute in the form <name>:<direct|inherit|propagate>")
+	cmd.Flags().StringVarP(&et.OrganizationID, "organization-id", "", "", "organization ID, defaults to the default organization of the context")
+	return cmd
+}
+
+func (c *EdgeTypeCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "create-edge-type", func(ctx context.Context) error {
+		if c.Name == "" {
+			return fmt.Errorf("edge type name is required")
+		}
+
+		if c.SourceType == "" || c.TargetType == "" {
+			return fmt.Errorf("source and target object types are required")
+		}
+
+		id, err := parseID("edge type id", c.ID)
+		if err != nil {
+			return err
+		}
+
+		orgID, err := parseID("organization id", c.organizationOrDefault(cmd, "organization-id", c.OrganizationID))
+		if err != nil {
+			return err
+		}
+
+		attributes, err := parseAttributes(c.Attributes)
+		if err != nil {
+			return err
+		}
+
+		azc, err := c.authzClient()
+		if err != nil {
+			return err
+		}
+
+		sourceTypeID, err := objectTypeID(ctx, azc, c.SourceType)
+		if err != nil {
+			return err
+		}
+
+		targetTypeID, err := objectTypeID(ctx, azc, c.TargetType)
+		if err != nil {
+			return err
+		}
+
+		et, err := azc.CreateEdgeType(ctx, id, sourceTypeID, targetTypeID, c.Name, attributes, organizationOptions(orgID)...)
+		if err != nil {
+			return fmt.Errorf("failed to create edge type %s: %v", c.Name, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created edge type %s (%s)\n", et.TypeName, et.ID)
+		return nil
+	})
+}
+
+// parseAttributes turns a list of <name>:<direct|inherit|propagate> values into
+// authz attributes, merging flags for repeated names.
+func parseAttributes(values []string) (authz.Attributes, error) {
+	var attributes authz.Attributes
+	index := map[string]int{}
+
+	for _, v := range values {
+		name, flag, found := strings.Cut(v, ":")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid attribute %s, expected <name>:<direct|inherit|propagate>", v)
+		}
+
+		i, ok := index[name]
+		if !ok {
+			attributes = append(attributes, authz.Attribute{Name: name})
+			i = len(attributes) - 1
+			index[name] = i
+		}
+
+		switch strings.ToLower(flag) {
+		case "direct":
+			attributes[i].Direct = true
+		case "inherit":
+			attributes[i].Inherit = true
+		case "propagate":
+			attributes[i].Propagate = true
+		default:
+			return nil, fmt.Errorf("invalid attribute flag %s for %s, expected direct, inherit or propagate", flag, name)
+		}
+	}
+
+	return attributes, nil
+}
+
+// edgeTypeID resolves an edge type name or ID to an ID.
+func edgeTypeID(ctx context.Context, azc *authz.Client, nameOrID string) (uuid.UUID, error) {
+	if id, err := uuid.FromString(nameOrID); err == nil {
+		return id, nil
+	}
+
+	id, err := azc.FindEdgeTypeID(ctx, nameOrID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to find edge type %s: %v", nameOrID, err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,49 @@
+package create
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/authz"
+)
+
+func TestParseAttributes(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []string
+		attributes authz.Attributes
+		err        string
+	}{
+		{"none", nil, nil, ""},
+		{"single", []string{"read:direct"}, authz.Attributes{{Name: "read", Direct: true}}, ""},
+		{
+			"flags are case insensitive",
+			[]string{"read:Inherit", "write:PROPAGATE"},
+			authz.Attributes{{Name: "read", Inherit: true}, {Name: "write", Propagate: true}},
+			"",
+		},
+		{
+			"repeated names are merged in order",
+			[]string{"read:direct", "write:direct", "read:inherit"},
+			authz.Attributes{{Name: "read", Direct: true, Inherit: true}, {Name: "write", Direct: true}},
+			"",
+		},
+		{"missing flag", []string{"read"}, nil, "invalid attribute read"},
+		{"missing name", []string{":direct"}, nil, "invalid attribute :direct"},
+		{"unknown flag", []string{"read:owner"}, nil, "invalid attribute flag owner for read"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attributes, err := parseAttributes(tt.values)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.attributes, attributes)
+		})
+	}
+}
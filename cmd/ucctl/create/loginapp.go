@@ -0,0 +1,108 @@
+package create
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/infra/secret"
+	"userclouds.com/plex"
+)
+
+const (
+	LoginAppUsage = "login-app"
+	LoginAppShort = "Create a plex login app"
+	LoginAppLong  = `Create a plex login app.  The client secret is not printed, it is stored with the secret
+provider configured in the environment and the location of the secret is printed instead.`
+
+	DefaultLoginAppAuthMethod = "client_secret_basic"
+	loginAppSecretService     = "plex"
+)
+
+// LoginAppCommand creates a plex login app.
+type LoginAppCommand struct {
+	*Command
+	Name         string
+	RedirectURIs []string
+	GrantTypes   []string
+	Scopes       []string
+	AuthMethod   string
+}
+
+// LoginAppCommand returns the login-app subcommand.
+func (c *Command) LoginAppCommand() *cobra.Command {
+	la := &LoginAppCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   LoginAppUsage,
+		Short: LoginAppShort,
+		Long:  LoginAppLong,
+		Args:  cobra.NoArgs,
+		RunE:  la.RunE,
+	}
+
+	cmd.Flags().StringVarP(&la.Name, "name", "", "", "login app name")
+	cmd.Flags().StringSliceVarP(&la.RedirectURIs, "redirect-uri", "", nil, "allowed redirect URI")
+	cmd.Flags().StringSliceVarP(&la.GrantTypes, "grant-types", "", []string{"authorization_code"}, "allowed grant types")
+	cmd.Flags().StringSliceVarP(&la.Scopes, "scopes", "", nil, "allowed scopes")
+	cmd.Flags().StringVarP(&la.AuthMethod, "token-endpoint-auth-method", "", DefaultLoginAppAuthMethod, "token endpoint authentication method")
+	return cmd
+}
+
+func (c *LoginAppCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "create-login-app", func(ctx context.Context) error {
+		if c.Name == "" {
+			return fmt.Errorf("login app name is required")
+		}
+
+		pc, err := c.plexClient()
+		if err != nil {
+			return err
+		}
+
+		app, err := pc.CreateLoginApp(ctx, &plex.LoginAppRequest{
+			ClientName:              c.Name,
+			RedirectURIs:            c.RedirectURIs,
+			GrantTypes:              c.GrantTypes,
+			Scope:                   strings.Join(c.Scopes, " "),
+			TokenEndpointAuthMethod: c.AuthMethod,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create login app %s: %v", c.Name, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created login app %s (%s)\n", c.Name, app.AppID)
+		fmt.Fprintf(cmd.OutOrStdout(), "Client ID: %s\n", app.ClientID)
+
+		if app.ClientSecret == "" {
+			return nil
+		}
+
+		location, err := storeClientSecret(ctx, app, "")
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Client secret: %s\n", location)
+		return nil
+	})
+}
+
+// storeClientSecret stores the client secret of a newly created login app and returns
+// its location.  If location is empty the secret is stored with the secret provider
+// configured in the environment.
+func storeClientSecret(ctx context.Context, app *plex.LoginAppResponse, location string) (string, error) {
+	var cs *secret.String
+	var err error
+	if location == "" {
+		cs, err = secret.NewString(ctx, loginAppSecretService, fmt.Sprintf("%s/client-secret", app.ClientID), app.ClientSecret)
+	} else {
+		cs, err = secret.NewStringAtLocation(ctx, location, app.ClientSecret)
+	}
+	if err != nil {
+		return "", fmt.Errorf("login app %s was created but its client secret could not be stored: %v", app.ClientID, err)
+	}
+
+	return cs.Location(), nil
+}
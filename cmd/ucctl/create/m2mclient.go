@@ -0,0 +1,82 @@
+package create
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/plex"
+)
+
+const (
+	M2MClientUsage = "m2m-client"
+	M2MClientShort = "Create machine-to-machine client credentials"
+	M2MClientLong  = `Create a login app that is only allowed the client credentials grant, for use by services
+and CI.  The client secret is never printed, it is written to --secret-location (for example
+aws://secrets/userclouds/prod/ci/ci-bot or kube://secrets/ci:ci-bot) or, if that isn't set, to
+the secret provider configured in the environment.`
+
+	m2mGrantType = "client_credentials"
+)
+
+// M2MClientCommand creates machine-to-machine client credentials.
+type M2MClientCommand struct {
+	*Command
+	Name           string
+	Scopes         []string
+	AuthMethod     string
+	SecretLocation string
+}
+
+// M2MClientCommand returns the m2m-client subcommand.
+func (c *Command) M2MClientCommand() *cobra.Command {
+	m := &M2MClientCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   M2MClientUsage,
+		Short: M2MClientShort,
+		Long:  M2MClientLong,
+		Args:  cobra.NoArgs,
+		RunE:  m.RunE,
+	}
+
+	cmd.Flags().StringVarP(&m.Name, "name", "", "", "client name")
+	cmd.Flags().StringSliceVarP(&m.Scopes, "scopes", "", nil, "allowed scopes")
+	cmd.Flags().StringVarP(&m.AuthMethod, "token-endpoint-auth-method", "", DefaultLoginAppAuthMethod, "token endpoint authentication method")
+	cmd.Flags().StringVarP(&m.SecretLocation, "secret-location", "", "", "secret provider location to write the client secret to")
+	return cmd
+}
+
+func (c *M2MClientCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "create-m2m-client", func(ctx context.Context) error {
+		if c.Name == "" {
+			return fmt.Errorf("client name is required")
+		}
+
+		pc, err := c.plexClient()
+		if err != nil {
+			return err
+		}
+
+		app, err := pc.CreateLoginApp(ctx, &plex.LoginAppRequest{
+			ClientName:              c.Name,
+			GrantTypes:              []string{m2mGrantType},
+			Scope:                   strings.Join(c.Scopes, " "),
+			TokenEndpointAuthMethod: c.AuthMethod,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create m2m client %s: %v", c.Name, err)
+		}
+
+		location, err := storeClientSecret(ctx, app, c.SecretLocation)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created m2m client %s (%s)\n", c.Name, app.AppID)
+		fmt.Fprintf(cmd.OutOrStdout(), "Client ID: %s\n", app.ClientID)
+		fmt.Fprintf(cmd.OutOrStdout(), "Client secret: %s\n", location)
+		return nil
+	})
+}
@@ -0,0 +1,148 @@
+package create
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/idp/policy"
+	"userclouds.com/idp/userstore"
+)
+
+const (
+	MutatorUsage = "mutator"
+	MutatorShort = "Create a userstore mutator"
+	MutatorLong  = `Create a userstore mutator from a YAML or JSON file (-f), or from flags for simple cases.
+Columns are specified with repeated --column flags in the form <column>[:<normalizer>], and columns,
+normalizers, validators and access policies may be referenced by name or ID.  Columns without a
+normalizer or validator use the passthrough normalizer.  Flags override the corresponding values in
+the file.`
+
+	DefaultMutatorSelector = "{id} = ?"
+)
+
+// MutatorCommand creates a userstore mutator.
+type MutatorCommand struct {
+	*Command
+	File         string
+	Name         string
+	Description  string
+	Columns      []string
+	Selector     string
+	AccessPolicy string
+}
+
+// MutatorCommand returns the mutator subcommand.
+func (c *Command) MutatorCommand() *cobra.Command {
+	m := &MutatorCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   MutatorUsage,
+		Short: MutatorShort,
+		Long:  MutatorLong,
+		Args:  cobra.NoArgs,
+		RunE:  m.RunE,
+	}
+
+	cmd.Flags().StringVarP(&m.File, "file", "f", "", "mutator definition file")
+	cmd.Flags().StringVarP(&m.Name, "name", "", "", "mutator name")
+	cmd.Flags().StringVarP(&m.Description, "description", "", "", "mutator description")
+	cmd.Flags().StringArrayVarP(&m.Columns, "column", "", nil, "column in the form <column>[:<normalizer>]")
+	cmd.Flags().StringVarP(&m.Selector, "selector", "", DefaultMutatorSelector, "selector where clause")
+	cmd.Flags().StringVarP(&m.AccessPolicy, "access-policy", "", "", "access policy name or ID (defaults to allow all)")
+	return cmd
+}
+
+func (c *MutatorCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "create-mutator", func(ctx context.Context) error {
+		mutator, err := c.mutator(cmd)
+		if err != nil {
+			return err
+		}
+
+		if err := mutator.Validate(); err != nil {
+			return fmt.Errorf("invalid mutator %s: %v", mutator.Name, err)
+		}
+
+		idpc, err := c.idpClient()
+		if err != nil {
+			return err
+		}
+
+		created, err := idpc.CreateMutator(ctx, *mutator)
+		if err != nil {
+			return fmt.Errorf("failed to create mutator %s: %v", mutator.Name, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created mutator %s (%s) version %d\n", created.Name, created.ID, created.Version)
+		return nil
+	})
+}
+
+// mutator builds the mutator from the definition file (if any) and the flags.
+func (c *MutatorCommand) mutator(cmd *cobra.Command) (*userstore.Mutator, error) {
+	mutator := &userstore.Mutator{}
+	if c.File != "" {
+		if err := readFile(c.File, mutator); err != nil {
+			return nil, err
+		}
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("name") {
+		mutator.Name = c.Name
+	}
+
+	if flags.Changed("description") {
+		mutator.Description = c.Description
+	}
+
+	if flags.Changed("column") {
+		columns, err := parseColumnInputs(c.Columns)
+		if err != nil {
+			return nil, err
+		}
+		mutator.Columns = columns
+	}
+
+	// columns from files may omit the normalizer entirely
+	for i, ci := range mutator.Columns {
+		if ci.Normalizer.Validate() != nil && ci.Validator.Validate() != nil {
+			mutator.Columns[i].Normalizer = userstore.ResourceID{ID: policy.TransformerPassthrough.ID}
+		}
+	}
+
+	if flags.Changed("selector") || mutator.SelectorConfig.WhereClause == "" {
+		mutator.SelectorConfig.WhereClause = c.Selector
+	}
+
+	if flags.Changed("access-policy") {
+		mutator.AccessPolicy = resourceID(c.AccessPolicy)
+	} else if mutator.AccessPolicy.Validate() != nil {
+		mutator.AccessPolicy = userstore.ResourceID{ID: policy.AccessPolicyAllowAll.ID}
+	}
+
+	return mutator, nil
+}
+
+// parseColumnInputs turns a list of <column>[:<normalizer>] values into column input
+// configs.
+func parseColumnInputs(values []string) ([]userstore.ColumnInputConfig, error) {
+	var columns []userstore.ColumnInputConfig
+	for _, v := range values {
+		column, normalizer, _ := strings.Cut(v, ":")
+		if column == "" {
+			return nil, fmt.Errorf("invalid column %s, expected <column>[:<normalizer>]", v)
+		}
+
+		cic := userstore.ColumnInputConfig{Column: resourceID(column)}
+		if normalizer != "" {
+			cic.Normalizer = resourceID(normalizer)
+		}
+
+		columns = append(columns, cic)
+	}
+
+	return columns, nil
+}
@@ -0,0 +1,77 @@
+package create
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+const progressWidth = 40
+
+// progress draws a single line progress bar.  It is only drawn when w is a terminal
+// so that redirected output isn't filled with carriage returns.
+type progress struct {
+	mu      sync.Mutex
+	w       io.Writer
+	total   int
+	done    int
+	enabled bool
+}
+
+func newProgress(w io.Writer, total int) *progress {
+	f, ok := w.(*os.File)
+	return &progress{
+		w:       w,
+		total:   total,
+		enabled: ok && term.IsTerminal(int(f.Fd())),
+	}
+}
+
+// Enabled returns true if the progress bar is being drawn.
+func (p *progress) Enabled() bool {
+	return p.enabled
+}
+
+// Increment advances the progress bar by one.
+func (p *progress) Increment() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	p.draw()
+}
+
+// Printf prints a message above the progress bar.
+func (p *progress) Printf(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.enabled {
+		fmt.Fprint(p.w, "\r\033[K")
+	}
+	fmt.Fprintf(p.w, format, args...)
+	p.draw()
+}
+
+// Finish ends the progress bar line.
+func (p *progress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.enabled {
+		fmt.Fprintln(p.w)
+	}
+}
+
+func (p *progress) draw() {
+	if !p.enabled || p.total == 0 {
+		return
+	}
+
+	filled := progressWidth * p.done / p.total
+	fmt.Fprintf(p.w, "\r[%s%s] %d/%d", strings.Repeat("=", filled), strings.Repeat(" ", progressWidth-filled), p.done, p.total)
+}
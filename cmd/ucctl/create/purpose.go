@@ -0,0 +1,63 @@
+package create
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/idp/userstore"
+)
+
+const (
+	PurposeUsage = "purpose"
+	PurposeShort = "Create a userstore purpose"
+	PurposeLong  = `Create a userstore purpose used to track the consented uses of user data.`
+)
+
+// PurposeCommand creates a userstore purpose.
+type PurposeCommand struct {
+	*Command
+	Name        string
+	Description string
+}
+
+// PurposeCommand returns the purpose subcommand.
+func (c *Command) PurposeCommand() *cobra.Command {
+	p := &PurposeCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   PurposeUsage,
+		Short: PurposeShort,
+		Long:  PurposeLong,
+		Args:  cobra.NoArgs,
+		RunE:  p.RunE,
+	}
+
+	cmd.Flags().StringVarP(&p.Name, "name", "", "", "purpose name")
+	cmd.Flags().StringVarP(&p.Description, "description", "", "", "purpose description")
+	return cmd
+}
+
+func (c *PurposeCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "create-purpose", func(ctx context.Context) error {
+		if c.Name == "" {
+			return fmt.Errorf("purpose name is required")
+		}
+
+		idpc, err := c.idpClient()
+		if err != nil {
+			return err
+		}
+
+		created, err := idpc.CreatePurpose(ctx, userstore.Purpose{
+			Name:        c.Name,
+			Description: c.Description,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create purpose %s: %v", c.Name, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created purpose %s (%s)\n", created.Name, created.ID)
+		return nil
+	})
+}
@@ -0,0 +1,92 @@
+package create
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/infra/ucdb"
+	"userclouds.com/internal/companyconfig"
+)
+
+const (
+	TenantUsage = "tenant"
+	TenantShort = "Create and provision a tenant"
+	TenantLong  = `Create and provision a tenant through the console API, waiting for provisioning to finish
+and printing the tenant URL.  The console API is authenticated with a console session ID, read
+from the environment variable named by --session.`
+
+	DefaultTenantWaitTimeout = 10 * time.Minute
+	tenantWaitInterval       = 5 * time.Second
+)
+
+// TenantCommand creates a tenant.
+type TenantCommand struct {
+	*Command
+	ConsoleOptions
+	CompanyID        string
+	Name             string
+	UseOrganizations bool
+	Wait             bool
+	WaitTimeout      time.Duration
+}
+
+// TenantCommand returns the tenant subcommand.
+func (c *Command) TenantCommand() *cobra.Command {
+	t := &TenantCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   TenantUsage,
+		Short: TenantShort,
+		Long:  TenantLong,
+		Args:  cobra.NoArgs,
+		RunE:  t.RunE,
+	}
+
+	t.addFlags(cmd)
+	cmd.Flags().StringVarP(&t.CompanyID, "company", "", "", "company ID")
+	cmd.Flags().StringVarP(&t.Name, "name", "", "", "tenant name")
+	cmd.Flags().BoolVarP(&t.UseOrganizations, "use-organizations", "", false, "enable organizations for the tenant")
+	cmd.Flags().BoolVarP(&t.Wait, "wait", "", true, "wait for the tenant to be provisioned")
+	cmd.Flags().DurationVarP(&t.WaitTimeout, "wait-timeout", "", DefaultTenantWaitTimeout, "how long to wait for the tenant to be provisioned")
+	return cmd
+}
+
+func (c *TenantCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.runWithValidation(cmd, "create-tenant", func(context.Context) error { return c.ConsoleOptions.validate() }, func(ctx context.Context) error {
+		if c.Name == "" {
+			return fmt.Errorf("tenant name is required")
+		}
+
+		companyID, err := parseRequiredID("company id", c.CompanyID)
+		if err != nil {
+			return err
+		}
+
+		tenant := companyconfig.Tenant{
+			BaseModel:        ucdb.NewBase(),
+			Name:             c.Name,
+			CompanyID:        companyID,
+			UseOrganizations: c.UseOrganizations,
+		}
+
+		cc := c.consoleClient()
+		created, err := cc.CreateTenant(ctx, tenant)
+		if err != nil {
+			return fmt.Errorf("failed to create tenant %s: %v", c.Name, err)
+		}
+
+		if c.Wait {
+			waitCtx, cancel := context.WithTimeout(ctx, c.WaitTimeout)
+			defer cancel()
+
+			if created, err = cc.WaitForTenant(waitCtx, companyID, tenant.ID, tenantWaitInterval); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created tenant %s (%s) at %s\n", created.Name, created.ID, created.TenantURL)
+		return nil
+	})
+}
@@ -0,0 +1,73 @@
+package create
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/idp/policy"
+	"userclouds.com/idp/userstore"
+)
+
+const (
+	TokenUsage = "token"
+	TokenShort = "Tokenize a value"
+	TokenLong  = `Tokenize a value with a tokenizing transformer and print the token.  The transformer and
+access policy may be referenced by name or ID.`
+)
+
+// TokenCommand creates a token.
+type TokenCommand struct {
+	*Command
+	Data         string
+	Transformer  string
+	AccessPolicy string
+}
+
+// TokenCommand returns the token subcommand.
+func (c *Command) TokenCommand() *cobra.Command {
+	t := &TokenCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   TokenUsage,
+		Short: TokenShort,
+		Long:  TokenLong,
+		Args:  cobra.NoArgs,
+		RunE:  t.RunE,
+	}
+
+	cmd.Flags().StringVarP(&t.Data, "data", "", "", "value to tokenize")
+	cmd.Flags().StringVarP(&t.Transformer, "transformer", "", "", "transformer name or ID")
+	cmd.Flags().StringVarP(&t.AccessPolicy, "access-policy", "", "", "access policy name or ID (defaults to allow all)")
+	return cmd
+}
+
+func (c *TokenCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "create-token", func(ctx context.Context) error {
+		if c.Data == "" {
+			return fmt.Errorf("data is required")
+		}
+
+		if c.Transformer == "" {
+			return fmt.Errorf("transformer is required")
+		}
+
+		accessPolicy := userstore.ResourceID{ID: policy.AccessPolicyAllowAll.ID}
+		if c.AccessPolicy != "" {
+			accessPolicy = resourceID(c.AccessPolicy)
+		}
+
+		idpc, err := c.idpClient()
+		if err != nil {
+			return err
+		}
+
+		token, err := idpc.CreateToken(ctx, c.Data, resourceID(c.Transformer), accessPolicy)
+		if err != nil {
+			return fmt.Errorf("failed to create token: %v", err)
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), token)
+		return nil
+	})
+}
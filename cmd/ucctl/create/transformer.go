@@ -0,0 +1,134 @@
+package create
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/idp/policy"
+)
+
+const (
+	TransformerUsage = "transformer"
+	TransformerShort = "Create a transformer"
+	TransformerLong  = `Create a transformer from a JavaScript function file.  The input and output data types may
+be referenced by name or ID.  Parameters are passed as a JSON string, either directly with
+--parameters or from a file with --parameters-file.`
+
+	DefaultTransformerDataType = "string"
+)
+
+// TransformerCommand creates a transformer.
+type TransformerCommand struct {
+	*Command
+	Name               string
+	Description        string
+	FunctionFile       string
+	Type               string
+	InputType          string
+	OutputType         string
+	Parameters         string
+	ParametersFile     string
+	ReuseExistingToken bool
+}
+
+// TransformerCommand returns the transformer subcommand.
+func (c *Command) TransformerCommand() *cobra.Command {
+	t := &TransformerCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   TransformerUsage,
+		Short: TransformerShort,
+		Long:  TransformerLong,
+		Args:  cobra.NoArgs,
+		RunE:  t.RunE,
+	}
+
+	cmd.Flags().StringVarP(&t.Name, "name", "", "", "transformer name")
+	cmd.Flags().StringVarP(&t.Description, "description", "", "", "transformer description")
+	cmd.Flags().StringVarP(&t.FunctionFile, "function-file", "", "", "file containing the transformer function")
+	cmd.Flags().StringVarP(&t.Type, "type", "", string(policy.TransformTypeTransform), "transform type (passthrough, transform, tokenizebyvalue or tokenizebyreference)")
+	cmd.Flags().StringVarP(&t.InputType, "input-type", "", DefaultTransformerDataType, "input data type name or ID")
+	cmd.Flags().StringVarP(&t.OutputType, "output-type", "", DefaultTransformerDataType, "output data type name or ID")
+	cmd.Flags().StringVarP(&t.Parameters, "parameters", "", "", "transformer parameters as JSON")
+	cmd.Flags().StringVarP(&t.ParametersFile, "parameters-file", "", "", "file containing the transformer parameters as JSON")
+	cmd.Flags().BoolVarP(&t.ReuseExistingToken, "reuse-existing-token", "", false, "return an existing token instead of creating a new one when tokenizing")
+	return cmd
+}
+
+func (c *TransformerCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "create-transformer", func(ctx context.Context) error {
+		transformer, err := c.transformer()
+		if err != nil {
+			return err
+		}
+
+		if err := transformer.Validate(); err != nil {
+			return fmt.Errorf("invalid transformer %s: %v", transformer.Name, err)
+		}
+
+		idpc, err := c.idpClient()
+		if err != nil {
+			return err
+		}
+
+		created, err := idpc.CreateTransformer(ctx, *transformer)
+		if err != nil {
+			return fmt.Errorf("failed to create transformer %s: %v", transformer.Name, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created transformer %s (%s) version %d\n", created.Name, created.ID, created.Version)
+		return nil
+	})
+}
+
+// transformer builds the transformer from the flags and files.
+func (c *TransformerCommand) transformer() (*policy.Transformer, error) {
+	if c.Name == "" {
+		return nil, fmt.Errorf("transformer name is required")
+	}
+
+	if c.FunctionFile == "" {
+		return nil, fmt.Errorf("transformer function file is required")
+	}
+
+	function, err := os.ReadFile(c.FunctionFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", c.FunctionFile, err)
+	}
+
+	if c.Parameters != "" && c.ParametersFile != "" {
+		return nil, fmt.Errorf("only one of --parameters and --parameters-file can be set")
+	}
+
+	params := c.Parameters
+	if c.ParametersFile != "" {
+		bs, err := os.ReadFile(c.ParametersFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", c.ParametersFile, err)
+		}
+		params = string(bs)
+	}
+
+	if params != "" && !json.Valid([]byte(params)) {
+		return nil, fmt.Errorf("transformer parameters must be valid JSON")
+	}
+
+	var transformType policy.TransformType
+	if err := transformType.UnmarshalText([]byte(c.Type)); err != nil {
+		return nil, fmt.Errorf("invalid transform type %s: %v", c.Type, err)
+	}
+
+	return &policy.Transformer{
+		Name:               c.Name,
+		Description:        c.Description,
+		InputDataType:      resourceID(c.InputType),
+		OutputDataType:     resourceID(c.OutputType),
+		TransformType:      transformType,
+		Function:           string(function),
+		Parameters:         params,
+		ReuseExistingToken: c.ReuseExistingToken,
+	}, nil
+}
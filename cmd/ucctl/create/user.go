@@ -0,0 +1,694 @@
+package create
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/spf13/cobra"
+
+	"userclouds.com/authz"
+	"userclouds.com/authz/ucauthz"
+	"userclouds.com/cmd/ucctl/prompt"
+	"userclouds.com/idp"
+	"userclouds.com/idp/userstore"
+	"userclouds.com/infra/crypto"
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/secret"
+	"userclouds.com/infra/uctypes/messaging/email/emailaddress"
+	"userclouds.com/plex"
+)
+
+const (
+	UserUsage = "user"
+	UserShort = "Create a user"
+	UserLong  = `Create a user.  If a password is given the user can log in with their username (which
+defaults to their email address) and password, otherwise the user is created without any
+authentication methods.  Prefer --generate-password to --password so the password doesn't end up
+in shell history; the generated password is printed once and can also be written to a secret
+provider location with --password-secret.
+
+The profile can be read from a JSON or YAML file of userstore column names to values with
+--profile-file, which allows any column to be set, including custom and array columns.  Simple
+fields can be set with repeated --profile column=value flags, which take precedence over the
+file.  --email and --name take precedence over both.
+
+With --upsert an existing user with the same email address is updated instead of a new user
+being created: the profile is updated, the password is set if one is given, and the remaining
+options are applied as for a new user.  This makes it safe to re-run onboarding scripts.
+
+With --admin the user is made an admin of the organization given by --organization.
+
+Federated identities can be attached to the user with repeated --oidc flags, e.g.
+--oidc provider=google,subject=1234 --oidc provider=microsoft,subject=5678, or with --oidc-file,
+a JSON or YAML list of objects with provider, subject and (for custom providers) issuer_url
+fields.  The issuer URL of the built-in providers defaults to the provider's issuer.
+
+MFA channels can be pre-enrolled with --mfa-email and --mfa-phone, so that the user doesn't
+have to set up MFA the first time they log in.  Only addresses that are already verified in the
+user's profile can be enrolled, e.g. --mfa-email with the user's email address along with
+--profile email_verified=true; other channels are enrolled by the user with an MFA code.  The
+first channel becomes the user's primary channel.
+
+With --interactive the organization, email address, name and authentication method are prompted
+for, with validation, instead of being read from flags.
+
+With -o json the created user's ID, profile, authentication methods and MFA channels are printed
+as JSON instead of the progress messages, along with the password if one was generated.
+
+With --invite an invitation is emailed to the user once they have been created, using the email
+templates of the login app given by --invite-client-id.  Accepting the invite signs the user in
+and redirects them to --invite-redirect-url.`
+
+	DefaultInviteTTL       = 7 * 24 * time.Hour
+	generatedPasswordBytes = 24
+	inviteStateBytes       = 16
+)
+
+// UserCommand creates a user.
+type UserCommand struct {
+	*Command
+	Email            string
+	Name             string
+	ProfileFile      string
+	Profile          []string
+	Username         string
+	Password         string
+	GeneratePassword bool
+	OIDC             []string
+	OIDCFile         string
+	PasswordSecret   string
+	OrganizationID   string
+	Upsert           bool
+	Admin            bool
+	MFAEmails        []string
+	MFAPhones        []string
+	Invite           bool
+	InviteClientID   string
+	InviteRedirect   string
+	InviteText       string
+	InviteTTL        time.Duration
+	Output           string
+	Interactive      bool
+
+	// out is where progress messages are written, which is discarded for JSON output.
+	out io.Writer
+}
+
+// userOutput is the JSON output of create user.
+type userOutput struct {
+	ID          uuid.UUID            `json:"id"`
+	Created     bool                 `json:"created"`
+	Profile     userstore.Record     `json:"profile"`
+	Authns      []idp.UserAuthn      `json:"authns"`
+	MFAChannels []idp.UserMFAChannel `json:"mfa_channels"`
+	Password    string               `json:"password,omitempty"`
+}
+
+// UserCommand returns the user subcommand.
+func (c *Command) UserCommand() *cobra.Command {
+	u := &UserCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   UserUsage,
+		Short: UserShort,
+		Long:  UserLong,
+		Args:  cobra.NoArgs,
+		RunE:  u.RunE,
+	}
+
+	cmd.Flags().StringVarP(&u.Email, "email", "", "", "user email address")
+	cmd.Flags().StringVarP(&u.Name, "name", "", "", "user name")
+	cmd.Flags().StringVarP(&u.ProfileFile, "profile-file", "", "", "JSON or YAML file containing the user profile")
+	cmd.Flags().StringArrayVarP(&u.Profile, "profile", "", nil, "profile field as column=value")
+	cmd.Flags().StringVarP(&u.Username, "username", "", "", "login username, defaults to the email address")
+	cmd.Flags().StringVarP(&u.Password, "password", "", "", "login password")
+	cmd.Flags().BoolVarP(&u.GeneratePassword, "generate-password", "", false, "generate a random login password")
+	cmd.Flags().StringVarP(&u.PasswordSecret, "password-secret", "", "", "secret provider location to write the generated password to")
+	cmd.MarkFlagsMutuallyExclusive("password", "generate-password")
+	cmd.Flags().StringArrayVarP(&u.OIDC, "oidc", "", nil, "OIDC identity as provider=...,subject=...[,issuer_url=...]")
+	cmd.Flags().StringVarP(&u.OIDCFile, "oidc-file", "", "", "JSON or YAML file containing a list of OIDC identities")
+	cmd.Flags().StringVarP(&u.OrganizationID, "organization", "", "", "organization ID, defaults to the default organization of the context")
+	cmd.Flags().BoolVarP(&u.Upsert, "upsert", "", false, "update the user with the same email address if there is one")
+	cmd.Flags().BoolVarP(&u.Admin, "admin", "", false, "make the user an admin of the organization")
+	cmd.Flags().StringArrayVarP(&u.MFAEmails, "mfa-email", "", nil, "email address to enroll as an MFA channel")
+	cmd.Flags().StringArrayVarP(&u.MFAPhones, "mfa-phone", "", nil, "phone number to enroll as an SMS MFA channel")
+	cmd.Flags().BoolVarP(&u.Invite, "invite", "", false, "email an invitation to the user")
+	cmd.Flags().StringVarP(&u.InviteClientID, "invite-client-id", "", "", "client ID of the login app the user is invited to")
+	cmd.Flags().StringVarP(&u.InviteRedirect, "invite-redirect-url", "", "", "URL to redirect to after the invite is accepted")
+	cmd.Flags().StringVarP(&u.InviteText, "invite-text", "", "", "additional text to include in the invite")
+	cmd.Flags().DurationVarP(&u.InviteTTL, "invite-ttl", "", DefaultInviteTTL, "how long the invite is valid for")
+	cmd.Flags().StringVarP(&u.Output, "output", "o", "", "output format, either empty for messages or json")
+	cmd.Flags().BoolVarP(&u.Interactive, "interactive", "i", false, "prompt for the user's details")
+	return cmd
+}
+
+func (c *UserCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "create-user", func(ctx context.Context) error {
+		switch c.Output {
+		case "":
+			c.out = cmd.OutOrStdout()
+		case "json":
+			c.out = io.Discard
+		default:
+			return fmt.Errorf("unknown output format %s", c.Output)
+		}
+
+		c.OrganizationID = c.organizationOrDefault(cmd, "organization", c.OrganizationID)
+
+		if c.Interactive {
+			if err := c.prompt(prompt.New(cmd.InOrStdin(), cmd.ErrOrStderr())); err != nil {
+				return err
+			}
+		}
+
+		orgID, err := parseID("organization", c.OrganizationID)
+		if err != nil {
+			return err
+		}
+
+		if c.Admin && orgID.IsNil() {
+			return fmt.Errorf("--admin requires --organization")
+		}
+
+		if c.PasswordSecret != "" && !c.GeneratePassword {
+			return fmt.Errorf("--password-secret requires --generate-password")
+		}
+
+		if c.Invite && (c.InviteClientID == "" || c.InviteRedirect == "") {
+			return fmt.Errorf("--invite requires --invite-client-id and --invite-redirect-url")
+		}
+
+		if c.GeneratePassword {
+			if err := c.generatePassword(ctx); err != nil {
+				return err
+			}
+		}
+
+		profile, err := c.profile()
+		if err != nil {
+			return err
+		}
+
+		authns, err := c.oidcAuthns()
+		if err != nil {
+			return err
+		}
+
+		var existing *idp.UserBaseProfileAndAuthnResponse
+		if c.Upsert {
+			if existing, err = c.findUser(ctx, profile); err != nil {
+				return err
+			}
+		}
+
+		var userID uuid.UUID
+		var existingAuthns []idp.UserAuthn
+		if existing == nil {
+			var opts []idp.Option
+			if !orgID.IsNil() {
+				opts = append(opts, idp.OrganizationID(orgID))
+			}
+
+			if userID, err = c.createUser(ctx, profile, opts); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.out, "Created user %s\n", userID)
+		} else {
+			if userID, err = c.updateUser(ctx, existing, profile); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.out, "Updated user %s\n", userID)
+			existingAuthns = existing.Authns
+		}
+
+		if c.GeneratePassword {
+			fmt.Fprintf(c.out, "Password: %s\n", c.Password)
+		}
+
+		if err := c.addOIDCAuthns(ctx, userID, authns, existingAuthns); err != nil {
+			return err
+		}
+
+		if c.Admin {
+			if err := c.setAdmin(ctx, userID, orgID); err != nil {
+				return err
+			}
+		}
+
+		if err := c.enrollMFA(ctx, userID); err != nil {
+			return err
+		}
+
+		if c.Invite {
+			email, _ := profile["email"].(string)
+			if err := c.sendInvite(ctx, userID, email); err != nil {
+				return err
+			}
+		}
+
+		if c.Output == "json" {
+			return c.writeJSON(ctx, cmd.OutOrStdout(), userID, existing == nil)
+		}
+
+		return nil
+	})
+}
+
+// writeJSON writes the user as it is after creation as JSON.
+func (c *UserCommand) writeJSON(ctx context.Context, w io.Writer, userID uuid.UUID, created bool) error {
+	mc, err := c.mgmtClient()
+	if err != nil {
+		return err
+	}
+
+	user, err := mc.GetClient().GetUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %v", userID, err)
+	}
+
+	authn, err := mc.GetUserBaseProfileAndAuthN(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get the authentication methods of user %s: %v", userID, err)
+	}
+
+	out := userOutput{
+		ID:          userID,
+		Created:     created,
+		Profile:     user.Profile,
+		Authns:      authn.Authns,
+		MFAChannels: authn.MFAChannels,
+	}
+	if c.GeneratePassword {
+		out.Password = c.Password
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// prompt asks for the details of the user, using the values of any flags that were set
+// as defaults.
+func (c *UserCommand) prompt(p *prompt.Prompter) error {
+	var err error
+	if c.OrganizationID, err = p.String("Organization ID (empty for none)", c.OrganizationID, func(value string) error {
+		_, err := parseID("organization", value)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if c.Email, err = p.String("Email", c.Email, func(value string) error {
+		return emailaddress.Address(value).Validate()
+	}); err != nil {
+		return err
+	}
+
+	if c.Name, err = p.String("Name", c.Name, nil); err != nil {
+		return err
+	}
+
+	method, err := p.Choice("Authentication", []string{"none", "password", "generate-password", "oidc"}, "generate-password")
+	if err != nil {
+		return err
+	}
+
+	switch method {
+	case "password":
+		c.GeneratePassword = false
+		if c.Password, err = p.Password("Password"); err != nil {
+			return err
+		}
+	case "generate-password":
+		c.Password = ""
+		c.GeneratePassword = true
+		if c.PasswordSecret, err = p.String("Password secret location (empty to only print the password)", c.PasswordSecret, nil); err != nil {
+			return err
+		}
+	case "oidc":
+		provider, err := p.Choice("OIDC provider", []string{"google", "microsoft", "facebook", "linkedin", "custom"}, "")
+		if err != nil {
+			return err
+		}
+
+		identity := "provider=" + provider
+		if provider == "custom" {
+			issuer, err := p.String("Issuer URL", "", prompt.NonEmpty)
+			if err != nil {
+				return err
+			}
+			identity += ",issuer_url=" + issuer
+		}
+
+		subject, err := p.String("Subject", "", prompt.NonEmpty)
+		if err != nil {
+			return err
+		}
+		c.OIDC = append(c.OIDC, identity+",subject="+subject)
+	}
+
+	if c.OrganizationID != "" {
+		admin, err := p.Choice("Organization admin", []string{"yes", "no"}, "no")
+		if err != nil {
+			return err
+		}
+		c.Admin = admin == "yes"
+	}
+
+	return nil
+}
+
+// generatePassword sets a random password for the user, storing it at the password
+// secret location if there is one.  The password is stored before the user is created
+// so that it can't be lost.
+func (c *UserCommand) generatePassword(ctx context.Context) error {
+	c.Password = crypto.MustRandomBase64(generatedPasswordBytes)
+
+	if c.PasswordSecret != "" {
+		ps, err := secret.NewStringAtLocation(ctx, c.PasswordSecret, c.Password)
+		if err != nil {
+			return fmt.Errorf("failed to store the generated password: %v", err)
+		}
+
+		fmt.Fprintf(c.out, "Password secret: %s\n", ps.Location())
+	}
+
+	return nil
+}
+
+// profile builds the user profile from the profile file and flags.
+func (c *UserCommand) profile() (userstore.Record, error) {
+	profile := userstore.Record{}
+	if c.ProfileFile != "" {
+		if err := readFile(c.ProfileFile, &profile); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, field := range c.Profile {
+		column, value, found := strings.Cut(field, "=")
+		if !found || column == "" {
+			return nil, fmt.Errorf("invalid profile field %s, expected column=value", field)
+		}
+		profile[column] = value
+	}
+
+	if c.Email != "" {
+		profile["email"] = c.Email
+	}
+	if c.Name != "" {
+		profile["name"] = c.Name
+	}
+
+	return profile, nil
+}
+
+// findUser returns the existing user with the profile's email address, or nil if
+// there isn't one.
+func (c *UserCommand) findUser(ctx context.Context, profile userstore.Record) (*idp.UserBaseProfileAndAuthnResponse, error) {
+	email, _ := profile["email"].(string)
+	if email == "" {
+		return nil, fmt.Errorf("--upsert requires an email address")
+	}
+
+	mc, err := c.mgmtClient()
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := mc.ListUserBaseProfilesAndAuthNForEmail(ctx, email, idp.AuthnTypeAll)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up users with email %s: %v", email, err)
+	}
+
+	switch len(users) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &users[0], nil
+	default:
+		return nil, fmt.Errorf("found %d users with email %s, can't choose one to update", len(users), email)
+	}
+}
+
+// updateUser updates the profile and password of an existing user.
+func (c *UserCommand) updateUser(ctx context.Context, user *idp.UserBaseProfileAndAuthnResponse, profile userstore.Record) (uuid.UUID, error) {
+	userID, err := uuid.FromString(user.ID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid user id %s: %v", user.ID, err)
+	}
+
+	mc, err := c.mgmtClient()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if _, err := mc.UpdateUser(ctx, userID, idp.UpdateUserRequest{Profile: profile}); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to update user %s: %v", userID, err)
+	}
+
+	if c.Password == "" {
+		return userID, nil
+	}
+
+	username := c.passwordUsername(profile)
+	for _, authn := range user.Authns {
+		if authn.AuthnType == idp.AuthnTypePassword && authn.Username == username {
+			if err := mc.UpdateUsernamePassword(ctx, username, c.Password); err != nil {
+				return uuid.Nil, fmt.Errorf("failed to update the password of user %s: %v", userID, err)
+			}
+
+			return userID, nil
+		}
+	}
+
+	if err := mc.AddPasswordAuthnToUser(ctx, user.ID, username, c.Password); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to add a password to user %s: %v", userID, err)
+	}
+
+	return userID, nil
+}
+
+// createUser creates the user, with password authentication if a password was given.
+func (c *UserCommand) createUser(ctx context.Context, profile userstore.Record, opts []idp.Option) (uuid.UUID, error) {
+	if c.Password == "" {
+		idpc, err := c.idpClient()
+		if err != nil {
+			return uuid.Nil, err
+		}
+
+		userID, err := idpc.CreateUser(ctx, profile, opts...)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to create user: %v", err)
+		}
+
+		return userID, nil
+	}
+
+	username := c.passwordUsername(profile)
+	if username == "" {
+		return uuid.Nil, fmt.Errorf("a username or email is required to create a user with a password")
+	}
+
+	mc, err := c.mgmtClient()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	userID, err := mc.CreateUserWithPassword(ctx, username, c.Password, profile, opts...)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create user %s: %v", username, err)
+	}
+
+	return userID, nil
+}
+
+// passwordUsername returns the username of the user's password authentication, which is
+// the profile's email address unless a username was given.  The email address is read
+// from the profile rather than --email, since it can also be set with --profile.
+func (c *UserCommand) passwordUsername(profile userstore.Record) string {
+	if c.Username != "" {
+		return c.Username
+	}
+
+	email, _ := profile["email"].(string)
+	return email
+}
+
+// setAdmin creates the edges making the user an admin (and member) of the organization.
+func (c *UserCommand) setAdmin(ctx context.Context, userID, orgID uuid.UUID) error {
+	azc, err := c.authzClient()
+	if err != nil {
+		return err
+	}
+
+	edgeTypes := []struct {
+		id   uuid.UUID
+		name string
+	}{
+		{ucauthz.AdminEdgeTypeID, ucauthz.EdgeTypeAdmin},
+		{ucauthz.MemberEdgeTypeID, ucauthz.EdgeTypeMember},
+	}
+
+	for _, et := range edgeTypes {
+		edge, err := azc.CreateEdge(ctx, uuid.Must(uuid.NewV4()), userID, orgID, et.id, authz.IfNotExists())
+		if err != nil {
+			return fmt.Errorf("user %s was created but its %s edge to %s was not: %v", userID, et.name, orgID, err)
+		}
+
+		fmt.Fprintf(c.out, "Created edge %s: %s %s %s\n", edge.ID, userID, et.name, orgID)
+	}
+
+	return nil
+}
+
+// oidcAuthn is a federated identity to attach to the user.
+type oidcAuthn struct {
+	Provider  oidc.ProviderType `json:"provider"`
+	IssuerURL string            `json:"issuer_url"`
+	Subject   string            `json:"subject"`
+}
+
+// oidcAuthns returns the OIDC identities from the OIDC file and flags.
+func (c *UserCommand) oidcAuthns() ([]oidcAuthn, error) {
+	var authns []oidcAuthn
+	if c.OIDCFile != "" {
+		if err := readFile(c.OIDCFile, &authns); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, flag := range c.OIDC {
+		var authn oidcAuthn
+		for _, field := range strings.Split(flag, ",") {
+			key, value, _ := strings.Cut(field, "=")
+			switch key {
+			case "provider":
+				if err := authn.Provider.UnmarshalText([]byte(value)); err != nil {
+					return nil, fmt.Errorf("invalid OIDC identity %s: %v", flag, err)
+				}
+			case "issuer_url":
+				authn.IssuerURL = value
+			case "subject":
+				authn.Subject = value
+			default:
+				return nil, fmt.Errorf("invalid OIDC identity %s: unknown field %s", flag, key)
+			}
+		}
+		authns = append(authns, authn)
+	}
+
+	for i := range authns {
+		if authns[i].IssuerURL == "" {
+			authns[i].IssuerURL = authns[i].Provider.GetDefaultIssuerURL()
+		}
+
+		if !authns[i].Provider.IsSupported() {
+			return nil, fmt.Errorf("unsupported OIDC provider %v", authns[i].Provider)
+		}
+
+		if err := authns[i].Provider.ValidateIssuerURL(authns[i].IssuerURL); err != nil {
+			return nil, err
+		}
+
+		if authns[i].Subject == "" {
+			return nil, fmt.Errorf("OIDC identities require a subject")
+		}
+	}
+
+	return authns, nil
+}
+
+// addOIDCAuthns attaches the OIDC identities to the user, skipping any that the user
+// already has.
+func (c *UserCommand) addOIDCAuthns(ctx context.Context, userID uuid.UUID, authns []oidcAuthn, existing []idp.UserAuthn) error {
+	if len(authns) == 0 {
+		return nil
+	}
+
+	mc, err := c.mgmtClient()
+	if err != nil {
+		return err
+	}
+
+	for _, authn := range authns {
+		if slices.ContainsFunc(existing, func(e idp.UserAuthn) bool {
+			return e.AuthnType == idp.AuthnTypeOIDC && e.OIDCProvider == authn.Provider && e.OIDCIssuerURL == authn.IssuerURL && e.OIDCSubject == authn.Subject
+		}) {
+			continue
+		}
+
+		if err := mc.AddOIDCAuthnToUser(ctx, userID.String(), authn.Provider, authn.IssuerURL, authn.Subject); err != nil {
+			return fmt.Errorf("user %s was created but its %v identity %s was not added: %v", userID, authn.Provider, authn.Subject, err)
+		}
+
+		fmt.Fprintf(c.out, "Added %v identity %s\n", authn.Provider, authn.Subject)
+	}
+
+	return nil
+}
+
+// enrollMFA adds the MFA channels given on the command line to the user.
+func (c *UserCommand) enrollMFA(ctx context.Context, userID uuid.UUID) error {
+	if len(c.MFAEmails) == 0 && len(c.MFAPhones) == 0 {
+		return nil
+	}
+
+	mc, err := c.mgmtClient()
+	if err != nil {
+		return err
+	}
+
+	channels := []struct {
+		channelType oidc.MFAChannelType
+		ids         []string
+	}{
+		{oidc.MFAEmailChannel, c.MFAEmails},
+		{oidc.MFASMSChannel, c.MFAPhones},
+	}
+
+	for _, ch := range channels {
+		for _, id := range ch.ids {
+			if err := mc.AddMFAChannelToUser(ctx, userID, ch.channelType, id); err != nil {
+				return fmt.Errorf("user %s was created but its %s MFA channel %s was not: %v", userID, ch.channelType, id, err)
+			}
+
+			fmt.Fprintf(c.out, "Enrolled %s MFA channel %s\n", ch.channelType, id)
+		}
+	}
+
+	return nil
+}
+
+// sendInvite emails an invitation to the newly created user.
+func (c *UserCommand) sendInvite(ctx context.Context, userID uuid.UUID, email string) error {
+	if email == "" {
+		return fmt.Errorf("user %s was created but can't be invited without an email address", userID)
+	}
+
+	pc, err := c.plexClient()
+	if err != nil {
+		return err
+	}
+
+	if err := pc.SendInvite(ctx, plex.SendInviteRequest{
+		InviteeEmail: email,
+		ClientID:     c.InviteClientID,
+		State:        crypto.MustRandomHex(inviteStateBytes),
+		RedirectURL:  c.InviteRedirect,
+		InviteText:   c.InviteText,
+		Expires:      time.Now().UTC().Add(c.InviteTTL),
+	}); err != nil {
+		return fmt.Errorf("user %s was created but the invite to %s was not sent: %v", userID, email, err)
+	}
+
+	fmt.Fprintf(c.out, "Sent invite to %s\n", email)
+	return nil
+}
@@ -0,0 +1,150 @@
+package create
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/idp/userstore"
+	"userclouds.com/infra/oidc"
+)
+
+func TestUserCommand_profile(t *testing.T) {
+	dir := t.TempDir()
+	profileFile := filepath.Join(dir, "profile.yaml")
+	assert.NoError(t, os.WriteFile(profileFile, []byte("email: file@example.com\nname: File\ntags: [a, b]\n"), 0600))
+	invalidFile := filepath.Join(dir, "invalid.yaml")
+	assert.NoError(t, os.WriteFile(invalidFile, []byte("- not a profile\n"), 0600))
+
+	tests := []struct {
+		name    string
+		command UserCommand
+		profile userstore.Record
+		err     string
+	}{
+		{"empty", UserCommand{}, userstore.Record{}, ""},
+		{"flags", UserCommand{Email: "jane@example.com", Name: "Jane"}, userstore.Record{"email": "jane@example.com", "name": "Jane"}, ""},
+		{
+			"profile fields",
+			UserCommand{Profile: []string{"nickname=jd", "note=a=b", "empty="}},
+			userstore.Record{"nickname": "jd", "note": "a=b", "empty": ""},
+			"",
+		},
+		{
+			"profile file",
+			UserCommand{ProfileFile: profileFile},
+			userstore.Record{"email": "file@example.com", "name": "File", "tags": []any{"a", "b"}},
+			"",
+		},
+		{
+			"flags override the file",
+			UserCommand{ProfileFile: profileFile, Email: "jane@example.com"},
+			userstore.Record{"email": "jane@example.com", "name": "File", "tags": []any{"a", "b"}},
+			"",
+		},
+		{
+			"precedence",
+			UserCommand{ProfileFile: profileFile, Profile: []string{"name=Field", "email=field@example.com"}, Email: "jane@example.com"},
+			userstore.Record{"email": "jane@example.com", "name": "Field", "tags": []any{"a", "b"}},
+			"",
+		},
+		{"missing value", UserCommand{Profile: []string{"nickname"}}, nil, "invalid profile field nickname"},
+		{"missing column", UserCommand{Profile: []string{"=jd"}}, nil, "invalid profile field =jd"},
+		{"missing file", UserCommand{ProfileFile: filepath.Join(dir, "missing.yaml")}, nil, "failed to read"},
+		{"invalid file", UserCommand{ProfileFile: invalidFile}, nil, "failed to parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := tt.command.profile()
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.profile, profile)
+		})
+	}
+}
+
+func TestUserCommand_oidcAuthns(t *testing.T) {
+	dir := t.TempDir()
+	oidcFile := filepath.Join(dir, "oidc.yaml")
+	assert.NoError(t, os.WriteFile(oidcFile, []byte("- provider: custom\n  issuer_url: https://idp.example.com\n  subject: file-subject\n"), 0600))
+
+	google := oidc.ProviderTypeGoogle.GetDefaultIssuerURL()
+	tests := []struct {
+		name    string
+		command UserCommand
+		authns  []oidcAuthn
+		err     string
+	}{
+		{"none", UserCommand{}, nil, ""},
+		{
+			"native provider defaults the issuer",
+			UserCommand{OIDC: []string{"provider=google,subject=12345"}},
+			[]oidcAuthn{{Provider: oidc.ProviderTypeGoogle, IssuerURL: google, Subject: "12345"}},
+			"",
+		},
+		{
+			"custom provider",
+			UserCommand{OIDC: []string{"provider=custom,issuer_url=https://idp.example.com,subject=abc"}},
+			[]oidcAuthn{{Provider: oidc.ProviderTypeCustom, IssuerURL: "https://idp.example.com", Subject: "abc"}},
+			"",
+		},
+		{
+			"file and flags",
+			UserCommand{OIDCFile: oidcFile, OIDC: []string{"provider=google,subject=12345"}},
+			[]oidcAuthn{
+				{Provider: oidc.ProviderTypeCustom, IssuerURL: "https://idp.example.com", Subject: "file-subject"},
+				{Provider: oidc.ProviderTypeGoogle, IssuerURL: google, Subject: "12345"},
+			},
+			"",
+		},
+		{"unknown provider", UserCommand{OIDC: []string{"provider=myspace,subject=abc"}}, nil, "invalid OIDC identity"},
+		{"unknown field", UserCommand{OIDC: []string{"provider=google,subject=abc,email=jane@example.com"}}, nil, "unknown field email"},
+		{"no provider", UserCommand{OIDC: []string{"subject=abc"}}, nil, "unsupported OIDC provider"},
+		{"no subject", UserCommand{OIDC: []string{"provider=google"}}, nil, "OIDC identities require a subject"},
+		{"custom provider without issuer", UserCommand{OIDC: []string{"provider=custom,subject=abc"}}, nil, "cannot be empty"},
+		{"native provider with another issuer", UserCommand{OIDC: []string{"provider=google,issuer_url=https://idp.example.com,subject=abc"}}, nil, "is invalid for native type"},
+		{"missing file", UserCommand{OIDCFile: filepath.Join(dir, "missing.yaml")}, nil, "failed to read"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authns, err := tt.command.oidcAuthns()
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.authns, authns)
+		})
+	}
+}
+
+func TestUserCommand_passwordUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  UserCommand
+		username string
+	}{
+		{"username", UserCommand{Username: "jdoe", Email: "jane@example.com"}, "jdoe"},
+		{"email flag", UserCommand{Email: "jane@example.com"}, "jane@example.com"},
+		{"profile email", UserCommand{Profile: []string{"email=jane@example.com"}}, "jane@example.com"},
+		{"email flag overrides profile", UserCommand{Email: "jane@example.com", Profile: []string{"email=john@example.com"}}, "jane@example.com"},
+		{"no email", UserCommand{Profile: []string{"name=Jane"}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := tt.command.profile()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.username, tt.command.passwordUsername(profile))
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package create
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/idp"
+	"userclouds.com/idp/userstore"
+)
+
+const (
+	UsersUsage = "users"
+	UsersShort = "Create users in bulk"
+	UsersLong  = `Create users in bulk from a CSV or NDJSON file.
+
+For CSV the first row of the file is the header, and by default each header is used as the
+userstore column name.  Use --column-map to map userstore columns to differently named headers,
+e.g. --column-map email=Email,name=FullName; when a column map is given only the mapped columns
+are imported.  Empty cells are skipped.
+
+For NDJSON each line is a JSON object of userstore columns to values, which allows nested
+profile fields to be expressed.
+
+Rows that fail are reported individually and do not stop the import.  With --failures-file the
+profiles of the failed rows are written as NDJSON, which can be passed back to --from-ndjson to
+retry only the failed rows.`
+
+	DefaultUsersConcurrency = 4
+)
+
+// UsersCommand creates users in bulk.
+type UsersCommand struct {
+	*Command
+	FromCSV      string
+	FromNDJSON   string
+	ColumnMap    map[string]string
+	Concurrency  int
+	FailuresFile string
+}
+
+// userRow is a single user read from an input file.  Line is the line of the file
+// that the user was read from, for error reporting.
+type userRow struct {
+	Line    int
+	Profile userstore.Record
+}
+
+// UsersCommand returns the users subcommand.
+func (c *Command) UsersCommand() *cobra.Command {
+	u := &UsersCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   UsersUsage,
+		Short: UsersShort,
+		Long:  UsersLong,
+		Args:  cobra.NoArgs,
+		RunE:  u.RunE,
+	}
+
+	cmd.Flags().StringVarP(&u.FromCSV, "from-csv", "", "", "CSV file of users to create")
+	cmd.Flags().StringVarP(&u.FromNDJSON, "from-ndjson", "", "", "NDJSON file of users to create")
+	cmd.Flags().StringToStringVarP(&u.ColumnMap, "column-map", "", nil, "userstore column to CSV header mapping")
+	cmd.Flags().IntVarP(&u.Concurrency, "concurrency", "", DefaultUsersConcurrency, "number of workers creating users")
+	cmd.Flags().StringVarP(&u.FailuresFile, "failures-file", "", "", "NDJSON file to write the profiles of failed rows to")
+	cmd.MarkFlagsMutuallyExclusive("from-csv", "from-ndjson")
+	return cmd
+}
+
+func (c *UsersCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "create-users", func(ctx context.Context) error {
+		if c.Concurrency < 1 {
+			return fmt.Errorf("concurrency must be at least 1")
+		}
+
+		rows, err := c.readUsers()
+		if err != nil {
+			return err
+		}
+
+		var failures *json.Encoder
+		if c.FailuresFile != "" {
+			f, err := os.Create(c.FailuresFile)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %v", c.FailuresFile, err)
+			}
+			defer f.Close()
+			failures = json.NewEncoder(f)
+		}
+
+		idpc, err := c.idpClient()
+		if err != nil {
+			return err
+		}
+
+		// successes are only listed individually when there's no progress bar
+		pb := newProgress(cmd.ErrOrStderr(), len(rows))
+		var mu sync.Mutex
+		failed := 0
+		createUsers(ctx, idpc, rows, c.Concurrency, func(row userRow, id string, err error) {
+			defer pb.Increment()
+
+			if err != nil {
+				pb.Printf("line %d: %v\n", row.Line, err)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				failed++
+				if failures != nil {
+					if err := failures.Encode(row.Profile); err != nil {
+						pb.Printf("failed to write line %d to %s: %v\n", row.Line, c.FailuresFile, err)
+					}
+				}
+				return
+			}
+
+			if !pb.Enabled() {
+				fmt.Fprintf(cmd.OutOrStdout(), "line %d: created user %s\n", row.Line, id)
+			}
+		})
+		pb.Finish()
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created %d of %d users\n", len(rows)-failed, len(rows))
+		if failed > 0 {
+			return fmt.Errorf("failed to create %d users", failed)
+		}
+
+		return nil
+	})
+}
+
+// readUsers reads the users to create from the input file.
+func (c *UsersCommand) readUsers() ([]userRow, error) {
+	path, read := c.FromCSV, func(r io.Reader) ([]userRow, error) { return readCSVUsers(r, c.ColumnMap) }
+	if c.FromNDJSON != "" {
+		path, read = c.FromNDJSON, readNDJSONUsers
+	}
+
+	if path == "" {
+		return nil, fmt.Errorf("an input file is required")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	rows, err := read(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	return rows, nil
+}
+
+// createUsers creates the users with the given number of workers, calling done as
+// each user is created or fails.
+func createUsers(ctx context.Context, idpc *idp.Client, rows []userRow, concurrency int, done func(row userRow, id string, err error)) {
+	work := make(chan userRow)
+
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range work {
+				id, err := idpc.CreateUser(ctx, row.Profile)
+				if err != nil {
+					done(row, "", err)
+					continue
+				}
+
+				done(row, id.String(), nil)
+			}
+		}()
+	}
+
+	for _, row := range rows {
+		work <- row
+	}
+	close(work)
+
+	wg.Wait()
+}
+
+// readCSVUsers reads users from CSV with a header row.  columnMap maps userstore
+// columns to CSV headers; if it is empty every header is used as a column name.
+func readCSVUsers(r io.Reader, columnMap map[string]string) ([]userRow, error) {
+	cr := csv.NewReader(r)
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %v", err)
+	}
+
+	// columns maps the index of each imported CSV field to its userstore column
+	columns := map[int]string{}
+	if len(columnMap) == 0 {
+		for i, h := range header {
+			columns[i] = h
+		}
+	} else {
+		indexes := map[string]int{}
+		for i, h := range header {
+			indexes[h] = i
+		}
+
+		for column, h := range columnMap {
+			i, found := indexes[h]
+			if !found {
+				return nil, fmt.Errorf("column %s is mapped to %s, which is not in the header", column, h)
+			}
+			columns[i] = column
+		}
+	}
+
+	var rows []userRow
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		profile := userstore.Record{}
+		for i, column := range columns {
+			if record[i] != "" {
+				profile[column] = record[i]
+			}
+		}
+
+		rows = append(rows, userRow{Line: line, Profile: profile})
+	}
+
+	return rows, nil
+}
+
+// readNDJSONUsers reads users from newline delimited JSON, one profile per line.
+// Blank lines are skipped.
+func readNDJSONUsers(r io.Reader) ([]userRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+
+	var rows []userRow
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		profile := userstore.Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &profile); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		rows = append(rows, userRow{Line: line, Profile: profile})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
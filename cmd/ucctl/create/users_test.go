@@ -0,0 +1,196 @@
+package create
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/idp/userstore"
+)
+
+func TestReadCSVUsers(t *testing.T) {
+	tests := []struct {
+		name      string
+		csv       string
+		columnMap map[string]string
+		rows      []userRow
+		err       string
+	}{
+		{
+			name: "headers are column names",
+			csv:  "email,name\njane@example.com,Jane\njohn@example.com,John\n",
+			rows: []userRow{
+				{Line: 2, Profile: userstore.Record{"email": "jane@example.com", "name": "Jane"}},
+				{Line: 3, Profile: userstore.Record{"email": "john@example.com", "name": "John"}},
+			},
+		},
+		{
+			name: "empty cells are skipped",
+			csv:  "email,name\njane@example.com,\n",
+			rows: []userRow{{Line: 2, Profile: userstore.Record{"email": "jane@example.com"}}},
+		},
+		{
+			name:      "column map",
+			csv:       "Email,FullName,Ignored\njane@example.com,Jane,x\n",
+			columnMap: map[string]string{"email": "Email", "name": "FullName"},
+			rows:      []userRow{{Line: 2, Profile: userstore.Record{"email": "jane@example.com", "name": "Jane"}}},
+		},
+		{
+			name: "lines of multiline fields",
+			csv:  "email,address\njane@example.com,\"1 Main St\nSpringfield\"\njohn@example.com,\n",
+			rows: []userRow{
+				{Line: 2, Profile: userstore.Record{"email": "jane@example.com", "address": "1 Main St\nSpringfield"}},
+				{Line: 4, Profile: userstore.Record{"email": "john@example.com"}},
+			},
+		},
+		{
+			name: "header only",
+			csv:  "email,name\n",
+		},
+		{
+			name:      "mapped header is missing",
+			csv:       "Email\njane@example.com\n",
+			columnMap: map[string]string{"name": "FullName"},
+			err:       "column name is mapped to FullName, which is not in the header",
+		},
+		{
+			name: "empty file",
+			csv:  "",
+			err:  "failed to read header",
+		},
+		{
+			name: "wrong number of fields",
+			csv:  "email,name\njane@example.com\n",
+			err:  "wrong number of fields",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := readCSVUsers(strings.NewReader(tt.csv), tt.columnMap)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.rows, rows)
+		})
+	}
+}
+
+func TestReadNDJSONUsers(t *testing.T) {
+	tests := []struct {
+		name   string
+		ndjson string
+		rows   []userRow
+		err    string
+	}{
+		{
+			name:   "profiles",
+			ndjson: "{\"email\":\"jane@example.com\"}\n{\"email\":\"john@example.com\",\"age\":42}\n",
+			rows: []userRow{
+				{Line: 1, Profile: userstore.Record{"email": "jane@example.com"}},
+				{Line: 2, Profile: userstore.Record{"email": "john@example.com", "age": float64(42)}},
+			},
+		},
+		{
+			name:   "nested fields",
+			ndjson: `{"email":"jane@example.com","address":{"city":"Springfield"},"tags":["a","b"]}`,
+			rows: []userRow{{Line: 1, Profile: userstore.Record{
+				"email":   "jane@example.com",
+				"address": map[string]any{"city": "Springfield"},
+				"tags":    []any{"a", "b"},
+			}}},
+		},
+		{
+			name:   "blank lines are skipped",
+			ndjson: "\n{\"email\":\"jane@example.com\"}\n\n",
+			rows:   []userRow{{Line: 2, Profile: userstore.Record{"email": "jane@example.com"}}},
+		},
+		{
+			name:   "empty file",
+			ndjson: "",
+		},
+		{
+			name:   "invalid JSON",
+			ndjson: "{\"email\":\"jane@example.com\"}\n{\"email\":\n",
+			err:    "line 2:",
+		},
+		{
+			name:   "not an object",
+			ndjson: "[\"jane@example.com\"]\n",
+			err:    "line 1:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := readNDJSONUsers(strings.NewReader(tt.ndjson))
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.rows, rows)
+		})
+	}
+}
+
+func TestUsersCommand_readUsers(t *testing.T) {
+	dir := t.TempDir()
+	csvFile := filepath.Join(dir, "users.csv")
+	assert.NoError(t, os.WriteFile(csvFile, []byte("Email\njane@example.com\n"), 0600))
+	ndjsonFile := filepath.Join(dir, "users.ndjson")
+	assert.NoError(t, os.WriteFile(ndjsonFile, []byte(`{"email":"john@example.com"}`+"\n"), 0600))
+
+	tests := []struct {
+		name    string
+		command UsersCommand
+		rows    []userRow
+		err     string
+	}{
+		{
+			name:    "csv with column map",
+			command: UsersCommand{FromCSV: csvFile, ColumnMap: map[string]string{"email": "Email"}},
+			rows:    []userRow{{Line: 2, Profile: userstore.Record{"email": "jane@example.com"}}},
+		},
+		{
+			name:    "ndjson",
+			command: UsersCommand{FromNDJSON: ndjsonFile},
+			rows:    []userRow{{Line: 1, Profile: userstore.Record{"email": "john@example.com"}}},
+		},
+		{
+			name:    "no input file",
+			command: UsersCommand{},
+			err:     "an input file is required",
+		},
+		{
+			name:    "missing file",
+			command: UsersCommand{FromCSV: filepath.Join(dir, "missing.csv")},
+			err:     "failed to open",
+		},
+		{
+			name:    "invalid file",
+			command: UsersCommand{FromNDJSON: csvFile},
+			err:     "failed to read " + csvFile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := tt.command.readUsers()
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.rows, rows)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+)
+
+// groupCommand is set on commands that connect to a tenant and can be run against each
+// context of a group with --context-group.
+const groupCommand = "ucctl/context-group"
+
+// annotated returns whether the command or one of its parents has the annotation.
+func annotated(cmd *cobra.Command, annotation string) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if _, ok := c.Annotations[annotation]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runGroup replaces the command's RunE with one that runs the command line again against
+// each context of the group named by --context-group, with the output of each context
+// prefixed by its name, and then prints a summary.
+func (r *Root) runGroup(cmd *cobra.Command) error {
+	if !annotated(cmd, groupCommand) {
+		return fmt.Errorf("%s does not support --context-group", cmd.CommandPath())
+	}
+
+	if cmd.Flags().Changed("context") {
+		return fmt.Errorf("--context and --context-group can't be used together")
+	}
+
+	path, err := config.PathFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	members, err := cfg.Group(r.ContextGroup)
+	if err != nil {
+		return err
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		var failed []string
+		errs := map[string]error{}
+		for _, uc := range members {
+			// the member runs reuse the loaded config, so an encrypted config's passphrase
+			// is only asked for once
+			member := &Root{args: memberArgs(r.commandArgs(), uc.Name), loaded: cfg}
+			memberCmd := member.Command()
+			memberCmd.SetArgs(member.args)
+			memberCmd.SetIn(cmd.InOrStdin())
+
+			out := newPrefixWriter(cmd.OutOrStdout(), uc.Name)
+			errOut := newPrefixWriter(cmd.ErrOrStderr(), uc.Name)
+			memberCmd.SetOut(out)
+			memberCmd.SetErr(errOut)
+
+			if err := memberCmd.ExecuteContext(cmd.Context()); err != nil {
+				failed = append(failed, uc.Name)
+				errs[uc.Name] = err
+			}
+			_ = out.Flush()
+			_ = errOut.Flush()
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "CONTEXT\tSTATUS\tERROR")
+		for _, uc := range members {
+			if err, ok := errs[uc.Name]; ok {
+				fmt.Fprintf(w, "%s\tfailed\t%v\n", uc.Name, err)
+			} else {
+				fmt.Fprintf(w, "%s\tok\t\n", uc.Name)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if len(failed) > 0 {
+			return fmt.Errorf("%d of %d contexts of group %s failed: %s", len(failed), len(members), r.ContextGroup, strings.Join(failed, ", "))
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+// commandArgs returns the command line arguments ucctl was run with.
+func (r *Root) commandArgs() []string {
+	if r.args != nil {
+		return r.args
+	}
+
+	return os.Args[1:]
+}
+
+// memberArgs returns the command line for a member of a context group, which selects the
+// member instead of the group.
+func memberArgs(args []string, member string) []string {
+	out := []string{"--context", member}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(out, args[i:]...)
+		}
+
+		if arg == "--context-group" {
+			// skip the flag's value too
+			i++
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--context-group=") {
+			continue
+		}
+
+		out = append(out, arg)
+	}
+
+	return out
+}
+
+// prefixWriter prefixes each line written to it, so that the output of the members of a
+// context group can be told apart.
+type prefixWriter struct {
+	w      io.Writer
+	prefix []byte
+	buf    bytes.Buffer
+}
+
+func newPrefixWriter(w io.Writer, name string) *prefixWriter {
+	return &prefixWriter{w: w, prefix: []byte("[" + name + "] ")}
+}
+
+// Write implements io.Writer, writing complete lines and buffering the rest.
+func (p *prefixWriter) Write(bs []byte) (int, error) {
+	p.buf.Write(bs)
+	for {
+		i := bytes.IndexByte(p.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(bs), nil
+		}
+
+		line := p.buf.Next(i + 1)
+		if _, err := p.w.Write(append(append([]byte{}, p.prefix...), line...)); err != nil {
+			return len(bs), err
+		}
+	}
+}
+
+// Flush writes a final partial line.
+func (p *prefixWriter) Flush() error {
+	if p.buf.Len() == 0 {
+		return nil
+	}
+
+	_, err := p.w.Write(append(append(append([]byte{}, p.prefix...), p.buf.Bytes()...), '\n'))
+	p.buf.Reset()
+	return err
+}
@@ -0,0 +1,115 @@
+package login
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+	"userclouds.com/infra/logtransports"
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/uclog"
+)
+
+// Command logs in to the tenant of a context and caches the access token, so that later
+// commands using the context don't need its client secret.
+type Command struct {
+	Verbose  bool
+	ClientID string
+	Device   bool
+	Scopes   []string
+}
+
+func (c *Command) RunE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	logLevel := uclog.LogLevelInfo
+	if c.Verbose {
+		logLevel = uclog.LogLevelDebug
+	}
+
+	logtransports.InitLoggerAndTransportsForTools(ctx, logLevel, logLevel, "login")
+	defer logtransports.Close()
+
+	if err := c.login(ctx, cmd); err != nil {
+		uclog.Errorf(ctx, "%v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *Command) login(ctx context.Context, cmd *cobra.Command) error {
+	state := config.FromContext(ctx)
+	if state == nil {
+		return fmt.Errorf("config was not loaded")
+	}
+
+	uc := state.Current
+	if uc == nil {
+		return fmt.Errorf("no context selected, use --context or ucctl context use")
+	}
+
+	if uc.URL == "" {
+		return fmt.Errorf("context %s has no tenant URL", uc.Name)
+	}
+
+	clientID := uc.ClientID
+	if c.ClientID != "" {
+		clientID = c.ClientID
+	}
+	if clientID == "" {
+		return fmt.Errorf("context %s has no client id, use --client-id", uc.Name)
+	}
+
+	client, err := uc.HTTPClient()
+	if err != nil {
+		return err
+	}
+
+	var t *config.Token
+	if c.Device || uc.ClientSecret.IsEmpty() {
+		t, err = c.deviceLogin(ctx, cmd, client, uc.URL, clientID)
+	} else {
+		t, err = c.clientCredentialsLogin(ctx, client, uc, clientID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := config.SaveToken(state.Path, uc.Name, t); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Logged in to %s, token for context %s expires at %s\n", uc.URL, uc.Name, t.ExpiresAt.Local().Format(time.RFC1123))
+	return nil
+}
+
+// clientCredentialsLogin gets a token with the client secret of the context.
+func (c *Command) clientCredentialsLogin(ctx context.Context, client *http.Client, uc *config.Context, clientID string) (*config.Token, error) {
+	clientSecret, err := uc.ResolveClientSecret(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenURL, err := config.TokenURL(uc.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := oidc.ClientCredentialsTokenSource{
+		TokenURL:     tokenURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		HTTPClient:   client,
+	}
+	accessToken, err := ts.GetToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a token from %s: %v", tokenURL, err)
+	}
+
+	return config.NewToken(&oidc.TokenResponse{AccessToken: accessToken})
+}
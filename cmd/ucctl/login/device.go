@@ -0,0 +1,182 @@
+package login
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+	"userclouds.com/infra/oidc"
+)
+
+const (
+	deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+	// defaultPollInterval is used when the device authorization response doesn't specify one.
+	defaultPollInterval = 5 * time.Second
+
+	// defaultDeviceCodeTTL is used when the device authorization response doesn't specify
+	// when the device code expires.
+	defaultDeviceCodeTTL = 10 * time.Minute
+)
+
+// discovery holds the endpoints of the tenant's OpenID configuration used by the device flow.
+type discovery struct {
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+}
+
+// deviceAuthorization is the response of the device authorization endpoint (RFC 8628).
+type deviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+
+	ErrorType string `json:"error"`
+	ErrorDesc string `json:"error_description"`
+}
+
+// deviceLogin performs the OAuth device authorization grant: the user approves the login
+// in a browser on any machine while ucctl polls the token endpoint.
+func (c *Command) deviceLogin(ctx context.Context, cmd *cobra.Command, client *http.Client, tenantURL, clientID string) (*config.Token, error) {
+	d, err := discover(ctx, client, tenantURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.DeviceAuthorizationEndpoint == "" {
+		return nil, fmt.Errorf("%s does not support the device authorization grant, set a client secret on the context to log in with client credentials", tenantURL)
+	}
+
+	form := url.Values{}
+	form.Set("client_id", clientID)
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+
+	var da deviceAuthorization
+	if err := postForm(ctx, client, d.DeviceAuthorizationEndpoint, form, &da); err != nil {
+		return nil, fmt.Errorf("failed to start device authorization: %v", err)
+	}
+	if da.ErrorType != "" {
+		return nil, fmt.Errorf("failed to start device authorization: %s %s", da.ErrorType, da.ErrorDesc)
+	}
+
+	if da.VerificationURIComplete != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "To log in, open %s\n", da.VerificationURIComplete)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "To log in, open %s\n", da.VerificationURI)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "and enter the code %s\n", da.UserCode)
+
+	interval := defaultPollInterval
+	if da.Interval > 0 {
+		interval = time.Duration(da.Interval) * time.Second
+	}
+
+	expiresIn := defaultDeviceCodeTTL
+	if da.ExpiresIn > 0 {
+		expiresIn = time.Duration(da.ExpiresIn) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, expiresIn)
+	defer cancel()
+
+	form = url.Values{}
+	form.Set("grant_type", deviceCodeGrantType)
+	form.Set("device_code", da.DeviceCode)
+	form.Set("client_id", clientID)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("device code expired before the login was approved")
+		case <-time.After(interval):
+		}
+
+		var resp oidc.TokenResponse
+		if err := postForm(ctx, client, d.TokenEndpoint, form, &resp); err != nil {
+			return nil, fmt.Errorf("failed to get a token from %s: %v", d.TokenEndpoint, err)
+		}
+
+		switch resp.ErrorType {
+		case "":
+			return config.NewToken(&resp)
+		case "authorization_pending":
+		case "slow_down":
+			interval += defaultPollInterval
+		case "expired_token":
+			return nil, fmt.Errorf("device code expired before the login was approved")
+		case "access_denied":
+			return nil, fmt.Errorf("login was denied")
+		default:
+			return nil, fmt.Errorf("login failed: %s %s", resp.ErrorType, resp.ErrorDesc)
+		}
+	}
+}
+
+// discover reads the tenant's OpenID configuration.
+func discover(ctx context.Context, client *http.Client, tenantURL string) (*discovery, error) {
+	u, err := url.JoinPath(tenantURL, "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenant URL %s: %v", tenantURL, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %s: %v", u, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %v", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: %s", u, resp.Status)
+	}
+
+	var d discovery
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", u, err)
+	}
+
+	if d.TokenEndpoint == "" {
+		return nil, fmt.Errorf("%s has no token endpoint", u)
+	}
+
+	return &d, nil
+}
+
+// postForm posts a form to an OAuth endpoint and decodes the JSON response into v.
+// OAuth errors are returned in the response body with a 400 status, so they are decoded
+// rather than treated as failures.
+func postForm(ctx context.Context, client *http.Client, endpoint string, form url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("unexpected response from %s: %s", endpoint, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,117 @@
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Prompter asks the operator for values on the command line.
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// fd is the file descriptor of the input if it is a terminal, used to read passwords
+	// without echoing them, or -1.
+	fd int
+}
+
+// New returns a prompter reading from in and writing the prompts to out.
+func New(in io.Reader, out io.Writer) *Prompter {
+	p := &Prompter{in: bufio.NewReader(in), out: out, fd: -1}
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		p.fd = int(f.Fd())
+	}
+
+	return p
+}
+
+// String prompts for a value until validate accepts it.  An empty answer selects def.
+func (p *Prompter) String(label, def string, validate func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", label, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", label)
+		}
+
+		value, err := p.readLine()
+		if err != nil {
+			return "", err
+		}
+
+		if value == "" {
+			value = def
+		}
+
+		if validate != nil {
+			if err := validate(value); err != nil {
+				fmt.Fprintf(p.out, "  %v\n", err)
+				continue
+			}
+		}
+
+		return value, nil
+	}
+}
+
+// Choice prompts until one of the options is chosen.
+func (p *Prompter) Choice(label string, options []string, def string) (string, error) {
+	return p.String(fmt.Sprintf("%s (%s)", label, strings.Join(options, ", ")), def, func(value string) error {
+		if !slices.Contains(options, value) {
+			return fmt.Errorf("choose one of %s", strings.Join(options, ", "))
+		}
+		return nil
+	})
+}
+
+// Password prompts for a non-empty password or other secret, without echoing it if the
+// input is a terminal.
+func (p *Prompter) Password(label string) (string, error) {
+	for {
+		fmt.Fprintf(p.out, "%s: ", label)
+
+		var value string
+		if p.fd >= 0 {
+			bs, err := term.ReadPassword(p.fd)
+			fmt.Fprintln(p.out)
+			if err != nil {
+				return "", fmt.Errorf("failed to read password: %v", err)
+			}
+			value = string(bs)
+		} else {
+			var err error
+			if value, err = p.readLine(); err != nil {
+				return "", err
+			}
+		}
+
+		if value != "" {
+			return value, nil
+		}
+
+		fmt.Fprintln(p.out, "  a value is required")
+	}
+}
+
+func (p *Prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("failed to read input: %v", err)
+	}
+
+	return strings.TrimSpace(line), nil
+}
+
+// NonEmpty is a validation function for String that requires a value.
+func NonEmpty(value string) error {
+	if value == "" {
+		return fmt.Errorf("a value is required")
+	}
+	return nil
+}
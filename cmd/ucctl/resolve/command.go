@@ -0,0 +1,118 @@
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/config"
+	"userclouds.com/idp"
+	"userclouds.com/infra/jsonclient"
+	"userclouds.com/infra/logtransports"
+	"userclouds.com/infra/oidc"
+	"userclouds.com/infra/uclog"
+)
+
+const (
+	DefaultClientSecretVar = "UC_CLIENT_SECRET"
+)
+
+// Command holds the options shared by all of the resolve subcommands.
+type Command struct {
+	URL             string
+	ClientID        string
+	ClientSecretVar string
+	Verbose         bool
+}
+
+// run initializes logging and then calls fn.  Errors are logged here since the root
+// command silences them.
+func (c *Command) run(cmd *cobra.Command, name string, fn func(ctx context.Context) error) error {
+	ctx := cmd.Context()
+
+	logLevel := uclog.LogLevelInfo
+	if c.Verbose {
+		logLevel = uclog.LogLevelDebug
+	}
+
+	logtransports.InitLoggerAndTransportsForTools(ctx, logLevel, logLevel, name)
+	defer logtransports.Close()
+
+	if err := fn(ctx); err != nil {
+		uclog.Errorf(ctx, "%v", err)
+		return err
+	}
+
+	return nil
+}
+
+// idpClient returns an idp client for the tenant, filling in the connection options
+// that weren't set by flags from the current context.
+func (c *Command) idpClient(ctx context.Context) (*idp.Client, error) {
+	url, clientID, clientSecret := c.URL, c.ClientID, os.Getenv(c.ClientSecretVar)
+	var client *http.Client
+	var opts []jsonclient.Option
+	if uc := config.CurrentContext(ctx); uc != nil {
+		// the context's connection settings only apply to its tenant
+		if url == "" || url == uc.URL {
+			var err error
+			if client, opts, err = uc.ClientOptions(); err != nil {
+				return nil, err
+			}
+		}
+
+		// the context's cached tokens are used when connecting to its tenant with its client
+		if (url == "" || url == uc.URL) && (clientID == "" || clientID == uc.ClientID) {
+			ts := config.FromContext(ctx).TokenSource(ctx, clientSecret)
+			return idp.NewClient(uc.URL, append(organizationOptions(ctx), idp.JSONClient(append(opts, jsonclient.TokenSource(ts))...))...)
+		}
+
+		if url == "" {
+			url = uc.URL
+		}
+
+		if clientID == "" {
+			clientID = uc.ClientID
+		}
+
+		if clientSecret == "" {
+			s, err := uc.ResolveClientSecret(ctx)
+			if err != nil {
+				return nil, err
+			}
+			clientSecret = s
+		}
+	}
+
+	if url == "" || clientID == "" || clientSecret == "" {
+		return nil, fmt.Errorf("tenant URL, client id and client secret are required")
+	}
+
+	tokenURL, err := config.TokenURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, jsonclient.TokenSource(oidc.ClientCredentialsTokenSource{
+		TokenURL:     tokenURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		HTTPClient:   client,
+	}))
+
+	return idp.NewClient(url, append(organizationOptions(ctx), idp.JSONClient(opts...))...)
+}
+
+// organizationOptions returns the idp options scoping API calls to the organization of
+// --as-organization, if it is set.
+func organizationOptions(ctx context.Context) []idp.Option {
+	orgID := config.AsOrganization(ctx)
+	if orgID.IsNil() {
+		return nil
+	}
+
+	return []idp.Option{idp.OrganizationID(orgID)}
+}
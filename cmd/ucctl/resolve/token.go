@@ -0,0 +1,76 @@
+package resolve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+	"github.com/spf13/cobra"
+
+	"userclouds.com/idp/policy"
+	"userclouds.com/idp/userstore"
+)
+
+const (
+	TokenUsage = "token TOKEN"
+	TokenShort = "Resolve a token"
+	TokenLong  = `Resolve a token to its original value, subject to the access policy of the token.  Purposes
+may be referenced by name or ID.`
+)
+
+// TokenCommand resolves a token.
+type TokenCommand struct {
+	*Command
+	Purposes      []string
+	ClientContext string
+}
+
+// TokenCommand returns the token subcommand.
+func (c *Command) TokenCommand() *cobra.Command {
+	t := &TokenCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   TokenUsage,
+		Short: TokenShort,
+		Long:  TokenLong,
+		Args:  cobra.ExactArgs(1),
+		RunE:  t.RunE,
+	}
+
+	cmd.Flags().StringArrayVarP(&t.Purposes, "purpose", "", nil, "purpose name or ID")
+	cmd.Flags().StringVarP(&t.ClientContext, "client-context", "", "", "client context passed to the access policy as JSON")
+	return cmd
+}
+
+func (c *TokenCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "resolve-token", func(ctx context.Context) error {
+		clientContext := policy.ClientContext{}
+		if c.ClientContext != "" {
+			if err := json.Unmarshal([]byte(c.ClientContext), &clientContext); err != nil {
+				return fmt.Errorf("invalid client context: %v", err)
+			}
+		}
+
+		var purposes []userstore.ResourceID
+		for _, p := range c.Purposes {
+			if id, err := uuid.FromString(p); err == nil {
+				purposes = append(purposes, userstore.ResourceID{ID: id})
+			} else {
+				purposes = append(purposes, userstore.ResourceID{Name: p})
+			}
+		}
+
+		idpc, err := c.idpClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		value, err := idpc.ResolveToken(ctx, args[0], clientContext, purposes)
+		if err != nil {
+			return fmt.Errorf("failed to resolve token: %v", err)
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), value)
+		return nil
+	})
+}
@@ -1,9 +1,21 @@
 package main
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/spf13/cobra"
 
+	"userclouds.com/cmd/ucctl/authzmodel"
+	"userclouds.com/cmd/ucctl/config"
+	"userclouds.com/cmd/ucctl/console"
+	"userclouds.com/cmd/ucctl/contexts"
+	"userclouds.com/cmd/ucctl/create"
+	"userclouds.com/cmd/ucctl/login"
+	"userclouds.com/cmd/ucctl/resolve"
+	"userclouds.com/cmd/ucctl/secrets"
 	"userclouds.com/cmd/ucctl/synctenant"
+	"userclouds.com/cmd/ucctl/tenant"
 )
 
 const (
@@ -13,9 +25,75 @@ const (
 	SyncTenantUsage = "synctenant [ARG...]"
 	SyncTenantShort = "Sync userclouds tenant resources"
 	SyncTenantLong  = `Sync userclouds tenant resources`
+	CreateUsage     = "create [RESOURCE]"
+	CreateShort     = "Create userclouds tenant resources"
+	CreateLong      = `Create userclouds tenant resources`
+	SecretUsage     = "secret [COMMAND]"
+	SecretShort     = "Manage secrets through the userclouds secret providers"
+	SecretLong      = `Manage secrets through the userclouds secret providers`
+	TenantUsage     = "tenant [COMMAND]"
+	TenantShort     = "Manage userclouds tenants through the console"
+	TenantLong      = `Manage userclouds tenants through the console.  The console API is authenticated with
+a console session ID, read from the environment variable named by --session.`
+	ContextUsage = "context [COMMAND]"
+	ContextShort = "Manage ucctl contexts"
+	ContextLong  = `Manage ucctl contexts.  A context holds the tenant URL and client credentials used by
+commands when the corresponding flags are not set.  Commands use the current context unless
+another one is selected for the invocation with --context or the UC_CONTEXT environment
+variable.  In a Kubernetes pod with no context selected, the in-cluster context is built from
+UC_TENANT_URL, UC_CLIENT_ID and UC_CLIENT_SECRET_LOCATION or UC_CLIENT_SECRET, or from the
+url, client_id and client_secret files mounted in /var/run/secrets/userclouds (UC_SECRETS_DIR).
+
+Context settings may reference environment variables as ${VAR}, e.g. client_id: ${UC_CLIENT_ID},
+so that config files can be committed without literal credentials.  Use $${VAR} for a literal
+${VAR}.
+
+Contexts can be grouped with ucctl context group, e.g. by region, and commands that connect to a
+tenant run against each context of a group with --context-group.`
+	AuthzUsage   = "authz [COMMAND]"
+	AuthzShort   = "Manage userclouds authz models"
+	AuthzLong    = `Manage userclouds authz models`
+	ResolveUsage = "resolve [RESOURCE]"
+	ResolveShort = "Resolve userclouds tenant resources"
+	ResolveLong  = `Resolve userclouds tenant resources`
+	ConfigUsage  = "config [COMMAND]"
+	ConfigShort  = "Check the ucctl config file"
+	ConfigLong   = `Check the ucctl config file`
+	LoginUsage   = "login"
+	LoginShort   = "Log in to the tenant of a context"
+	LoginLong    = `Log in to the tenant of a context and cache the access token, so that later commands
+using the context run without its client secret until the token expires.
+
+If the context has a client secret, the token is obtained with the client credentials grant.
+Otherwise, or with --device, the OAuth device authorization grant is used: ucctl prints a URL
+and code to approve the login in a browser on any machine, which suits headless machines.`
+
+	// skipVersionCheck is set on commands that must keep working when the config pins a
+	// newer ucctl version, so that the config can still be fixed.
+	skipVersionCheck = "ucctl/skip-version-check"
+
+	// skipConfig is set on commands that read the config file themselves, so that they
+	// work even if it doesn't load.
+	skipConfig = "ucctl/skip-config"
 )
 
-type Root struct{}
+// version is the ucctl release version, set at build time with
+// -ldflags "-X main.version=v1.2.3".
+var version = config.DevVersion
+
+type Root struct {
+	ConfigPath     string
+	Context        string
+	RequestTimeout time.Duration
+	Retries        int
+	RetryBackoff   time.Duration
+	ContextGroup   string
+	AsOrganization string
+
+	// args and loaded are set when running a member of a context group.
+	args   []string
+	loaded *config.Config
+}
 
 func NewRoot() *Root {
 	return &Root{}
@@ -33,14 +111,81 @@ func (r *Root) Command() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			_ = cmd.Help()
 		},
-		SilenceUsage:  true,
-		SilenceErrors: true,
+		PersistentPreRunE: r.loadConfig,
+		Version:           version,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+
+	defaultPath, err := config.DefaultPath()
+	if err != nil {
+		// not fatal, the user can still pass --config
+		defaultPath = ""
 	}
+	rootCmd.PersistentFlags().StringVarP(&r.ConfigPath, "config", "", defaultPath, "config file, or a list of files to merge, defaults to $"+config.ConfigVar+" if it is set")
+	rootCmd.PersistentFlags().StringVarP(&r.Context, "context", "", "", "context to use instead of the current context, defaults to $"+config.ContextVar)
+	_ = rootCmd.RegisterFlagCompletionFunc("context", contexts.CompleteName)
+	rootCmd.PersistentFlags().StringVarP(&r.ContextGroup, "context-group", "", "", "run the command against each context of the group")
+	_ = rootCmd.RegisterFlagCompletionFunc("context-group", contexts.CompleteGroup)
+	rootCmd.PersistentFlags().StringVarP(&r.AsOrganization, "as-organization", "", "", "organization ID to scope API calls to where supported, e.g. to operate on one customer's objects and users")
+	rootCmd.PersistentFlags().DurationVarP(&r.RequestTimeout, "request-timeout", "", 0, "timeout of requests to the tenant, overrides the context's timeout")
+	rootCmd.PersistentFlags().IntVarP(&r.Retries, "retries", "", 0, "retries of requests that fail due to network errors, overrides the context's retries")
+	rootCmd.PersistentFlags().DurationVarP(&r.RetryBackoff, "retry-backoff", "", 0, "pause between retries, overrides the context's retry backoff")
 
 	rootCmd.AddCommand(SyncTenantCommand())
+	rootCmd.AddCommand(CreateCommand())
+	rootCmd.AddCommand(SecretCommand())
+	rootCmd.AddCommand(TenantCommand())
+	rootCmd.AddCommand(ContextCommand())
+	rootCmd.AddCommand(AuthzCommand())
+	rootCmd.AddCommand(ResolveCommand())
+	rootCmd.AddCommand(LoginCommand())
+	rootCmd.AddCommand(ConfigCommand())
 	return rootCmd
 }
 
+// loadConfig loads the config file, checks that this version of ucctl is allowed to use
+// the selected context and attaches the config to the command context.  With
+// --context-group, the command is set up to run against each context of the group instead.
+func (r *Root) loadConfig(cmd *cobra.Command, args []string) error {
+	if annotated(cmd, skipConfig) {
+		return nil
+	}
+
+	if cmd.Flags().Changed("context-group") {
+		if err := r.runGroup(cmd); err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), err)
+			return err
+		}
+		return nil
+	}
+
+	var state *config.State
+	var err error
+	if r.loaded != nil {
+		var path string
+		if path, err = config.PathFromFlags(cmd); err == nil {
+			state, err = config.SelectContext(cmd, path, r.loaded)
+		}
+	} else {
+		state, err = config.StateFromFlags(cmd, true)
+	}
+	if err != nil {
+		fmt.Fprintln(cmd.ErrOrStderr(), err)
+		return err
+	}
+
+	if !annotated(cmd, skipVersionCheck) {
+		if err := state.Config.CheckVersion(state.Current, version); err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), err)
+			return err
+		}
+	}
+
+	cmd.SetContext(config.WithState(cmd.Context(), state))
+	return nil
+}
+
 func SyncTenantCommand() *cobra.Command {
 	st := synctenant.Command{}
 	cmd := &cobra.Command{
@@ -63,3 +208,187 @@ func SyncTenantCommand() *cobra.Command {
 	cmd.PersistentFlags().BoolVarP(&st.InsertOnly, "insert-only", "", false, "only insert only")
 	return cmd
 }
+
+func CreateCommand() *cobra.Command {
+	cc := &create.Command{}
+	cmd := &cobra.Command{
+		Use:         CreateUsage,
+		Short:       CreateShort,
+		Long:        CreateLong,
+		Annotations: map[string]string{groupCommand: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.PersistentFlags().BoolVarP(&cc.Verbose, "verbose", "v", false, "verbose output")
+	cmd.PersistentFlags().StringVarP(&cc.URL, "url", "", "", "tenant URL")
+	cmd.PersistentFlags().StringVarP(&cc.ClientID, "client-id", "", "", "client ID")
+	cmd.PersistentFlags().StringVarP(&cc.ClientSecretVar, "client-secret", "", create.DefaultClientSecretVar, "client secret")
+
+	cmd.AddCommand(cc.ObjectTypeCommand())
+	cmd.AddCommand(cc.ObjectCommand())
+	cmd.AddCommand(cc.EdgeTypeCommand())
+	cmd.AddCommand(cc.EdgeCommand())
+	cmd.AddCommand(cc.AccessorCommand())
+	cmd.AddCommand(cc.MutatorCommand())
+	cmd.AddCommand(cc.ColumnCommand())
+	cmd.AddCommand(cc.PurposeCommand())
+	cmd.AddCommand(cc.AccessPolicyCommand())
+	cmd.AddCommand(cc.TransformerCommand())
+	cmd.AddCommand(cc.LoginAppCommand())
+	cmd.AddCommand(cc.TenantCommand())
+	cmd.AddCommand(cc.CompanyCommand())
+	cmd.AddCommand(cc.TokenCommand())
+	cmd.AddCommand(cc.M2MClientCommand())
+	cmd.AddCommand(cc.UserCommand())
+	cmd.AddCommand(cc.UsersCommand())
+	return cmd
+}
+
+func SecretCommand() *cobra.Command {
+	sc := &secrets.Command{}
+	cmd := &cobra.Command{
+		Use:   SecretUsage,
+		Short: SecretShort,
+		Long:  SecretLong,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.PersistentFlags().BoolVarP(&sc.Verbose, "verbose", "v", false, "verbose output")
+
+	cmd.AddCommand(sc.GetCommand())
+	cmd.AddCommand(sc.SetCommand())
+	cmd.AddCommand(sc.ListCommand())
+	cmd.AddCommand(sc.DeleteCommand())
+	cmd.AddCommand(sc.RotateCommand())
+	cmd.AddCommand(sc.MigrateCommand())
+	cmd.AddCommand(sc.ReplicateCommand())
+	cmd.AddCommand(sc.LintCommand())
+	cmd.AddCommand(sc.ValidateCommand())
+	return cmd
+}
+
+func TenantCommand() *cobra.Command {
+	tc := &tenant.Command{}
+	cmd := &cobra.Command{
+		Use:   TenantUsage,
+		Short: TenantShort,
+		Long:  TenantLong,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.PersistentFlags().BoolVarP(&tc.Verbose, "verbose", "v", false, "verbose output")
+	cmd.PersistentFlags().StringVarP(&tc.ConsoleURL, "console-url", "", "", "console URL")
+	cmd.PersistentFlags().StringVarP(&tc.CompanyID, "company", "", "", "company ID")
+	cmd.PersistentFlags().StringVarP(&tc.SessionVar, "session", "", console.DefaultSessionVar, "console session")
+
+	cmd.AddCommand(tc.CreateEphemeralCommand())
+	cmd.AddCommand(tc.ReapExpiredCommand())
+	return cmd
+}
+
+func ContextCommand() *cobra.Command {
+	cc := &contexts.Command{}
+	cmd := &cobra.Command{
+		Use:   ContextUsage,
+		Short: ContextShort,
+		Long:  ContextLong,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.PersistentFlags().BoolVarP(&cc.Verbose, "verbose", "v", false, "verbose output")
+
+	for _, sub := range []*cobra.Command{cc.ListCommand(), cc.CurrentCommand(), cc.InitCommand(), cc.SetCommand(), cc.UseCommand(), cc.RenameCommand(), cc.DeleteCommand(), cc.ExportCommand(), cc.ImportCommand(), cc.EncryptCommand(), cc.DecryptCommand(), cc.GroupCommand()} {
+		sub.Annotations = map[string]string{skipVersionCheck: "true"}
+		cmd.AddCommand(sub)
+	}
+
+	// context test is the only context subcommand that connects to a tenant
+	test := cc.TestCommand()
+	test.Annotations = map[string]string{skipVersionCheck: "true", groupCommand: "true"}
+	cmd.AddCommand(test)
+	return cmd
+}
+
+func AuthzCommand() *cobra.Command {
+	ac := &authzmodel.Command{}
+	cmd := &cobra.Command{
+		Use:         AuthzUsage,
+		Short:       AuthzShort,
+		Long:        AuthzLong,
+		Annotations: map[string]string{groupCommand: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.PersistentFlags().BoolVarP(&ac.Verbose, "verbose", "v", false, "verbose output")
+	cmd.PersistentFlags().StringVarP(&ac.URL, "url", "", "", "tenant URL")
+	cmd.PersistentFlags().StringVarP(&ac.ClientID, "client-id", "", "", "client ID")
+	cmd.PersistentFlags().StringVarP(&ac.ClientSecretVar, "client-secret", "", authzmodel.DefaultClientSecretVar, "client secret")
+
+	cmd.AddCommand(ac.CompileCommand())
+	return cmd
+}
+
+func ResolveCommand() *cobra.Command {
+	rc := &resolve.Command{}
+	cmd := &cobra.Command{
+		Use:         ResolveUsage,
+		Short:       ResolveShort,
+		Long:        ResolveLong,
+		Annotations: map[string]string{groupCommand: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.PersistentFlags().BoolVarP(&rc.Verbose, "verbose", "v", false, "verbose output")
+	cmd.PersistentFlags().StringVarP(&rc.URL, "url", "", "", "tenant URL")
+	cmd.PersistentFlags().StringVarP(&rc.ClientID, "client-id", "", "", "client ID")
+	cmd.PersistentFlags().StringVarP(&rc.ClientSecretVar, "client-secret", "", resolve.DefaultClientSecretVar, "client secret")
+
+	cmd.AddCommand(rc.TokenCommand())
+	return cmd
+}
+
+func LoginCommand() *cobra.Command {
+	lc := &login.Command{}
+	cmd := &cobra.Command{
+		Use:         LoginUsage,
+		Short:       LoginShort,
+		Long:        LoginLong,
+		Annotations: map[string]string{groupCommand: "true"},
+		Args:        cobra.NoArgs,
+		RunE:        lc.RunE,
+	}
+
+	cmd.Flags().BoolVarP(&lc.Verbose, "verbose", "v", false, "verbose output")
+	cmd.Flags().StringVarP(&lc.ClientID, "client-id", "", "", "client ID, defaults to the client ID of the context")
+	cmd.Flags().BoolVarP(&lc.Device, "device", "", false, "use the device authorization grant even if the context has a client secret")
+	cmd.Flags().StringSliceVarP(&lc.Scopes, "scopes", "", nil, "scopes to request with the device authorization grant")
+	return cmd
+}
+
+func ConfigCommand() *cobra.Command {
+	cc := &contexts.Command{}
+	cmd := &cobra.Command{
+		Use:         ConfigUsage,
+		Short:       ConfigShort,
+		Long:        ConfigLong,
+		Annotations: map[string]string{skipConfig: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(cc.ValidateCommand())
+	return cmd
+}
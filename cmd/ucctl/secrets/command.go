@@ -0,0 +1,55 @@
+package secrets
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/infra/logtransports"
+	"userclouds.com/infra/secret/prefix"
+	"userclouds.com/infra/secret/provider"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+// Command holds the options shared by all of the secret subcommands.
+type Command struct {
+	Verbose bool
+}
+
+// run initializes logging and then calls fn.  Errors are logged here since the root
+// command silences them.
+func (c *Command) run(cmd *cobra.Command, name string, fn func(ctx context.Context) error) error {
+	ctx := cmd.Context()
+
+	logLevel := uclog.LogLevelInfo
+	if c.Verbose {
+		logLevel = uclog.LogLevelDebug
+	}
+
+	logtransports.InitLoggerAndTransportsForTools(ctx, logLevel, logLevel, name)
+	defer logtransports.Close()
+
+	if err := fn(ctx); err != nil {
+		uclog.Errorf(ctx, "%v", err)
+		return err
+	}
+
+	return nil
+}
+
+// providerForLocation returns the provider and the provider specific path for a
+// secret location, e.g. aws://secrets/userclouds/foo.
+func providerForLocation(location string) (provider.Interface, string, error) {
+	pv, err := provider.FromLocation(location)
+	if err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+
+	px, err := prefix.PrefixFromString(location)
+	if err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+
+	return pv, px.Value(location), nil
+}
@@ -0,0 +1,65 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/prompt"
+)
+
+const (
+	DeleteUsage = "delete LOCATION"
+	DeleteShort = "Delete a secret"
+	DeleteLong  = `Delete a secret from its provider, e.g. ucctl secret delete aws://secrets/userclouds/prod/plex/client-secret.
+The deletion is confirmed with a prompt unless --yes is set.`
+)
+
+// DeleteCommand deletes a secret.
+type DeleteCommand struct {
+	*Command
+	Yes bool
+}
+
+// DeleteCommand returns the delete subcommand.
+func (c *Command) DeleteCommand() *cobra.Command {
+	d := &DeleteCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   DeleteUsage,
+		Short: DeleteShort,
+		Long:  DeleteLong,
+		Args:  cobra.ExactArgs(1),
+		RunE:  d.RunE,
+	}
+
+	cmd.Flags().BoolVarP(&d.Yes, "yes", "y", false, "delete without confirmation")
+	return cmd
+}
+
+func (c *DeleteCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "secret-delete", func(ctx context.Context) error {
+		location := args[0]
+		s, err := secretAtLocation(location)
+		if err != nil {
+			return err
+		}
+
+		if !c.Yes {
+			answer, err := prompt.New(cmd.InOrStdin(), cmd.ErrOrStderr()).Choice(fmt.Sprintf("Delete secret %s?", location), []string{"yes", "no"}, "no")
+			if err != nil {
+				return err
+			}
+			if answer != "yes" {
+				return nil
+			}
+		}
+
+		if err := s.Delete(ctx); err != nil {
+			return fmt.Errorf("failed to delete secret %s: %v", location, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Deleted secret %s\n", location)
+		return nil
+	})
+}
@@ -0,0 +1,67 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/infra/secret"
+)
+
+const (
+	GetUsage = "get LOCATION"
+	GetShort = "Print the value of a secret"
+	GetLong  = `Print the value of a secret, e.g. ucctl secret get aws://secrets/userclouds/prod/plex/client-secret.
+The secret is always read from its provider, and fields of JSON-valued secrets can be selected with
+a #FIELD suffix.`
+)
+
+// GetCommand prints the value of a secret.
+type GetCommand struct {
+	*Command
+}
+
+// GetCommand returns the get subcommand.
+func (c *Command) GetCommand() *cobra.Command {
+	g := &GetCommand{Command: c}
+	return &cobra.Command{
+		Use:   GetUsage,
+		Short: GetShort,
+		Long:  GetLong,
+		Args:  cobra.ExactArgs(1),
+		RunE:  g.RunE,
+	}
+}
+
+func (c *GetCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "secret-get", func(ctx context.Context) error {
+		s, err := secretAtLocation(args[0])
+		if err != nil {
+			return err
+		}
+
+		value, err := s.ResolveWithTTL(ctx, 0)
+		if err != nil {
+			return fmt.Errorf("failed to read secret %s: %v", args[0], err)
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), value)
+		return nil
+	})
+}
+
+// secretAtLocation returns the secret at a location, which must be a valid location
+// rather than a legacy secret value.
+func secretAtLocation(location string) (*secret.String, error) {
+	s := secret.FromLocation(location)
+	if !s.HasPrefix() {
+		return nil, fmt.Errorf("invalid secret location, expected <provider>://<path>")
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid secret location %s: %v", location, err)
+	}
+
+	return s, nil
+}
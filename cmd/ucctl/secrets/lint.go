@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/infra/namespace/universe"
+	"userclouds.com/infra/secret"
+)
+
+const (
+	LintUsage = "lint FILE..."
+	LintShort = "Check config files for secrets that aren't safe for production"
+	LintLong  = `Check YAML config files for secrets that use a development-only provider (dev://,
+dev-literal:// or dev-file://) or are stored in plaintext, which must not be deployed to a
+cloud universe.  Plaintext secrets are recognized by their key, e.g. client_secret, password or
+api_key.  The command fails if any issue is found.`
+)
+
+// LintCommand checks config files for secrets that aren't safe for production.
+type LintCommand struct {
+	*Command
+	Universe string
+}
+
+// LintCommand returns the lint subcommand.
+func (c *Command) LintCommand() *cobra.Command {
+	l := &LintCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   LintUsage,
+		Short: LintShort,
+		Long:  LintLong,
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  l.RunE,
+	}
+
+	cmd.Flags().StringVarP(&l.Universe, "universe", "", string(universe.Prod), "universe the config files are deployed to")
+	return cmd
+}
+
+func (c *LintCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "secret-lint", func(ctx context.Context) error {
+		u := universe.Universe(c.Universe)
+		if err := u.Validate(); err != nil {
+			return fmt.Errorf("invalid universe %s: %v", c.Universe, err)
+		}
+
+		var count int
+		for _, name := range args {
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return fmt.Errorf("failed to read config file %s: %v", name, err)
+			}
+
+			issues, err := secret.LintConfigYAML(data, u)
+			if err != nil {
+				return fmt.Errorf("failed to parse config file %s: %v", name, err)
+			}
+
+			for _, issue := range issues {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", name, issue)
+			}
+			count += len(issues)
+		}
+
+		if count > 0 {
+			return fmt.Errorf("found %d secret issues in %s config files", count, u)
+		}
+		return nil
+	})
+}
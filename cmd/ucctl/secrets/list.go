@@ -0,0 +1,54 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/infra/secret/provider"
+)
+
+const (
+	ListUsage = "list LOCATION_PREFIX"
+	ListShort = "List the secrets of a provider"
+	ListLong  = `List the locations of the secrets of a provider that start with a prefix, e.g.
+ucctl secret list aws://secrets/userclouds/prod/ or ucctl secret list kube://secrets/.  Only
+providers that can enumerate their secrets, such as aws, azkv, kube and file, support listing.`
+)
+
+// ListCommand lists the secrets of a provider.
+type ListCommand struct {
+	*Command
+}
+
+// ListCommand returns the list subcommand.
+func (c *Command) ListCommand() *cobra.Command {
+	l := &ListCommand{Command: c}
+	return &cobra.Command{
+		Use:   ListUsage,
+		Short: ListShort,
+		Long:  ListLong,
+		Args:  cobra.ExactArgs(1),
+		RunE:  l.RunE,
+	}
+}
+
+func (c *ListCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "secret-list", func(ctx context.Context) error {
+		pv, pathPrefix, err := providerForLocation(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid secret location %s: %v", args[0], err)
+		}
+
+		paths, err := provider.List(ctx, pv, pathPrefix)
+		if err != nil {
+			return fmt.Errorf("failed to list secrets with prefix %s: %v", args[0], err)
+		}
+
+		for _, path := range paths {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s%s\n", pv.Prefix(), path)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,183 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"userclouds.com/infra/secret"
+)
+
+const (
+	MigrateUsage = "migrate [CONFIG...]"
+	MigrateShort = "Copy secrets from one provider to another"
+	MigrateLong  = `Copy secrets from one provider to another and print their new locations, e.g. to move from
+kubernetes secrets to AWS secrets manager:
+
+  ucctl secret migrate --from kube://secrets/userclouds/plex-client --to aws://secrets/userclouds/prod/plex/client
+
+With config files, every secret location in them that starts with --from is copied to the same
+path under --to, e.g. --from kube://secrets/userclouds/ --to aws://secrets/userclouds/prod/, and
+with --write the config files are rewritten to point at the copies.  Secrets that are already up
+to date aren't written again, so migrations can be re-run.  The source secrets aren't deleted.`
+)
+
+// MigrateCommand copies secrets between providers.
+type MigrateCommand struct {
+	*Command
+	From  string
+	To    string
+	Write bool
+}
+
+// MigrateCommand returns the migrate subcommand.
+func (c *Command) MigrateCommand() *cobra.Command {
+	m := &MigrateCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   MigrateUsage,
+		Short: MigrateShort,
+		Long:  MigrateLong,
+		RunE:  m.RunE,
+	}
+
+	cmd.Flags().StringVarP(&m.From, "from", "", "", "source secret location, or location prefix with config files")
+	cmd.Flags().StringVarP(&m.To, "to", "", "", "destination secret location, or location prefix with config files")
+	cmd.Flags().BoolVarP(&m.Write, "write", "", false, "rewrite the locations in the config files")
+	return cmd
+}
+
+func (c *MigrateCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "secret-migrate", func(ctx context.Context) error {
+		if c.From == "" || c.To == "" {
+			return fmt.Errorf("source and destination locations are required")
+		}
+
+		if len(args) == 0 {
+			if c.Write {
+				return fmt.Errorf("--write requires config files")
+			}
+
+			to, err := c.migrate(ctx, c.From, c.To)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s -> %s\n", c.From, to)
+			return nil
+		}
+
+		for _, name := range args {
+			if err := c.migrateConfig(ctx, cmd, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateConfig migrates the secrets of a config file under the --from prefix.
+func (c *MigrateCommand) migrateConfig(ctx context.Context, cmd *cobra.Command, name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %v", name, err)
+	}
+
+	locations, err := configLocations(data, c.From)
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", name, err)
+	}
+
+	rewritten := string(data)
+	for _, from := range locations {
+		// fields are selected from the copy, rather than copying only the field
+		base, field, hasField := strings.Cut(from, secret.FieldSeparator)
+		to, err := c.migrate(ctx, base, c.To+strings.TrimPrefix(base, c.From))
+		if err != nil {
+			return fmt.Errorf("failed to migrate %s of config file %s: %v", from, name, err)
+		}
+		if hasField {
+			to += secret.FieldSeparator + field
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%s: %s -> %s\n", name, from, to)
+		rewritten = strings.ReplaceAll(rewritten, from, to)
+	}
+
+	if !c.Write || len(locations) == 0 {
+		return nil
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		return fmt.Errorf("failed to rewrite config file %s: %v", name, err)
+	}
+	if err := os.WriteFile(name, []byte(rewritten), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to rewrite config file %s: %v", name, err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Rewrote %d secret locations in %s\n", len(locations), name)
+	return nil
+}
+
+// migrate copies the secret at from to the destination location and returns the location
+// of the copy.
+func (c *MigrateCommand) migrate(ctx context.Context, from, to string) (string, error) {
+	src, err := secretAtLocation(from)
+	if err != nil {
+		return "", err
+	}
+
+	dst, dstPath, err := providerForLocation(to)
+	if err != nil {
+		return "", fmt.Errorf("invalid destination location %s: %v", to, err)
+	}
+
+	copied, err := secret.Replicate(ctx, src, dst, dstPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to copy secret %s to %s: %v", from, to, err)
+	}
+
+	return copied.Location(), nil
+}
+
+// configLocations returns the distinct string values of a YAML config that start with
+// locationPrefix, longest first so that rewriting a location doesn't change a longer one
+// that it is a prefix of.
+func configLocations(data []byte, locationPrefix string) ([]string, error) {
+	var cfg any
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	var locations []string
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for _, e := range v {
+				walk(e)
+			}
+		case []any:
+			for _, e := range v {
+				walk(e)
+			}
+		case string:
+			if strings.HasPrefix(v, locationPrefix) && !slices.Contains(locations, v) {
+				locations = append(locations, v)
+			}
+		}
+	}
+	walk(cfg)
+
+	slices.SortFunc(locations, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return strings.Compare(a, b)
+	})
+	return locations, nil
+}
@@ -0,0 +1,104 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/infra/secret"
+	"userclouds.com/infra/secret/provider"
+	"userclouds.com/infra/uclog"
+)
+
+const (
+	ReplicateUsage = "replicate"
+	ReplicateShort = "Replicate a secret into another provider"
+	ReplicateLong  = `Replicate a secret into another provider, e.g. from AWS secrets manager into a kubernetes
+secret for in-cluster consumers.  With --interval the secret is re-read and the replica updated
+whenever the value changes until the command is interrupted.`
+)
+
+// ReplicateCommand mirrors a secret into a second provider.
+type ReplicateCommand struct {
+	*Command
+	From     string
+	To       string
+	Interval time.Duration
+}
+
+// ReplicateCommand returns the replicate subcommand.
+func (c *Command) ReplicateCommand() *cobra.Command {
+	r := &ReplicateCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   ReplicateUsage,
+		Short: ReplicateShort,
+		Long:  ReplicateLong,
+		Args:  cobra.NoArgs,
+		RunE:  r.RunE,
+	}
+
+	cmd.Flags().StringVarP(&r.From, "from", "", "", "source secret location")
+	cmd.Flags().StringVarP(&r.To, "to", "", "", "destination secret location")
+	cmd.Flags().DurationVarP(&r.Interval, "interval", "", 0, "replicate on an interval until interrupted")
+	return cmd
+}
+
+func (c *ReplicateCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "secret-replicate", func(ctx context.Context) error {
+		if c.From == "" || c.To == "" {
+			return fmt.Errorf("source and destination locations are required")
+		}
+
+		src := secret.FromLocation(c.From)
+		if err := src.Validate(); err != nil {
+			return fmt.Errorf("invalid source location %s: %v", c.From, err)
+		}
+
+		dst, dstPath, err := providerForLocation(c.To)
+		if err != nil {
+			return fmt.Errorf("invalid destination location %s: %v", c.To, err)
+		}
+
+		replica, err := c.replicate(ctx, src, dst, dstPath)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Replicated secret to %s\n", replica.Location())
+		if c.Interval <= 0 {
+			return nil
+		}
+
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		ticker := time.NewTicker(c.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				// keep going on errors so that a transient failure doesn't stop replication
+				if _, err := c.replicate(ctx, src, dst, dstPath); err != nil {
+					uclog.Errorf(ctx, "%v", err)
+				}
+			}
+		}
+	})
+}
+
+func (c *ReplicateCommand) replicate(ctx context.Context, src *secret.String, dst provider.Interface, dstPath string) (*secret.String, error) {
+	replica, err := secret.Replicate(ctx, src, dst, dstPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replicate secret to %s: %v", c.To, err)
+	}
+
+	return replica, nil
+}
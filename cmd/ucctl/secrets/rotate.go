@@ -0,0 +1,157 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/infra/crypto"
+	"userclouds.com/infra/secret"
+)
+
+const (
+	RotateUsage = "rotate LOCATION"
+	RotateShort = "Rotate a secret to a new value"
+	RotateLong  = `Rotate a secret to a new value, which is generated with --generate or read from stdin with
+--value-from-stdin.  The value it replaces stays readable so that services can accept both until
+every client has picked up the new one, and the rotation is then ended with --finalize or undone
+with --cancel.  Providers that keep previous versions of secrets, such as AWS, keep it as a
+version (LOCATION@AWSPREVIOUS), and others at LOCATION` + secret.PreviousSuffix + `, which is never
+overwritten unless it was created by rotating the secret.
+
+With --verify the new value is read back from the provider after it is saved, and the rotation
+is cancelled if it doesn't match.  With --finalize the previous value is deleted right away, for
+secrets that don't need an overlap.`
+)
+
+// RotateCommand rotates a secret.
+type RotateCommand struct {
+	*Command
+	Generate       bool
+	Bytes          int
+	ValueFromStdin bool
+	Verify         bool
+	Finalize       bool
+	Cancel         bool
+}
+
+// RotateCommand returns the rotate subcommand.
+func (c *Command) RotateCommand() *cobra.Command {
+	r := &RotateCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   RotateUsage,
+		Short: RotateShort,
+		Long:  RotateLong,
+		Args:  cobra.ExactArgs(1),
+		RunE:  r.RunE,
+	}
+
+	cmd.Flags().BoolVarP(&r.Generate, "generate", "", false, "generate a random base64 value")
+	cmd.Flags().IntVarP(&r.Bytes, "bytes", "", crypto.ClientSecretBytes, "random bytes of a generated value")
+	cmd.Flags().BoolVarP(&r.ValueFromStdin, "value-from-stdin", "", false, "read the new value from stdin")
+	cmd.Flags().BoolVarP(&r.Verify, "verify", "", false, "read the new value back and cancel the rotation if it doesn't match")
+	cmd.Flags().BoolVarP(&r.Finalize, "finalize", "", false, "end the rotation by deleting the previous value")
+	cmd.Flags().BoolVarP(&r.Cancel, "cancel", "", false, "undo a rotation in progress by restoring the previous value")
+	cmd.MarkFlagsMutuallyExclusive("generate", "value-from-stdin", "cancel")
+	cmd.MarkFlagsMutuallyExclusive("finalize", "cancel")
+	return cmd
+}
+
+func (c *RotateCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "secret-rotate", func(ctx context.Context) error {
+		location := args[0]
+		s, err := secretAtLocation(location)
+		if err != nil {
+			return err
+		}
+
+		if c.Cancel {
+			if err := s.CancelRotation(ctx); err != nil {
+				return fmt.Errorf("failed to cancel the rotation of secret %s: %v", location, err)
+			}
+			c.record(cmd, "Cancelled the rotation of secret %s, restoring its previous value", location)
+			return nil
+		}
+
+		if c.Generate || c.ValueFromStdin {
+			if err := c.rotate(ctx, cmd, s, location); err != nil {
+				return err
+			}
+		} else if !c.Finalize {
+			return fmt.Errorf("one of --generate, --value-from-stdin, --finalize or --cancel is required")
+		}
+
+		if c.Finalize {
+			if err := s.FinalizeRotation(ctx); err != nil {
+				return fmt.Errorf("failed to finalize the rotation of secret %s: %v", location, err)
+			}
+			c.record(cmd, "Finalized the rotation of secret %s, deleting its previous value", location)
+		}
+
+		return nil
+	})
+}
+
+// rotate rotates the secret to the new value, verifying it if requested.
+func (c *RotateCommand) rotate(ctx context.Context, cmd *cobra.Command, s *secret.String, location string) error {
+	value, err := c.value(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Rotate(ctx, value); err != nil {
+		return fmt.Errorf("failed to rotate secret %s: %v", location, err)
+	}
+	c.record(cmd, "Rotated secret %s, the previous value is readable at %s until the rotation is finalized", location, s.Previous().Location())
+
+	if !c.Verify {
+		return nil
+	}
+
+	// read through a fresh copy, so that the provider rather than the cache is checked
+	resolved, err := secret.FromLocation(location).ResolveWithTTL(ctx, 0)
+	if err == nil && resolved == value {
+		c.record(cmd, "Verified the new value of secret %s", location)
+		return nil
+	}
+	if err == nil {
+		err = fmt.Errorf("the provider returned another value")
+	}
+
+	if cancelErr := s.CancelRotation(ctx); cancelErr != nil {
+		return fmt.Errorf("failed to verify secret %s (%v), and failed to cancel its rotation: %v", location, err, cancelErr)
+	}
+	c.record(cmd, "Cancelled the rotation of secret %s, restoring its previous value", location)
+	return fmt.Errorf("failed to verify the new value of secret %s: %v", location, err)
+}
+
+// value returns the new value of the secret.
+func (c *RotateCommand) value(cmd *cobra.Command) (string, error) {
+	if c.Generate {
+		if c.Bytes < 16 {
+			return "", fmt.Errorf("generated values must have at least 16 random bytes")
+		}
+		return crypto.MustRandomBase64(c.Bytes), nil
+	}
+
+	bs, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return "", fmt.Errorf("failed to read the new value from stdin: %v", err)
+	}
+
+	value := strings.TrimSuffix(strings.TrimSuffix(string(bs), "\n"), "\r")
+	if value == "" {
+		return "", fmt.Errorf("the new value read from stdin is empty")
+	}
+	return value, nil
+}
+
+// record prints a timestamped step of the rotation, so that the output of the run
+// documents it.
+func (c *RotateCommand) record(cmd *cobra.Command, format string, args ...any) {
+	fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", time.Now().UTC().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
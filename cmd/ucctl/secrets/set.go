@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/prompt"
+	"userclouds.com/infra/secret"
+)
+
+const (
+	SetUsage = "set LOCATION [VALUE]"
+	SetShort = "Create or update a secret"
+	SetLong  = `Create or update a secret, e.g. ucctl secret set aws://secrets/userclouds/prod/plex/client-secret.
+The value is read from --from-file, or prompted for without echoing it if it isn't given as an
+argument, which keeps it out of the shell history.  Values piped to stdin are read up to the
+first newline.`
+)
+
+// SetCommand creates or updates a secret.
+type SetCommand struct {
+	*Command
+	FromFile string
+}
+
+// SetCommand returns the set subcommand.
+func (c *Command) SetCommand() *cobra.Command {
+	s := &SetCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   SetUsage,
+		Short: SetShort,
+		Long:  SetLong,
+		Args:  cobra.RangeArgs(1, 2),
+		RunE:  s.RunE,
+	}
+
+	cmd.Flags().StringVarP(&s.FromFile, "from-file", "", "", "read the value from a file")
+	return cmd
+}
+
+func (c *SetCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "secret-set", func(ctx context.Context) error {
+		location := args[0]
+		if _, err := secretAtLocation(location); err != nil {
+			return err
+		}
+
+		value, err := c.value(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		s, err := secret.NewStringAtLocation(ctx, location, value)
+		if err != nil {
+			return fmt.Errorf("failed to save secret %s: %v", location, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Saved secret to %s\n", s.Location())
+		return nil
+	})
+}
+
+// value returns the value to save from the arguments, --from-file or a prompt.
+func (c *SetCommand) value(cmd *cobra.Command, args []string) (string, error) {
+	switch {
+	case len(args) == 2 && c.FromFile != "":
+		return "", fmt.Errorf("the value can't be given both as an argument and with --from-file")
+	case len(args) == 2:
+		if args[1] == "" {
+			return "", fmt.Errorf("the value must not be empty")
+		}
+		return args[1], nil
+	case c.FromFile != "":
+		bs, err := os.ReadFile(c.FromFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the value from %s: %v", c.FromFile, err)
+		}
+
+		// files created with echo or editors usually end with a newline
+		value := strings.TrimSuffix(strings.TrimSuffix(string(bs), "\n"), "\r")
+		if value == "" {
+			return "", fmt.Errorf("the value in %s is empty", c.FromFile)
+		}
+		return value, nil
+	default:
+		return prompt.New(cmd.InOrStdin(), cmd.ErrOrStderr()).Password("Secret value")
+	}
+}
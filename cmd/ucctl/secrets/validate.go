@@ -0,0 +1,77 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"userclouds.com/infra/secret"
+	"userclouds.com/infra/secret/prefix"
+	"userclouds.com/infra/ucerr"
+)
+
+const (
+	ValidateUsage = "validate FILE..."
+	ValidateShort = "Check that the secrets of config files resolve"
+	ValidateLong  = `Check that every secret location in YAML config files resolves with its provider, e.g.
+before deploying them.  Secrets that don't exist, or that can't be read with the current
+credentials, are reported, and the command fails if any are found.  Run it with the
+credentials of the service that the config is deployed for, since it checks access too.`
+)
+
+// ValidateCommand checks that the secrets of config files resolve.
+type ValidateCommand struct {
+	*Command
+}
+
+// ValidateCommand returns the validate subcommand.
+func (c *Command) ValidateCommand() *cobra.Command {
+	v := &ValidateCommand{Command: c}
+	return &cobra.Command{
+		Use:   ValidateUsage,
+		Short: ValidateShort,
+		Long:  ValidateLong,
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  v.RunE,
+	}
+}
+
+func (c *ValidateCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "secret-validate", func(ctx context.Context) error {
+		var count, failed int
+		for _, name := range args {
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return fmt.Errorf("failed to read config file %s: %v", name, err)
+			}
+
+			values, err := configLocations(data, "")
+			if err != nil {
+				return fmt.Errorf("failed to parse config file %s: %v", name, err)
+			}
+			slices.Sort(values)
+
+			for _, location := range values {
+				if _, err := prefix.PrefixFromString(location); err != nil {
+					continue
+				}
+
+				count++
+				if err := secret.FromLocation(location).ValidateDeep(ctx); err != nil {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", name, ucerr.FirstLine(err))
+					failed++
+				}
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d secrets in the config files failed to validate", failed, count)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%d secrets in the config files resolve\n", count)
+		return nil
+	})
+}
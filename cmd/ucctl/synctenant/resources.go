@@ -55,6 +55,68 @@ func (r *Resources) Get(ctx context.Context, azc *authz.Client) error {
 	return nil
 }
 
+// GetTypes fetches only the object types and edge types, for callers that don't sync
+// objects and edges.
+func (r *Resources) GetTypes(ctx context.Context, azc *authz.Client) error {
+	uclog.Infof(ctx, "Fetching ObjectTypes")
+	if err := r.readAllObjectTypes(ctx, azc); err != nil {
+		return err
+	}
+	uclog.Infof(ctx, "Fetched %d object types", len(r.objectTypes))
+
+	uclog.Infof(ctx, "Fetching edgeTypes")
+	if err := r.readAllEdgeTypes(ctx, azc); err != nil {
+		return err
+	}
+	uclog.Infof(ctx, "Fetched %d edgeTypes", len(r.edgeTypes))
+
+	return nil
+}
+
+// AddObjectType adds an object type to the resources.
+func (r *Resources) AddObjectType(ot authz.ObjectType) {
+	r.objectTypes = append(r.objectTypes, ot)
+}
+
+// AddEdgeType adds an edge type to the resources.
+func (r *Resources) AddEdgeType(et authz.EdgeType) {
+	r.edgeTypes = append(r.edgeTypes, et)
+}
+
+// MatchTypesByName replaces the IDs of the object types and edge types with the IDs of
+// the types with the same names in dst, so that generated types line up with types that
+// already exist in the destination.
+func (r *Resources) MatchTypesByName(dst *Resources) {
+	objectTypeIDs := make(map[uuid.UUID]uuid.UUID)
+	for i := range r.objectTypes {
+		for _, dstObjectType := range dst.objectTypes {
+			if r.objectTypes[i].TypeName == dstObjectType.TypeName {
+				objectTypeIDs[r.objectTypes[i].ID] = dstObjectType.ID
+				r.objectTypes[i].ID = dstObjectType.ID
+				break
+			}
+		}
+	}
+
+	for i := range r.edgeTypes {
+		et := &r.edgeTypes[i]
+		if id, ok := objectTypeIDs[et.SourceObjectTypeID]; ok {
+			et.SourceObjectTypeID = id
+		}
+
+		if id, ok := objectTypeIDs[et.TargetObjectTypeID]; ok {
+			et.TargetObjectTypeID = id
+		}
+
+		for _, dstEdgeType := range dst.edgeTypes {
+			if et.TypeName == dstEdgeType.TypeName {
+				et.ID = dstEdgeType.ID
+				break
+			}
+		}
+	}
+}
+
 func (r *Resources) Insert(ctx context.Context, azc *authz.Client) error {
 	uclog.Infof(ctx, "Inserting ObjectTypes")
 	for _, ot := range r.objectTypes {
@@ -0,0 +1,78 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gofrs/uuid"
+	"github.com/spf13/cobra"
+
+	"userclouds.com/cmd/ucctl/console"
+	"userclouds.com/infra/logtransports"
+	"userclouds.com/infra/uclog"
+)
+
+// Command holds the options shared by all of the tenant subcommands.
+type Command struct {
+	ConsoleURL string
+	CompanyID  string
+	SessionVar string
+	Verbose    bool
+}
+
+// run initializes logging, validates the console options and then calls fn.  Errors
+// are logged here since the root command silences them.
+func (c *Command) run(cmd *cobra.Command, name string, fn func(ctx context.Context) error) error {
+	ctx := cmd.Context()
+
+	logLevel := uclog.LogLevelInfo
+	if c.Verbose {
+		logLevel = uclog.LogLevelDebug
+	}
+
+	logtransports.InitLoggerAndTransportsForTools(ctx, logLevel, logLevel, name)
+	defer logtransports.Close()
+
+	if err := c.validate(); err != nil {
+		uclog.Errorf(ctx, "%v", err)
+		return err
+	}
+
+	if err := fn(ctx); err != nil {
+		uclog.Errorf(ctx, "%v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *Command) validate() error {
+	if c.ConsoleURL == "" {
+		return fmt.Errorf("console URL is required")
+	}
+
+	if _, err := c.companyID(); err != nil {
+		return err
+	}
+
+	if os.Getenv(c.SessionVar) == "" {
+		return fmt.Errorf("console session is not set")
+	}
+
+	return nil
+}
+
+func (c *Command) companyID() (uuid.UUID, error) {
+	id, err := uuid.FromString(c.CompanyID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid company id %s: %v", c.CompanyID, err)
+	}
+
+	return id, nil
+}
+
+// consoleClient returns a client for the console API.
+func (c *Command) consoleClient() *console.Client {
+	return console.NewClient(c.ConsoleURL, os.Getenv(c.SessionVar))
+}
@@ -0,0 +1,192 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/spf13/cobra"
+
+	"userclouds.com/infra/ucdb"
+	"userclouds.com/infra/uclog"
+	"userclouds.com/internal/companyconfig"
+)
+
+const (
+	CreateEphemeralUsage = "create-ephemeral"
+	CreateEphemeralShort = "Create a short-lived tenant"
+	CreateEphemeralLong  = `Create a short-lived tenant, e.g. for a CI run.  The tenant is marked with its expiry so
+that reap-expired can delete it once the TTL has passed, and is named <prefix>-<expiry>.`
+
+	ReapExpiredUsage = "reap-expired"
+	ReapExpiredShort = "Delete expired short-lived tenants"
+	ReapExpiredLong  = `Delete the short-lived tenants created by create-ephemeral whose TTL has passed.  Only
+tenants that were marked with an expiry when they were created are deleted, whatever their
+name.`
+
+	DefaultEphemeralPrefix = "ephemeral"
+	DefaultEphemeralTTL    = 4 * time.Hour
+	DefaultWaitTimeout     = 10 * time.Minute
+
+	// tenant names are limited to 25 characters when generating the tenant URL, leave
+	// room for the separator and the encoded expiry.
+	maxEphemeralPrefixLength = 16
+	waitInterval             = 5 * time.Second
+)
+
+// ephemeralName returns the tenant name for an ephemeral tenant expiring at expiry.
+func ephemeralName(prefix string, expiry time.Time) string {
+	return fmt.Sprintf("%s-%s", prefix, strconv.FormatInt(expiry.Unix(), 36))
+}
+
+// ephemeralExpiry returns the expiry of an ephemeral tenant created with prefix, or false
+// if the tenant isn't one.  The tenant must be marked with an expiry, and be named after
+// it, so that tenants which only look ephemeral, e.g. ephemeral-demo, are never reaped.
+func ephemeralExpiry(prefix string, t companyconfig.Tenant) (time.Time, bool) {
+	if t.EphemeralExpiry.IsZero() || t.Name != ephemeralName(prefix, t.EphemeralExpiry) {
+		return time.Time{}, false
+	}
+
+	return t.EphemeralExpiry.UTC(), true
+}
+
+// CreateEphemeralCommand creates a tenant that is tagged with an expiry.
+type CreateEphemeralCommand struct {
+	*Command
+	Prefix      string
+	TTL         time.Duration
+	Wait        bool
+	WaitTimeout time.Duration
+}
+
+// CreateEphemeralCommand returns the create-ephemeral subcommand.
+func (c *Command) CreateEphemeralCommand() *cobra.Command {
+	ce := &CreateEphemeralCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   CreateEphemeralUsage,
+		Short: CreateEphemeralShort,
+		Long:  CreateEphemeralLong,
+		Args:  cobra.NoArgs,
+		RunE:  ce.RunE,
+	}
+
+	cmd.Flags().StringVarP(&ce.Prefix, "prefix", "", DefaultEphemeralPrefix, "tenant name prefix")
+	cmd.Flags().DurationVarP(&ce.TTL, "ttl", "", DefaultEphemeralTTL, "time until the tenant can be reaped")
+	cmd.Flags().BoolVarP(&ce.Wait, "wait", "", true, "wait for the tenant to be provisioned")
+	cmd.Flags().DurationVarP(&ce.WaitTimeout, "wait-timeout", "", DefaultWaitTimeout, "how long to wait for the tenant to be provisioned")
+	return cmd
+}
+
+func (c *CreateEphemeralCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "tenant-create-ephemeral", func(ctx context.Context) error {
+		if c.Prefix == "" || len(c.Prefix) > maxEphemeralPrefixLength {
+			return fmt.Errorf("tenant name prefix must be between 1 and %d characters", maxEphemeralPrefixLength)
+		}
+
+		if c.TTL <= 0 {
+			return fmt.Errorf("ttl must be positive")
+		}
+
+		companyID, err := c.companyID()
+		if err != nil {
+			return err
+		}
+
+		// the database keeps microseconds, but names only encode seconds
+		expiry := time.Now().UTC().Add(c.TTL).Truncate(time.Second)
+		tenant := companyconfig.Tenant{
+			BaseModel:       ucdb.NewBase(),
+			Name:            ephemeralName(c.Prefix, expiry),
+			CompanyID:       companyID,
+			EphemeralExpiry: expiry,
+		}
+
+		cc := c.consoleClient()
+		created, err := cc.CreateTenant(ctx, tenant)
+		if err != nil {
+			return fmt.Errorf("failed to create tenant %s: %v", tenant.Name, err)
+		}
+
+		if c.Wait {
+			waitCtx, cancel := context.WithTimeout(ctx, c.WaitTimeout)
+			defer cancel()
+
+			if created, err = cc.WaitForTenant(waitCtx, companyID, tenant.ID, waitInterval); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created tenant %s (%s) at %s, expires %s\n",
+			created.Name, created.ID, created.TenantURL, expiry.Format(time.RFC3339))
+		return nil
+	})
+}
+
+// ReapExpiredCommand deletes ephemeral tenants whose expiry has passed.
+type ReapExpiredCommand struct {
+	*Command
+	Prefix string
+	DryRun bool
+}
+
+// ReapExpiredCommand returns the reap-expired subcommand.
+func (c *Command) ReapExpiredCommand() *cobra.Command {
+	re := &ReapExpiredCommand{Command: c}
+	cmd := &cobra.Command{
+		Use:   ReapExpiredUsage,
+		Short: ReapExpiredShort,
+		Long:  ReapExpiredLong,
+		Args:  cobra.NoArgs,
+		RunE:  re.RunE,
+	}
+
+	cmd.Flags().StringVarP(&re.Prefix, "prefix", "", DefaultEphemeralPrefix, "tenant name prefix")
+	cmd.Flags().BoolVarP(&re.DryRun, "dry-run", "", false, "dry run")
+	return cmd
+}
+
+func (c *ReapExpiredCommand) RunE(cmd *cobra.Command, args []string) error {
+	return c.run(cmd, "tenant-reap-expired", func(ctx context.Context) error {
+		companyID, err := c.companyID()
+		if err != nil {
+			return err
+		}
+
+		cc := c.consoleClient()
+		tenants, err := cc.ListTenants(ctx, companyID)
+		if err != nil {
+			return fmt.Errorf("failed to list tenants for company %s: %v", companyID, err)
+		}
+
+		now := time.Now().UTC()
+		var failed []uuid.UUID
+		for _, t := range tenants {
+			expiry, ok := ephemeralExpiry(c.Prefix, t)
+			if !ok || expiry.After(now) {
+				continue
+			}
+
+			if c.DryRun {
+				fmt.Fprintf(cmd.OutOrStdout(), "Would delete tenant %s (%s), expired %s\n", t.Name, t.ID, expiry.Format(time.RFC3339))
+				continue
+			}
+
+			// keep going so that one bad tenant doesn't stop the rest from being reaped
+			if err := cc.DeleteTenant(ctx, companyID, t.ID); err != nil {
+				uclog.Errorf(ctx, "failed to delete tenant %s (%s): %v", t.Name, t.ID, err)
+				failed = append(failed, t.ID)
+				continue
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Deleted tenant %s (%s), expired %s\n", t.Name, t.ID, expiry.Format(time.RFC3339))
+		}
+
+		if len(failed) > 0 {
+			return fmt.Errorf("failed to delete %d expired tenants: %v", len(failed), failed)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,50 @@
+package tenant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/internal/companyconfig"
+)
+
+func TestEphemeralName(t *testing.T) {
+	expiry := time.Date(2026, 10, 16, 12, 30, 0, 0, time.UTC)
+	name := ephemeralName("ci", expiry)
+	assert.Equal(t, "ci-tn02q0", name)
+	assert.LessOrEqual(t, len(ephemeralName("sixteen-chars-pf", expiry)), 25)
+
+	got, ok := ephemeralExpiry("ci", companyconfig.Tenant{Name: name, EphemeralExpiry: expiry})
+	assert.True(t, ok)
+	assert.Equal(t, expiry, got)
+
+	// expiries read back from the database in another time zone are the same instant
+	got, ok = ephemeralExpiry("ci", companyconfig.Tenant{Name: name, EphemeralExpiry: expiry.In(time.FixedZone("PDT", -7*3600))})
+	assert.True(t, ok)
+	assert.Equal(t, expiry, got)
+}
+
+func TestEphemeralExpiry_notEphemeral(t *testing.T) {
+	expiry := time.Date(2026, 10, 16, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		tenant companyconfig.Tenant
+	}{
+		{"alphanumeric name", companyconfig.Tenant{Name: "ephemeral-demo"}},
+		{"production name", companyconfig.Tenant{Name: "ephemeral-prod"}},
+		{"encoded name without expiry", companyconfig.Tenant{Name: ephemeralName("ephemeral", expiry)}},
+		{"expiry with another name", companyconfig.Tenant{Name: "ephemeral-demo", EphemeralExpiry: expiry}},
+		{"expiry with renamed tenant", companyconfig.Tenant{Name: "prod", EphemeralExpiry: expiry}},
+		{"name of another expiry", companyconfig.Tenant{Name: ephemeralName("ephemeral", expiry.Add(time.Hour)), EphemeralExpiry: expiry}},
+		{"another prefix", companyconfig.Tenant{Name: ephemeralName("other", expiry), EphemeralExpiry: expiry}},
+		{"longer prefix", companyconfig.Tenant{Name: "my-" + ephemeralName("ephemeral", expiry), EphemeralExpiry: expiry}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := ephemeralExpiry("ephemeral", tt.tenant)
+			assert.False(t, ok)
+		})
+	}
+}
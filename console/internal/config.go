@@ -11,6 +11,7 @@ import (
 	"userclouds.com/infra/logtransports"
 	serviceNamespace "userclouds.com/infra/namespace/service"
 	"userclouds.com/infra/namespace/universe"
+	"userclouds.com/infra/secret/provider"
 	"userclouds.com/infra/service"
 	"userclouds.com/infra/ucdb"
 	"userclouds.com/infra/ucerr"
@@ -55,6 +56,7 @@ type Config struct {
 	FeatureFlagConfig *featureflags.Config `yaml:"featureflags,omitempty" json:"featureflags,omitempty" validate:"allownil"`
 	Sentry            *ucsentry.Config     `yaml:"sentry,omitempty" json:"sentry,omitempty" validate:"allownil"`
 	Tracing           *uctrace.Config      `yaml:"tracing,omitempty" json:"tracing,omitempty" validate:"allownil"`
+	Secrets           *provider.Config     `yaml:"secrets,omitempty" json:"secrets,omitempty" validate:"allownil"`
 
 	OnPremSQLShimPorts []int `yaml:"onprem_sqlshim_ports,omitempty" json:"onprem_sqlshim_ports,omitempty"`
 }
@@ -65,6 +65,11 @@ func (o Config) Validate() error {
 			return ucerr.Wrap(err)
 		}
 	}
+	if o.Secrets != nil {
+		if err := o.Secrets.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
 	// .extraValidate() lets you do any validation you can't express in codegen tags yet
 	if err := o.extraValidate(); err != nil {
 		return ucerr.Wrap(err)
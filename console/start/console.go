@@ -9,6 +9,7 @@ import (
 	"userclouds.com/infra/logtransports"
 	"userclouds.com/infra/migrate"
 	serviceNamespace "userclouds.com/infra/namespace/service"
+	"userclouds.com/infra/secret"
 	"userclouds.com/infra/service"
 	"userclouds.com/infra/ucdb"
 	"userclouds.com/infra/ucerr"
@@ -64,6 +65,9 @@ func RunConsole() {
 	if err != nil {
 		uclog.Fatalf(ctx, "failed to load config: %v", err)
 	}
+	if err := secret.Configure(cfg.Secrets); err != nil {
+		uclog.Fatalf(ctx, "failed to configure the secret provider: %v", err)
+	}
 
 	ts, err := m2m.GetM2MTokenSource(ctx, cfg.ConsoleTenantID)
 	if err != nil {
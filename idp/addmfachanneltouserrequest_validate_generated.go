@@ -0,0 +1,25 @@
+// NOTE: automatically generated file -- DO NOT EDIT
+
+package idp
+
+import (
+	"userclouds.com/infra/ucerr"
+)
+
+// Validate implements Validateable
+func (o AddMFAChannelToUserRequest) Validate() error {
+	if o.UserID.IsNil() {
+		return ucerr.Friendlyf(nil, "AddMFAChannelToUserRequest.UserID can't be nil")
+	}
+	if err := o.ChannelType.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	if o.ChannelTypeID == "" {
+		return ucerr.Friendlyf(nil, "AddMFAChannelToUserRequest.ChannelTypeID can't be empty")
+	}
+	// .extraValidate() lets you do any validation you can't express in codegen tags yet
+	if err := o.extraValidate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+	return nil
+}
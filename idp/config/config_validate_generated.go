@@ -50,6 +50,11 @@ func (o Config) Validate() error {
 			return ucerr.Wrap(err)
 		}
 	}
+	if o.Secrets != nil {
+		if err := o.Secrets.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
 	if o.DataImportConfig != nil {
 		if err := o.DataImportConfig.Validate(); err != nil {
 			return ucerr.Wrap(err)
@@ -79,7 +79,7 @@ func NewHandler(cfg *config.Config, searchUpdateConfig *config.SearchUpdateConfi
 	return hb.Build()
 }
 
-//go:generate genhandler /authn collection,User,h.newRoleBasedAuthorizer(),/users collection,UserBaseProfile,h.newRoleBasedAuthorizer(),/baseprofiles
+//go:generate genhandler /authn collection,User,h.newRoleBasedAuthorizer(),/users collection,UserBaseProfile,h.newRoleBasedAuthorizer(),/baseprofiles POST,addMFAChannelToUser,/addmfachanneltouser
 
 func (h *handler) newRoleBasedAuthorizer() uchttp.CollectionAuthorizer {
 	return &uchttp.MethodAuthorizer{
@@ -240,6 +240,25 @@ func (h *handler) AddAuthnToUserHandler(w http.ResponseWriter, r *http.Request)
 	jsonapi.Marshal(w, nil, jsonapi.Code(http.StatusNoContent))
 }
 
+// OpenAPI Summary: Add MFA Channel To User
+// OpenAPI Tags: Users
+// OpenAPI Description: This endpoint adds an email or SMS MFA channel to an existing user, so that they don't have to enroll it the first time they log in. The channel's address must already be verified in the user's profile, i.e. match the email or phone_number column with email_verified or phone_number_verified set. The channel becomes the user's primary MFA channel if they don't have one.
+func (h *handler) addMFAChannelToUser(ctx context.Context, req idp.AddMFAChannelToUserRequest) (any, int, []auditlog.Entry, error) {
+	tenantAuthn := MustGetTenantAuthn(ctx)
+
+	if err := tenantAuthn.Manager.AddMFAChannelToUser(ctx, req.UserID, req.ChannelType, req.ChannelTypeID); err != nil {
+		switch {
+		case errors.Is(err, ErrMFAChannelNotVerified):
+			return nil, http.StatusBadRequest, nil, ucerr.Wrap(err)
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, http.StatusNotFound, nil, ucerr.Wrap(err)
+		}
+		return nil, http.StatusInternalServerError, nil, ucerr.Wrap(err)
+	}
+
+	return nil, http.StatusNoContent, nil, nil
+}
+
 func newGetUserResponse(
 	ctx context.Context,
 	user *storage.BaseUser,
@@ -316,6 +316,50 @@ func TestMFA(t *testing.T) {
 	validateLoginResponse(t, w.Result())
 }
 
+func TestAddMFAChannelToUser(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tf := newTestFixture(t)
+
+	addChannel := func(userID uuid.UUID, channelType oidc.MFAChannelType, channelTypeID string) int {
+		req := idp.AddMFAChannelToUserRequest{UserID: userID, ChannelType: channelType, ChannelTypeID: channelTypeID}
+		return tf.request(http.MethodPost, "/authn/addmfachanneltouser", req).Result().StatusCode
+	}
+
+	// users that don't exist can't have channels added
+	assert.Equal(t, addChannel(uuid.Must(uuid.NewV4()), oidc.MFAEmailChannel, testEmail), http.StatusNotFound)
+
+	// nor can addresses that aren't verified in the user's profile
+	unverifiedID, err := createUserWithPassword(tf, genUsername(), testPassword)
+	assert.NoErr(t, err)
+	assert.Equal(t, addChannel(unverifiedID, oidc.MFAEmailChannel, testEmail), http.StatusBadRequest)
+
+	verifiedID, err := createUserWithPasswordAndProfile(tf, genUsername(), testPassword, userstore.Record{"email": testEmail, "email_verified": true})
+	assert.NoErr(t, err)
+	assert.Equal(t, addChannel(verifiedID, oidc.MFAEmailChannel, "bar@contoso.com"), http.StatusBadRequest)
+	assert.Equal(t, addChannel(verifiedID, oidc.MFASMSChannel, "+15555550100"), http.StatusBadRequest)
+
+	host, err := tenantmap.GetHostFromTenantURL(tf.tenant.TenantURL)
+	assert.NoErr(t, err)
+	mgr, err := authn.GetManager(ctx, tf.tenants, host)
+	assert.NoErr(t, err)
+	for _, userID := range []uuid.UUID{unverifiedID, verifiedID} {
+		channels, _, err := mgr.GetMFASettings(ctx, oidc.MFAChannelTypeSet{oidc.MFAEmailChannel: true, oidc.MFASMSChannel: true}, userID)
+		assert.NoErr(t, err)
+		assert.Equal(t, len(channels.Channels), 0)
+	}
+
+	// the verified email address is added as the user's primary channel
+	assert.Equal(t, addChannel(verifiedID, oidc.MFAEmailChannel, testEmail), http.StatusNoContent)
+	channels, _, err := mgr.GetMFASettings(ctx, oidc.MFAChannelTypeSet{oidc.MFAEmailChannel: true}, verifiedID)
+	assert.NoErr(t, err)
+	assert.Equal(t, len(channels.Channels), 1)
+	channel := channels.Channels[channels.PrimaryChannelID]
+	assert.Equal(t, channel.ChannelType, oidc.MFAEmailChannel)
+	assert.Equal(t, channel.ChannelTypeID, testEmail)
+	assert.True(t, channel.Verified)
+}
+
 func TestCreateUserWithPassword(t *testing.T) {
 	t.Parallel()
 	tf := newTestFixture(t)
@@ -27,6 +27,29 @@ func handlerBuilder(builder *builder.HandlerBuilder, h *handler) {
 		GetAll(h.listUserBaseProfilesGenerated).
 		WithAuthorizer(h.newRoleBasedAuthorizer())
 
+	builder.MethodHandler("/addmfachanneltouser").Post(h.addMFAChannelToUserGenerated)
+
+}
+
+func (h *handler) addMFAChannelToUserGenerated(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req idp.AddMFAChannelToUserRequest
+	if err := jsonapi.Unmarshal(r, &req); err != nil {
+		jsonapi.MarshalError(ctx, w, err)
+		return
+	}
+
+	var res any
+	res, code, entries, err := h.addMFAChannelToUser(ctx, req)
+	auditlog.PostMultipleAsync(ctx, entries)
+
+	if err != nil {
+		jsonapi.MarshalError(ctx, w, err, jsonapi.Code(code))
+		return
+	}
+
+	jsonapi.Marshal(w, res, jsonapi.Code(code))
 }
 
 func (h *handler) createUserGenerated(w http.ResponseWriter, r *http.Request) {
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -83,6 +84,71 @@ func (m *Manager) CreateTestMFAEmailChannel(ctx context.Context, userID uuid.UUI
 	return ucerr.Wrap(m.configStorage.SaveUserMFAConfiguration(ctx, userMFASettings))
 }
 
+// ErrMFAChannelNotVerified is returned when an MFA channel is added to a user whose profile
+// doesn't have the channel's address verified
+var ErrMFAChannelNotVerified = ucerr.New("MFA channel address is not verified for the user")
+
+// mfaChannelProfileColumns maps the MFA channel types that can be added to a user to the
+// userstore column with the channel's address, which is verified if the column of the same
+// name suffixed with _verified is true
+var mfaChannelProfileColumns = map[oidc.MFAChannelType]string{
+	oidc.MFAEmailChannel: "email",
+	oidc.MFASMSChannel:   "phone_number",
+}
+
+// AddMFAChannelToUser adds an MFA channel to an existing user, making it the primary channel
+// if the user doesn't have one yet.  The channel is only added if the user's profile already
+// has its address verified, e.g. an email address with email_verified set; other channels
+// must be enrolled by the user so that they are verified with an MFA code.
+func (m *Manager) AddMFAChannelToUser(ctx context.Context, userID uuid.UUID, channelType oidc.MFAChannelType, channelTypeID string) error {
+	column, found := mfaChannelProfileColumns[channelType]
+	if !found {
+		return ucerr.Friendlyf(nil, "MFA channel type '%v' can't be added to a user", channelType)
+	}
+
+	userData, _, err := userstoreinternal.GetUsers(ctx, false, "", false, userID.String())
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	if len(userData) == 0 {
+		return ucerr.Friendlyf(sql.ErrNoRows, "user %v not found", userID)
+	}
+
+	var profile userstore.Record
+	if err := json.Unmarshal([]byte(userData[0]), &profile); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if !strings.EqualFold(profile.StringValue(column), channelTypeID) || !profile.BoolValue(column+"_verified") {
+		return ucerr.Friendlyf(ErrMFAChannelNotVerified, "%s MFA channel '%s' must match the user's verified %s", channelType, channelTypeID, column)
+	}
+
+	userMFASettings, err := m.configStorage.GetUserMFAConfiguration(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return ucerr.Wrap(err)
+	}
+
+	if userMFASettings == nil {
+		userMFASettings = &storage.UserMFAConfiguration{BaseModel: ucdb.NewBaseWithID(userID)}
+		userMFASettings.MFAChannels = oidc.NewMFAChannels()
+	}
+
+	channel, err := userMFASettings.MFAChannels.AddChannel(channelType, channelTypeID, channelTypeID, true)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if !userMFASettings.MFAChannels.HasPrimaryChannel() {
+		if err := userMFASettings.MFAChannels.SetPrimary(channel.ID); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+
+	userMFASettings.MarkEvaluated()
+
+	return ucerr.Wrap(m.configStorage.SaveUserMFAConfiguration(ctx, userMFASettings))
+}
+
 // GetMFASettings returns the set of supported MFA channels for the user based on the specified set of MFA
 // channel types, as well as a flag signifying whether the user should re-evaluate their MFA settings
 func (m *Manager) GetMFASettings(ctx context.Context,
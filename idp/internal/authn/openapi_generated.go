@@ -29,6 +29,24 @@ func BuildOpenAPISpec(ctx context.Context, reflector *openapi3.Reflector) {
 		reflector.AddTypeMapping(uuid.UUID{}, uuidDef)
 	}
 
+	{
+		op, err := reflector.NewOperationContext(http.MethodPost, "/authn/addmfachanneltouser")
+		if err != nil {
+			uclog.Fatalf(ctx, "failed to creation operation context: %v", err)
+		}
+		op.SetSummary("Add MFA Channel To User")
+		op.SetDescription("This endpoint adds an email or SMS MFA channel to an existing user, so that they don't have to enroll it the first time they log in. The channel's address must already be verified in the user's profile, i.e. match the email or phone_number column with email_verified or phone_number_verified set. The channel becomes the user's primary MFA channel if they don't have one.")
+		op.SetTags("Users")
+		op.AddReqStructure(new(idp.AddMFAChannelToUserRequest))
+		op.AddRespStructure(new(any), openapi.WithHTTPStatus(http.StatusNoContent))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusBadRequest))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusInternalServerError))
+		op.AddRespStructure(nil, openapi.WithHTTPStatus(http.StatusNotFound))
+		if err := reflector.AddOperation(op); err != nil {
+			uclog.Fatalf(ctx, "failed to add operation: %v", err)
+		}
+	}
+
 	{
 		op, err := reflector.NewOperationContext(http.MethodGet, "/authn/baseprofiles")
 		if err != nil {
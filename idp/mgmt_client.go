@@ -361,3 +361,35 @@ func (c *ManagementClient) AddOIDCAuthnToUser(ctx context.Context, userID string
 
 	return ucerr.Wrap(c.jsonClient.Post(ctx, paths.AddAuthnToUser, req, nil))
 }
+
+// AddMFAChannelToUserRequest is the request struct to add an MFA channel to a user
+type AddMFAChannelToUserRequest struct {
+	UserID        uuid.UUID           `json:"user_id" validate:"notnil"`
+	ChannelType   oidc.MFAChannelType `json:"channel_type"`
+	ChannelTypeID string              `json:"channel_type_id" validate:"notempty"`
+}
+
+func (r *AddMFAChannelToUserRequest) extraValidate() error {
+	if r.ChannelType != oidc.MFAEmailChannel && r.ChannelType != oidc.MFASMSChannel {
+		return ucerr.Errorf("MFA channel type '%v' can't be added to a user", r.ChannelType)
+	}
+
+	return nil
+}
+
+//go:generate genvalidate AddMFAChannelToUserRequest
+
+// AddMFAChannelToUser adds an email or SMS MFA channel to an existing user, so that the user
+// doesn't need to enroll the channel the first time they log in.  The channel's address must
+// already be verified in the user's profile, i.e. be their email or phone_number with
+// email_verified or phone_number_verified set.  The channel becomes the user's primary
+// channel if they don't already have one.
+func (c *ManagementClient) AddMFAChannelToUser(ctx context.Context, userID uuid.UUID, channelType oidc.MFAChannelType, channelTypeID string) error {
+	req := AddMFAChannelToUserRequest{
+		UserID:        userID,
+		ChannelType:   channelType,
+		ChannelTypeID: channelTypeID,
+	}
+
+	return ucerr.Wrap(c.jsonClient.Post(ctx, paths.AddMFAChannelToUser, req, nil))
+}
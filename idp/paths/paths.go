@@ -12,8 +12,9 @@ var (
 	IDPBasePath = "/authn" // TODO change this
 
 	// TODO: finish converting IDP path handling to use these
-	CreateUser     = fmt.Sprintf("%s/users", IDPBasePath)
-	AddAuthnToUser = fmt.Sprintf("%s/addauthntouser", IDPBasePath)
+	CreateUser          = fmt.Sprintf("%s/users", IDPBasePath)
+	AddAuthnToUser      = fmt.Sprintf("%s/addauthntouser", IDPBasePath)
+	AddMFAChannelToUser = fmt.Sprintf("%s/addmfachanneltouser", IDPBasePath)
 
 	UserStoreBasePath = "/userstore"
 
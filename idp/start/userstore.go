@@ -11,6 +11,7 @@ import (
 	"userclouds.com/infra/logtransports"
 	serviceNamespace "userclouds.com/infra/namespace/service"
 	"userclouds.com/infra/namespace/universe"
+	"userclouds.com/infra/secret"
 	"userclouds.com/infra/service"
 	"userclouds.com/infra/ucerr"
 	"userclouds.com/infra/uclog"
@@ -37,6 +38,9 @@ func RunUserStore() {
 	if err != nil {
 		uclog.Fatalf(ctx, "failed to load IDP configuration: %v", err)
 	}
+	if err := secret.Configure(cfg.Secrets); err != nil {
+		uclog.Fatalf(ctx, "failed to configure the secret provider: %v", err)
+	}
 	ts, err := m2m.GetM2MTokenSource(ctx, cfg.ConsoleTenantID)
 	if err != nil {
 		uclog.Fatalf(ctx, "failed to get m2m secret: %v", err)
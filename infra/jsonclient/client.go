@@ -106,7 +106,9 @@ func New(url string, opts ...Option) *Client {
 	c := &Client{
 		baseURL: url,
 		options: options{
-			headers: make(http.Header),
+			headers:     make(http.Header),
+			maxAttempts: maxRetries,
+			backoff:     backoff,
 		},
 	}
 
@@ -197,14 +199,14 @@ func (c *Client) refreshBearerTokenRetry(ctx context.Context, retries int) error
 		if err != nil {
 			// TODO: can we simplify this design somehow? would be nice for GetToken to reuse client or at least opts?
 			if c.options.retryNetworkErrors && isNetworkError(err) {
-				if retries >= maxRetries {
+				if retries >= c.options.maxAttempts {
 					return ucerr.Errorf("max retries exceeded: %v", err)
 				}
 
 				c.logWarning(ctx, http.MethodPost, "refreshBearerToken",
 					ucerr.Errorf("network error, retry %d: %v", retries, err).Error(), 0)
 
-				time.Sleep(backoff)
+				time.Sleep(c.options.backoff)
 				return ucerr.Wrap(c.refreshBearerTokenRetry(ctx, retries+1))
 			}
 			return ucerr.Wrap(err)
@@ -337,7 +339,10 @@ func (c *Client) makeRequestRetry(ctx context.Context,
 			return ucerr.New("`CustomDecoder` option should only be specified with a nil `response`")
 		}
 
-		client := uctrace.MakeHTTPClient()
+		client := options.httpClient
+		if client == nil {
+			client = uctrace.MakeHTTPClient()
+		}
 
 		reqURL := c.buildURL(path)
 		req, err := http.NewRequestWithContext(ctx, method, reqURL, bytes.NewReader(bs))
@@ -378,11 +383,11 @@ func (c *Client) makeRequestRetry(ctx context.Context,
 		}
 		if err != nil {
 			if options.retryNetworkErrors && isNetworkError(err) {
-				if retries >= maxRetries {
+				if retries >= options.maxAttempts {
 					return ucerr.Errorf("max retries exceeded: %v", err)
 				}
 				c.logWarning(ctx, req.Method, req.URL.String(), ucerr.Errorf("network error, retry %d: %v", retries, err).Error(), 0)
-				time.Sleep(backoff)
+				time.Sleep(options.backoff)
 				return ucerr.Wrap(c.makeRequestRetry(ctx, method, path, bs, response, opts, retries+1))
 			}
 			return ucerr.Wrap(err)
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/go-http-utils/headers"
 
@@ -41,10 +42,15 @@ type options struct {
 	decodeFunc DecodeFunc
 
 	// retryNetworkErrors causes the client to retry requests that fail due to network errors,
-	// up to `maxRetries`, with a `backoff` pause each time
+	// up to `maxAttempts` attempts, with a `backoff` pause each time
 	retryNetworkErrors bool
+	maxAttempts        int
+	backoff            time.Duration
 
 	bypassRouting bool // bypass localhost routing for cross-service calls
+
+	// httpClient is used for requests instead of the default tracing client if set
+	httpClient *http.Client
 }
 
 func (o *options) clone() *options {
@@ -186,3 +192,20 @@ func RetryNetworkErrors(retry bool) Option {
 		opts.retryNetworkErrors = retry
 	})
 }
+
+// RetryPolicy makes the client retry on underlying network errors, making up to attempts
+// attempts in total with a backoff pause between them
+func RetryPolicy(attempts int, backoff time.Duration) Option {
+	return optFunc(func(opts *options) {
+		opts.retryNetworkErrors = true
+		opts.maxAttempts = attempts
+		opts.backoff = backoff
+	})
+}
+
+// HTTPClient sets the HTTP client used for requests, e.g. to customize TLS settings
+func HTTPClient(client *http.Client) Option {
+	return optFunc(func(opts *options) {
+		opts.httpClient = client
+	})
+}
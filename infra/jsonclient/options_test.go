@@ -42,3 +42,17 @@ func TestHeaderFuncOption(t *testing.T) {
 	}))
 	assert.NoErr(t, client.Get(ctx, "/", nil))
 }
+
+func TestHTTPClientOption(t *testing.T) {
+	ctx := context.Background()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// the test server's certificate isn't trusted by the default client
+	client := jsonclient.New(srv.URL)
+	assert.NotNil(t, client.Get(ctx, "/", nil))
+
+	client = jsonclient.New(srv.URL, jsonclient.HTTPClient(srv.Client()))
+	assert.NoErr(t, client.Get(ctx, "/", nil))
+}
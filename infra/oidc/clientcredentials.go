@@ -19,6 +19,9 @@ type ClientCredentialsTokenSource struct {
 	ClientSecret    string   `json:"client_secret" yaml:"client_secret" validate:"notempty"` // TODO (sgarrity 6/24): should this be secret.String?
 	CustomAudiences []string `json:"custom_audiences" yaml:"custom_audiences"`
 	SubjectJWT      string   `json:"subject_jwt" yaml:"subject_jwt"` // optional, ID Token for a UC user if this access token is being created on their behalf
+
+	// HTTPClient is used to request the token if set, e.g. for a token endpoint behind a private CA
+	HTTPClient *http.Client `json:"-" yaml:"-"`
 }
 
 //go:generate genvalidate ClientCredentialsTokenSource
@@ -47,7 +50,11 @@ func (ccts ClientCredentialsTokenSource) GetToken() (string, error) {
 	}
 	req.Header.Add(headers.ContentType, "application/x-www-form-urlencoded")
 	// TODO: re-use client?
-	resp, err := http.DefaultClient.Do(req)
+	client := http.DefaultClient
+	if ccts.HTTPClient != nil {
+		client = ccts.HTTPClient
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", ucerr.Wrap(err)
 	}
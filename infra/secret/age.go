@@ -0,0 +1,70 @@
+package secret
+
+import (
+	"context"
+	"time"
+
+	"userclouds.com/infra/secret/prefix"
+	"userclouds.com/infra/secret/provider"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+// Timestamps returns when the secret was created and last updated in its provider, for
+// providers that track them (see provider.TimestampGetter).  Selected fields and pinned
+// versions report the timestamps of the whole secret.
+func (s *String) Timestamps(ctx context.Context) (time.Time, time.Time, error) {
+	if s.IsEmpty() || !s.HasPrefix() {
+		return time.Time{}, time.Time{}, ucerr.New("secret isn't stored in a secret provider")
+	}
+
+	pv, err := s.GetProvider()
+	if err != nil {
+		return time.Time{}, time.Time{}, ucerr.Wrap(err)
+	}
+
+	px, err := prefix.PrefixFromString(pv.Prefix())
+	if err != nil {
+		return time.Time{}, time.Time{}, ucerr.Wrap(err)
+	}
+
+	path, _ := splitField(pv, px.Value(s.location))
+	path, _ = provider.SplitVersion(pv, path)
+	created, updated, err := provider.GetTimestamps(ctx, pv, path)
+	if err != nil {
+		return time.Time{}, time.Time{}, ucerr.Wrap(err)
+	}
+
+	return created, updated, nil
+}
+
+// Age returns how long ago the secret was last updated in its provider.
+func (s *String) Age(ctx context.Context) (time.Duration, error) {
+	_, updated, err := s.Timestamps(ctx)
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	return time.Since(updated), nil
+}
+
+// WarnIfStale logs a warning for each of the secrets that was last updated more than
+// maxAge ago, e.g. to enforce a rotation policy at startup, and returns them.  Secrets
+// whose age is unknown, such as dev secrets, are skipped.
+func WarnIfStale(ctx context.Context, maxAge time.Duration, secrets ...*String) []*String {
+	var stale []*String
+	for _, s := range secrets {
+		age, err := s.Age(ctx)
+		if err != nil {
+			uclog.Debugf(ctx, "skipping age check of secret %s: %v", auditLocation(s.location), err)
+			continue
+		}
+
+		if age > maxAge {
+			uclog.Warningf(ctx, "secret %s was last updated %v ago, more than the maximum of %v, and should be rotated", auditLocation(s.location), age.Round(time.Minute), maxAge)
+			stale = append(stale, s)
+		}
+	}
+
+	return stale
+}
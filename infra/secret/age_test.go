@@ -0,0 +1,44 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret/provider/file"
+)
+
+func TestString_Age(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	pv := file.New().WithRoot(root)
+
+	fresh, err := NewStringWithProvider(ctx, "plex", "fresh", "value", pv)
+	assert.NoError(t, err)
+	old, err := NewStringWithProvider(ctx, "plex", "old", "value", pv)
+	assert.NoError(t, err)
+	updated := time.Now().Add(-48 * time.Hour)
+	assert.NoError(t, os.Chtimes(strings.TrimPrefix(old.Location(), file.Prefix), updated, updated))
+
+	age, err := fresh.Age(ctx)
+	assert.NoError(t, err)
+	assert.Less(t, age, time.Minute)
+
+	_, ts, err := old.Timestamps(ctx)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, updated, ts, time.Second)
+
+	// dev secrets don't have an age
+	dev := NewTestString("value")
+	_, err = dev.Age(ctx)
+	assert.Error(t, err)
+	_, err = FromLocation("legacy").Age(ctx)
+	assert.Error(t, err)
+
+	stale := WarnIfStale(ctx, 24*time.Hour, fresh, old, &dev)
+	assert.Equal(t, []*String{old}, stale)
+}
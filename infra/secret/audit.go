@@ -0,0 +1,122 @@
+package secret
+
+import (
+	"context"
+	"maps"
+	"sync/atomic"
+	"time"
+
+	"userclouds.com/infra/secret/prefix"
+)
+
+// AuditOperation is an operation on a secret that is audited.
+type AuditOperation string
+
+const (
+	// AuditResolve is the resolution of a secret, including from the cache.
+	AuditResolve AuditOperation = "resolve"
+	// AuditSave is the creation or update of a secret.
+	AuditSave AuditOperation = "save"
+	// AuditDelete is the deletion of a secret.
+	AuditDelete AuditOperation = "delete"
+	// AuditRotate is the rotation of a secret to a new value.
+	AuditRotate AuditOperation = "rotate"
+	// AuditFinalizeRotation is the end of a rotation, deleting the previous value.
+	AuditFinalizeRotation AuditOperation = "finalize_rotation"
+	// AuditCancelRotation is the cancellation of a rotation, restoring the previous value.
+	AuditCancelRotation AuditOperation = "cancel_rotation"
+)
+
+// AuditEvent records an operation on a secret stored in a provider.  It never includes
+// the value of the secret.
+type AuditEvent struct {
+	Time      time.Time
+	Operation AuditOperation
+	// Location is the location of the secret, except for dev secrets whose location is
+	// the secret itself, which are replaced by their prefix.
+	Location string
+	// Err is the error of a failed operation.
+	Err error
+	// Fields are the fields set on the context with WithAuditFields, e.g. the service
+	// accessing the secret.
+	Fields map[string]string
+}
+
+// Auditor records operations on secrets, e.g. so that security can trace which
+// component read which secret when.  Implementations must be safe for concurrent use.
+type Auditor interface {
+	Audit(ctx context.Context, e AuditEvent)
+}
+
+// AuditFunc adapts a function to the Auditor interface.
+type AuditFunc func(ctx context.Context, e AuditEvent)
+
+// Audit implements Auditor
+func (f AuditFunc) Audit(ctx context.Context, e AuditEvent) {
+	f(ctx, e)
+}
+
+// auditorBox wraps the auditor, since atomic.Value requires a consistent type.
+type auditorBox struct {
+	Auditor
+}
+
+var auditor atomic.Value
+
+// SetAuditor sets the package-wide auditor of operations on secrets, or removes it if a
+// is nil.
+func SetAuditor(a Auditor) {
+	auditor.Store(auditorBox{a})
+}
+
+type auditFieldsKey struct{}
+
+// WithAuditFields returns a context whose secret operations are audited with fields,
+// in addition to the fields already set on ctx.
+func WithAuditFields(ctx context.Context, fields map[string]string) context.Context {
+	merged := maps.Clone(auditFields(ctx))
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	maps.Copy(merged, fields)
+
+	return context.WithValue(ctx, auditFieldsKey{}, merged)
+}
+
+// auditFields returns the audit fields set on ctx.
+func auditFields(ctx context.Context) map[string]string {
+	fields, _ := ctx.Value(auditFieldsKey{}).(map[string]string)
+	return fields
+}
+
+// audit reports an operation on the secret at location to the package-wide auditor if
+// set.
+func audit(ctx context.Context, op AuditOperation, location string, err error) {
+	box, ok := auditor.Load().(auditorBox)
+	if !ok || box.Auditor == nil {
+		return
+	}
+
+	box.Audit(ctx, AuditEvent{
+		Time:      time.Now().UTC(),
+		Operation: op,
+		Location:  auditLocation(location),
+		Err:       err,
+		Fields:    maps.Clone(auditFields(ctx)),
+	})
+}
+
+// auditLocation returns the location of a secret to audit, which doesn't reveal the
+// secret.
+func auditLocation(location string) string {
+	px, err := prefix.PrefixFromString(location)
+	if err != nil {
+		return FixedMask
+	}
+
+	if px == prefix.PrefixDev || px == prefix.PrefixDevLiteral {
+		return string(px) + FixedMask
+	}
+
+	return location
+}
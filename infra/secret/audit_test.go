@@ -0,0 +1,52 @@
+package secret
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret/provider/dev"
+	"userclouds.com/infra/secret/provider/file"
+)
+
+func TestAudit(t *testing.T) {
+	ctx := WithAuditFields(context.Background(), map[string]string{"service": "plex"})
+	ctx = WithAuditFields(ctx, map[string]string{"component": "startup"})
+	InvalidateAll(ctx)
+
+	var mu sync.Mutex
+	var events []AuditEvent
+	SetAuditor(AuditFunc(func(ctx context.Context, e AuditEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}))
+	defer SetAuditor(nil)
+
+	pv := file.New().WithRoot(t.TempDir())
+	s, err := NewStringWithProvider(ctx, "plex", "audited", "supersecret", pv)
+	assert.NoError(t, err)
+	_, err = s.Resolve(ctx)
+	assert.NoError(t, err)
+	location := s.Location()
+	assert.NoError(t, s.Delete(ctx))
+
+	// dev secrets are stored in their location, which isn't audited
+	d, err := NewStringWithProvider(ctx, "plex", "audited", "supersecret", dev.New())
+	assert.NoError(t, err)
+	_, err = d.Resolve(ctx)
+	assert.NoError(t, err)
+
+	var ops []AuditOperation
+	for _, e := range events {
+		ops = append(ops, e.Operation)
+		assert.Equal(t, map[string]string{"service": "plex", "component": "startup"}, e.Fields)
+		assert.NotContains(t, e.Location, "supersecret")
+		assert.NotContains(t, e.Location, "c3VwZXJzZWNyZXQ")
+	}
+	assert.Equal(t, []AuditOperation{AuditSave, AuditResolve, AuditDelete, AuditSave, AuditResolve}, ops)
+	assert.Equal(t, location, events[0].Location)
+	assert.Equal(t, "dev://"+FixedMask, events[4].Location)
+}
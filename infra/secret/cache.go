@@ -1,52 +1,250 @@
 package secret
 
 import (
+	"context"
+	"math/rand/v2"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"userclouds.com/infra/uclog"
 )
 
-// TODO: Get rid of the package level cache
-var c = &cache{secrets: map[string]cacheObject{}}
-var secretCacheDuration = time.Hour * 24
+const (
+	// CacheTTLEnvKey overrides how long resolved secrets are cached, as a duration such
+	// as "5m".  A TTL of 0 disables caching.
+	CacheTTLEnvKey = "UC_SECRET_CACHE_TTL"
+
+	// DefaultCacheTTL is how long resolved secrets are cached unless overridden.
+	DefaultCacheTTL = time.Hour * 24
+
+	// DefaultRefreshInterval is how often the background refresher checks the cache.
+	DefaultRefreshInterval = time.Minute
+)
+
+// Cache caches resolved secrets by location.  Implementations must be safe for
+// concurrent use.
+type Cache interface {
+	// Get returns the secret cached for location if it was stored less than ttl ago.
+	Get(ctx context.Context, location string, ttl time.Duration) (string, bool)
+	// Store caches the secret resolved for location.
+	Store(ctx context.Context, location, secret string)
+	// Delete removes the secret cached for location.
+	Delete(ctx context.Context, location string)
+	// Reset removes all cached secrets.
+	Reset(ctx context.Context)
+}
+
+// NoCache is a Cache that doesn't cache anything, so that every resolution fetches the
+// secret from its provider.
+var NoCache Cache = noCache{}
+
+type noCache struct{}
+
+func (noCache) Get(ctx context.Context, location string, ttl time.Duration) (string, bool) {
+	return "", false
+}
+
+func (noCache) Store(ctx context.Context, location, secret string) {}
+
+func (noCache) Delete(ctx context.Context, location string) {}
+
+func (noCache) Reset(ctx context.Context) {}
+
+// cacheBox wraps the default cache, since atomic.Value requires a consistent type.
+type cacheBox struct {
+	Cache
+}
+
+var (
+	cacheTTL     atomic.Int64
+	defaultCache atomic.Value
+)
+
+// init sets up the default cache, with the TTL from UC_SECRET_CACHE_TTL if it is a valid
+// duration and DefaultCacheTTL otherwise, since nothing can be logged this early.
+func init() {
+	defaultCache.Store(cacheBox{NewMemoryCache(0)})
+	cacheTTL.Store(int64(DefaultCacheTTL))
+
+	if ttl, err := time.ParseDuration(os.Getenv(CacheTTLEnvKey)); err == nil {
+		SetCacheTTL(ttl)
+	}
+}
+
+// SetCache sets the cache used package-wide by secrets that don't have their own, see
+// String.WithCache.  The default is an unbounded MemoryCache.
+func SetCache(cache Cache) {
+	if cache == nil {
+		cache = NoCache
+	}
+	defaultCache.Store(cacheBox{cache})
+}
+
+// GetCache returns the cache used package-wide.
+func GetCache() Cache {
+	return defaultCache.Load().(cacheBox).Cache
+}
+
+// SetCacheTTL sets how long resolved secrets are cached package-wide, overriding
+// UC_SECRET_CACHE_TTL.  A TTL of 0 (or less) disables caching.
+func SetCacheTTL(ttl time.Duration) {
+	cacheTTL.Store(int64(max(ttl, 0)))
+}
+
+// GetCacheTTL returns how long resolved secrets are cached package-wide.
+func GetCacheTTL() time.Duration {
+	return time.Duration(cacheTTL.Load())
+}
 
 type cacheObject struct {
-	Secret  string
-	Expires time.Time
+	Secret string
+	Stored time.Time
+
+	// refreshAt is the fraction of the TTL after which the refresher re-resolves the
+	// secret, randomized so that instances started together don't refresh together
+	refreshAt float64
 }
 
-// cache is an in-memory cache of secrets.
-type cache struct {
+// MemoryCache is an in-memory Cache of secrets.
+type MemoryCache struct {
 	secrets      map[string]cacheObject
+	maxEntries   int
 	secretsMutex sync.RWMutex
 }
 
-// Get returns a secret and a boolean value if it exists and has not reached its
-// expiration, otherwise it returns an empty string and a falsy "found" value.
-func (c *cache) Get(loc string) (string, bool) {
+// NewMemoryCache returns an in-memory cache holding up to maxEntries secrets, evicting
+// the least recently stored one when full.  A maxEntries of 0 doesn't limit the size.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{secrets: map[string]cacheObject{}, maxEntries: maxEntries}
+}
+
+// Get returns a secret and a boolean value if it exists and was stored less than ttl
+// ago, otherwise it returns an empty string and a falsy "found" value.  Since the age is
+// checked on read, TTL changes also apply to secrets that are already cached.
+func (c *MemoryCache) Get(ctx context.Context, loc string, ttl time.Duration) (string, bool) {
 	c.secretsMutex.RLock()
 	defer c.secretsMutex.RUnlock()
 
 	co, ok := c.secrets[loc]
-	if ok && time.Now().UTC().Before(co.Expires) {
+	if ok && time.Since(co.Stored) < ttl {
 		return co.Secret, true
 	}
 
-	delete(c.secrets, loc)
 	return "", false
 }
 
-// Store creates a secret in the cache and set an expiration on it.
-func (c *cache) Store(loc string, secret string) {
+// Store creates a secret in the cache.
+func (c *MemoryCache) Store(ctx context.Context, loc string, secret string) {
 	c.secretsMutex.Lock()
 	defer c.secretsMutex.Unlock()
 
+	if _, ok := c.secrets[loc]; !ok && c.maxEntries > 0 && len(c.secrets) >= c.maxEntries {
+		c.evictOldest()
+	}
+
 	c.secrets[loc] = cacheObject{
-		secret,
-		time.Now().UTC().Add(secretCacheDuration),
+		Secret:    secret,
+		Stored:    time.Now().UTC(),
+		refreshAt: 0.6 + 0.2*rand.Float64(),
 	}
 }
 
+// evictOldest removes the least recently stored secret.  The caller must hold the lock.
+func (c *MemoryCache) evictOldest() {
+	var oldest string
+	var oldestStored time.Time
+	for loc, co := range c.secrets {
+		if oldest == "" || co.Stored.Before(oldestStored) {
+			oldest, oldestStored = loc, co.Stored
+		}
+	}
+	delete(c.secrets, oldest)
+}
+
+// Delete removes a secret from the cache.
+func (c *MemoryCache) Delete(ctx context.Context, loc string) {
+	c.secretsMutex.Lock()
+	defer c.secretsMutex.Unlock()
+
+	delete(c.secrets, loc)
+}
+
 // Reset resets the cache state to empty.
-func (c *cache) Reset() {
+func (c *MemoryCache) Reset(ctx context.Context) {
+	c.secretsMutex.Lock()
+	defer c.secretsMutex.Unlock()
+
 	c.secrets = map[string]cacheObject{}
 }
+
+// Refresh re-resolves the cached secrets that are due, i.e. whose age is past their
+// refresh point of ttl.  Secrets that fail to resolve are left to expire, so a provider
+// outage doesn't evict secrets that are still valid.
+func (c *MemoryCache) Refresh(ctx context.Context, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	var due []string
+	c.secretsMutex.RLock()
+	for loc, co := range c.secrets {
+		if time.Since(co.Stored) >= time.Duration(float64(ttl)*co.refreshAt) {
+			due = append(due, loc)
+		}
+	}
+	c.secretsMutex.RUnlock()
+
+	for _, loc := range due {
+		secret, err := FromLocation(loc).fetch(ctx)
+		if err != nil {
+			uclog.Warningf(ctx, "failed to refresh cached secret: %v", err)
+			continue
+		}
+		c.Store(ctx, loc, secret)
+	}
+}
+
+// Invalidate removes the secret at location from the package-wide cache, so that it is
+// fetched from its provider the next time it is resolved, even if it recently failed to
+// resolve.
+func Invalidate(ctx context.Context, location string) {
+	GetCache().Delete(ctx, location)
+	failures.clear(location)
+}
+
+// InvalidateAll empties the package-wide cache and forgets failures to resolve secrets.
+func InvalidateAll(ctx context.Context) {
+	GetCache().Reset(ctx)
+	failures.reset()
+}
+
+// StartRefresher re-resolves secrets in the package-wide cache in the background before
+// they expire, until ctx is done.  Each secret is refreshed after 60-80% of the cache
+// TTL, so services pick up rotated secrets without fetching every secret at once when
+// they expire.  Only caches with a Refresh method, such as MemoryCache, are refreshed.
+// An interval of 0 uses DefaultRefreshInterval.
+func StartRefresher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if r, ok := GetCache().(interface {
+					Refresh(ctx context.Context, ttl time.Duration)
+				}); ok {
+					r.Refresh(ctx, GetCacheTTL())
+				}
+			}
+		}
+	}()
+}
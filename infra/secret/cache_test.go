@@ -0,0 +1,85 @@
+package secret
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret/provider/file"
+)
+
+func TestCache_InvalidateAndRefresh(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(0)
+	defer SetCache(GetCache())
+	SetCache(c)
+
+	// the refresher resolves locations with their default provider, so root it at the
+	// same directory
+	root := t.TempDir()
+	t.Setenv(file.RootEnvKey, root)
+	pv := file.New().WithRoot(root)
+	s, err := NewStringWithProvider(ctx, "plex", "refresh-secret", "old", pv)
+	assert.NoError(t, err)
+	path := strings.TrimPrefix(s.Location(), file.Prefix)
+
+	value, err := s.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", value)
+
+	assert.NoError(t, pv.Save(ctx, path, "new"))
+	Invalidate(ctx, s.Location())
+	value, err = s.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", value)
+
+	// nothing is due with the default TTL
+	assert.NoError(t, pv.Save(ctx, path, "newer"))
+	c.Refresh(ctx, DefaultCacheTTL)
+	value, found := c.Get(ctx, s.Location(), DefaultCacheTTL)
+	assert.True(t, found)
+	assert.Equal(t, "new", value)
+
+	// every secret is due with a tiny TTL
+	c.Refresh(ctx, time.Nanosecond)
+	value, found = c.Get(ctx, s.Location(), DefaultCacheTTL)
+	assert.True(t, found)
+	assert.Equal(t, "newer", value)
+
+	// failures leave the cached secret in place
+	assert.NoError(t, pv.Delete(ctx, path))
+	c.Refresh(ctx, time.Nanosecond)
+	value, found = c.Get(ctx, s.Location(), DefaultCacheTTL)
+	assert.True(t, found)
+	assert.Equal(t, "newer", value)
+}
+
+func TestCache_Scope(t *testing.T) {
+	ctx := context.Background()
+
+	c := NewMemoryCache(2)
+	c.Store(ctx, "a", "1")
+	c.Store(ctx, "b", "2")
+	c.Store(ctx, "c", "3")
+	_, found := c.Get(ctx, "a", time.Hour)
+	assert.False(t, found)
+	_, found = c.Get(ctx, "c", time.Hour)
+	assert.True(t, found)
+
+	// secrets with their own cache don't use the package-wide one
+	s := FromLocation("dev-literal://not-actually-secret").WithCache(NoCache)
+	value, err := s.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "not-actually-secret", value)
+	_, found = GetCache().Get(ctx, s.Location(), time.Hour)
+	assert.False(t, found)
+
+	own := NewMemoryCache(0)
+	_, err = s.WithCache(own).Resolve(ctx)
+	assert.NoError(t, err)
+	_, found = own.Get(ctx, s.Location(), time.Hour)
+	assert.True(t, found)
+}
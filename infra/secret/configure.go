@@ -0,0 +1,65 @@
+package secret
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"userclouds.com/infra/secret/provider"
+	"userclouds.com/infra/ucerr"
+)
+
+// providerBox wraps the configured provider, since atomic.Value requires a consistent type.
+type providerBox struct {
+	provider.Interface
+}
+
+var configuredProvider atomic.Value
+
+// Configure sets the provider of the service from its config, see SetProvider.  A nil
+// config goes back to the provider selected by UC_SECRET_MANAGER.
+func Configure(cfg *provider.Config) error {
+	if cfg == nil {
+		SetProvider(nil)
+		return nil
+	}
+
+	pv, err := provider.FromConfig(cfg)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	SetProvider(pv)
+	return nil
+}
+
+// SetProvider sets the provider that NewString and NewMap save secrets with, instead of
+// the one selected by UC_SECRET_MANAGER.  Secrets whose location starts with its prefix
+// resolve with it too, so that its settings, such as the AWS region or role, apply to
+// the secrets in the service's config.  A nil provider goes back to the environment.
+func SetProvider(pv provider.Interface) {
+	configuredProvider.Store(providerBox{pv})
+}
+
+// getProvider returns the provider set with SetProvider or Configure, or the one
+// selected by the environment.
+func getProvider() (provider.Interface, error) {
+	if box, ok := configuredProvider.Load().(providerBox); ok && box.Interface != nil {
+		return box.Interface, nil
+	}
+
+	pv, err := provider.FromEnv()
+	return pv, ucerr.Wrap(err)
+}
+
+// providerForLocation returns the configured provider if the location is one of its
+// secrets, or a provider for the location's prefix otherwise.
+func providerForLocation(location string) (provider.Interface, error) {
+	if box, ok := configuredProvider.Load().(providerBox); ok && box.Interface != nil {
+		if strings.HasPrefix(location, box.Prefix()) {
+			return box.Interface, nil
+		}
+	}
+
+	pv, err := provider.FromLocation(location)
+	return pv, ucerr.Wrap(err)
+}
@@ -0,0 +1,38 @@
+package secret
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret/provider"
+)
+
+func TestConfigure(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	defer SetProvider(nil)
+
+	assert.NoError(t, Configure(&provider.Config{Type: "file", Root: root}))
+	s, err := NewString(ctx, "plex", "configured-secret", "hunter2")
+	assert.NoError(t, err)
+	assert.Equal(t, "file://"+filepath.Join(root, NewPath("plex", "configured-secret").String()), s.Location())
+
+	// secrets of the configured provider resolve with it, and others with their prefix
+	configured, err := getProvider()
+	assert.NoError(t, err)
+	pv, err := FromLocation(s.Location()).GetProvider()
+	assert.NoError(t, err)
+	assert.Same(t, configured, pv)
+	value, err := FromLocation(s.Location()).Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+	pv, err = FromLocation("env://OTHER").GetProvider()
+	assert.NoError(t, err)
+	assert.Equal(t, "env://", pv.Prefix())
+
+	assert.Error(t, Configure(&provider.Config{Type: "vault"}))
+	assert.NoError(t, Configure(nil))
+}
@@ -0,0 +1,103 @@
+package secret
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"userclouds.com/infra/ucerr"
+)
+
+const (
+	// DefaultMinFailureBackoff is how long a secret that failed to resolve isn't fetched
+	// again after its first failure.
+	DefaultMinFailureBackoff = time.Second
+
+	// DefaultMaxFailureBackoff caps the backoff of a secret that keeps failing to resolve.
+	DefaultMaxFailureBackoff = time.Minute
+)
+
+var (
+	minFailureBackoff atomic.Int64
+	maxFailureBackoff atomic.Int64
+
+	failures = &failureCache{failures: map[string]failure{}}
+)
+
+func init() {
+	SetFailureBackoff(DefaultMinFailureBackoff, DefaultMaxFailureBackoff)
+}
+
+// SetFailureBackoff sets how long secrets that failed to resolve aren't fetched again,
+// so that a missing secret or a provider outage doesn't make every request hit the
+// provider.  The backoff starts at minBackoff and doubles with each consecutive failure
+// up to maxBackoff.  A minBackoff of 0 disables backing off.
+func SetFailureBackoff(minBackoff, maxBackoff time.Duration) {
+	minFailureBackoff.Store(int64(max(minBackoff, 0)))
+	maxFailureBackoff.Store(int64(max(maxBackoff, minBackoff, 0)))
+}
+
+type failure struct {
+	err     error
+	count   int
+	retryAt time.Time
+}
+
+// failureCache remembers the secrets that failed to resolve, by location.
+type failureCache struct {
+	failures map[string]failure
+	mutex    sync.Mutex
+}
+
+// check returns an error if the secret at loc failed to resolve recently and shouldn't
+// be fetched again yet.
+func (f *failureCache) check(loc string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	fl, ok := f.failures[loc]
+	if !ok || !time.Now().Before(fl.retryAt) {
+		return nil
+	}
+
+	return ucerr.Errorf("secret failed to resolve %d times, not retrying until %s: %w", fl.count, fl.retryAt.Format(time.RFC3339), fl.err)
+}
+
+// record notes a failure to resolve the secret at loc and backs it off.
+func (f *failureCache) record(loc string, err error) {
+	minBackoff := time.Duration(minFailureBackoff.Load())
+	if minBackoff <= 0 {
+		return
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	fl := f.failures[loc]
+	fl.err = err
+	fl.count++
+
+	backoff := time.Duration(maxFailureBackoff.Load())
+	if fl.count < 32 {
+		backoff = min(minBackoff<<(fl.count-1), backoff)
+	}
+	fl.retryAt = time.Now().Add(backoff)
+
+	f.failures[loc] = fl
+}
+
+// clear forgets the failures of the secret at loc.
+func (f *failureCache) clear(loc string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	delete(f.failures, loc)
+}
+
+// reset forgets all failures.
+func (f *failureCache) reset() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.failures = map[string]failure{}
+}
@@ -0,0 +1,62 @@
+package secret
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret/provider/file"
+)
+
+func TestResolve_FailureBackoff(t *testing.T) {
+	ctx := context.Background()
+	InvalidateAll(ctx)
+	dir := t.TempDir()
+	pv := file.New().WithRoot(dir)
+	s := FromLocation(file.Prefix + filepath.Join(dir, "missing")).WithProvider(pv)
+
+	_, err := s.Resolve(ctx)
+	assert.Error(t, err)
+
+	// the secret isn't fetched again while backing off
+	assert.NoError(t, pv.Save(ctx, "missing", "found"))
+	_, err = s.Resolve(ctx)
+	assert.ErrorContains(t, err, "not retrying until")
+
+	// unless the cache is bypassed or invalidated
+	value, err := s.ResolveWithTTL(ctx, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "found", value)
+
+	assert.NoError(t, pv.Delete(ctx, "missing"))
+	_, err = s.Resolve(ctx)
+	assert.Error(t, err)
+	assert.NoError(t, pv.Save(ctx, "missing", "found"))
+	Invalidate(ctx, s.Location())
+	value, err = s.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "found", value)
+}
+
+func TestFailureCache_Backoff(t *testing.T) {
+	defer SetFailureBackoff(DefaultMinFailureBackoff, DefaultMaxFailureBackoff)
+	SetFailureBackoff(time.Minute, 3*time.Minute)
+
+	f := &failureCache{failures: map[string]failure{}}
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+		f.record("aws://secrets/missing", assert.AnError)
+		assert.WithinDuration(t, time.Now().Add(want), f.failures["aws://secrets/missing"].retryAt, time.Second)
+	}
+	assert.Error(t, f.check("aws://secrets/missing"))
+	assert.NoError(t, f.check("aws://secrets/other"))
+
+	f.clear("aws://secrets/missing")
+	assert.NoError(t, f.check("aws://secrets/missing"))
+
+	SetFailureBackoff(0, 0)
+	f.record("aws://secrets/missing", assert.AnError)
+	assert.NoError(t, f.check("aws://secrets/missing"))
+}
@@ -0,0 +1,50 @@
+package secret
+
+import (
+	"encoding/json"
+	"strings"
+
+	"userclouds.com/infra/secret/provider"
+	"userclouds.com/infra/ucerr"
+)
+
+// FieldSeparator separates the location of a JSON-valued secret from the field that is
+// selected from it, e.g. aws://secrets/userclouds/prod/db-creds#password, so that
+// credential bundles stored as a single secret can be referenced field by field.
+const FieldSeparator = "#"
+
+// splitField returns the provider path and selected field of a path.  The path is split
+// at the last '#', except for dev secrets, whose path is the secret itself.
+func splitField(pv provider.Interface, path string) (string, string) {
+	if pv.IsDev() {
+		return path, ""
+	}
+
+	i := strings.LastIndex(path, FieldSeparator)
+	if i <= 0 || i == len(path)-1 {
+		return path, ""
+	}
+
+	return path[:i], path[i+1:]
+}
+
+// selectField returns a top-level field of a JSON object secret.  String fields are
+// returned unquoted, and other fields as JSON.
+func selectField(value, field string) (string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", ucerr.Errorf("secret isn't a JSON object, so its field '%s' can't be selected", field)
+	}
+
+	raw, ok := fields[field]
+	if !ok {
+		return "", ucerr.Errorf("secret has no field '%s'", field)
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+
+	return string(raw), nil
+}
@@ -0,0 +1,47 @@
+package secret
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret/provider/file"
+)
+
+func TestString_Field(t *testing.T) {
+	ctx := context.Background()
+	InvalidateAll(ctx)
+	pv := file.New().WithRoot(t.TempDir())
+
+	creds, err := NewStringWithProvider(ctx, "db", "creds", `{"username":"app","password":"hunter2","port":5432}`, pv)
+	assert.NoError(t, err)
+
+	password := FromLocation(creds.Location() + "#password").WithProvider(pv)
+	v, err := password.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", v)
+
+	port := FromLocation(creds.Location() + "#port").WithProvider(pv)
+	v, err = port.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "5432", v)
+
+	_, err = FromLocation(creds.Location() + "#missing").WithProvider(pv).Resolve(ctx)
+	assert.ErrorContains(t, err, "no field 'missing'")
+
+	plain, err := NewStringWithProvider(ctx, "db", "plain", "not json", pv)
+	assert.NoError(t, err)
+	_, err = FromLocation(plain.Location() + "#password").WithProvider(pv).Resolve(ctx)
+	assert.Error(t, err)
+
+	// the whole secret is changed through its location without the field
+	assert.Error(t, password.Update(ctx, "new"))
+	assert.Error(t, password.Delete(ctx))
+
+	// dev secrets are the value itself, which may contain '#'
+	ts := NewTestString("pass#word")
+	v, err = ts.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "pass#word", v)
+}
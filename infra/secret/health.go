@@ -0,0 +1,25 @@
+package secret
+
+import (
+	"context"
+
+	"userclouds.com/infra/secret/provider"
+	"userclouds.com/infra/ucerr"
+)
+
+// CheckProviders checks that the backend of the secret provider configured for the
+// service (see SetProvider) is reachable and usable by the service, so that
+// services can include it in their readiness probes and fail fast if they are
+// misconfigured, e.g. missing IAM permissions or RBAC bindings.
+func CheckProviders(ctx context.Context) error {
+	pv, err := getProvider()
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if err := provider.Ping(ctx, pv); err != nil {
+		return ucerr.Errorf("secret provider %s is unhealthy: %w", pv.Prefix(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package secret
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret/provider"
+	"userclouds.com/infra/secret/provider/file"
+)
+
+func TestCheckProviders(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+
+	t.Setenv(provider.SecretManagerEnvKey, "file")
+	t.Setenv(file.RootEnvKey, root)
+	assert.NoError(t, CheckProviders(ctx))
+
+	t.Setenv(file.RootEnvKey, filepath.Join(root, "missing"))
+	assert.ErrorContains(t, CheckProviders(ctx), "secret provider file:// is unhealthy")
+
+	t.Setenv(provider.SecretManagerEnvKey, "unknown")
+	assert.Error(t, CheckProviders(ctx))
+}
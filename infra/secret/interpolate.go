@@ -0,0 +1,67 @@
+package secret
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// secretRef matches a ${secret:<location>} reference to a secret embedded in a config
+// value, or an escaped $${secret:<location>} that is kept as ${secret:<location>}.
+var secretRef = regexp.MustCompile(`\$?\$\{secret:([^}]*)\}`)
+
+// HasReferences returns true if s embeds ${secret:<location>} references.
+func HasReferences(s string) bool {
+	for _, m := range secretRef.FindAllString(s, -1) {
+		if !strings.HasPrefix(m, "$$") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Interpolate replaces the ${secret:<location>} references embedded in a config value,
+// such as postgres://app:${secret:aws://secrets/userclouds/prod/db/password}@db/app,
+// with the resolved secrets, so that connection strings don't have to be split into
+// separate secret fields.  Resolved values are inserted as is, without escaping.  Use
+// $${secret:<location>} for a literal ${secret:<location>}.
+func Interpolate(ctx context.Context, s string) (string, error) {
+	var errs []string
+	out := secretRef.ReplaceAllStringFunc(s, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+
+		location := secretRef.FindStringSubmatch(ref)[1]
+		value, err := resolveReference(ctx, location)
+		if err != nil {
+			errs = append(errs, ucerr.FirstLine(err))
+		}
+		return value
+	})
+
+	if len(errs) > 0 {
+		return "", ucerr.Errorf("failed to resolve %d secret references: %s", len(errs), strings.Join(errs, "; "))
+	}
+
+	return out, nil
+}
+
+// resolveReference resolves the location of a ${secret:<location>} reference, which must
+// point at a secret provider, since the location of a legacy secret is the secret itself.
+func resolveReference(ctx context.Context, location string) (string, error) {
+	s := FromLocation(location)
+	if s.IsEmpty() || !s.HasPrefix() {
+		return "", ucerr.Errorf("secret reference %s isn't a secret provider location", mask(location))
+	}
+
+	value, err := s.Resolve(ctx)
+	if err != nil {
+		return "", ucerr.Errorf("secret reference %s: %w", mask(location), err)
+	}
+
+	return value, nil
+}
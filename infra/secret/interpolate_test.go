@@ -0,0 +1,34 @@
+package secret
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolate(t *testing.T) {
+	ctx := context.Background()
+
+	v, err := Interpolate(ctx, "postgres://app:${secret:dev-literal://hunter2}@db:5432/app?user=${secret:dev://YXBw}")
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://app:hunter2@db:5432/app?user=app", v)
+
+	v, err = Interpolate(ctx, "no references")
+	assert.NoError(t, err)
+	assert.Equal(t, "no references", v)
+
+	// escaped references are kept
+	v, err = Interpolate(ctx, "$${secret:dev-literal://hunter2}")
+	assert.NoError(t, err)
+	assert.Equal(t, "${secret:dev-literal://hunter2}", v)
+	assert.False(t, HasReferences("$${secret:dev-literal://hunter2}"))
+	assert.True(t, HasReferences("a ${secret:dev-literal://hunter2} b"))
+
+	_, err = Interpolate(ctx, "${secret:plaintext}")
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "plaintext")
+
+	_, err = Interpolate(ctx, "${secret:unknown://foo} ${secret:}")
+	assert.ErrorContains(t, err, "failed to resolve 2 secret references")
+}
@@ -0,0 +1,52 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync/atomic"
+
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+// StrictPrefixEnvKey enables strict mode when set to true, see SetStrictPrefix.
+const StrictPrefixEnvKey = "UC_SECRET_STRICT_PREFIX"
+
+var strictPrefix atomic.Bool
+
+// init enables strict mode from UC_SECRET_STRICT_PREFIX.  Values that aren't booleans
+// leave it disabled rather than being reported, as logging isn't configured yet.
+func init() {
+	if strict, err := strconv.ParseBool(os.Getenv(StrictPrefixEnvKey)); err == nil {
+		SetStrictPrefix(strict)
+	}
+}
+
+// SetStrictPrefix sets whether secrets must be stored in a provider.  By default,
+// locations without a prefix are legacy secrets whose location is the secret itself,
+// such as values in older tenant database rows, and resolve to themselves.  In strict
+// mode they fail to resolve and validate instead, e.g. for production services that
+// should never see raw secrets.
+func SetStrictPrefix(strict bool) {
+	strictPrefix.Store(strict)
+}
+
+// resolveUnprefixed resolves a legacy secret without a prefix to its location.
+func (s *String) resolveUnprefixed(ctx context.Context) (string, error) {
+	if err := s.checkUnprefixed(); err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	uclog.Debugf(ctx, "resolving unprefixed legacy secret %s as is", mask(s.location))
+	return s.location, nil
+}
+
+// checkUnprefixed returns an error for legacy secrets without a prefix in strict mode.
+func (s String) checkUnprefixed() error {
+	if strictPrefix.Load() {
+		return ucerr.Errorf("secret %s has no provider prefix, which isn't allowed when %s is set", mask(s.location), StrictPrefixEnvKey)
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package secret
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestString_Unprefixed(t *testing.T) {
+	ctx := context.Background()
+	s := FromLocation("legacy-raw-value")
+
+	v, err := s.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy-raw-value", v)
+	assert.NoError(t, s.Validate())
+
+	SetStrictPrefix(true)
+	defer SetStrictPrefix(false)
+
+	_, err = s.Resolve(ctx)
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "legacy-raw-value")
+	assert.Error(t, s.Validate())
+
+	// empty and prefixed secrets are unaffected
+	v, err = FromLocation("").Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, v)
+	ts := NewTestString("value")
+	v, err = ts.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", v)
+}
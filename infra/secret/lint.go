@@ -0,0 +1,158 @@
+package secret
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"userclouds.com/infra/namespace/universe"
+	"userclouds.com/infra/secret/prefix"
+	"userclouds.com/infra/ucerr"
+)
+
+// LintIssue is a secret in a config that shouldn't be used in the universe it was
+// linted for.  It never includes the value of the secret.
+type LintIssue struct {
+	Path    string // the field of the secret, e.g. db.password or clients[0].secret
+	Message string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Path, i.Message)
+}
+
+// devPrefixes are the prefixes of secrets that are only meant for development.
+var devPrefixes = []prefix.Prefix{prefix.PrefixDev, prefix.PrefixDevLiteral, prefix.PrefixDevFile}
+
+// secretKeyRegex matches the YAML keys that LintConfigYAML treats as secrets.
+var secretKeyRegex = regexp.MustCompile(`(?i)(secret|password|passwd|token|api_?key|private_?key)$`)
+
+var stringType = reflect.TypeFor[String]()
+
+// LintConfig returns the secret.String fields of cfg, which is usually a pointer to a
+// service config struct, that use a development-only provider or hold a plaintext
+// secret.  Only cloud universes (prod, staging and debug) are linted, others return no
+// issues.
+func LintConfig(cfg any, u universe.Universe) []LintIssue {
+	if !u.IsCloud() {
+		return nil
+	}
+
+	var issues []LintIssue
+	lintValue(reflect.ValueOf(cfg), "", &issues)
+	return issues
+}
+
+// LintConfigYAML lints a YAML config like LintConfig, for configs without a Go type.
+// Since it can't tell which fields are secrets, any value using a development-only
+// provider is reported, while plaintext and unknown prefixes are only reported for
+// keys named like secrets, e.g. client_secret, password or api_key.
+func LintConfigYAML(data []byte, u universe.Universe) ([]LintIssue, error) {
+	var cfg any
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if !u.IsCloud() {
+		return nil, nil
+	}
+
+	var issues []LintIssue
+	lintYAML(cfg, "", false, &issues)
+	return issues, nil
+}
+
+func lintValue(v reflect.Value, path string, issues *[]LintIssue) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			lintValue(v.Elem(), path, issues)
+		}
+	case reflect.Struct:
+		if v.Type() == stringType {
+			lintLocation(v.FieldByName("location").String(), path, true, issues)
+			return
+		}
+
+		for i := range v.NumField() {
+			if f := v.Type().Field(i); f.IsExported() {
+				lintValue(v.Field(i), joinLintPath(path, fieldName(f)), issues)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			lintValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), issues)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+		})
+		for _, k := range keys {
+			lintValue(v.MapIndex(k), joinLintPath(path, fmt.Sprint(k)), issues)
+		}
+	}
+}
+
+func lintYAML(v any, path string, isSecret bool, issues *[]LintIssue) {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			lintYAML(v[k], joinLintPath(path, k), secretKeyRegex.MatchString(k), issues)
+		}
+	case []any:
+		for i, e := range v {
+			lintYAML(e, fmt.Sprintf("%s[%d]", path, i), isSecret, issues)
+		}
+	case string:
+		lintLocation(v, path, isSecret, issues)
+	}
+}
+
+// lintLocation reports a secret location that uses a development-only provider, or, for
+// known secrets, that isn't a provider location.
+func lintLocation(location, path string, isSecret bool, issues *[]LintIssue) {
+	if location == "" {
+		return
+	}
+
+	px, err := prefix.PrefixFromString(location)
+	switch {
+	case err == nil && slices.Contains(devPrefixes, px.Inner()):
+		*issues = append(*issues, LintIssue{Path: path, Message: fmt.Sprintf("uses the development-only %s provider", px)})
+	case !isSecret:
+		// values that aren't known to be secrets may be anything
+	case !strings.Contains(location, "://"):
+		*issues = append(*issues, LintIssue{Path: path, Message: "is a plaintext secret rather than a secret provider location"})
+	case err != nil:
+		*issues = append(*issues, LintIssue{Path: path, Message: "has an unknown secret provider prefix"})
+	}
+}
+
+// fieldName returns the name of a field in the config file, following its json or yaml tag.
+func fieldName(f reflect.StructField) string {
+	for _, key := range []string{"json", "yaml"} {
+		if name, _, _ := strings.Cut(f.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return f.Name
+}
+
+func joinLintPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
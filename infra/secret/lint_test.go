@@ -0,0 +1,84 @@
+package secret
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/namespace/universe"
+)
+
+type lintTestClient struct {
+	Name   string `json:"name"`
+	Secret String `json:"client_secret"`
+}
+
+type lintTestConfig struct {
+	DB struct {
+		Password *String `yaml:"password"`
+	} `yaml:"db"`
+	Clients  []lintTestClient  `json:"clients"`
+	Keys     map[string]String `json:"keys"`
+	APIKey   String            `json:"api_key"`
+	Optional *String           `json:"optional"`
+	internal String
+}
+
+func TestLintConfig(t *testing.T) {
+	cfg := lintTestConfig{
+		Clients: []lintTestClient{
+			{Name: "ok", Secret: *FromLocation("aws://secrets/userclouds/prod/plex/client")},
+			{Name: "dev", Secret: *FromLocation("dev://aHVudGVyMg==")},
+		},
+		Keys: map[string]String{
+			"b": *FromLocation("plaintext"),
+			"a": NewTestString("literal"),
+		},
+		APIKey:   *FromLocation("kube://secrets/userclouds/api-key"),
+		internal: *FromLocation("plaintext"),
+	}
+	cfg.DB.Password = FromLocation("https://example.com")
+
+	issues := LintConfig(&cfg, universe.Prod)
+	assert.Equal(t, []LintIssue{
+		{Path: "db.password", Message: "has an unknown secret provider prefix"},
+		{Path: "clients[1].client_secret", Message: "uses the development-only dev:// provider"},
+		{Path: "keys.a", Message: "uses the development-only dev-literal:// provider"},
+		{Path: "keys.b", Message: "is a plaintext secret rather than a secret provider location"},
+	}, issues)
+	for _, issue := range issues {
+		assert.NotContains(t, issue.String(), "aHVudGVyMg==")
+	}
+
+	assert.Empty(t, LintConfig(&cfg, universe.Dev))
+}
+
+func TestLintConfigYAML(t *testing.T) {
+	data := []byte(`
+db:
+  host: localhost
+  password: hunter2
+clients:
+  - name: dev
+    client_secret: dev-literal://hunter2
+  - name: prod
+    client_secret: aws://secrets/userclouds/prod/plex/client
+token_url: https://example.com/token
+other: dev://aHVudGVyMg==
+`)
+
+	issues, err := LintConfigYAML(data, universe.Staging)
+	assert.NoError(t, err)
+	assert.Equal(t, []LintIssue{
+		{Path: "clients[0].client_secret", Message: "uses the development-only dev-literal:// provider"},
+		{Path: "db.password", Message: "is a plaintext secret rather than a secret provider location"},
+		{Path: "other", Message: "uses the development-only dev:// provider"},
+	}, issues)
+
+	issues, err = LintConfigYAML(data, universe.Dev)
+	assert.NoError(t, err)
+	assert.Empty(t, issues)
+
+	_, err = LintConfigYAML([]byte("a: [b"), universe.Prod)
+	assert.Error(t, err)
+}
@@ -0,0 +1,208 @@
+package secret
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"strconv"
+
+	"userclouds.com/infra/secret/provider"
+	"userclouds.com/infra/ucerr"
+)
+
+// Map is a set of related secrets, such as a database credential set, stored as a JSON
+// object under a single location so that they are saved, rotated and resolved together.
+// Like String, it is serialized as its location and only resolved on demand.
+type Map struct {
+	s String
+}
+
+// Values are the resolved key/value pairs of a Map.
+type Values map[string]string
+
+// NewMap returns a new secret.Map that is stored according to the underlying provider,
+// like NewString.
+func NewMap(ctx context.Context, serviceName, name string, values map[string]string) (*Map, error) {
+	pv, err := getProvider()
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return NewMapWithProvider(ctx, serviceName, name, values, pv)
+}
+
+// NewMapWithProvider allows the definition of a provider that the map will be associated
+// with.
+func NewMapWithProvider(ctx context.Context, serviceName, name string, values map[string]string, pv provider.Interface) (*Map, error) {
+	if len(values) == 0 {
+		return &Map{}, nil
+	}
+
+	bs, err := json.Marshal(values)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	s, err := NewStringWithProvider(ctx, serviceName, name, string(bs), pv)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &Map{s: *s}, nil
+}
+
+// NewMapAtLocation stores the map at an explicit location, like NewStringAtLocation.
+func NewMapAtLocation(ctx context.Context, location string, values map[string]string) (*Map, error) {
+	bs, err := json.Marshal(values)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	s, err := NewStringAtLocation(ctx, location, string(bs))
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return &Map{s: *s}, nil
+}
+
+// MapFromLocation returns a new secret.Map with the specified location
+func MapFromLocation(location string) *Map {
+	return &Map{s: String{location: location}}
+}
+
+// Resolve returns the key/value pairs of the map.  Values stored as JSON numbers or
+// booleans, e.g. by the AWS console's key/value editor, are returned in their JSON form.
+func (m *Map) Resolve(ctx context.Context) (Values, error) {
+	raw, err := m.s.Resolve(ctx)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if raw == "" {
+		return Values{}, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		// don't include the error, which may quote the secret
+		return nil, ucerr.Errorf("secret map %s is not a JSON object", m.s.String())
+	}
+
+	values := make(Values, len(fields))
+	for k, v := range fields {
+		var s string
+		if err := json.Unmarshal(v, &s); err != nil {
+			s = string(v)
+		}
+		values[k] = s
+	}
+
+	return values, nil
+}
+
+// WithCache sets the cache that the map is resolved through, like String.WithCache.
+func (m *Map) WithCache(cache Cache) *Map {
+	m.s.WithCache(cache)
+	return m
+}
+
+// Get resolves the map and returns the value of a single key.
+func (m *Map) Get(ctx context.Context, key string) (string, error) {
+	values, err := m.Resolve(ctx)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	return values.String(key)
+}
+
+// String returns the value of key, or an error if the map doesn't have it.
+func (v Values) String(key string) (string, error) {
+	s, ok := v[key]
+	if !ok {
+		return "", ucerr.Errorf("secret map has no key '%s'", key)
+	}
+
+	return s, nil
+}
+
+// Int returns the value of key as an integer.
+func (v Values) Int(key string) (int, error) {
+	s, err := v.String(key)
+	if err != nil {
+		return 0, ucerr.Wrap(err)
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, ucerr.Errorf("secret map key '%s' is not an integer", key)
+	}
+
+	return i, nil
+}
+
+// Bool returns the value of key as a boolean.
+func (v Values) Bool(key string) (bool, error) {
+	s, err := v.String(key)
+	if err != nil {
+		return false, ucerr.Wrap(err)
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, ucerr.Errorf("secret map key '%s' is not a boolean", key)
+	}
+
+	return b, nil
+}
+
+// Delete removes the map from the secret store (if applicable) and clears the location
+func (m *Map) Delete(ctx context.Context) error {
+	return ucerr.Wrap(m.s.Delete(ctx))
+}
+
+// Location returns the location of the map
+func (m Map) Location() string {
+	return m.s.Location()
+}
+
+// IsEmpty checks if the map location is empty
+func (m Map) IsEmpty() bool {
+	return m.s.IsEmpty()
+}
+
+// Validate implements Validateable
+func (m Map) Validate() error {
+	return ucerr.Wrap(m.s.Validate())
+}
+
+// String implements Stringer to obscure the location when logged, like String.String
+func (m *Map) String() string {
+	return m.s.String()
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (m *Map) UnmarshalYAML(unmarshal func(any) error) error {
+	return ucerr.Wrap(m.s.UnmarshalYAML(unmarshal))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (m Map) MarshalText() ([]byte, error) {
+	return m.s.MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (m *Map) UnmarshalText(b []byte) error {
+	return ucerr.Wrap(m.s.UnmarshalText(b))
+}
+
+// Scan implements sql.Scanner
+func (m *Map) Scan(value any) error {
+	return ucerr.Wrap(m.s.Scan(value))
+}
+
+// Value implements sql.Valuer
+func (m Map) Value() (driver.Value, error) {
+	return m.s.Value()
+}
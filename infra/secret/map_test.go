@@ -0,0 +1,55 @@
+package secret
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+
+	"userclouds.com/infra/secret/provider/dev"
+)
+
+func TestMap(t *testing.T) {
+	ctx := context.Background()
+
+	m, err := NewMapWithProvider(ctx, "test", "db", map[string]string{"user": "uc", "password": "hunter2", "port": "5432"}, dev.New())
+	assert.NoError(t, err)
+
+	values, err := m.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, Values{"user": "uc", "password": "hunter2", "port": "5432"}, values)
+
+	port, err := values.Int("port")
+	assert.NoError(t, err)
+	assert.Equal(t, 5432, port)
+
+	_, err = values.Bool("user")
+	assert.ErrorContains(t, err, "is not a boolean")
+
+	_, err = m.Get(ctx, "missing")
+	assert.ErrorContains(t, err, "no key 'missing'")
+
+	// maps are serialized as their location, and JSON values that aren't strings resolve
+	// to their JSON form
+	var got struct {
+		DB Map `yaml:"db"`
+	}
+	assert.NoError(t, yaml.Unmarshal([]byte(`db: 'dev-literal://{"host":"db.internal","port":5432,"tls":true}'`), &got))
+	values, err = got.DB.Resolve(ctx)
+	assert.NoError(t, err)
+	tls, err := values.Bool("tls")
+	assert.NoError(t, err)
+	assert.True(t, tls)
+	port, err = values.Int("port")
+	assert.NoError(t, err)
+	assert.Equal(t, 5432, port)
+
+	bs, err := yaml.Marshal(got)
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), "dev-literal://")
+
+	_, err = MapFromLocation("dev-literal://not-json").Resolve(ctx)
+	assert.ErrorContains(t, err, "is not a JSON object")
+	assert.NotContains(t, err.Error(), "not-json")
+}
@@ -0,0 +1,101 @@
+package secret
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"userclouds.com/infra/secret/prefix"
+	"userclouds.com/infra/ucerr"
+)
+
+// MaskMode controls how a secret.String is rendered by String() (and by
+// MarshalText when redact-on-marshal is enabled).
+type MaskMode int32
+
+const (
+	// MaskModeLength renders one asterisk per character of the location.  This
+	// is the historical behavior and remains the default.
+	MaskModeLength MaskMode = iota
+	// MaskModeFixed renders a fixed-length placeholder regardless of the location
+	// length so that nothing about the secret leaks into logs.
+	MaskModeFixed
+	// MaskModePrefix reveals only the provider prefix of the location followed by the
+	// fixed placeholder (e.g. aws://secrets/********), which is useful for debugging
+	// which backend a secret is pointing at.  Unprefixed locations are rendered with the
+	// fixed placeholder alone.
+	MaskModePrefix
+)
+
+// MaskModeEnvKey sets the masking mode to length, fixed or prefix, see SetMaskMode.
+const MaskModeEnvKey = "UC_SECRET_MASK_MODE"
+
+var maskModeNames = map[string]MaskMode{
+	"length": MaskModeLength,
+	"fixed":  MaskModeFixed,
+	"prefix": MaskModePrefix,
+}
+
+// ParseMaskMode returns the masking mode with the name, as set in UC_SECRET_MASK_MODE.
+func ParseMaskMode(name string) (MaskMode, error) {
+	m, ok := maskModeNames[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return MaskModeLength, ucerr.Errorf("unknown secret mask mode '%s', expected length, fixed or prefix", name)
+	}
+
+	return m, nil
+}
+
+// FixedMask is the placeholder used by MaskModeFixed and MaskModePrefix.
+const FixedMask = "********"
+
+var (
+	maskMode        atomic.Int32
+	redactOnMarshal atomic.Bool
+)
+
+// init sets the masking mode from UC_SECRET_MASK_MODE.  Invalid values are ignored,
+// leaving the default mode, since logging isn't set up while packages are initialized.
+func init() {
+	if m, err := ParseMaskMode(os.Getenv(MaskModeEnvKey)); err == nil {
+		SetMaskMode(m)
+	}
+}
+
+// SetMaskMode sets the package-wide masking mode used by String().
+func SetMaskMode(m MaskMode) {
+	maskMode.Store(int32(m))
+}
+
+// GetMaskMode returns the package-wide masking mode.
+func GetMaskMode() MaskMode {
+	return MaskMode(maskMode.Load())
+}
+
+// SetRedactOnMarshal controls whether MarshalText emits the masked form of the
+// location instead of the location itself.  This should only be enabled in processes
+// that never need to round trip secrets through text (e.g. CLIs printing configs),
+// since the masked value can't be resolved.
+func SetRedactOnMarshal(redact bool) {
+	redactOnMarshal.Store(redact)
+}
+
+// mask renders a location according to the current package-wide masking mode.
+func mask(location string) string {
+	if location == "" {
+		return ""
+	}
+
+	switch GetMaskMode() {
+	case MaskModeFixed:
+		return FixedMask
+	case MaskModePrefix:
+		px, err := prefix.PrefixFromString(location)
+		if err != nil {
+			return FixedMask
+		}
+		return px.String() + FixedMask
+	default:
+		return strings.Repeat("*", len(location))
+	}
+}
@@ -0,0 +1,75 @@
+package secret
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestString_MaskModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     MaskMode
+		location string
+		output   string
+	}{
+		{"length", MaskModeLength, "aws://secrets/foo", "*****************"},
+		{"length empty", MaskModeLength, "", ""},
+		{"fixed", MaskModeFixed, "aws://secrets/foo", FixedMask},
+		{"fixed short", MaskModeFixed, "dev://Zm9v", FixedMask},
+		{"fixed empty", MaskModeFixed, "", ""},
+		{"prefix aws", MaskModePrefix, "aws://secrets/foo", "aws://secrets/" + FixedMask},
+		{"prefix kube", MaskModePrefix, "kube://secrets/foo", "kube://secrets/" + FixedMask},
+		{"prefix long", MaskModePrefix, "aws://secrets/a/much/longer/path", "aws://secrets/" + FixedMask},
+		{"prefix unprefixed", MaskModePrefix, "plaintext", FixedMask},
+		{"prefix empty", MaskModePrefix, "", ""},
+	}
+
+	defer SetMaskMode(MaskModeLength)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetMaskMode(tt.mode)
+			s := FromLocation(tt.location)
+			assert.Equal(t, tt.output, s.String())
+		})
+	}
+}
+
+func TestParseMaskMode(t *testing.T) {
+	m, err := ParseMaskMode("prefix")
+	assert.NoError(t, err)
+	assert.Equal(t, MaskModePrefix, m)
+
+	m, err = ParseMaskMode(" Fixed ")
+	assert.NoError(t, err)
+	assert.Equal(t, MaskModeFixed, m)
+
+	_, err = ParseMaskMode("stars")
+	assert.Error(t, err)
+
+	_, err = ParseMaskMode("prefix-fixed")
+	assert.Error(t, err)
+}
+
+func TestString_RedactOnMarshal(t *testing.T) {
+	s := testStruct{Secret: *FromLocation("aws://secrets/my-secret")}
+
+	defer SetMaskMode(MaskModeLength)
+	defer SetRedactOnMarshal(false)
+
+	bs, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"secret":"aws://secrets/my-secret"}`, string(bs))
+
+	SetRedactOnMarshal(true)
+	SetMaskMode(MaskModePrefix)
+	bs, err = json.Marshal(s)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"secret":"aws://secrets/********"}`, string(bs))
+
+	// sql values are never redacted
+	v, err := s.Secret.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "aws://secrets/my-secret", v)
+}
@@ -0,0 +1,126 @@
+package secret
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"userclouds.com/infra/secret/prefix"
+	"userclouds.com/infra/ucmetrics"
+)
+
+// ResolveResult is the outcome of resolving a secret.
+type ResolveResult string
+
+const (
+	// ResolveCacheHit means the secret was resolved from the cache.
+	ResolveCacheHit ResolveResult = "cache_hit"
+	// ResolveFetched means the secret was fetched from its provider.
+	ResolveFetched ResolveResult = "fetched"
+	// ResolveFailed means fetching the secret from its provider failed.
+	ResolveFailed ResolveResult = "error"
+	// ResolveBackedOff means the secret wasn't fetched since it failed recently.
+	ResolveBackedOff ResolveResult = "backed_off"
+)
+
+// ResolveObservation describes the resolution of a secret stored in a provider.  It
+// never includes the secret or its full location.
+type ResolveObservation struct {
+	// Provider is the prefix of the secret's location, e.g. aws://secrets/
+	Provider string
+	// PathPrefix is the path of the secret without its name, limited to three segments,
+	// e.g. userclouds/prod/plex.  It is empty for dev secrets, which are stored in the
+	// location itself.
+	PathPrefix string
+	Result     ResolveResult
+	Duration   time.Duration
+	Err        error
+}
+
+// Metrics observes secret resolution, e.g. to graph the health of secret providers.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	ObserveResolve(ctx context.Context, o ResolveObservation)
+}
+
+// MetricsFunc adapts a function to the Metrics interface.
+type MetricsFunc func(ctx context.Context, o ResolveObservation)
+
+// ObserveResolve implements Metrics
+func (f MetricsFunc) ObserveResolve(ctx context.Context, o ResolveObservation) {
+	f(ctx, o)
+}
+
+// metricsBox wraps the metrics, since atomic.Value requires a consistent type.
+type metricsBox struct {
+	Metrics
+}
+
+var metrics atomic.Value
+
+// SetMetrics sets the package-wide observer of secret resolution, or removes it if m is
+// nil.  See PrometheusMetrics for the standard one.
+func SetMetrics(m Metrics) {
+	metrics.Store(metricsBox{m})
+}
+
+// observeResolve reports the resolution of the secret at location, which started at
+// start, to the package-wide metrics if set.
+func observeResolve(ctx context.Context, location string, start time.Time, result ResolveResult, err error) {
+	box, ok := metrics.Load().(metricsBox)
+	if !ok || box.Metrics == nil {
+		return
+	}
+
+	provider, pathPrefix := resolveLabels(location)
+	box.ObserveResolve(ctx, ResolveObservation{
+		Provider:   provider,
+		PathPrefix: pathPrefix,
+		Result:     result,
+		Duration:   time.Since(start),
+		Err:        err,
+	})
+}
+
+// resolveLabels returns the provider and path prefix of a location, leaving out the
+// name of the secret to keep the label cardinality low.
+func resolveLabels(location string) (string, string) {
+	px, err := prefix.PrefixFromString(location)
+	if err != nil {
+		return "unknown", ""
+	}
+
+	if px == prefix.PrefixDev || px == prefix.PrefixDevLiteral {
+		return string(px), ""
+	}
+
+	segments := strings.Split(px.Value(location), "/")
+	segments = segments[:len(segments)-1]
+	if len(segments) > 3 {
+		segments = segments[:3]
+	}
+
+	return string(px), strings.Join(segments, "/")
+}
+
+// PrometheusMetrics returns Metrics that count resolutions by provider, path prefix
+// and result as uc_secret_resolves_total, and track the duration of fetches from the
+// providers as uc_secret_fetch_duration_seconds.
+func PrometheusMetrics() Metrics {
+	return prometheusMetrics()
+}
+
+var prometheusMetrics = sync.OnceValue(func() Metrics {
+	subsystem := ucmetrics.Subsystem("secret")
+	resolves := ucmetrics.CreateCounter(subsystem, "resolves_total", "The total number of secret resolutions", "provider", "path_prefix", "result")
+	fetchDuration := ucmetrics.CreateHistogram(subsystem, "fetch_duration_seconds", "Histogram of secret fetch durations from providers in seconds", "provider", "path_prefix", "result")
+
+	return MetricsFunc(func(ctx context.Context, o ResolveObservation) {
+		resolves.WithLabelValues(o.Provider, o.PathPrefix, string(o.Result)).Inc()
+		if o.Result == ResolveFetched || o.Result == ResolveFailed {
+			fetchDuration.WithLabelValues(o.Provider, o.PathPrefix, string(o.Result)).Observe(o.Duration.Seconds())
+		}
+	})
+})
@@ -0,0 +1,53 @@
+package secret
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	InvalidateAll(ctx)
+
+	var mu sync.Mutex
+	var observed []ResolveObservation
+	SetMetrics(MetricsFunc(func(ctx context.Context, o ResolveObservation) {
+		mu.Lock()
+		defer mu.Unlock()
+		observed = append(observed, o)
+	}))
+	defer SetMetrics(nil)
+
+	s := FromLocation("dev-literal://not-actually-secret")
+	for range 2 {
+		_, err := s.Resolve(ctx)
+		assert.NoError(t, err)
+	}
+	_, err := FromLocation("unknown://userclouds/prod/plex/client-secret").Resolve(ctx)
+	assert.Error(t, err)
+
+	assert.Len(t, observed, 3)
+	assert.Equal(t, "dev-literal://", observed[0].Provider)
+	assert.Empty(t, observed[0].PathPrefix)
+	assert.Equal(t, ResolveFetched, observed[0].Result)
+	assert.Equal(t, ResolveCacheHit, observed[1].Result)
+	assert.Equal(t, "unknown", observed[2].Provider)
+	assert.Equal(t, ResolveFailed, observed[2].Result)
+	assert.Error(t, observed[2].Err)
+}
+
+func TestResolveLabels(t *testing.T) {
+	for location, want := range map[string][2]string{
+		"aws://secrets/userclouds/prod/plex/client-secret":       {"aws://secrets/", "userclouds/prod/plex"},
+		"aws://secrets/userclouds/prod/plex/oidc/client-secret":  {"aws://secrets/", "userclouds/prod/plex"},
+		"aws://secrets/my-secret":                                {"aws://secrets/", ""},
+		"dev-literal://userclouds/prod/plex/not-actually-secret": {"dev-literal://", ""},
+		"not-a-location": {"unknown", ""},
+	} {
+		provider, pathPrefix := resolveLabels(location)
+		assert.Equal(t, want, [2]string{provider, pathPrefix}, location)
+	}
+}
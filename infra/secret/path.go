@@ -0,0 +1,69 @@
+package secret
+
+import (
+	"fmt"
+	"strings"
+
+	"userclouds.com/infra/namespace/universe"
+	"userclouds.com/infra/ucerr"
+)
+
+// PathRoot is the first segment of every secret path, used to namespace our secrets
+// in a customer's secret manager.
+const PathRoot = "userclouds"
+
+// Path is a universe-scoped secret path of the form userclouds/<universe>/<service>/<name>.
+// All path formatting should go through this type so that changes to the format (like
+// eventually dropping the universe segment) can be made in one place.
+type Path struct {
+	Universe universe.Universe
+	Service  string
+	Name     string
+}
+
+// NewPath returns a secret path for the service and name in the current universe.
+func NewPath(serviceName, name string) Path {
+	return Path{Universe: universe.Current(), Service: serviceName, Name: name}
+}
+
+// ParsePath parses a path produced by Path.String.  The name may itself contain slashes.
+func ParsePath(s string) (Path, error) {
+	parts := strings.SplitN(s, "/", 4)
+	if len(parts) != 4 || parts[0] != PathRoot {
+		return Path{}, ucerr.Errorf("invalid secret path %s, expected %s/<universe>/<service>/<name>", s, PathRoot)
+	}
+
+	p := Path{Universe: universe.Universe(parts[1]), Service: parts[2], Name: parts[3]}
+	if err := p.Validate(); err != nil {
+		return Path{}, ucerr.Wrap(err)
+	}
+
+	return p, nil
+}
+
+// String implements fmt.Stringer
+func (p Path) String() string {
+	// This used to differentiate paths between on-prem and the hosted cloud.  For OSS this
+	// isn't an issue anymore, so we just pass through the 'userclouds' prefixed path.
+	// TODO: We should look at getting rid of uv in the path.
+	// TODO: Question, should the uc prefix only be done when we are using external SM?  Currently
+	//   this is only used by LocationFromName which is only called from m2m/auth, so might be ok.
+	return fmt.Sprintf("%s/%s/%s/%s", PathRoot, p.Universe, p.Service, p.Name)
+}
+
+// Validate implements Validateable
+func (p Path) Validate() error {
+	if err := p.Universe.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if p.Service == "" || strings.Contains(p.Service, "/") {
+		return ucerr.Errorf("invalid secret path service %q", p.Service)
+	}
+
+	if p.Name == "" {
+		return ucerr.New("secret path name can't be empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package secret
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/namespace/universe"
+)
+
+func TestPath_String(t *testing.T) {
+	p := Path{Universe: universe.Prod, Service: "plex", Name: "client-secret"}
+	assert.Equal(t, "userclouds/prod/plex/client-secret", p.String())
+}
+
+func TestPath_Parse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		path  Path
+		valid bool
+	}{
+		{"valid", "userclouds/prod/plex/client-secret", Path{universe.Prod, "plex", "client-secret"}, true},
+		{"name with slashes", "userclouds/dev/idp/tenant/abc", Path{universe.Dev, "idp", "tenant/abc"}, true},
+		{"missing root", "prod/plex/client-secret", Path{}, false},
+		{"wrong root", "other/prod/plex/client-secret", Path{}, false},
+		{"too short", "userclouds/prod/plex", Path{}, false},
+		{"unknown universe", "userclouds/nope/plex/client-secret", Path{}, false},
+		{"empty service", "userclouds/prod//client-secret", Path{}, false},
+		{"empty name", "userclouds/prod/plex/", Path{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePath(tt.input)
+			if !tt.valid {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.path, p)
+			assert.Equal(t, tt.input, p.String())
+		})
+	}
+}
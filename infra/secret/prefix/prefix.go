@@ -1,6 +1,12 @@
 package prefix
 
-import "strings"
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"userclouds.com/infra/ucerr"
+)
 
 type PrefixError string
 
@@ -18,10 +24,18 @@ type Prefix string
 const (
 	// PrefixAWS tells secret that this string is in fact resolvable with AWS secret manager
 	// as opposed to other systems in the future, or just plaintext (for eg. dev)
-	// TODO: config linter in the future that ensures all secret.* fields are prefixed in prod configs?
+	// Configs can be checked for secrets that aren't in a provider with secret.LintConfig
 	PrefixAWS Prefix = "aws://secrets/"
+	// PrefixAzureKeyVault tells secret that this string is resolvable with Azure Key Vault
+	PrefixAzureKeyVault Prefix = "azkv://"
+	// PrefixChain tells us this secret is resolved by the chain of providers set in
+	// UC_SECRET_MANAGER, trying each of them in order
+	PrefixChain Prefix = "chain://"
 	// PrefixDev tells us this is a dev-only Base64 encoded secret
 	PrefixDev Prefix = "dev://"
+	// PrefixDevFile tells us this is a dev-only secret persisted in a local directory,
+	// ~/.userclouds/secrets by default
+	PrefixDevFile Prefix = "dev-file://"
 	// PrefixDevLiteral tells us this is dev-only and not obfuscated
 	// This exists separate from DevPrefix because sometimes it's useful
 	// to be able to read the secret in plaintext (eg. in ci.yaml files)
@@ -31,10 +45,16 @@ const (
 	PrefixKubernetes Prefix = "kube://secrets/"
 	// PrefixEnv tells us this is a secret from the environment variables
 	PrefixEnv Prefix = "env://"
+	// PrefixFile tells us this is a secret read from a file, e.g. a mounted volume
+	PrefixFile Prefix = "file://"
 )
 
 //go:generate genconstant Prefix
 
+// EncryptedPrefix is prepended to the prefix of another provider, e.g. enc+kube://secrets/,
+// for secrets that are encrypted before that provider stores them.
+const EncryptedPrefix = "enc+"
+
 // Matches is a poorly named function to check if a string starts with a prefix
 func (p Prefix) Matches(s string) bool {
 	return strings.HasPrefix(s, string(p))
@@ -49,12 +69,99 @@ func (p Prefix) String() string {
 	return string(p)
 }
 
+// Encrypted returns true for the prefixes of encrypted secrets, e.g. enc+kube://secrets/
+func (p Prefix) Encrypted() bool {
+	return strings.HasPrefix(string(p), EncryptedPrefix)
+}
+
+// Inner returns the prefix of the provider that stores an encrypted secret, or p itself
+// if the secret isn't encrypted.
+func (p Prefix) Inner() Prefix {
+	return Prefix(strings.TrimPrefix(string(p), EncryptedPrefix))
+}
+
+// PrefixFromString returns the built-in or registered prefix that s starts with, marked
+// as encrypted if s starts with enc+.
 func PrefixFromString(s string) (Prefix, error) {
+	if inner, ok := strings.CutPrefix(s, EncryptedPrefix); ok {
+		px, err := PrefixFromString(inner)
+		if err != nil || px.Encrypted() {
+			return "", ErrorPrefixInvalid
+		}
+		return EncryptedPrefix + px, nil
+	}
+
 	for _, prefix := range AllPrefixes {
 		if strings.HasPrefix(s, string(prefix)) {
 			return prefix, nil
 		}
 	}
 
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	for _, prefix := range registered {
+		if strings.HasPrefix(s, string(prefix)) {
+			return prefix, nil
+		}
+	}
+
 	return "", ErrorPrefixInvalid
 }
+
+var (
+	registeredMu sync.RWMutex
+	registered   []Prefix
+)
+
+// Register adds a prefix for a custom secret provider, which must be of the form
+// <name>:// and must not overlap with a known prefix.
+func Register(p Prefix) error {
+	name, found := strings.CutSuffix(string(p), "://")
+	if !found || name == "" || strings.Contains(name, "://") || strings.HasPrefix(name, EncryptedPrefix) {
+		return ucerr.Errorf("invalid secret prefix '%s', expected <name>://", p)
+	}
+
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	for _, known := range slices.Concat(AllPrefixes, registered) {
+		if strings.HasPrefix(string(known), string(p)) || strings.HasPrefix(string(p), string(known)) {
+			return ucerr.Errorf("secret prefix '%s' overlaps with the known prefix '%s'", p, known)
+		}
+	}
+
+	registered = append(registered, p)
+	return nil
+}
+
+// Registered returns true if the prefix was added with Register rather than being built in.
+func Registered(p Prefix) bool {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	return slices.Contains(registered, p)
+}
+
+// Prefixes returns the built-in prefixes in AllPrefixes, followed by the registered ones
+// in the order they were registered.
+func Prefixes() []Prefix {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	return slices.Concat(AllPrefixes, registered)
+}
+
+// Validate checks that p is a built-in or registered prefix, or the encrypted form of one.
+// Prefix.Validate is generated from the built-in constants, so it rejects the others.
+func Validate(p Prefix) error {
+	if p.Encrypted() {
+		inner := p.Inner()
+		if inner.Encrypted() {
+			return ucerr.Wrap(ErrorPrefixInvalid)
+		}
+		return ucerr.Wrap(Validate(inner))
+	}
+
+	if Registered(p) {
+		return nil
+	}
+
+	return ucerr.Wrap(p.Validate())
+}
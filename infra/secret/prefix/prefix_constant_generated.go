@@ -9,12 +9,20 @@ func (t Prefix) MarshalText() ([]byte, error) {
 	switch t {
 	case PrefixAWS:
 		return []byte("aws://secrets/"), nil
+	case PrefixAzureKeyVault:
+		return []byte("azkv://"), nil
+	case PrefixChain:
+		return []byte("chain://"), nil
 	case PrefixDev:
 		return []byte("dev://"), nil
+	case PrefixDevFile:
+		return []byte("dev-file://"), nil
 	case PrefixDevLiteral:
 		return []byte("dev-literal://"), nil
 	case PrefixEnv:
 		return []byte("env://"), nil
+	case PrefixFile:
+		return []byte("file://"), nil
 	case PrefixKubernetes:
 		return []byte("kube://secrets/"), nil
 	default:
@@ -28,12 +36,20 @@ func (t *Prefix) UnmarshalText(b []byte) error {
 	switch s {
 	case "aws://secrets/":
 		*t = PrefixAWS
+	case "azkv://":
+		*t = PrefixAzureKeyVault
+	case "chain://":
+		*t = PrefixChain
 	case "dev://":
 		*t = PrefixDev
+	case "dev-file://":
+		*t = PrefixDevFile
 	case "dev-literal://":
 		*t = PrefixDevLiteral
 	case "env://":
 		*t = PrefixEnv
+	case "file://":
+		*t = PrefixFile
 	case "kube://secrets/":
 		*t = PrefixKubernetes
 	default:
@@ -47,12 +63,20 @@ func (t *Prefix) Validate() error {
 	switch *t {
 	case PrefixAWS:
 		return nil
+	case PrefixAzureKeyVault:
+		return nil
+	case PrefixChain:
+		return nil
 	case PrefixDev:
 		return nil
+	case PrefixDevFile:
+		return nil
 	case PrefixDevLiteral:
 		return nil
 	case PrefixEnv:
 		return nil
+	case PrefixFile:
+		return nil
 	case PrefixKubernetes:
 		return nil
 	default:
@@ -64,9 +88,13 @@ func (t *Prefix) Validate() error {
 func (t Prefix) Enum() []any {
 	return []any{
 		"aws://secrets/",
+		"azkv://",
+		"chain://",
 		"dev://",
+		"dev-file://",
 		"dev-literal://",
 		"env://",
+		"file://",
 		"kube://secrets/",
 	}
 }
@@ -74,8 +102,12 @@ func (t Prefix) Enum() []any {
 // AllPrefixes is a slice of all Prefix values
 var AllPrefixes = []Prefix{
 	PrefixAWS,
+	PrefixAzureKeyVault,
+	PrefixChain,
 	PrefixDev,
+	PrefixDevFile,
 	PrefixDevLiteral,
 	PrefixEnv,
+	PrefixFile,
 	PrefixKubernetes,
 }
@@ -48,6 +48,7 @@ func TestPrefix_Values(t *testing.T) {
 		{"dev secret path", "dev://my-secret", "my-secret"},
 		{"dev-literal secret path", "dev-literal://my-secret", "my-secret"},
 		{"longer path", "aws://secrets/path-to/my-secret", "path-to/my-secret"},
+		{"encrypted secret path", "enc+kube://secrets/my-secret", "my-secret"},
 	}
 
 	for _, tt := range tests {
@@ -71,6 +72,7 @@ func TestPrefix_PrefixFromString(t *testing.T) {
 		{"env secret", "env://my-secret", PrefixEnv},
 		{"dev secret", "dev://my-secret", PrefixDev},
 		{"dev-literal", "dev-literal://my-secret", PrefixDevLiteral},
+		{"encrypted secret", "enc+kube://secrets/my-secret", "enc+kube://secrets/"},
 	}
 
 	for _, tt := range tests {
@@ -80,4 +82,48 @@ func TestPrefix_PrefixFromString(t *testing.T) {
 			assert.Equal(t, tt.output, prefix)
 		})
 	}
+
+	_, err := PrefixFromString("enc+enc+kube://secrets/my-secret")
+	assert.ErrorIs(t, err, ErrorPrefixInvalid)
+	_, err = PrefixFromString("enc+my-secret")
+	assert.ErrorIs(t, err, ErrorPrefixInvalid)
+}
+
+func TestPrefix_Encrypted(t *testing.T) {
+	px := Prefix("enc+kube://secrets/")
+	assert.True(t, px.Encrypted())
+	assert.Equal(t, PrefixKubernetes, px.Inner())
+	assert.False(t, PrefixKubernetes.Encrypted())
+	assert.Equal(t, PrefixKubernetes, PrefixKubernetes.Inner())
+}
+
+func TestPrefix_Register(t *testing.T) {
+	assert.Error(t, Register("registertest"))
+	assert.Error(t, Register("enc+registertest://"))
+	assert.Error(t, Register("kube://"))
+
+	assert.Error(t, Validate("registertest://"))
+	_, err := PrefixFromString("registertest://my-secret")
+	assert.ErrorIs(t, err, ErrorPrefixInvalid)
+
+	assert.NoError(t, Register("registertest://"))
+	assert.Error(t, Register("registertest://"))
+	assert.True(t, Registered("registertest://"))
+	assert.Contains(t, Prefixes(), Prefix("registertest://"))
+	assert.NoError(t, Validate("registertest://"))
+	assert.NoError(t, Validate("enc+registertest://"))
+
+	px, err := PrefixFromString("registertest://my-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, Prefix("registertest://"), px)
+	assert.Equal(t, "my-secret", px.Value("registertest://my-secret"))
+
+	px, err = PrefixFromString("enc+registertest://my-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, Prefix("enc+registertest://"), px)
+
+	assert.NoError(t, Validate(PrefixKubernetes))
+	assert.NoError(t, Validate("enc+kube://secrets/"))
+	assert.Error(t, Validate("enc+enc+kube://secrets/"))
+	assert.Error(t, Validate("not-a-secret"))
 }
@@ -0,0 +1,64 @@
+package aws
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"userclouds.com/infra/ucerr"
+)
+
+const (
+	// MaxSecretNameLength is the maximum length of a secrets manager secret name.
+	MaxSecretNameLength = 512
+)
+
+var (
+	validNameRegex        = regexp.MustCompile(`^[a-zA-Z0-9/_+=.@-]+$`)
+	invalidNameCharsRegex = regexp.MustCompile(`[^a-zA-Z0-9/_+=.@-]+`)
+
+	// regionRegex matches AWS region names, e.g. us-west-2 or us-gov-east-1
+	regionRegex = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+)
+
+// splitRegion returns the region that a path is qualified with, if its first segment
+// is a region name, and the name of the secret.
+func splitRegion(path string) (string, string) {
+	region, name, ok := strings.Cut(path, "/")
+	if !ok || name == "" || !regionRegex.MatchString(region) {
+		return "", path
+	}
+
+	return region, name
+}
+
+// ValidatePath checks that the path is a valid secrets manager secret name, optionally
+// qualified with a region.  The returned error includes a sanitized name that can be
+// used instead.
+func (p *Provider) ValidatePath(path string) error {
+	_, path = splitRegion(path)
+
+	var reason string
+	switch {
+	case path == "":
+		return ucerr.Errorf("secret name must not be empty")
+	case len(path) > MaxSecretNameLength:
+		reason = fmt.Sprintf("must be no more than %d characters", MaxSecretNameLength)
+	case !validNameRegex.MatchString(path):
+		reason = "must only contain alphanumeric characters and /_+=.@-"
+	default:
+		return nil
+	}
+
+	return ucerr.Errorf("secret name '%s' is not valid for AWS secrets manager: %s (try '%s')", path, reason, SanitizeName(path))
+}
+
+// SanitizeName converts a path into a name that is valid for a secrets manager secret.
+func SanitizeName(path string) string {
+	name := invalidNameCharsRegex.ReplaceAllString(path, "-")
+	if len(name) > MaxSecretNameLength {
+		name = name[:MaxSecretNameLength]
+	}
+
+	return name
+}
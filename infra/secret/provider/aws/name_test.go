@@ -0,0 +1,56 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAWS_ValidatePath(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		valid     bool
+		sanitized string
+	}{
+		{"simple", "userclouds/test/plex/my-secret", true, ""},
+		{"allowed symbols", "a/b_c+d=e.f@g-h", true, ""},
+		{"region", "us-east-1/userclouds/test/plex/my-secret", true, ""},
+		{"empty", "", false, ""},
+		{"spaces", "userclouds/test/my secret", false, "userclouds/test/my-secret"},
+		{"colon", "userclouds/test:secret", false, "userclouds/test-secret"},
+		{"too long", strings.Repeat("a", MaxSecretNameLength+1), false, strings.Repeat("a", MaxSecretNameLength)},
+	}
+
+	p := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.ValidatePath(tt.path)
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Error(t, err)
+			if tt.sanitized != "" {
+				assert.Equal(t, tt.sanitized, SanitizeName(tt.path))
+				assert.NoError(t, p.ValidatePath(SanitizeName(tt.path)))
+				assert.Contains(t, err.Error(), tt.sanitized)
+			}
+		})
+	}
+}
+
+func TestAWS_splitRegion(t *testing.T) {
+	for path, want := range map[string][2]string{
+		"us-east-1/userclouds/test/my-secret":  {"us-east-1", "userclouds/test/my-secret"},
+		"us-gov-west-1/my-secret":              {"us-gov-west-1", "my-secret"},
+		"userclouds/test/my-secret":            {"", "userclouds/test/my-secret"},
+		"us-east-1":                            {"", "us-east-1"},
+		"cross-service-auth-token/us-east-1/x": {"", "cross-service-auth-token/us-east-1/x"},
+	} {
+		region, name := splitRegion(path)
+		assert.Equal(t, want, [2]string{region, name}, path)
+	}
+}
@@ -5,10 +5,19 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/gofrs/uuid"
 
 	"userclouds.com/infra/namespace/universe"
 	"userclouds.com/infra/ucaws"
@@ -19,26 +28,135 @@ import (
 const (
 	Prefix                            = "aws://secrets/"
 	DefaultSecretRecoveryWindowInDays = 7
+
+	// MaxBatchSize is the maximum number of secrets that GetBatch retrieves per call.
+	MaxBatchSize = 20
+
+	// RoleARNEnvKey is the IAM role to assume before calling Secrets Manager, e.g. to
+	// access secrets in another account.
+	RoleARNEnvKey = "UC_AWS_SECRETS_ROLE_ARN"
+	// ExternalIDEnvKey is the external ID required by the trust policy of the role.
+	ExternalIDEnvKey = "UC_AWS_SECRETS_EXTERNAL_ID"
+	// ReplicaRegionsEnvKey is a comma separated list of regions that created secrets are
+	// replicated to, and that secrets are read from if their primary region fails.
+	ReplicaRegionsEnvKey = "UC_AWS_SECRETS_REPLICA_REGIONS"
+	// KMSKeyIDEnvKey is the customer managed KMS key (ID, ARN or alias) that created
+	// secrets are encrypted with, which is usually set per universe.
+	KMSKeyIDEnvKey = "UC_AWS_SECRETS_KMS_KEY_ID"
+	// TagsEnvKey is a comma separated list of key=value tags added to created secrets,
+	// e.g. team=platform,cost-center=1234.
+	TagsEnvKey = "UC_AWS_SECRETS_TAGS"
+
+	roleSessionName = "userclouds-secrets"
+
+	// the stages of the current and previous versions of secrets
+	stageCurrent  = "AWSCURRENT"
+	stagePrevious = "AWSPREVIOUS"
 )
 
 // Provider is a SecretProvider implementation for AWS resources.
 type Provider struct {
-	client Client
-	region string
+	mu      sync.Mutex
+	cfg     *aws.Config       // loaded with the first client
+	clients map[string]Client // by region, "" for the default region
+
+	region     string
+	roleARN    string
+	externalID string
+	replicas   []string
+	kmsKeyID   string
+	tags       map[string]string
+}
+
+type kmsKeyIDKey struct{}
+
+// ContextWithKMSKey returns a context whose secrets are saved encrypted with the KMS
+// key kmsKeyID, overriding the key of the provider.
+func ContextWithKMSKey(ctx context.Context, kmsKeyID string) context.Context {
+	return context.WithValue(ctx, kmsKeyIDKey{}, kmsKeyID)
 }
 
-// New returns an initialized provider.
-// TODO: need to turn on multi-region replication for secret manager
+// New returns an initialized provider, which assumes the role in UC_AWS_SECRETS_ROLE_ARN
+// and replicates secrets to UC_AWS_SECRETS_REPLICA_REGIONS if they are set.
 // TODO: need to turn on secret rotation
 // TODO: need to audit which creds have access to which secrets
 func New() *Provider {
-	return &Provider{}
+	var replicas []string
+	for _, region := range strings.Split(os.Getenv(ReplicaRegionsEnvKey), ",") {
+		if region = strings.TrimSpace(region); region != "" {
+			replicas = append(replicas, region)
+		}
+	}
+
+	return &Provider{
+		clients:    map[string]Client{},
+		roleARN:    os.Getenv(RoleARNEnvKey),
+		externalID: os.Getenv(ExternalIDEnvKey),
+		replicas:   replicas,
+		kmsKeyID:   os.Getenv(KMSKeyIDEnvKey),
+		tags:       parseTags(os.Getenv(TagsEnvKey)),
+	}
 }
 
-// WithSecretsManagerClient overrides the client.  This is generally used
-// for testing purposes.
+// WithTags adds tags to the secrets that the provider creates, in addition to the tags
+// in UC_AWS_SECRETS_TAGS.  Tags with the same key replace those from the environment.
+func (p *Provider) WithTags(tags map[string]string) *Provider {
+	if p.tags == nil {
+		p.tags = map[string]string{}
+	}
+	maps.Copy(p.tags, tags)
+	return p
+}
+
+// WithKMSKey sets the customer managed KMS key that saved secrets are encrypted with,
+// overriding UC_AWS_SECRETS_KMS_KEY_ID.  An empty key uses the aws/secretsmanager key.
+func (p *Provider) WithKMSKey(kmsKeyID string) *Provider {
+	p.kmsKeyID = kmsKeyID
+	return p
+}
+
+// WithReplicaRegions sets the regions that created secrets are replicated to, and that
+// secrets are read from if their primary region fails, overriding
+// UC_AWS_SECRETS_REPLICA_REGIONS.
+func (p *Provider) WithReplicaRegions(regions ...string) *Provider {
+	p.replicas = regions
+	return p
+}
+
+// WithRegion sets the region of secrets whose path isn't qualified with one, overriding
+// the default region of the AWS config.
+func (p *Provider) WithRegion(region string) *Provider {
+	p.region = region
+	return p
+}
+
+// WithAssumeRole sets the IAM role to assume before calling Secrets Manager, overriding
+// UC_AWS_SECRETS_ROLE_ARN and UC_AWS_SECRETS_EXTERNAL_ID.  The external ID is optional.
+func (p *Provider) WithAssumeRole(roleARN, externalID string) *Provider {
+	p.roleARN = roleARN
+	p.externalID = externalID
+	return p
+}
+
+// WithSecretsManagerClient overrides the client of the default region.  This is generally
+// used for testing purposes.
 func (p *Provider) WithSecretsManagerClient(client Client) *Provider {
-	p.client = client
+	return p.WithRegionClient("", client)
+}
+
+// WithRegionClient overrides the client of region, which is used for the secrets that are
+// qualified with it and to fail over to it as a replica.  This is generally used for
+// testing purposes.
+func (p *Provider) WithRegionClient(region string, client Client) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.clients == nil {
+		p.clients = map[string]Client{}
+	}
+	if region == p.region {
+		region = ""
+	}
+	p.clients[region] = client
 	return p
 }
 
@@ -54,37 +172,220 @@ func (p *Provider) IsDev() bool {
 }
 
 // Get retrieves a secret version from a secret manager object and returns the value.
+// Paths may be qualified with the region the secret is stored in, e.g.
+// us-east-1/userclouds/prod/plex/client-secret, which otherwise defaults to the region
+// of the AWS config.
 func (p *Provider) Get(ctx context.Context, path string) (string, error) {
-	if err := p.initClient(ctx); err != nil {
+	// VersionStage defaults to AWSCURRENT if unspecified
+	return p.getSecret(ctx, path, &secretsmanager.GetSecretValueInput{VersionStage: aws.String(stageCurrent)})
+}
+
+// GetVersion retrieves a specific version of a secret, which is either a version ID or a
+// staging label such as AWSPREVIOUS.
+func (p *Provider) GetVersion(ctx context.Context, path, version string) (string, error) {
+	input := &secretsmanager.GetSecretValueInput{}
+	if _, err := uuid.FromString(version); err == nil {
+		input.VersionId = &version
+	} else {
+		input.VersionStage = &version
+	}
+
+	return p.getSecret(ctx, path, input)
+}
+
+// GetTimestamps returns when the secret was created and last changed.
+func (p *Provider) GetTimestamps(ctx context.Context, path string) (time.Time, time.Time, error) {
+	region, name := splitRegion(path)
+	client, err := p.client(ctx, region)
+	if err != nil {
+		return time.Time{}, time.Time{}, ucerr.Wrap(err)
+	}
+
+	result, err := client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{SecretId: &name})
+	if err != nil {
+		return time.Time{}, time.Time{}, ucerr.Errorf("failed to describe AWS secret '%s' in '%s': %w", name, p.regionOrDefault(region), err)
+	}
+
+	created := aws.ToTime(result.CreatedDate)
+	updated := aws.ToTime(result.LastChangedDate)
+	if updated.IsZero() {
+		updated = created
+	}
+
+	return created, updated, nil
+}
+
+func (p *Provider) getSecret(ctx context.Context, path string, input *secretsmanager.GetSecretValueInput) (string, error) {
+	region, name := splitRegion(path)
+	input.SecretId = &name
+
+	client, err := p.client(ctx, region)
+	if err != nil {
 		return "", ucerr.Wrap(err)
 	}
 
-	// VersionStage defaults to AWSCURRENT if unspecified
-	input := &secretsmanager.GetSecretValueInput{SecretId: &path, VersionStage: aws.String("AWSCURRENT")}
 	// In this sample we only handle the specific exceptions for the 'GetSecretValue' API.
 	// See https://docs.aws.amazon.com/secretsmanager/latest/apireference/API_GetSecretValue.html
-	result, err := p.client.GetSecretValue(ctx, input)
+	result, err := client.GetSecretValue(ctx, input)
 	if err != nil {
-		return "", ucerr.Errorf("failed to load AWS secret '%s' from '%s': %w", path, p.region, err)
+		result, err = p.getFromReplica(ctx, input, p.regionOrDefault(region), err)
 	}
-	uclog.Debugf(ctx, "Loaded AWS secret '%s' from '%s'", path, p.region)
-	value, err := decodeSecret(result)
-
-	// decode AWS's JSON wrapper if necessary
-	var awsSec awsSecret
-	var secret string
-	if err := json.Unmarshal([]byte(value), &awsSec); err == nil {
-		secret = awsSec.String
-	} else {
-		secret = value
+	if err != nil {
+		return "", ucerr.Errorf("failed to load AWS secret '%s' from '%s': %w", name, p.regionOrDefault(region), err)
 	}
+	uclog.Debugf(ctx, "Loaded AWS secret '%s' from '%s'", name, p.regionOrDefault(region))
 
+	secret, err := secretValue(result)
 	return secret, ucerr.Wrap(err)
 }
 
+// GetBatch retrieves secrets with BatchGetSecretValue, up to MaxBatchSize per call and
+// region, which requires the secretsmanager:BatchGetSecretValue permission in addition
+// to secretsmanager:GetSecretValue on the secrets.  Secrets that a batch doesn't return,
+// e.g. because they don't exist or the permission is missing, are retrieved with Get, so
+// that they fail over to replicas and fail with the same errors.
+func (p *Provider) GetBatch(ctx context.Context, paths []string) ([]string, []error) {
+	values := make([]string, len(paths))
+	errs := make([]error, len(paths))
+
+	// a call only retrieves the secrets of one region
+	var regions []string
+	byRegion := map[string][]int{}
+	for i, path := range paths {
+		region, _ := splitRegion(path)
+		if _, ok := byRegion[region]; !ok {
+			regions = append(regions, region)
+		}
+		byRegion[region] = append(byRegion[region], i)
+	}
+
+	for _, region := range regions {
+		for indexes := range slices.Chunk(byRegion[region], MaxBatchSize) {
+			names := make([]string, 0, len(indexes))
+			for _, i := range indexes {
+				_, name := splitRegion(paths[i])
+				names = append(names, name)
+			}
+
+			found := p.batchGet(ctx, region, names)
+			for j, i := range indexes {
+				if value, ok := found[names[j]]; ok {
+					values[i] = value
+					continue
+				}
+				values[i], errs[i] = p.Get(ctx, paths[i])
+			}
+		}
+	}
+
+	return values, errs
+}
+
+// batchGet returns the values of the secrets in region that BatchGetSecretValue
+// retrieves, by both the name and the ARN of each secret.
+func (p *Provider) batchGet(ctx context.Context, region string, names []string) map[string]string {
+	found := map[string]string{}
+	client, err := p.client(ctx, region)
+	if err != nil {
+		return found
+	}
+
+	input := &secretsmanager.BatchGetSecretValueInput{SecretIdList: slices.Compact(slices.Sorted(slices.Values(names)))}
+	for {
+		result, err := client.BatchGetSecretValue(ctx, input)
+		if err != nil {
+			uclog.Warningf(ctx, "failed to batch load %d AWS secrets from '%s', loading them one at a time: %v", len(input.SecretIdList), p.regionOrDefault(region), err)
+			return found
+		}
+
+		for _, entry := range result.SecretValues {
+			if entry.Name == nil {
+				continue
+			}
+
+			value, err := secretValue(&secretsmanager.GetSecretValueOutput{
+				Name:         entry.Name,
+				SecretString: entry.SecretString,
+				SecretBinary: entry.SecretBinary,
+			})
+			if err != nil {
+				continue
+			}
+
+			found[*entry.Name] = value
+			if entry.ARN != nil {
+				found[*entry.ARN] = value
+			}
+		}
+
+		if result.NextToken == nil || *result.NextToken == "" {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+
+	uclog.Debugf(ctx, "Batch loaded %d of %d AWS secrets from '%s'", len(found), len(input.SecretIdList), p.regionOrDefault(region))
+	return found
+}
+
+// Exists returns whether the secret exists, without reading its value.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	region, name := splitRegion(path)
+	client, err := p.client(ctx, region)
+	if err != nil {
+		return false, ucerr.Wrap(err)
+	}
+
+	_, err = client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{SecretId: &name})
+	var notFoundErr *types.ResourceNotFoundException
+	if errors.As(err, &notFoundErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, ucerr.Errorf("failed to describe AWS secret '%s' in '%s': %w", name, p.regionOrDefault(region), err)
+	}
+
+	return true, nil
+}
+
+// getFromReplica retrieves a secret from the first replica region that returns it, after
+// failing to retrieve it from failedRegion with err.  Secrets that don't exist aren't
+// retrieved from replicas, which would only repeat the error.
+func (p *Provider) getFromReplica(ctx context.Context, input *secretsmanager.GetSecretValueInput, failedRegion string, err error) (*secretsmanager.GetSecretValueOutput, error) {
+	var notFoundErr *types.ResourceNotFoundException
+	if errors.As(err, &notFoundErr) {
+		return nil, err
+	}
+
+	for _, replica := range p.replicas {
+		if replica == failedRegion {
+			continue
+		}
+
+		client, replicaErr := p.client(ctx, replica)
+		if replicaErr != nil {
+			continue
+		}
+
+		result, replicaErr := client.GetSecretValue(ctx, input)
+		if replicaErr == nil {
+			uclog.Warningf(ctx, "Loaded AWS secret '%s' from replica region '%s' after failing in '%s': %v", *input.SecretId, replica, failedRegion, err)
+			return result, nil
+		}
+	}
+
+	return nil, err
+}
+
 // Save creates or updates a secret in the AWS secrets manager.
 func (p *Provider) Save(ctx context.Context, path, secret string) error {
-	if err := p.initClient(ctx); err != nil {
+	if err := p.ValidatePath(path); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	region, name := splitRegion(path)
+	client, err := p.client(ctx, region)
+	if err != nil {
 		return ucerr.Wrap(err)
 	}
 
@@ -95,48 +396,321 @@ func (p *Provider) Save(ctx context.Context, path, secret string) error {
 	}
 	js := string(j)
 
-	uclog.Infof(ctx, "creating secret '%s' in AWS", path)
-	_, err = p.client.CreateSecret(ctx, &secretsmanager.CreateSecretInput{Name: &path, SecretString: &js, Tags: getTagsForSecret()})
+	kmsKeyID := p.kmsKey(ctx)
+	uclog.Infof(ctx, "creating secret '%s' in AWS region '%s'", name, p.regionOrDefault(region))
+	_, err = client.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+		Name:              &name,
+		SecretString:      &js,
+		Tags:              getTagsForSecret(p.tags),
+		KmsKeyId:          kmsKeyID,
+		AddReplicaRegions: p.replicaRegions(p.regionOrDefault(region), kmsKeyID),
+	})
 	if err == nil {
 		return nil
 	}
 	var resourceExistsErr *types.ResourceExistsException
 	if errors.As(err, &resourceExistsErr) {
-		uclog.Infof(ctx, "Secret '%s' already exists, updating it instead", path)
-		_, err = p.client.UpdateSecret(ctx, &secretsmanager.UpdateSecretInput{SecretId: &path, SecretString: &js})
+		uclog.Infof(ctx, "Secret '%s' already exists, updating it instead", name)
+		_, err = client.UpdateSecret(ctx, &secretsmanager.UpdateSecretInput{SecretId: &name, SecretString: &js, KmsKeyId: kmsKeyID})
 		return ucerr.Wrap(err)
 	}
 	return ucerr.Wrap(err)
 }
 
+// PreviousVersion returns AWSPREVIOUS, the stage that Secrets Manager moves to the version
+// replaced when a secret is saved.
+func (p *Provider) PreviousVersion() string {
+	return stagePrevious
+}
+
+// FinalizeRotation removes the AWSPREVIOUS stage from the version that has it, so that
+// the value replaced by the rotation can no longer be retrieved.
+func (p *Provider) FinalizeRotation(ctx context.Context, path string) error {
+	client, name, stages, err := p.versionStages(ctx, path)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	previous, ok := stages[stagePrevious]
+	if !ok {
+		return ucerr.Errorf("AWS secret '%s' has no %s version", name, stagePrevious)
+	}
+
+	uclog.Infof(ctx, "removing %s from version %s of AWS secret '%s'", stagePrevious, previous, name)
+	_, err = client.UpdateSecretVersionStage(ctx, &secretsmanager.UpdateSecretVersionStageInput{
+		SecretId:            &name,
+		VersionStage:        aws.String(stagePrevious),
+		RemoveFromVersionId: &previous,
+	})
+	return ucerr.Wrap(err)
+}
+
+// CancelRotation moves AWSCURRENT back to the AWSPREVIOUS version, and then removes
+// AWSPREVIOUS, which Secrets Manager moves to the version that was current.
+func (p *Provider) CancelRotation(ctx context.Context, path string) error {
+	client, name, stages, err := p.versionStages(ctx, path)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	current, previous := stages[stageCurrent], stages[stagePrevious]
+	if current == "" || previous == "" {
+		return ucerr.Errorf("AWS secret '%s' has no %s version", name, stagePrevious)
+	}
+
+	uclog.Infof(ctx, "restoring version %s of AWS secret '%s'", previous, name)
+	if _, err := client.UpdateSecretVersionStage(ctx, &secretsmanager.UpdateSecretVersionStageInput{
+		SecretId:            &name,
+		VersionStage:        aws.String(stageCurrent),
+		MoveToVersionId:     &previous,
+		RemoveFromVersionId: &current,
+	}); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	_, err = client.UpdateSecretVersionStage(ctx, &secretsmanager.UpdateSecretVersionStageInput{
+		SecretId:            &name,
+		VersionStage:        aws.String(stagePrevious),
+		RemoveFromVersionId: &current,
+	})
+	return ucerr.Wrap(err)
+}
+
+// versionStages returns the client and name of the secret at path, and the IDs of its
+// versions by stage.
+func (p *Provider) versionStages(ctx context.Context, path string) (Client, string, map[string]string, error) {
+	region, name := splitRegion(path)
+	client, err := p.client(ctx, region)
+	if err != nil {
+		return nil, "", nil, ucerr.Wrap(err)
+	}
+
+	result, err := client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{SecretId: &name})
+	if err != nil {
+		return nil, "", nil, ucerr.Errorf("failed to describe AWS secret '%s' in '%s': %w", name, p.regionOrDefault(region), err)
+	}
+
+	stages := map[string]string{}
+	for id, versionStages := range result.VersionIdsToStages {
+		for _, stage := range versionStages {
+			stages[stage] = id
+		}
+	}
+
+	return client, name, stages, nil
+}
+
 // Delete removes a secret from the AWS secrets manager.
 func (p *Provider) Delete(ctx context.Context, path string) error {
-	if err := p.initClient(ctx); err != nil {
+	region, name := splitRegion(path)
+	client, err := p.client(ctx, region)
+	if err != nil {
 		return ucerr.Wrap(err)
 	}
 
-	uclog.Infof(ctx, "Delete secret '%s' in AWS", path)
-	_, err := p.client.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{SecretId: &path, RecoveryWindowInDays: aws.Int64(DefaultSecretRecoveryWindowInDays)})
+	uclog.Infof(ctx, "Delete secret '%s' in AWS region '%s'", name, p.regionOrDefault(region))
+	_, err = client.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{SecretId: &name, RecoveryWindowInDays: aws.Int64(DefaultSecretRecoveryWindowInDays)})
 	return ucerr.Wrap(err)
 }
 
-// initClient is a helper that initializes the AWS client.
-func (p *Provider) initClient(ctx context.Context) error {
-	if p.client != nil {
-		return nil
+// List returns the paths of all secrets whose names start with pathPrefix, following
+// ListSecrets pagination until all pages have been read.  If pathPrefix is qualified
+// with a region, the secrets of that region are listed and their paths are qualified
+// with it too.
+func (p *Provider) List(ctx context.Context, pathPrefix string) ([]string, error) {
+	region, pathPrefix := splitRegion(pathPrefix)
+	client, err := p.client(ctx, region)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	qualifier := ""
+	if region != "" {
+		qualifier = region + "/"
+	}
+
+	input := &secretsmanager.ListSecretsInput{}
+	if pathPrefix != "" {
+		input.Filters = []types.Filter{
+			{
+				Key:    types.FilterNameStringTypeName,
+				Values: []string{pathPrefix},
+			},
+		}
+	}
+
+	var paths []string
+	for {
+		result, err := client.ListSecrets(ctx, input)
+		if err != nil {
+			return nil, ucerr.Errorf("failed to list AWS secrets with prefix '%s' in '%s': %w", pathPrefix, p.regionOrDefault(region), err)
+		}
+
+		for _, entry := range result.SecretList {
+			// the name filter is case insensitive, so make sure we only return exact prefix matches
+			if entry.Name != nil && strings.HasPrefix(*entry.Name, pathPrefix) {
+				paths = append(paths, qualifier+*entry.Name)
+			}
+		}
+
+		if result.NextToken == nil || *result.NextToken == "" {
+			break
+		}
+		input.NextToken = result.NextToken
 	}
 
-	cfg, err := ucaws.NewConfigWithDefaultRegion(ctx)
+	return paths, nil
+}
+
+// Ping checks that AWS secrets manager is reachable in the default region with the
+// provider's credentials, by listing at most one secret.  This requires the
+// secretsmanager:ListSecrets permission.
+func (p *Provider) Ping(ctx context.Context) error {
+	client, err := p.client(ctx, "")
 	if err != nil {
 		return ucerr.Wrap(err)
 	}
 
-	p.client = secretsmanager.NewFromConfig(cfg)
-	p.region = cfg.Region
+	if _, err := client.ListSecrets(ctx, &secretsmanager.ListSecretsInput{MaxResults: aws.Int32(1)}); err != nil {
+		return ucerr.Errorf("failed to reach AWS secrets manager in '%s': %w", p.regionOrDefault(""), err)
+	}
+
+	return nil
+}
+
+// client returns the client of region, or of the default region if it's empty.  Clients
+// are created the first time their region is used, from an AWS config that is only loaded
+// once, so that they share the credentials of the assumed role.
+func (p *Provider) client(ctx context.Context, region string) (Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.clients == nil {
+		p.clients = map[string]Client{}
+	}
+	if region == p.region {
+		region = ""
+	}
+	if client, ok := p.clients[region]; ok {
+		return client, nil
+	}
+
+	if p.cfg == nil {
+		cfg, err := ucaws.NewConfigWithDefaultRegion(ctx)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+		if p.region != "" {
+			cfg.Region = p.region
+		}
+
+		if err := p.assumeRole(&cfg); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+
+		p.cfg = &cfg
+		p.region = cfg.Region
+
+		// the region may turn out to be the default one
+		if region == p.region {
+			region = ""
+			if client, ok := p.clients[region]; ok {
+				return client, nil
+			}
+		}
+	}
+
+	cfg := p.cfg.Copy()
+	if region != "" {
+		cfg.Region = region
+	}
+
+	client := secretsmanager.NewFromConfig(cfg)
+	p.clients[region] = client
+	return client, nil
+}
+
+// replicaRegions returns the regions that a secret created in region is replicated to.
+// Replicas are encrypted with the same KMS key if it is a multi-region key ID, which is
+// valid in every region, and with the default key of their region otherwise.
+func (p *Provider) replicaRegions(region string, kmsKeyID *string) []types.ReplicaRegionType {
+	var replicaKeyID *string
+	if kmsKeyID != nil && strings.HasPrefix(*kmsKeyID, "mrk-") {
+		replicaKeyID = kmsKeyID
+	}
+
+	var replicas []types.ReplicaRegionType
+	for _, replica := range p.replicas {
+		if replica != region {
+			replicas = append(replicas, types.ReplicaRegionType{Region: aws.String(replica), KmsKeyId: replicaKeyID})
+		}
+	}
+
+	return replicas
+}
+
+// kmsKey returns the KMS key to encrypt secrets saved with ctx with, or nil to use the
+// default key.
+func (p *Provider) kmsKey(ctx context.Context) *string {
+	if kmsKeyID, ok := ctx.Value(kmsKeyIDKey{}).(string); ok && kmsKeyID != "" {
+		return &kmsKeyID
+	}
+
+	if p.kmsKeyID != "" {
+		return aws.String(p.kmsKeyID)
+	}
 
 	return nil
 }
 
+// regionOrDefault returns region, or the region of the AWS config if it's empty.
+func (p *Provider) regionOrDefault(region string) string {
+	if region == "" {
+		return p.region
+	}
+
+	return region
+}
+
+// assumeRole switches the credentials of cfg to those of the configured role, if any.
+// The temporary credentials are cached and refreshed before they expire.
+func (p *Provider) assumeRole(cfg *aws.Config) error {
+	if p.roleARN == "" {
+		return nil
+	}
+
+	if !ucaws.IsValidAwsARN(p.roleARN) {
+		return ucerr.Errorf("invalid IAM role ARN '%s' to assume for AWS secrets", p.roleARN)
+	}
+
+	assumeRole := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(*cfg), p.roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = roleSessionName
+		if p.externalID != "" {
+			o.ExternalID = aws.String(p.externalID)
+		}
+	})
+	cfg.Credentials = aws.NewCredentialsCache(assumeRole)
+
+	return nil
+}
+
+// secretValue returns the value of a retrieved secret, decoding AWS's JSON wrapper if
+// necessary, but leaving other JSON objects such as the key/value secrets of the AWS
+// console (and secret.Map) unchanged.
+func secretValue(result *secretsmanager.GetSecretValueOutput) (string, error) {
+	value, err := decodeSecret(result)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	var awsSec awsSecret
+	if err := json.Unmarshal([]byte(value), &awsSec); err == nil && awsSec.String != "" {
+		return awsSec.String, nil
+	}
+
+	return value, nil
+}
+
 func decodeSecret(result *secretsmanager.GetSecretValueOutput) (string, error) {
 	// Decrypts secret using the associated KMS CMK.
 	// Depending on whether the secret is a string or binary, one of these fields will be populated.
@@ -157,7 +731,10 @@ func decodeSecret(result *secretsmanager.GetSecretValueOutput) (string, error) {
 	return secret, nil
 }
 
-func getTagsForSecret() []types.Tag {
+// getTagsForSecret returns the tags of a created secret, which are the universe tags
+// followed by the configured tags in key order.  Configured tags can't replace the
+// universe tags.
+func getTagsForSecret(configured map[string]string) []types.Tag {
 	uv := universe.Current()
 	tags := []types.Tag{
 		{
@@ -171,5 +748,28 @@ func getTagsForSecret() []types.Tag {
 			Value: aws.String("eks"),
 		})
 	}
+
+	for _, key := range slices.Sorted(maps.Keys(configured)) {
+		if key == universe.EnvKeyUniverse || key == "UC_ENV_TYPE" {
+			continue
+		}
+		tags = append(tags, types.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(configured[key]),
+		})
+	}
+	return tags
+}
+
+// parseTags parses a comma separated list of key=value tags, skipping malformed ones.
+func parseTags(s string) map[string]string {
+	tags := map[string]string{}
+	for _, tag := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(tag, "=")
+		if key = strings.TrimSpace(key); ok && key != "" {
+			tags[key] = strings.TrimSpace(value)
+		}
+	}
+
 	return tags
 }
@@ -2,12 +2,19 @@ package aws
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+
+	"userclouds.com/infra/namespace/universe"
 )
 
 func TestAWS_getAWSSecretWithClient(t *testing.T) {
@@ -22,3 +29,358 @@ func TestAWS_getAWSSecretWithClient(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "testsecret", secret)
 }
+
+func TestAWS_List(t *testing.T) {
+	ctx := context.Background()
+	sm := &MockSecretsManagerClient{}
+	sm.On("ListSecrets", ctx, mock.MatchedBy(func(in *secretsmanager.ListSecretsInput) bool {
+		return in.NextToken == nil
+	}), mock.Anything).Return(&secretsmanager.ListSecretsOutput{
+		SecretList: []types.SecretListEntry{
+			{Name: aws.String("userclouds/test/service/one")},
+			{Name: aws.String("UserClouds/test/service/other-case")},
+		},
+		NextToken: aws.String("page2"),
+	}, nil).Once()
+	sm.On("ListSecrets", ctx, mock.MatchedBy(func(in *secretsmanager.ListSecretsInput) bool {
+		return in.NextToken != nil && *in.NextToken == "page2"
+	}), mock.Anything).Return(&secretsmanager.ListSecretsOutput{
+		SecretList: []types.SecretListEntry{
+			{Name: aws.String("userclouds/test/service/two")},
+		},
+	}, nil).Once()
+
+	provider := New().WithSecretsManagerClient(sm)
+	paths, err := provider.List(ctx, "userclouds/test/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"userclouds/test/service/one", "userclouds/test/service/two"}, paths)
+	sm.AssertExpectations(t)
+}
+
+func TestAWS_GetVersion(t *testing.T) {
+	ctx := context.Background()
+	versionID := "7a4f3c2e-0d6b-4b1e-9f3a-2c8d5e6f7a8b"
+	sm := &MockSecretsManagerClient{}
+	sm.On("GetSecretValue", ctx, mock.MatchedBy(func(in *secretsmanager.GetSecretValueInput) bool {
+		return in.VersionStage != nil && *in.VersionStage == "AWSPREVIOUS" && in.VersionId == nil
+	}), mock.Anything).Return(&secretsmanager.GetSecretValueOutput{
+		SecretString: aws.String(`{"string":"previous"}`),
+	}, nil).Once()
+	sm.On("GetSecretValue", ctx, mock.MatchedBy(func(in *secretsmanager.GetSecretValueInput) bool {
+		return in.VersionId != nil && *in.VersionId == versionID && in.VersionStage == nil
+	}), mock.Anything).Return(&secretsmanager.GetSecretValueOutput{
+		SecretString: aws.String(`{"string":"pinned"}`),
+	}, nil).Once()
+
+	provider := New().WithSecretsManagerClient(sm)
+	secret, err := provider.GetVersion(ctx, "dummysecret", "AWSPREVIOUS")
+	assert.NoError(t, err)
+	assert.Equal(t, "previous", secret)
+
+	secret, err = provider.GetVersion(ctx, "dummysecret", versionID)
+	assert.NoError(t, err)
+	assert.Equal(t, "pinned", secret)
+	sm.AssertExpectations(t)
+}
+
+func TestAWS_Rotation(t *testing.T) {
+	ctx := context.Background()
+	current, previous := "7a4f3c2e-0d6b-4b1e-9f3a-2c8d5e6f7a8b", "0c1d2e3f-4a5b-4c6d-8e7f-8091a2b3c4d5"
+	stages := func(in *secretsmanager.UpdateSecretVersionStageInput, stage string, moveTo, removeFrom *string) bool {
+		return *in.SecretId == "dummysecret" && *in.VersionStage == stage && aws.ToString(in.MoveToVersionId) == aws.ToString(moveTo) && aws.ToString(in.RemoveFromVersionId) == aws.ToString(removeFrom)
+	}
+
+	sm := &MockSecretsManagerClient{}
+	sm.On("DescribeSecret", ctx, mock.Anything, mock.Anything).Return(&secretsmanager.DescribeSecretOutput{
+		VersionIdsToStages: map[string][]string{current: {"AWSCURRENT"}, previous: {"AWSPREVIOUS", "custom"}},
+	}, nil)
+	sm.On("UpdateSecretVersionStage", ctx, mock.MatchedBy(func(in *secretsmanager.UpdateSecretVersionStageInput) bool {
+		return stages(in, "AWSPREVIOUS", nil, &previous)
+	}), mock.Anything).Return(&secretsmanager.UpdateSecretVersionStageOutput{}, nil).Once()
+	sm.On("UpdateSecretVersionStage", ctx, mock.MatchedBy(func(in *secretsmanager.UpdateSecretVersionStageInput) bool {
+		return stages(in, "AWSCURRENT", &previous, &current)
+	}), mock.Anything).Return(&secretsmanager.UpdateSecretVersionStageOutput{}, nil).Once()
+	sm.On("UpdateSecretVersionStage", ctx, mock.MatchedBy(func(in *secretsmanager.UpdateSecretVersionStageInput) bool {
+		return stages(in, "AWSPREVIOUS", nil, &current)
+	}), mock.Anything).Return(&secretsmanager.UpdateSecretVersionStageOutput{}, nil).Once()
+
+	provider := New().WithSecretsManagerClient(sm)
+	assert.Equal(t, "AWSPREVIOUS", provider.PreviousVersion())
+	assert.NoError(t, provider.FinalizeRotation(ctx, "dummysecret"))
+	assert.NoError(t, provider.CancelRotation(ctx, "dummysecret"))
+	sm.AssertExpectations(t)
+
+	// secrets that were never updated have no previous version
+	sm = &MockSecretsManagerClient{}
+	sm.On("DescribeSecret", ctx, mock.Anything, mock.Anything).Return(&secretsmanager.DescribeSecretOutput{
+		VersionIdsToStages: map[string][]string{current: {"AWSCURRENT"}},
+	}, nil)
+	provider = New().WithSecretsManagerClient(sm)
+	assert.ErrorContains(t, provider.FinalizeRotation(ctx, "dummysecret"), "has no AWSPREVIOUS version")
+	assert.ErrorContains(t, provider.CancelRotation(ctx, "dummysecret"), "has no AWSPREVIOUS version")
+}
+
+func TestAWS_assumeRole(t *testing.T) {
+	cfg := aws.Config{Region: "us-west-2"}
+	assert.NoError(t, New().WithAssumeRole("", "").assumeRole(&cfg))
+	assert.Nil(t, cfg.Credentials)
+
+	t.Setenv(RoleARNEnvKey, "arn:aws:iam::123456789012:role/userclouds-secrets")
+	t.Setenv(ExternalIDEnvKey, "external-id")
+	p := New()
+	assert.Equal(t, "external-id", p.externalID)
+	assert.NoError(t, p.assumeRole(&cfg))
+	assert.True(t, aws.IsCredentialsProvider(cfg.Credentials, (*stscreds.AssumeRoleProvider)(nil)))
+
+	assert.Error(t, New().WithAssumeRole("not-an-arn", "").assumeRole(&cfg))
+}
+
+func TestAWS_regionQualifiedPath(t *testing.T) {
+	ctx := context.Background()
+
+	sm := &MockSecretsManagerClient{}
+	eu := &MockSecretsManagerClient{}
+	eu.On("GetSecretValue", ctx, mock.MatchedBy(func(in *secretsmanager.GetSecretValueInput) bool {
+		return *in.SecretId == "userclouds/test/my-secret"
+	}), mock.Anything).Return(&secretsmanager.GetSecretValueOutput{
+		SecretString: aws.String(`{"string":"from-eu"}`),
+	}, nil).Once()
+	eu.On("ListSecrets", ctx, mock.Anything, mock.Anything).Return(&secretsmanager.ListSecretsOutput{
+		SecretList: []types.SecretListEntry{{Name: aws.String("userclouds/test/my-secret")}},
+	}, nil).Once()
+
+	provider := New().WithSecretsManagerClient(sm).WithRegionClient("eu-west-1", eu)
+	secret, err := provider.Get(ctx, "eu-west-1/userclouds/test/my-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "from-eu", secret)
+
+	paths, err := provider.List(ctx, "eu-west-1/userclouds/test/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"eu-west-1/userclouds/test/my-secret"}, paths)
+	sm.AssertExpectations(t)
+	eu.AssertExpectations(t)
+}
+
+func TestAWS_regionClients(t *testing.T) {
+	ctx := context.Background()
+	p := New().WithRegion("us-west-2")
+	p.cfg = &aws.Config{Region: "us-west-2"}
+
+	west, err := p.client(ctx, "")
+	assert.NoError(t, err)
+	east, err := p.client(ctx, "us-east-1")
+	assert.NoError(t, err)
+	assert.NotSame(t, west, east)
+
+	// clients are created once per region, and the default region is the same as ""
+	again, err := p.client(ctx, "us-east-1")
+	assert.NoError(t, err)
+	assert.Same(t, east, again)
+	again, err = p.client(ctx, "us-west-2")
+	assert.NoError(t, err)
+	assert.Same(t, west, again)
+
+	assert.Equal(t, "us-east-1", east.(*secretsmanager.Client).Options().Region)
+	assert.Equal(t, "us-west-2", west.(*secretsmanager.Client).Options().Region)
+}
+
+func TestAWS_replicas(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(ReplicaRegionsEnvKey, "us-west-2, us-east-1")
+	p := New()
+	assert.Equal(t, []string{"us-west-2", "us-east-1"}, p.replicas)
+
+	sm := &MockSecretsManagerClient{}
+	sm.On("CreateSecret", ctx, mock.MatchedBy(func(in *secretsmanager.CreateSecretInput) bool {
+		// secrets aren't replicated to the region they're created in
+		return len(in.AddReplicaRegions) == 1 && *in.AddReplicaRegions[0].Region == "us-east-1"
+	}), mock.Anything).Return(&secretsmanager.CreateSecretOutput{}, nil).Once()
+	replica := &MockSecretsManagerClient{}
+	p.region = "us-west-2"
+	p.WithSecretsManagerClient(sm).WithRegionClient("us-east-1", replica)
+	assert.NoError(t, p.Save(ctx, "userclouds/test/my-secret", "secret"))
+
+	// reads fail over to replicas, except for secrets that don't exist
+	sm.On("GetSecretValue", ctx, mock.Anything, mock.Anything).Return((*secretsmanager.GetSecretValueOutput)(nil), errors.New("service unavailable")).Once()
+	replica.On("GetSecretValue", ctx, mock.Anything, mock.Anything).Return(&secretsmanager.GetSecretValueOutput{
+		SecretString: aws.String(`{"string":"from-replica"}`),
+	}, nil).Once()
+	secret, err := p.Get(ctx, "userclouds/test/my-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "from-replica", secret)
+
+	sm.On("GetSecretValue", ctx, mock.Anything, mock.Anything).Return((*secretsmanager.GetSecretValueOutput)(nil), &types.ResourceNotFoundException{}).Once()
+	_, err = p.Get(ctx, "userclouds/test/missing")
+	assert.Error(t, err)
+	sm.AssertExpectations(t)
+	replica.AssertExpectations(t)
+}
+
+func TestAWS_kmsKey(t *testing.T) {
+	ctx := context.Background()
+	sm := &MockSecretsManagerClient{}
+	sm.On("CreateSecret", ctx, mock.MatchedBy(func(in *secretsmanager.CreateSecretInput) bool {
+		return in.KmsKeyId == nil
+	}), mock.Anything).Return(&secretsmanager.CreateSecretOutput{}, nil).Once()
+	sm.On("CreateSecret", ctx, mock.MatchedBy(func(in *secretsmanager.CreateSecretInput) bool {
+		return in.KmsKeyId != nil && *in.KmsKeyId == "alias/userclouds-prod" && in.AddReplicaRegions[0].KmsKeyId == nil
+	}), mock.Anything).Return(&secretsmanager.CreateSecretOutput{}, nil).Once()
+
+	p := New().WithSecretsManagerClient(sm).WithReplicaRegions("us-east-1")
+	assert.NoError(t, p.Save(ctx, "userclouds/test/my-secret", "secret"))
+	p.WithKMSKey("alias/userclouds-prod")
+	assert.NoError(t, p.Save(ctx, "userclouds/test/my-secret", "secret"))
+
+	// keys set on the context override the provider's, and multi-region keys are used
+	// for the replicas too
+	callCtx := ContextWithKMSKey(ctx, "mrk-1234abcd")
+	sm.On("CreateSecret", callCtx, mock.MatchedBy(func(in *secretsmanager.CreateSecretInput) bool {
+		return *in.KmsKeyId == "mrk-1234abcd" && *in.AddReplicaRegions[0].KmsKeyId == "mrk-1234abcd"
+	}), mock.Anything).Return(&secretsmanager.CreateSecretOutput{}, nil).Once()
+	assert.NoError(t, p.Save(callCtx, "userclouds/test/my-secret", "secret"))
+	sm.AssertExpectations(t)
+}
+
+func TestAWS_tags(t *testing.T) {
+	t.Setenv(universe.EnvKeyUniverse, string(universe.Dev))
+	t.Setenv(TagsEnvKey, "team=platform, cost-center=1234,malformed,UC_UNIVERSE=prod")
+	p := New().WithTags(map[string]string{"service": "plex", "team": "identity"})
+
+	tags := map[string]string{}
+	var keys []string
+	for _, tag := range getTagsForSecret(p.tags) {
+		tags[*tag.Key] = *tag.Value
+		keys = append(keys, *tag.Key)
+	}
+	assert.Equal(t, []string{"UC_UNIVERSE", "cost-center", "service", "team"}, keys)
+	assert.Equal(t, "1234", tags["cost-center"])
+	assert.Equal(t, "identity", tags["team"])
+	assert.Equal(t, "dev", tags["UC_UNIVERSE"])
+}
+
+func TestAWS_Ping(t *testing.T) {
+	ctx := context.Background()
+	sm := &MockSecretsManagerClient{}
+	sm.On("ListSecrets", ctx, mock.MatchedBy(func(in *secretsmanager.ListSecretsInput) bool {
+		return in.MaxResults != nil && *in.MaxResults == 1
+	}), mock.Anything).Return(&secretsmanager.ListSecretsOutput{}, nil).Once()
+	sm.On("ListSecrets", ctx, mock.Anything, mock.Anything).Return(&secretsmanager.ListSecretsOutput{}, errors.New("AccessDeniedException")).Once()
+
+	provider := New().WithSecretsManagerClient(sm)
+	assert.NoError(t, provider.Ping(ctx))
+	assert.ErrorContains(t, provider.Ping(ctx), "AccessDeniedException")
+	sm.AssertExpectations(t)
+}
+
+func TestAWS_GetTimestamps(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	changed := created.Add(time.Hour)
+
+	sm := &MockSecretsManagerClient{}
+	sm.On("DescribeSecret", ctx, mock.MatchedBy(func(in *secretsmanager.DescribeSecretInput) bool {
+		return *in.SecretId == "userclouds/test/changed"
+	}), mock.Anything).Return(&secretsmanager.DescribeSecretOutput{CreatedDate: &created, LastChangedDate: &changed}, nil).Once()
+	sm.On("DescribeSecret", ctx, mock.MatchedBy(func(in *secretsmanager.DescribeSecretInput) bool {
+		return *in.SecretId == "userclouds/test/new"
+	}), mock.Anything).Return(&secretsmanager.DescribeSecretOutput{CreatedDate: &created}, nil).Once()
+
+	provider := New().WithSecretsManagerClient(sm).WithRegionClient("eu-west-1", sm)
+	c, u, err := provider.GetTimestamps(ctx, "userclouds/test/changed")
+	assert.NoError(t, err)
+	assert.Equal(t, created, c)
+	assert.Equal(t, changed, u)
+
+	c, u, err = provider.GetTimestamps(ctx, "eu-west-1/userclouds/test/new")
+	assert.NoError(t, err)
+	assert.Equal(t, created, c)
+	assert.Equal(t, created, u)
+	sm.AssertExpectations(t)
+}
+
+func TestAWS_Exists(t *testing.T) {
+	ctx := context.Background()
+
+	sm := &MockSecretsManagerClient{}
+	sm.On("DescribeSecret", ctx, mock.MatchedBy(func(in *secretsmanager.DescribeSecretInput) bool {
+		return *in.SecretId == "userclouds/test/found"
+	}), mock.Anything).Return(&secretsmanager.DescribeSecretOutput{}, nil).Once()
+	sm.On("DescribeSecret", ctx, mock.MatchedBy(func(in *secretsmanager.DescribeSecretInput) bool {
+		return *in.SecretId == "userclouds/test/missing"
+	}), mock.Anything).Return((*secretsmanager.DescribeSecretOutput)(nil), &types.ResourceNotFoundException{}).Once()
+	sm.On("DescribeSecret", ctx, mock.MatchedBy(func(in *secretsmanager.DescribeSecretInput) bool {
+		return *in.SecretId == "userclouds/test/forbidden"
+	}), mock.Anything).Return((*secretsmanager.DescribeSecretOutput)(nil), errors.New("AccessDeniedException")).Once()
+
+	provider := New().WithSecretsManagerClient(sm)
+	exists, err := provider.Exists(ctx, "userclouds/test/found")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = provider.Exists(ctx, "userclouds/test/missing")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = provider.Exists(ctx, "userclouds/test/forbidden")
+	assert.ErrorContains(t, err, "AccessDeniedException")
+	sm.AssertExpectations(t)
+}
+
+func TestAWS_GetBatch(t *testing.T) {
+	ctx := context.Background()
+
+	sm := &MockSecretsManagerClient{}
+	sm.On("BatchGetSecretValue", ctx, mock.MatchedBy(func(in *secretsmanager.BatchGetSecretValueInput) bool {
+		return len(in.SecretIdList) == MaxBatchSize
+	}), mock.Anything).Return(&secretsmanager.BatchGetSecretValueOutput{
+		SecretValues: []types.SecretValueEntry{
+			{Name: aws.String("userclouds/test/a"), SecretString: aws.String(`{"string":"a"}`)},
+			{Name: aws.String("userclouds/test/b"), SecretString: aws.String(`{"user":"b"}`)},
+		},
+		Errors: []types.APIErrorType{{SecretId: aws.String("userclouds/test/missing"), ErrorCode: aws.String("ResourceNotFoundException")}},
+	}, nil).Once()
+	sm.On("BatchGetSecretValue", ctx, mock.MatchedBy(func(in *secretsmanager.BatchGetSecretValueInput) bool {
+		return len(in.SecretIdList) == 2
+	}), mock.Anything).Return(&secretsmanager.BatchGetSecretValueOutput{
+		SecretValues: []types.SecretValueEntry{
+			{Name: aws.String("userclouds/test/c"), SecretString: aws.String(`{"string":"c"}`)},
+		},
+	}, nil).Once()
+
+	// secrets that a batch doesn't return are retrieved one at a time
+	sm.On("GetSecretValue", ctx, mock.MatchedBy(func(in *secretsmanager.GetSecretValueInput) bool {
+		return *in.SecretId != "userclouds/test/missing"
+	}), mock.Anything).Return(&secretsmanager.GetSecretValueOutput{
+		SecretString: aws.String(`{"string":"one-by-one"}`),
+	}, nil)
+	sm.On("GetSecretValue", ctx, mock.Anything, mock.Anything).Return((*secretsmanager.GetSecretValueOutput)(nil), &types.ResourceNotFoundException{}).Once()
+
+	// as are those of regions where the batch fails
+	eu := &MockSecretsManagerClient{}
+	eu.On("BatchGetSecretValue", ctx, mock.Anything, mock.Anything).Return((*secretsmanager.BatchGetSecretValueOutput)(nil), errors.New("AccessDeniedException")).Once()
+	eu.On("GetSecretValue", ctx, mock.Anything, mock.Anything).Return(&secretsmanager.GetSecretValueOutput{
+		SecretString: aws.String(`{"string":"from-eu"}`),
+	}, nil).Once()
+
+	paths := []string{"userclouds/test/a", "eu-west-1/userclouds/test/eu", "userclouds/test/b", "userclouds/test/missing"}
+	for i := range MaxBatchSize - 2 {
+		paths = append(paths, fmt.Sprintf("userclouds/test/other-%02d", i))
+	}
+	paths = append(paths, "userclouds/test/c")
+
+	provider := New().WithSecretsManagerClient(sm).WithRegionClient("eu-west-1", eu)
+	values, errs := provider.GetBatch(ctx, paths)
+	assert.Len(t, values, len(paths))
+	assert.Equal(t, []string{"a", "from-eu", `{"user":"b"}`, ""}, values[:4])
+	assert.Equal(t, "one-by-one", values[4])
+	assert.Equal(t, "c", values[len(values)-1])
+	for i, err := range errs {
+		if paths[i] == "userclouds/test/missing" {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err, paths[i])
+		}
+	}
+	sm.AssertExpectations(t)
+	eu.AssertExpectations(t)
+}
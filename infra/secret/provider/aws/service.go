@@ -14,6 +14,10 @@ type Client interface {
 	CreateSecret(ctx context.Context, params *secretsmanager.CreateSecretInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error)
 	UpdateSecret(ctx context.Context, params *secretsmanager.UpdateSecretInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretOutput, error)
 	DeleteSecret(ctx context.Context, params *secretsmanager.DeleteSecretInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.DeleteSecretOutput, error)
+	ListSecrets(ctx context.Context, params *secretsmanager.ListSecretsInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretsOutput, error)
+	DescribeSecret(ctx context.Context, params *secretsmanager.DescribeSecretInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error)
+	BatchGetSecretValue(ctx context.Context, params *secretsmanager.BatchGetSecretValueInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error)
+	UpdateSecretVersionStage(ctx context.Context, params *secretsmanager.UpdateSecretVersionStageInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretVersionStageOutput, error)
 }
 
 // MockSecretsManagerClient is an implementation of the Client interface used
@@ -42,3 +46,23 @@ func (c *MockSecretsManagerClient) DeleteSecret(ctx context.Context, params *sec
 	args := c.Called(ctx, params, opts)
 	return args.Get(0).(*secretsmanager.DeleteSecretOutput), args.Error(1)
 }
+
+func (c *MockSecretsManagerClient) DescribeSecret(ctx context.Context, params *secretsmanager.DescribeSecretInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error) {
+	args := c.Called(ctx, params, opts)
+	return args.Get(0).(*secretsmanager.DescribeSecretOutput), args.Error(1)
+}
+
+func (c *MockSecretsManagerClient) ListSecrets(ctx context.Context, params *secretsmanager.ListSecretsInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretsOutput, error) {
+	args := c.Called(ctx, params, opts)
+	return args.Get(0).(*secretsmanager.ListSecretsOutput), args.Error(1)
+}
+
+func (c *MockSecretsManagerClient) BatchGetSecretValue(ctx context.Context, params *secretsmanager.BatchGetSecretValueInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error) {
+	args := c.Called(ctx, params, opts)
+	return args.Get(0).(*secretsmanager.BatchGetSecretValueOutput), args.Error(1)
+}
+
+func (c *MockSecretsManagerClient) UpdateSecretVersionStage(ctx context.Context, params *secretsmanager.UpdateSecretVersionStageInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretVersionStageOutput, error) {
+	args := c.Called(ctx, params, opts)
+	return args.Get(0).(*secretsmanager.UpdateSecretVersionStageOutput), args.Error(1)
+}
@@ -0,0 +1,177 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"userclouds.com/infra/ucerr"
+)
+
+const (
+	// these are the environment variables used by the Azure SDKs and CLI
+	tenantIDEnvKey      = "AZURE_TENANT_ID"
+	clientIDEnvKey      = "AZURE_CLIENT_ID"
+	clientSecretEnvKey  = "AZURE_CLIENT_SECRET"
+	authorityHostEnvKey = "AZURE_AUTHORITY_HOST"
+
+	defaultAuthorityHost = "https://login.microsoftonline.com"
+	imdsTokenURL         = "http://169.254.169.254/metadata/identity/oauth2/token"
+	keyVaultResource     = "https://vault.azure.net"
+
+	// tokens are refreshed this long before they expire
+	tokenExpiryMargin = time.Minute
+)
+
+// credential issues access tokens for the key vault API.
+type credential interface {
+	token(ctx context.Context, client *http.Client) (string, error)
+}
+
+// credentialFromEnv returns the client secret credential of a service principal if
+// AZURE_CLIENT_SECRET is set, and the managed identity credential otherwise.  A user
+// assigned managed identity is selected with AZURE_CLIENT_ID.
+func credentialFromEnv() credential {
+	if secret := os.Getenv(clientSecretEnvKey); secret != "" {
+		authority := os.Getenv(authorityHostEnvKey)
+		if authority == "" {
+			authority = defaultAuthorityHost
+		}
+
+		return &cachedCredential{fetch: clientSecretToken{
+			authority:    strings.TrimSuffix(authority, "/"),
+			tenantID:     os.Getenv(tenantIDEnvKey),
+			clientID:     os.Getenv(clientIDEnvKey),
+			clientSecret: secret,
+		}.fetch}
+	}
+
+	return &cachedCredential{fetch: managedIdentityToken{clientID: os.Getenv(clientIDEnvKey)}.fetch}
+}
+
+// cachedCredential reuses a token until shortly before it expires.
+type cachedCredential struct {
+	fetch func(ctx context.Context, client *http.Client) (string, time.Time, error)
+
+	mu        sync.Mutex
+	cached    string
+	expiresAt time.Time
+}
+
+func (c *cachedCredential) token(ctx context.Context, client *http.Client) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached != "" && time.Now().Add(tokenExpiryMargin).Before(c.expiresAt) {
+		return c.cached, nil
+	}
+
+	token, expiresAt, err := c.fetch(ctx, client)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	c.cached, c.expiresAt = token, expiresAt
+	return token, nil
+}
+
+// clientSecretToken issues tokens with the client credentials grant of a service principal.
+type clientSecretToken struct {
+	authority    string
+	tenantID     string
+	clientID     string
+	clientSecret string
+}
+
+func (t clientSecretToken) fetch(ctx context.Context, client *http.Client) (string, time.Time, error) {
+	if t.tenantID == "" || t.clientID == "" {
+		return "", time.Time{}, ucerr.Errorf("%s and %s must be set to authenticate with %s", tenantIDEnvKey, clientIDEnvKey, clientSecretEnvKey)
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {t.clientID},
+		"client_secret": {t.clientSecret},
+		"scope":         {keyVaultResource + "/.default"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.authority+"/"+url.PathEscape(t.tenantID)+"/oauth2/v2.0/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, ucerr.Wrap(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return requestToken(client, req, "client secret")
+}
+
+// managedIdentityToken issues tokens for the managed identity of the VM or AKS node pool
+// through the instance metadata service.
+type managedIdentityToken struct {
+	clientID string
+}
+
+func (t managedIdentityToken) fetch(ctx context.Context, client *http.Client) (string, time.Time, error) {
+	query := url.Values{"api-version": {"2018-02-01"}, "resource": {keyVaultResource}}
+	if t.clientID != "" {
+		query.Set("client_id", t.clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsTokenURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", time.Time{}, ucerr.Wrap(err)
+	}
+	req.Header.Set("Metadata", "true")
+
+	return requestToken(client, req, "managed identity")
+}
+
+// tokenResponse is returned by both the identity platform and the instance metadata
+// service, which differ in how they encode the expiry.
+type tokenResponse struct {
+	AccessToken      string          `json:"access_token"`
+	ExpiresIn        json.RawMessage `json:"expires_in"`
+	ExpiresOn        json.RawMessage `json:"expires_on"`
+	Error            string          `json:"error"`
+	ErrorDescription string          `json:"error_description"`
+}
+
+// requestToken sends a token request and returns the token along with its expiry.
+func requestToken(client *http.Client, req *http.Request, method string) (string, time.Time, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", time.Time{}, ucerr.Errorf("failed to get Azure %s token: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var tr tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return "", time.Time{}, ucerr.Errorf("failed to decode Azure %s token response (%s): %w", method, resp.Status, err)
+	}
+
+	if resp.StatusCode != http.StatusOK || tr.AccessToken == "" {
+		return "", time.Time{}, ucerr.Errorf("failed to get Azure %s token (%s): %s %s", method, resp.Status, tr.Error, tr.ErrorDescription)
+	}
+
+	if on := jsonSeconds(tr.ExpiresOn); on > 0 {
+		return tr.AccessToken, time.Unix(on, 0), nil
+	}
+
+	return tr.AccessToken, time.Now().Add(time.Duration(jsonSeconds(tr.ExpiresIn)) * time.Second), nil
+}
+
+// jsonSeconds parses a number of seconds that may be encoded as a JSON number or string,
+// returning 0 if it can't be parsed.
+func jsonSeconds(raw json.RawMessage) int64 {
+	s := strings.Trim(string(raw), `"`)
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
@@ -0,0 +1,174 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"time"
+
+	"userclouds.com/infra/namespace/universe"
+	"userclouds.com/infra/ucerr"
+)
+
+const (
+	// DNSSuffixEnvKey overrides the DNS suffix of key vaults, e.g. vault.azure.cn for
+	// Azure China.
+	DNSSuffixEnvKey = "UC_AZURE_KEY_VAULT_DNS_SUFFIX"
+
+	defaultDNSSuffix = "vault.azure.net"
+	apiVersion       = "7.4"
+	requestTimeout   = 30 * time.Second
+)
+
+// keyVaultClient is a Client for the key vault secrets REST API.
+type keyVaultClient struct {
+	credential credential
+	httpClient *http.Client
+
+	// vaultURL returns the base URL of a vault.
+	vaultURL func(vault string) string
+}
+
+// newKeyVaultClient returns a client authenticating with the credential.
+func newKeyVaultClient(cred credential) *keyVaultClient {
+	suffix := os.Getenv(DNSSuffixEnvKey)
+	if suffix == "" {
+		suffix = defaultDNSSuffix
+	}
+
+	return &keyVaultClient{
+		credential: cred,
+		httpClient: &http.Client{Timeout: requestTimeout},
+		vaultURL: func(vault string) string {
+			return fmt.Sprintf("https://%s.%s", vault, suffix)
+		},
+	}
+}
+
+type secretBundle struct {
+	Value string            `json:"value"`
+	Tags  map[string]string `json:"tags,omitempty"`
+}
+
+type secretListResult struct {
+	Value []struct {
+		ID string `json:"id"`
+	} `json:"value"`
+	NextLink string `json:"nextLink"`
+}
+
+type keyVaultError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// GetSecret returns the value of a version of a secret, or of the current version if
+// version is empty.
+func (c *keyVaultClient) GetSecret(ctx context.Context, vault, name, version string) (string, error) {
+	u := c.secretURL(vault, name)
+	if version != "" {
+		u = fmt.Sprintf("%s/secrets/%s/%s?api-version=%s", c.vaultURL(vault), url.PathEscape(name), url.PathEscape(version), apiVersion)
+	}
+
+	var bundle secretBundle
+	if err := c.do(ctx, http.MethodGet, u, nil, &bundle); err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	return bundle.Value, nil
+}
+
+// SetSecret creates a secret, or adds a new version of an existing secret.
+func (c *keyVaultClient) SetSecret(ctx context.Context, vault, name, value string) error {
+	bundle := secretBundle{
+		Value: value,
+		Tags:  map[string]string{universe.EnvKeyUniverse: string(universe.Current())},
+	}
+
+	return ucerr.Wrap(c.do(ctx, http.MethodPut, c.secretURL(vault, name), bundle, nil))
+}
+
+// DeleteSecret deletes all versions of a secret.
+func (c *keyVaultClient) DeleteSecret(ctx context.Context, vault, name string) error {
+	return ucerr.Wrap(c.do(ctx, http.MethodDelete, c.secretURL(vault, name), nil, nil))
+}
+
+// ListSecrets returns the names of the secrets in a vault, following nextLink until all
+// pages have been read.
+func (c *keyVaultClient) ListSecrets(ctx context.Context, vault string) ([]string, error) {
+	next := c.vaultURL(vault) + "/secrets?api-version=" + apiVersion
+	var names []string
+	for next != "" {
+		var result secretListResult
+		if err := c.do(ctx, http.MethodGet, next, nil, &result); err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+
+		for _, s := range result.Value {
+			// the ID is the secret URL, i.e. https://<vault>/secrets/<name>
+			names = append(names, path.Base(s.ID))
+		}
+		next = result.NextLink
+	}
+
+	return names, nil
+}
+
+func (c *keyVaultClient) secretURL(vault, name string) string {
+	return fmt.Sprintf("%s/secrets/%s?api-version=%s", c.vaultURL(vault), url.PathEscape(name), apiVersion)
+}
+
+// do sends an authenticated request, encoding body and decoding the response into out
+// if they are set.
+func (c *keyVaultClient) do(ctx context.Context, method, u string, body, out any) error {
+	token, err := c.credential.token(ctx, c.httpClient)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+		reqBody = bytes.NewReader(bs)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var kve keyVaultError
+		if err := json.NewDecoder(resp.Body).Decode(&kve); err == nil && kve.Error.Code != "" {
+			return ucerr.Errorf("key vault request failed (%s): %s: %s", resp.Status, kve.Error.Code, kve.Error.Message)
+		}
+		return ucerr.Errorf("key vault request failed (%s)", resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return ucerr.Wrap(json.NewDecoder(resp.Body).Decode(out))
+}
@@ -0,0 +1,94 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type staticCredential string
+
+func (c staticCredential) token(ctx context.Context, client *http.Client) (string, error) {
+	return string(c), nil
+}
+
+func TestAzure_keyVaultClient(t *testing.T) {
+	ctx := context.Background()
+	secrets := map[string]string{"plex-client-secret": "testsecret"}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, apiVersion, r.URL.Query().Get("api-version"))
+
+		switch {
+		case r.URL.Path == "/secrets" && r.URL.Query().Get("page") == "":
+			_, _ = w.Write([]byte(`{"value":[{"id":"https://vault/secrets/plex-client-secret"}],"nextLink":"` + server.URL + `/secrets?api-version=7.4&page=2"}`))
+		case r.URL.Path == "/secrets":
+			_, _ = w.Write([]byte(`{"value":[{"id":"https://vault/secrets/other"}]}`))
+		case r.Method == http.MethodPut:
+			var bundle secretBundle
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&bundle))
+			secrets[r.URL.Path[len("/secrets/"):]] = bundle.Value
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			value, ok := secrets[r.URL.Path[len("/secrets/"):]]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":{"code":"SecretNotFound","message":"not found"}}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(secretBundle{Value: value})
+		}
+	}))
+	defer server.Close()
+
+	c := newKeyVaultClient(staticCredential("token"))
+	c.vaultURL = func(vault string) string { return server.URL }
+
+	value, err := c.GetSecret(ctx, "vault", "plex-client-secret", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "testsecret", value)
+
+	assert.NoError(t, c.SetSecret(ctx, "vault", "new-secret", "value"))
+	assert.Equal(t, "value", secrets["new-secret"])
+
+	_, err = c.GetSecret(ctx, "vault", "missing", "")
+	assert.ErrorContains(t, err, "SecretNotFound")
+
+	names, err := c.ListSecrets(ctx, "vault")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"plex-client-secret", "other"}, names)
+}
+
+func TestAzure_cachedCredential(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "true", r.Header.Get("Metadata"))
+		assert.Equal(t, keyVaultResource, r.URL.Query().Get("resource"))
+		_, _ = w.Write([]byte(`{"access_token":"token","expires_on":"` + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10) + `"}`))
+	}))
+	defer server.Close()
+
+	cred := &cachedCredential{fetch: func(ctx context.Context, client *http.Client) (string, time.Time, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?resource="+keyVaultResource, nil)
+		assert.NoError(t, err)
+		req.Header.Set("Metadata", "true")
+		return requestToken(client, req, "managed identity")
+	}}
+
+	// the token is cached until it is about to expire
+	for range 2 {
+		token, err := cred.token(ctx, http.DefaultClient)
+		assert.NoError(t, err)
+		assert.Equal(t, "token", token)
+	}
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,71 @@
+package azure
+
+import (
+	"regexp"
+	"strings"
+
+	"userclouds.com/infra/ucerr"
+)
+
+const (
+	// MaxSecretNameLength is the maximum length of a key vault secret name.
+	MaxSecretNameLength = 127
+)
+
+var (
+	validVaultNameRegex   = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]{1,22}[a-zA-Z0-9]$`)
+	validNameRegex        = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+	invalidNameCharsRegex = regexp.MustCompile(`[^a-zA-Z0-9-]+`)
+)
+
+// ValidatePath checks that the secret name generated from the path is a valid key vault
+// secret name.  The returned error includes a sanitized name that can be used instead.
+func (p *Provider) ValidatePath(path string) error {
+	name := path
+	if isVaultPath(path) {
+		_, name, _ = strings.Cut(path, "/")
+	} else {
+		name = pathToSecretName(path)
+	}
+
+	var reason string
+	switch {
+	case name == "":
+		return ucerr.Errorf("secret name must not be empty")
+	case len(name) > MaxSecretNameLength:
+		reason = "must be no more than 127 characters"
+	case !validNameRegex.MatchString(name):
+		reason = "must only contain alphanumeric characters and dashes"
+	default:
+		return nil
+	}
+
+	return ucerr.Errorf("secret path '%s' is not a valid Azure key vault secret name '%s': %s (try '%s')", path, name, reason, SanitizeName(path))
+}
+
+// SanitizeName converts a path into a name that is valid for a key vault secret.
+func SanitizeName(path string) string {
+	name := invalidNameCharsRegex.ReplaceAllString(pathToSecretName(path), "-")
+	if len(name) > MaxSecretNameLength {
+		name = name[:MaxSecretNameLength]
+	}
+
+	return strings.Trim(name, "-")
+}
+
+// isVaultPath returns true if the path is in the form <vault>/<name> where the vault
+// is a valid key vault name.  Paths generated by NewString always contain more segments.
+func isVaultPath(path string) bool {
+	vault, name, found := strings.Cut(path, "/")
+	if !found || strings.Contains(name, "/") {
+		return false
+	}
+
+	return validVaultNameRegex.MatchString(vault) && !strings.Contains(vault, "--")
+}
+
+// pathToSecretName turns a userclouds secret path into a key vault compatible name,
+// since key vault names can't contain slashes or underscores.
+func pathToSecretName(path string) string {
+	return strings.NewReplacer("/", "--", "_", "-", ".", "-").Replace(path)
+}
@@ -0,0 +1,30 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAzure_ValidatePath(t *testing.T) {
+	p := New().WithVault("userclouds-prod")
+
+	// NewString paths are converted to valid names
+	assert.NoError(t, p.ValidatePath("userclouds/onprem/plex/client_secret"))
+	assert.Equal(t, "userclouds--onprem--plex--client-secret", pathToSecretName("userclouds/onprem/plex/client_secret"))
+	assert.NoError(t, p.ValidatePath("userclouds-prod/plex-client-secret"))
+
+	err := p.ValidatePath("userclouds/onprem/plex/client secret")
+	assert.ErrorContains(t, err, "try 'userclouds--onprem--plex--client-secret'")
+
+	err = p.ValidatePath("userclouds-prod/plex+secret")
+	assert.ErrorContains(t, err, "must only contain alphanumeric characters and dashes")
+}
+
+func TestAzure_isVaultPath(t *testing.T) {
+	assert.True(t, isVaultPath("userclouds-prod/plex-client-secret"))
+	assert.False(t, isVaultPath("userclouds/onprem/plex/client-secret"))
+	assert.False(t, isVaultPath("plex-client-secret"))
+	assert.False(t, isVaultPath("-vault/name"))
+	assert.False(t, isVaultPath("ab/name"))
+}
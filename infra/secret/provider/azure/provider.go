@@ -0,0 +1,210 @@
+package azure
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+const (
+	Prefix = "azkv://"
+
+	// VaultEnvKey names the key vault that secrets created with NewString are stored in,
+	// since their paths don't include a vault.
+	VaultEnvKey = "UC_AZURE_KEY_VAULT"
+
+	// healthCheckSecret is the secret that Ping reads, which isn't expected to exist.
+	healthCheckSecret = "userclouds-health-check"
+
+	// secretNotFoundCode is the key vault error code of secrets that don't exist.
+	secretNotFoundCode = "SecretNotFound"
+)
+
+// Provider is a SecretProvider implementation for Azure Key Vault.  Secrets are located
+// by <vault>/<name>, e.g. azkv://userclouds-prod/plex-client-secret.
+type Provider struct {
+	client Client
+	vault  string
+}
+
+// New returns an initialized provider.  The client authenticates with the client secret
+// of a service principal if AZURE_CLIENT_SECRET is set, and with the managed identity of
+// the VM or AKS node pool otherwise.
+func New() *Provider {
+	return &Provider{vault: os.Getenv(VaultEnvKey)}
+}
+
+// WithClient overrides the key vault client.  This is generally used for testing
+// purposes.
+func (p *Provider) WithClient(client Client) *Provider {
+	p.client = client
+	return p
+}
+
+// WithVault sets the key vault that secrets are stored in when their path doesn't
+// include one, overriding UC_AZURE_KEY_VAULT.
+func (p *Provider) WithVault(vault string) *Provider {
+	p.vault = vault
+	return p
+}
+
+// Prefix returns the URI prefix for a secret stored in Azure Key Vault.
+func (p *Provider) Prefix() string {
+	return Prefix
+}
+
+// IsDev is a helper function that returns true if the provider is explicitly used
+// in development environments.  This allows for dev specific behaviors to be handled.
+func (p *Provider) IsDev() bool {
+	return false
+}
+
+// Get retrieves the current version of a secret and returns its value.
+func (p *Provider) Get(ctx context.Context, path string) (string, error) {
+	return p.GetVersion(ctx, path, "")
+}
+
+// GetVersion retrieves a specific version of a secret by its version ID.
+func (p *Provider) GetVersion(ctx context.Context, path, version string) (string, error) {
+	vault, name, err := p.parsePath(path)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	p.initClient()
+	secret, err := p.client.GetSecret(ctx, vault, name, version)
+	if err != nil {
+		return "", ucerr.Errorf("failed to load Azure secret '%s' from key vault '%s': %w", name, vault, err)
+	}
+	uclog.Debugf(ctx, "Loaded Azure secret '%s' from key vault '%s'", name, vault)
+
+	return secret, nil
+}
+
+// Save creates a secret, or adds a new version of an existing secret.
+func (p *Provider) Save(ctx context.Context, path, secret string) error {
+	if err := p.ValidatePath(path); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	vault, name, err := p.parsePath(path)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	p.initClient()
+	uclog.Infof(ctx, "saving secret '%s' in Azure key vault '%s'", name, vault)
+	return ucerr.Wrap(p.client.SetSecret(ctx, vault, name, secret))
+}
+
+// Delete removes a secret from the key vault.  Vaults with soft delete enabled keep
+// the secret recoverable for their retention period.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	vault, name, err := p.parsePath(path)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	p.initClient()
+	uclog.Infof(ctx, "Delete secret '%s' in Azure key vault '%s'", name, vault)
+	return ucerr.Wrap(p.client.DeleteSecret(ctx, vault, name))
+}
+
+// Location returns the vault-qualified path that a secret saved with path can be
+// retrieved from, i.e. <vault>/<name>.
+func (p *Provider) Location(path string) string {
+	if isVaultPath(path) {
+		return path
+	}
+
+	return p.vault + "/" + pathToSecretName(path)
+}
+
+// List returns the secrets of the default key vault whose names start with the name of
+// pathPrefix.  The returned values are vault-qualified paths, which can be passed back
+// into Get, Save, and Delete unchanged.
+func (p *Provider) List(ctx context.Context, pathPrefix string) ([]string, error) {
+	if p.vault == "" {
+		return nil, ucerr.Errorf("no Azure key vault to list, set %s", VaultEnvKey)
+	}
+
+	p.initClient()
+	names, err := p.client.ListSecrets(ctx, p.vault)
+	if err != nil {
+		return nil, ucerr.Errorf("failed to list Azure secrets in key vault '%s': %w", p.vault, err)
+	}
+
+	namePrefix := pathToSecretName(pathPrefix)
+	var paths []string
+	for _, name := range names {
+		// secret names are case insensitive
+		if strings.HasPrefix(strings.ToLower(name), strings.ToLower(namePrefix)) {
+			paths = append(paths, p.vault+"/"+name)
+		}
+	}
+
+	return paths, nil
+}
+
+// Ping checks that the default key vault is reachable and that the service is allowed to
+// read its secrets, by reading a secret that doesn't exist, which fails with SecretNotFound
+// rather than Forbidden if it is.  Without a default vault there is nothing to check.
+func (p *Provider) Ping(ctx context.Context) error {
+	if p.vault == "" {
+		return nil
+	}
+
+	p.initClient()
+	_, err := p.client.GetSecret(ctx, p.vault, healthCheckSecret, "")
+	if err != nil && !strings.Contains(err.Error(), secretNotFoundCode) {
+		return ucerr.Errorf("failed to reach key vault %s: %w", p.vault, err)
+	}
+
+	return nil
+}
+
+// Exists returns whether the secret exists in its key vault.  Key vault has no way to
+// check without reading the secret, so the service must be allowed to read it.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	vault, name, err := p.parsePath(path)
+	if err != nil {
+		return false, ucerr.Wrap(err)
+	}
+
+	p.initClient()
+	if _, err := p.client.GetSecret(ctx, vault, name, ""); err != nil {
+		if strings.Contains(err.Error(), secretNotFoundCode) {
+			return false, nil
+		}
+		return false, ucerr.Errorf("failed to load Azure secret '%s' from key vault '%s': %w", name, vault, err)
+	}
+
+	return true, nil
+}
+
+// initClient initializes the key vault client if it has not been previously set.
+func (p *Provider) initClient() {
+	if p.client != nil {
+		return
+	}
+
+	p.client = newKeyVaultClient(credentialFromEnv())
+}
+
+// parsePath returns the vault and secret name for a path.  Vault-qualified paths are
+// split, and all other paths are converted to a name in the default vault.
+func (p *Provider) parsePath(path string) (string, string, error) {
+	if isVaultPath(path) {
+		vault, name, _ := strings.Cut(path, "/")
+		return vault, name, nil
+	}
+
+	if p.vault == "" {
+		return "", "", ucerr.Errorf("secret path '%s' doesn't include a key vault and %s is not set", path, VaultEnvKey)
+	}
+
+	return p.vault, pathToSecretName(path), nil
+}
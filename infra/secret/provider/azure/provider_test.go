@@ -0,0 +1,61 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAzure_Get(t *testing.T) {
+	ctx := context.Background()
+	kv := &MockClient{}
+	kv.On("GetSecret", ctx, "userclouds-prod", "plex-client-secret", "").Return("testsecret", nil)
+	kv.On("GetSecret", ctx, "userclouds-default", "userclouds--onprem--plex--client-secret", "").Return("legacysecret", nil)
+
+	p := New().WithClient(kv).WithVault("userclouds-default")
+	secret, err := p.Get(ctx, "userclouds-prod/plex-client-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "testsecret", secret)
+
+	// paths without a vault use the default vault
+	secret, err = p.Get(ctx, "userclouds/onprem/plex/client_secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "legacysecret", secret)
+
+	_, err = New().WithClient(kv).WithVault("").Get(ctx, "userclouds/onprem/plex/client_secret")
+	assert.ErrorContains(t, err, VaultEnvKey)
+	kv.AssertExpectations(t)
+}
+
+func TestAzure_SaveAndList(t *testing.T) {
+	ctx := context.Background()
+	kv := &MockClient{}
+	kv.On("SetSecret", ctx, "userclouds-default", "userclouds--onprem--plex--client-secret", "s3cret").Return(nil)
+	kv.On("ListSecrets", ctx, "userclouds-default").Return([]string{"userclouds--onprem--plex--client-secret", "other"}, nil)
+
+	p := New().WithClient(kv).WithVault("userclouds-default")
+	assert.NoError(t, p.Save(ctx, "userclouds/onprem/plex/client_secret", "s3cret"))
+	assert.Equal(t, "userclouds-default/userclouds--onprem--plex--client-secret", p.Location("userclouds/onprem/plex/client_secret"))
+
+	paths, err := p.List(ctx, "userclouds/onprem/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"userclouds-default/userclouds--onprem--plex--client-secret"}, paths)
+	kv.AssertExpectations(t)
+}
+
+func TestAzure_Ping(t *testing.T) {
+	ctx := context.Background()
+	kv := &MockClient{}
+	kv.On("GetSecret", ctx, "vault", healthCheckSecret, "").Return("", errors.New("key vault request failed (404 Not Found): SecretNotFound: not found")).Once()
+	kv.On("GetSecret", ctx, "vault", healthCheckSecret, "").Return("", errors.New("key vault request failed (403 Forbidden): Forbidden: denied")).Once()
+
+	provider := New().WithClient(kv).WithVault("vault")
+	assert.NoError(t, provider.Ping(ctx))
+	assert.ErrorContains(t, provider.Ping(ctx), "Forbidden")
+
+	// nothing to check without a default vault
+	assert.NoError(t, New().WithClient(kv).WithVault("").Ping(ctx))
+	kv.AssertExpectations(t)
+}
@@ -0,0 +1,41 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// Client is an interface that defines the required functions for the provider to
+// interact with Azure Key Vault.  This mirrors the key vault secrets REST API.
+type Client interface {
+	GetSecret(ctx context.Context, vault, name, version string) (string, error)
+	SetSecret(ctx context.Context, vault, name, value string) error
+	DeleteSecret(ctx context.Context, vault, name string) error
+	ListSecrets(ctx context.Context, vault string) ([]string, error)
+}
+
+// MockClient is an implementation of the Client interface used for testing.
+type MockClient struct {
+	mock.Mock
+}
+
+func (c *MockClient) GetSecret(ctx context.Context, vault, name, version string) (string, error) {
+	args := c.Called(ctx, vault, name, version)
+	return args.String(0), args.Error(1)
+}
+
+func (c *MockClient) SetSecret(ctx context.Context, vault, name, value string) error {
+	args := c.Called(ctx, vault, name, value)
+	return args.Error(0)
+}
+
+func (c *MockClient) DeleteSecret(ctx context.Context, vault, name string) error {
+	args := c.Called(ctx, vault, name)
+	return args.Error(0)
+}
+
+func (c *MockClient) ListSecrets(ctx context.Context, vault string) ([]string, error) {
+	args := c.Called(ctx, vault)
+	return args.Get(0).([]string), args.Error(1)
+}
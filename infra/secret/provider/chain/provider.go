@@ -0,0 +1,204 @@
+package chain
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+const (
+	Prefix = "chain://"
+)
+
+// Interface mirrors provider.Interface, which can't be imported here since the provider
+// package builds chains.
+type Interface interface {
+	Get(ctx context.Context, path string) (string, error)
+	Delete(ctx context.Context, path string) error
+	Save(ctx context.Context, path, secret string) error
+	Prefix() string
+	IsDev() bool
+}
+
+// Provider tries an ordered list of providers, so that the same secret locations resolve
+// in environments that store secrets differently, e.g. in kubernetes secrets in a cluster
+// and in environment variables in CI.  Secrets are saved to and deleted from the first
+// provider, which is the primary one.
+type Provider struct {
+	providers []Interface
+}
+
+// New returns a provider trying providers in order.
+func New(providers ...Interface) (*Provider, error) {
+	if len(providers) == 0 {
+		return nil, ucerr.Errorf("secret provider chain must have at least one provider")
+	}
+
+	return &Provider{providers: providers}, nil
+}
+
+// Prefix returns the URI prefix for a secret resolved through the chain.
+func (p *Provider) Prefix() string {
+	return Prefix
+}
+
+// IsDev returns false, since chained secrets are stored by path even if a development
+// provider is part of the chain.
+func (p *Provider) IsDev() bool {
+	return false
+}
+
+// Get returns the secret from the first provider that resolves the path.  If none of them
+// do, the error lists each provider's failure.
+func (p *Provider) Get(ctx context.Context, path string) (string, error) {
+	var failures []string
+	for _, pv := range p.providers {
+		secret, err := pv.Get(ctx, path)
+		if err == nil {
+			uclog.Debugf(ctx, "Resolved secret '%s' with provider %s", path, pv.Prefix())
+			return secret, nil
+		}
+
+		failures = append(failures, pv.Prefix()+" "+ucerr.FirstLine(err))
+	}
+
+	return "", ucerr.Errorf("no secret provider in the chain resolved '%s': %s", path, strings.Join(failures, "; "))
+}
+
+// GetVersion returns a version of the secret from the first provider that resolves it.
+// Providers that don't keep versions are skipped.
+func (p *Provider) GetVersion(ctx context.Context, path, version string) (string, error) {
+	var failures []string
+	for _, pv := range p.providers {
+		vg, ok := pv.(interface {
+			GetVersion(ctx context.Context, path, version string) (string, error)
+		})
+		if !ok {
+			failures = append(failures, pv.Prefix()+" doesn't keep versions")
+			continue
+		}
+
+		secret, err := vg.GetVersion(ctx, path, version)
+		if err == nil {
+			uclog.Debugf(ctx, "Resolved version %s of secret '%s' with provider %s", version, path, pv.Prefix())
+			return secret, nil
+		}
+
+		failures = append(failures, pv.Prefix()+" "+ucerr.FirstLine(err))
+	}
+
+	return "", ucerr.Errorf("no secret provider in the chain resolved version %s of '%s': %s", version, path, strings.Join(failures, "; "))
+}
+
+// GetTimestamps returns when the secret was created and last updated from the first
+// provider that resolves it.  Providers that don't track them are skipped.
+func (p *Provider) GetTimestamps(ctx context.Context, path string) (time.Time, time.Time, error) {
+	var failures []string
+	for _, pv := range p.providers {
+		tg, ok := pv.(interface {
+			GetTimestamps(ctx context.Context, path string) (time.Time, time.Time, error)
+		})
+		if !ok {
+			failures = append(failures, pv.Prefix()+" doesn't track when secrets are updated")
+			continue
+		}
+
+		created, updated, err := tg.GetTimestamps(ctx, path)
+		if err == nil {
+			return created, updated, nil
+		}
+
+		failures = append(failures, pv.Prefix()+" "+ucerr.FirstLine(err))
+	}
+
+	return time.Time{}, time.Time{}, ucerr.Errorf("no secret provider in the chain has timestamps for '%s': %s", path, strings.Join(failures, "; "))
+}
+
+// Save stores the secret with the primary provider.
+func (p *Provider) Save(ctx context.Context, path, secret string) error {
+	return ucerr.Wrap(p.providers[0].Save(ctx, path, secret))
+}
+
+// Delete removes the secret from the primary provider.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	return ucerr.Wrap(p.providers[0].Delete(ctx, path))
+}
+
+// ValidatePath checks that the primary provider can store the path.
+func (p *Provider) ValidatePath(path string) error {
+	if v, ok := p.providers[0].(interface{ ValidatePath(string) error }); ok {
+		return ucerr.Wrap(v.ValidatePath(path))
+	}
+
+	return nil
+}
+
+// Ping checks the providers of the chain that depend on a backend.  The chain is healthy
+// if any of them is, like Get resolves secrets if any provider does, and the error lists
+// each provider's failure otherwise.
+func (p *Provider) Ping(ctx context.Context) error {
+	var failures []string
+	for _, pv := range p.providers {
+		hc, ok := pv.(interface {
+			Ping(ctx context.Context) error
+		})
+		if !ok {
+			return nil
+		}
+
+		err := hc.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+
+		failures = append(failures, pv.Prefix()+" "+ucerr.FirstLine(err))
+	}
+
+	return ucerr.Errorf("no secret provider in the chain is reachable: %s", strings.Join(failures, "; "))
+}
+
+// Exists returns whether any provider in the chain has the secret.  Providers that can't
+// check whether a secret exists have it if it resolves.  If none of them have it and
+// some fail, the error lists each provider's failure.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	var failures []string
+	for _, pv := range p.providers {
+		var err error
+		if ec, ok := pv.(interface {
+			Exists(ctx context.Context, path string) (bool, error)
+		}); ok {
+			var exists bool
+			if exists, err = ec.Exists(ctx, path); exists {
+				return true, nil
+			}
+		} else if _, err = pv.Get(ctx, path); err == nil {
+			return true, nil
+		}
+
+		if err != nil {
+			failures = append(failures, pv.Prefix()+" "+ucerr.FirstLine(err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return false, ucerr.Errorf("no secret provider in the chain has '%s': %s", path, strings.Join(failures, "; "))
+	}
+
+	return false, nil
+}
+
+// List returns the secrets of the primary provider which start with pathPrefix.
+func (p *Provider) List(ctx context.Context, pathPrefix string) ([]string, error) {
+	lister, ok := p.providers[0].(interface {
+		List(ctx context.Context, pathPrefix string) ([]string, error)
+	})
+	if !ok {
+		return nil, ucerr.Errorf("secret provider %s does not support listing", p.providers[0].Prefix())
+	}
+
+	paths, err := lister.List(ctx, pathPrefix)
+	return paths, ucerr.Wrap(err)
+}
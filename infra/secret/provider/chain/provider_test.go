@@ -0,0 +1,59 @@
+package chain
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret/provider/env"
+	"userclouds.com/infra/secret/provider/file"
+)
+
+func TestChain_Get(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	t.Setenv("USERCLOUDS_ONPREM_PLEX_FROM_ENV", "env-secret")
+
+	p, err := New(file.New().WithRoot(root), env.New())
+	assert.NoError(t, err)
+
+	// secrets are saved with the primary provider, which resolves them first
+	assert.NoError(t, p.Save(ctx, "userclouds/onprem/plex/from_env", "file-secret"))
+	secret, err := p.Get(ctx, "userclouds/onprem/plex/from_env")
+	assert.NoError(t, err)
+	assert.Equal(t, "file-secret", secret)
+
+	// later providers are tried when the earlier ones fail
+	assert.NoError(t, p.Delete(ctx, "userclouds/onprem/plex/from_env"))
+	secret, err = p.Get(ctx, "userclouds/onprem/plex/from_env")
+	assert.NoError(t, err)
+	assert.Equal(t, "env-secret", secret)
+
+	_, err = p.Get(ctx, "userclouds/onprem/plex/missing")
+	assert.ErrorContains(t, err, "file:// ")
+	assert.ErrorContains(t, err, "env:// ")
+
+	_, err = New()
+	assert.Error(t, err)
+}
+
+func TestChain_Ping(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	missing := file.New().WithRoot(filepath.Join(root, "missing"))
+
+	pv, err := New(missing, file.New().WithRoot(root))
+	assert.NoError(t, err)
+	assert.NoError(t, pv.Ping(ctx))
+
+	// providers without a backend are always reachable
+	pv, err = New(missing, env.New())
+	assert.NoError(t, err)
+	assert.NoError(t, pv.Ping(ctx))
+
+	pv, err = New(missing, missing)
+	assert.NoError(t, err)
+	assert.ErrorContains(t, pv.Ping(ctx), "no secret provider in the chain is reachable")
+}
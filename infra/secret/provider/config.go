@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"strings"
+
+	"userclouds.com/infra/secret/provider/aws"
+	"userclouds.com/infra/secret/provider/azure"
+	"userclouds.com/infra/secret/provider/file"
+	"userclouds.com/infra/secret/provider/kubernetes"
+	"userclouds.com/infra/ucerr"
+)
+
+// Config selects the secret provider of a service in its config, instead of
+// UC_SECRET_MANAGER.  The settings only apply to the providers that use them, e.g.
+//
+//	secrets:
+//	  type: aws
+//	  region: us-west-2
+//	  role: arn:aws:iam::123456789012:role/secrets-reader
+type Config struct {
+	// Type names the provider like UC_SECRET_MANAGER, e.g. aws, kubernetes,
+	// chain:kubernetes,env or enc+kubernetes
+	Type string `yaml:"type" json:"type" validate:"notempty"`
+
+	Region     string `yaml:"region,omitempty" json:"region,omitempty"`           // aws
+	Role       string `yaml:"role,omitempty" json:"role,omitempty"`               // aws role ARN to assume
+	ExternalID string `yaml:"external_id,omitempty" json:"external_id,omitempty"` // aws, with role
+	Namespace  string `yaml:"namespace,omitempty" json:"namespace,omitempty"`     // kubernetes
+	Vault      string `yaml:"vault,omitempty" json:"vault,omitempty"`             // azure
+	Root       string `yaml:"root,omitempty" json:"root,omitempty"`               // file
+}
+
+//go:generate genvalidate Config
+
+func (c Config) extraValidate() error {
+	if c.ExternalID != "" && c.Role == "" {
+		return ucerr.Friendlyf(nil, "Config.ExternalID requires Config.Role to be set")
+	}
+
+	if _, err := FromConfig(&c); err != nil {
+		return ucerr.Friendlyf(err, "Config.Type '%s' isn't a valid secret provider", c.Type)
+	}
+
+	return nil
+}
+
+// FromConfig returns the provider selected by cfg, or the one selected by the environment
+// if cfg is nil (see FromEnv).
+func FromConfig(cfg *Config) (Interface, error) {
+	if cfg == nil {
+		return FromEnv()
+	}
+
+	stores := cfg.storeMap()
+	if names, ok := strings.CutPrefix(cfg.Type, chainPrefix); ok {
+		return chainFromNames(stores, names)
+	}
+
+	return fromName(stores, cfg.Type)
+}
+
+// storeMap returns the built-in providers with the settings of the config applied.
+func (c Config) storeMap() map[string]Interface {
+	stores := storeMap()
+
+	awsProvider := aws.New()
+	if c.Region != "" {
+		awsProvider.WithRegion(c.Region)
+	}
+	if c.Role != "" {
+		awsProvider.WithAssumeRole(c.Role, c.ExternalID)
+	}
+	stores["aws"] = awsProvider
+
+	if c.Namespace != "" {
+		stores["kubernetes"] = kubernetes.New().WithNamespace(c.Namespace)
+	}
+	if c.Vault != "" {
+		stores["azure"] = azure.New().WithVault(c.Vault)
+	}
+	if c.Root != "" {
+		stores["file"] = file.New().WithRoot(c.Root)
+	}
+
+	return stores
+}
@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromConfig(t *testing.T) {
+	t.Setenv(SecretManagerEnvKey, "file")
+
+	pv, err := FromConfig(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "file://", pv.Prefix())
+
+	pv, err = FromConfig(&Config{Type: "aws", Region: "us-west-2", Role: "arn:aws:iam::123456789012:role/secrets"})
+	assert.NoError(t, err)
+	assert.Equal(t, "aws://secrets/", pv.Prefix())
+
+	pv, err = FromConfig(&Config{Type: "chain:kubernetes,env", Namespace: "userclouds"})
+	assert.NoError(t, err)
+	assert.Equal(t, "chain://", pv.Prefix())
+
+	root := t.TempDir()
+	pv, err = FromConfig(&Config{Type: "file", Root: root})
+	assert.NoError(t, err)
+	assert.NoError(t, ValidatePath(pv, "db-password"))
+	assert.Equal(t, root+"/db-password", pv.(Locator).Location("db-password"))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, Config{Type: "kubernetes", Namespace: "userclouds"}.Validate())
+	assert.Error(t, Config{}.Validate())
+	assert.Error(t, Config{Type: "vault"}.Validate())
+	assert.Error(t, Config{Type: "aws", ExternalID: "external"}.Validate())
+}
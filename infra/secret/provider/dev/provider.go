@@ -4,17 +4,21 @@ import (
 	"context"
 	"encoding/base64"
 
+	"userclouds.com/infra/secret/provider/file"
 	"userclouds.com/infra/ucerr"
 )
 
 const (
 	PrefixDev        = "dev://"
 	PrefixDevLiteral = "dev-literal://"
+	PrefixDevFile    = "dev-file://"
 )
 
 // Provider defines a development provider.
 type Provider struct {
 	decode bool
+	store  *file.Provider // persists secrets in a local directory if set, see WithStore
+	key    []byte         // encrypts persisted secrets if set, see WithStoreKey
 }
 
 // New returns an initialized development provider.
@@ -35,6 +39,10 @@ func (p *Provider) WithLiterals() *Provider {
 // second as dev-literal which contains an unencoded plain text secret.  These are used
 // in testing and should not be used in production services.
 func (p *Provider) Prefix() string {
+	if p.store != nil {
+		return PrefixDevFile
+	}
+
 	// This is a bit naive, but at the moment dev only enables decode.
 	if p.decode {
 		return PrefixDev
@@ -45,13 +53,20 @@ func (p *Provider) Prefix() string {
 
 // IsDev is a helper function that returns true if the provider is explicitly used
 // in development environments.  This allows for dev specific behaviors to be handled.
+// Persisted secrets are saved at stable locations like any other provider's, so it
+// returns false for them.
 func (p *Provider) IsDev() bool {
-	return true
+	return p.store == nil
 }
 
 // Get is just a passthrough returning the 'path' which is the secret value
-// i.e. dev://<base64_encoded_secret> or dev-literal://<secret>.
+// i.e. dev://<base64_encoded_secret> or dev-literal://<secret>.  Persisted secrets are
+// read from the store instead, i.e. dev-file://<path>.
 func (p *Provider) Get(ctx context.Context, path string) (string, error) {
+	if p.store != nil {
+		return p.getStored(ctx, path)
+	}
+
 	secret := path
 
 	if p.decode {
@@ -66,12 +81,20 @@ func (p *Provider) Get(ctx context.Context, path string) (string, error) {
 	return secret, nil
 }
 
-// Save does nothing for the dev provider.
+// Save does nothing for the dev provider, unless it persists secrets.
 func (p *Provider) Save(ctx context.Context, path, secret string) error {
+	if p.store != nil {
+		return p.saveStored(ctx, path, secret)
+	}
+
 	return nil
 }
 
-// Delete does nothing for the dev provider.
+// Delete does nothing for the dev provider, unless it persists secrets.
 func (p *Provider) Delete(ctx context.Context, path string) error {
+	if p.store != nil {
+		return ucerr.Wrap(p.store.Delete(ctx, path))
+	}
+
 	return nil
 }
@@ -0,0 +1,154 @@
+package dev
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"userclouds.com/infra/secret/provider/file"
+	"userclouds.com/infra/ucerr"
+)
+
+const (
+	// StoreDirEnvKey overrides the directory that persisted dev secrets are saved under.
+	StoreDirEnvKey = "UC_DEV_SECRET_DIR"
+
+	// StoreKeyEnvKey sets a passphrase that persisted dev secrets are encrypted with.  This
+	// only keeps them from being read at a glance, and isn't meant to protect production
+	// secrets.
+	StoreKeyEnvKey = "UC_DEV_SECRET_KEY"
+
+	// encryptedPrefix marks persisted secrets that are encrypted, so that secrets saved
+	// before a key was set can still be read.
+	encryptedPrefix = "enc:"
+)
+
+// NewStore returns a development provider that persists secrets in UC_DEV_SECRET_DIR, or
+// ~/.userclouds/secrets if it isn't set, so that secrets created with NewString in local
+// development keep the same dev-file:// location across restarts.  Secrets are encrypted
+// if UC_DEV_SECRET_KEY is set.
+func NewStore() *Provider {
+	dir := os.Getenv(StoreDirEnvKey)
+	if dir == "" {
+		// fall back to the working directory if there's no home, e.g. in a container
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".userclouds", "secrets")
+	}
+
+	return New().WithStore(dir).WithStoreKey(os.Getenv(StoreKeyEnvKey))
+}
+
+// WithStore persists secrets in files under dir, overriding UC_DEV_SECRET_DIR.
+func (p *Provider) WithStore(dir string) *Provider {
+	p.store = file.New().WithRoot(dir)
+	return p
+}
+
+// WithStoreKey encrypts persisted secrets with a key derived from passphrase, overriding
+// UC_DEV_SECRET_KEY.  An empty passphrase saves them in plain text.
+func (p *Provider) WithStoreKey(passphrase string) *Provider {
+	p.key = nil
+	if passphrase != "" {
+		key := sha256.Sum256([]byte(passphrase))
+		p.key = key[:]
+	}
+	return p
+}
+
+// ValidatePath checks that a persisted secret can be saved at the path, which must be
+// relative to the store directory.  Other dev secrets have no path restrictions.
+func (p *Provider) ValidatePath(path string) error {
+	if p.store == nil {
+		return nil
+	}
+
+	if filepath.IsAbs(path) {
+		return ucerr.Errorf("dev secret path '%s' must be relative to the secret directory", path)
+	}
+
+	return ucerr.Wrap(p.store.ValidatePath(path))
+}
+
+// getStored reads a persisted secret, decrypting it if needed.
+func (p *Provider) getStored(ctx context.Context, path string) (string, error) {
+	if err := p.ValidatePath(path); err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	secret, err := p.store.Get(ctx, path)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	encrypted, ok := strings.CutPrefix(secret, encryptedPrefix)
+	if !ok {
+		return secret, nil
+	}
+	if p.key == nil {
+		return "", ucerr.Errorf("dev secret %s is encrypted, but %s isn't set", path, StoreKeyEnvKey)
+	}
+
+	bs, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	gcm, err := p.cipher()
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	if len(bs) < gcm.NonceSize() {
+		return "", ucerr.Errorf("dev secret %s is corrupted", path)
+	}
+
+	plain, err := gcm.Open(nil, bs[:gcm.NonceSize()], bs[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ucerr.Errorf("failed to decrypt dev secret %s, was it saved with another %s? %w", path, StoreKeyEnvKey, err)
+	}
+
+	return string(plain), nil
+}
+
+// saveStored persists a secret, encrypting it if a key is set.
+func (p *Provider) saveStored(ctx context.Context, path, secret string) error {
+	if err := p.ValidatePath(path); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if p.key != nil {
+		gcm, err := p.cipher()
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return ucerr.Wrap(err)
+		}
+
+		secret = encryptedPrefix + base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil))
+	}
+
+	return ucerr.Wrap(p.store.Save(ctx, path, secret))
+}
+
+// cipher returns the AEAD that persisted secrets are encrypted with.
+func (p *Provider) cipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(p.key)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return gcm, nil
+}
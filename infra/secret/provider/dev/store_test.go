@@ -0,0 +1,77 @@
+package dev
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	pv := New().WithStore(dir)
+	assert.Equal(t, PrefixDevFile, pv.Prefix())
+	assert.False(t, pv.IsDev())
+
+	assert.NoError(t, pv.Save(ctx, "userclouds/dev/plex/client_secret", "hunter2"))
+	bs, err := os.ReadFile(filepath.Join(dir, "userclouds", "dev", "plex", "client_secret"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", string(bs))
+
+	// a new provider reads the secret, e.g. after a restart
+	v, err := New().WithStore(dir).Get(ctx, "userclouds/dev/plex/client_secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", v)
+
+	assert.Error(t, pv.Save(ctx, "/etc/passwd", "nope"))
+	assert.Error(t, pv.Save(ctx, "../escape", "nope"))
+	_, err = pv.Get(ctx, "/etc/passwd")
+	assert.Error(t, err)
+
+	assert.NoError(t, pv.Delete(ctx, "userclouds/dev/plex/client_secret"))
+	_, err = pv.Get(ctx, "userclouds/dev/plex/client_secret")
+	assert.Error(t, err)
+}
+
+func TestStore_Encrypted(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	pv := New().WithStore(dir).WithStoreKey("passphrase")
+	assert.NoError(t, pv.Save(ctx, "test/secret", "hunter2"))
+	bs, err := os.ReadFile(filepath.Join(dir, "test", "secret"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(bs), encryptedPrefix))
+	assert.NotContains(t, string(bs), "hunter2")
+
+	v, err := pv.Get(ctx, "test/secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", v)
+
+	_, err = New().WithStore(dir).WithStoreKey("other").Get(ctx, "test/secret")
+	assert.Error(t, err)
+	_, err = New().WithStore(dir).Get(ctx, "test/secret")
+	assert.Error(t, err)
+
+	// plain text secrets saved before the key was set can still be read
+	assert.NoError(t, New().WithStore(dir).Save(ctx, "test/plain", "plain"))
+	v, err = pv.Get(ctx, "test/plain")
+	assert.NoError(t, err)
+	assert.Equal(t, "plain", v)
+}
+
+func TestNewStore(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(StoreDirEnvKey, dir)
+	t.Setenv(StoreKeyEnvKey, "")
+
+	ctx := context.Background()
+	assert.NoError(t, NewStore().Save(ctx, "test/secret", "value"))
+	_, err := os.Stat(filepath.Join(dir, "test", "secret"))
+	assert.NoError(t, err)
+}
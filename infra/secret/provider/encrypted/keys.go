@@ -0,0 +1,161 @@
+package encrypted
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"userclouds.com/infra/ucerr"
+)
+
+// KeyEnvKey holds the base64 encoded 32 byte keys that data keys are wrapped with when no
+// KeyWrapper is set.  Keys are comma separated: the first one wraps new data keys, and
+// the others can still unwrap the data keys of secrets saved before it was rotated in.
+const KeyEnvKey = "UC_SECRET_ENCRYPTION_KEY"
+
+// KeyWrapper encrypts the data keys that secrets are encrypted with, e.g. with a KMS key
+// or a local key encryption key, so that the stored ciphertext can only be decrypted by
+// services that are allowed to unwrap them.
+type KeyWrapper interface {
+	// KeyID identifies the key that WrapKey uses, and is stored with each secret.
+	KeyID() string
+	WrapKey(ctx context.Context, dataKey []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+var defaultKeyWrapper atomic.Value // keyWrapperHolder
+
+// keyWrapperHolder lets atomic.Value hold KeyWrappers of different types, and none.
+type keyWrapperHolder struct {
+	KeyWrapper
+}
+
+// SetKeyWrapper sets the KeyWrapper of the providers created afterwards, e.g. one backed
+// by a KMS key, overriding UC_SECRET_ENCRYPTION_KEY.  A nil wrapper goes back to the
+// environment.
+func SetKeyWrapper(k KeyWrapper) {
+	defaultKeyWrapper.Store(keyWrapperHolder{k})
+}
+
+// keyWrapperFromEnv returns the KeyWrapper set with SetKeyWrapper, or the local keys in
+// UC_SECRET_ENCRYPTION_KEY.
+func keyWrapperFromEnv() (KeyWrapper, error) {
+	if h, ok := defaultKeyWrapper.Load().(keyWrapperHolder); ok && h.KeyWrapper != nil {
+		return h.KeyWrapper, nil
+	}
+
+	value := os.Getenv(KeyEnvKey)
+	if value == "" {
+		return nil, ucerr.Errorf("encrypted secrets require %s or a key wrapper to be set", KeyEnvKey)
+	}
+
+	var keys [][]byte
+	for encoded := range strings.SplitSeq(value, ",") {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, ucerr.Errorf("invalid key in %s: %w", KeyEnvKey, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return NewLocalKeyWrapper(keys...)
+}
+
+// LocalKeyWrapper wraps data keys with AES-GCM key encryption keys held by the service.
+type LocalKeyWrapper struct {
+	ids   []string
+	aeads map[string]cipher.AEAD
+}
+
+// NewLocalKeyWrapper returns a KeyWrapper that wraps data keys with the first of keys,
+// and unwraps them with any of them.  Keys must be 32 bytes.
+func NewLocalKeyWrapper(keys ...[]byte) (*LocalKeyWrapper, error) {
+	if len(keys) == 0 {
+		return nil, ucerr.New("at least one key encryption key is required")
+	}
+
+	k := &LocalKeyWrapper{aeads: map[string]cipher.AEAD{}}
+	for _, key := range keys {
+		if len(key) != 32 {
+			return nil, ucerr.Errorf("key encryption keys must be 32 bytes, got %d", len(key))
+		}
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, ucerr.Wrap(err)
+		}
+
+		// the id is a hash of the key, so that it can be stored without revealing it
+		sum := sha256.Sum256(key)
+		id := "local:" + hex.EncodeToString(sum[:8])
+		k.ids = append(k.ids, id)
+		k.aeads[id] = aead
+	}
+
+	return k, nil
+}
+
+// KeyID returns the id of the key that new data keys are wrapped with.
+func (k *LocalKeyWrapper) KeyID() string {
+	return k.ids[0]
+}
+
+// WrapKey encrypts a data key with the first key.
+func (k *LocalKeyWrapper) WrapKey(_ context.Context, dataKey []byte) ([]byte, error) {
+	return seal(k.aeads[k.ids[0]], dataKey, []byte(k.ids[0]))
+}
+
+// UnwrapKey decrypts a data key with the key it was wrapped with.
+func (k *LocalKeyWrapper) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.aeads[keyID]
+	if !ok {
+		return nil, ucerr.Errorf("data key was wrapped with unknown key '%s'", keyID)
+	}
+
+	return open(aead, wrapped, []byte(keyID))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return gcm, nil
+}
+
+// seal encrypts plain, prepending a random nonce to the ciphertext.
+func seal(aead cipher.AEAD, plain, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return aead.Seal(nonce, nonce, plain, additionalData), nil
+}
+
+// open decrypts the output of seal.
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ucerr.New("ciphertext is too short")
+	}
+
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return plain, nil
+}
@@ -0,0 +1,244 @@
+package encrypted
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"userclouds.com/infra/ucerr"
+)
+
+const (
+	// Prefix is prepended to the prefix of the provider that stores the ciphertext, e.g.
+	// enc+kube://secrets/.
+	Prefix = "enc+"
+
+	envelopeVersion = 1
+)
+
+// Interface mirrors provider.Interface, which can't be imported here since the provider
+// package wraps providers with this one.
+type Interface interface {
+	Get(ctx context.Context, path string) (string, error)
+	Delete(ctx context.Context, path string) error
+	Save(ctx context.Context, path, secret string) error
+	Prefix() string
+	IsDev() bool
+}
+
+// Provider encrypts secrets before another provider stores them, so that backends that
+// are readable by more than the services using the secrets, such as plain kubernetes
+// secrets, only ever hold ciphertext.  Each secret is encrypted with its own random data
+// key, which is stored next to it wrapped by a KeyWrapper.
+type Provider struct {
+	inner Interface
+	keys  KeyWrapper
+	err   error // why there are no keys
+}
+
+// envelope is what the underlying provider stores.
+type envelope struct {
+	Version    int    `json:"v"`
+	KeyID      string `json:"kid"`
+	WrappedKey string `json:"key"`
+	Ciphertext string `json:"data"`
+}
+
+// New returns a provider encrypting the secrets stored by inner, with the key wrapper set
+// with SetKeyWrapper or the keys in UC_SECRET_ENCRYPTION_KEY.
+func New(inner Interface) *Provider {
+	keys, err := keyWrapperFromEnv()
+	return &Provider{inner: inner, keys: keys, err: err}
+}
+
+// WithKeyWrapper sets the KeyWrapper that data keys are wrapped with.
+func (p *Provider) WithKeyWrapper(k KeyWrapper) *Provider {
+	p.keys = k
+	p.err = nil
+	return p
+}
+
+// Prefix returns the URI prefix of the underlying provider, marked as encrypted.
+func (p *Provider) Prefix() string {
+	return Prefix + p.inner.Prefix()
+}
+
+// IsDev returns false, the ciphertext is stored by path.
+func (p *Provider) IsDev() bool {
+	return false
+}
+
+// Get decrypts the secret stored at path.  Secrets that weren't saved encrypted are
+// rejected rather than returned as is.
+func (p *Provider) Get(ctx context.Context, path string) (string, error) {
+	if p.keys == nil {
+		return "", ucerr.Wrap(p.err)
+	}
+
+	stored, err := p.inner.Get(ctx, path)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	var env envelope
+	if err := json.Unmarshal([]byte(stored), &env); err != nil || env.Version != envelopeVersion {
+		return "", ucerr.Errorf("secret '%s' of %s isn't encrypted", path, p.inner.Prefix())
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(env.WrappedKey)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(env.Ciphertext)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	dataKey, err := p.keys.UnwrapKey(ctx, env.KeyID, wrapped)
+	if err != nil {
+		return "", ucerr.Errorf("failed to unwrap the data key of secret '%s': %w", path, err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	plain, err := open(aead, ciphertext, p.additionalData(path))
+	if err != nil {
+		return "", ucerr.Errorf("failed to decrypt secret '%s': %w", path, err)
+	}
+
+	return string(plain), nil
+}
+
+// Save encrypts the secret with a new data key and saves it with the underlying provider.
+func (p *Provider) Save(ctx context.Context, path, secret string) error {
+	if p.keys == nil {
+		return ucerr.Wrap(p.err)
+	}
+	if p.inner.IsDev() {
+		return ucerr.Errorf("cannot encrypt %s secrets, which are stored in their location", p.inner.Prefix())
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	ciphertext, err := seal(aead, []byte(secret), p.additionalData(path))
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	keyID := p.keys.KeyID()
+	wrapped, err := p.keys.WrapKey(ctx, dataKey)
+	if err != nil {
+		return ucerr.Errorf("failed to wrap the data key of secret '%s': %w", path, err)
+	}
+
+	bs, err := json.Marshal(envelope{
+		Version:    envelopeVersion,
+		KeyID:      keyID,
+		WrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	})
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	return ucerr.Wrap(p.inner.Save(ctx, path, string(bs)))
+}
+
+// Delete deletes the secret from the underlying provider.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	return ucerr.Wrap(p.inner.Delete(ctx, path))
+}
+
+// additionalData authenticates where the secret is stored, so that ciphertext can't be
+// moved to another secret.  It is the location rather than the path, since secrets saved
+// by path are read back from their location, e.g. by namespaced path for kubernetes.
+func (p *Provider) additionalData(path string) []byte {
+	return []byte(p.Location(path))
+}
+
+// Location returns where the underlying provider stores the secret.
+func (p *Provider) Location(path string) string {
+	if l, ok := p.inner.(interface{ Location(path string) string }); ok {
+		return l.Location(path)
+	}
+
+	return path
+}
+
+// Exists returns whether the underlying provider has the secret.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	if ec, ok := p.inner.(interface {
+		Exists(ctx context.Context, path string) (bool, error)
+	}); ok {
+		return ec.Exists(ctx, path)
+	}
+
+	if _, err := p.inner.Get(ctx, path); err != nil {
+		return false, ucerr.Wrap(err)
+	}
+
+	return true, nil
+}
+
+// ValidatePath checks that the underlying provider can store the path.
+func (p *Provider) ValidatePath(path string) error {
+	if v, ok := p.inner.(interface{ ValidatePath(path string) error }); ok {
+		return ucerr.Wrap(v.ValidatePath(path))
+	}
+
+	return nil
+}
+
+// List returns the paths of the secrets of the underlying provider.
+func (p *Provider) List(ctx context.Context, pathPrefix string) ([]string, error) {
+	l, ok := p.inner.(interface {
+		List(ctx context.Context, pathPrefix string) ([]string, error)
+	})
+	if !ok {
+		return nil, ucerr.Errorf("secret provider %s does not support listing", p.inner.Prefix())
+	}
+
+	return l.List(ctx, pathPrefix)
+}
+
+// Ping checks that the underlying provider is healthy and that data keys can be wrapped.
+func (p *Provider) Ping(ctx context.Context) error {
+	if p.keys == nil {
+		return ucerr.Wrap(p.err)
+	}
+	if _, err := p.keys.WrapKey(ctx, make([]byte, 32)); err != nil {
+		return ucerr.Errorf("failed to wrap a data key: %w", err)
+	}
+
+	if hc, ok := p.inner.(interface {
+		Ping(ctx context.Context) error
+	}); ok {
+		return ucerr.Wrap(hc.Ping(ctx))
+	}
+
+	return nil
+}
+
+// GetTimestamps returns when the underlying provider created and last updated the secret.
+func (p *Provider) GetTimestamps(ctx context.Context, path string) (time.Time, time.Time, error) {
+	tg, ok := p.inner.(interface {
+		GetTimestamps(ctx context.Context, path string) (time.Time, time.Time, error)
+	})
+	if !ok {
+		return time.Time{}, time.Time{}, ucerr.Errorf("secret provider %s does not track when secrets are updated", p.inner.Prefix())
+	}
+
+	return tg.GetTimestamps(ctx, path)
+}
@@ -0,0 +1,89 @@
+package encrypted
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret/provider/file"
+)
+
+func testKey(b byte) []byte {
+	return []byte(strings.Repeat(string(b), 32))
+}
+
+func TestProvider_SaveGet(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	t.Setenv(KeyEnvKey, base64.StdEncoding.EncodeToString(testKey('a')))
+
+	p := New(file.New().WithRoot(root))
+	assert.Equal(t, "enc+file://", p.Prefix())
+	assert.NoError(t, p.Save(ctx, "db-password", "hunter2"))
+
+	// the underlying provider only holds ciphertext
+	stored, err := os.ReadFile(filepath.Join(root, "db-password"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(stored), "hunter2")
+
+	secret, err := p.Get(ctx, "db-password")
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", secret)
+
+	// ciphertext can't be moved to another secret
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "other"), stored, 0600))
+	_, err = p.Get(ctx, "other")
+	assert.ErrorContains(t, err, "failed to decrypt")
+
+	// plaintext secrets aren't returned as is
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "plain"), []byte("hunter2"), 0600))
+	_, err = p.Get(ctx, "plain")
+	assert.ErrorContains(t, err, "isn't encrypted")
+}
+
+func TestProvider_KeyRotation(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	old := base64.StdEncoding.EncodeToString(testKey('a'))
+	t.Setenv(KeyEnvKey, old)
+	assert.NoError(t, New(file.New().WithRoot(root)).Save(ctx, "db-password", "hunter2"))
+
+	// secrets saved with an older key can be read while it is listed after the new one
+	t.Setenv(KeyEnvKey, base64.StdEncoding.EncodeToString(testKey('b'))+","+old)
+	secret, err := New(file.New().WithRoot(root)).Get(ctx, "db-password")
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", secret)
+
+	t.Setenv(KeyEnvKey, base64.StdEncoding.EncodeToString(testKey('b')))
+	_, err = New(file.New().WithRoot(root)).Get(ctx, "db-password")
+	assert.ErrorContains(t, err, "unknown key")
+}
+
+func TestProvider_KeyWrapper(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(KeyEnvKey, "")
+
+	p := New(file.New().WithRoot(t.TempDir()))
+	assert.ErrorContains(t, p.Save(ctx, "db-password", "hunter2"), KeyEnvKey)
+	assert.Error(t, p.Ping(ctx))
+
+	k, err := NewLocalKeyWrapper(testKey('a'))
+	assert.NoError(t, err)
+	SetKeyWrapper(k)
+	defer SetKeyWrapper(nil)
+
+	p = New(file.New().WithRoot(t.TempDir()))
+	assert.NoError(t, p.Ping(ctx))
+	assert.NoError(t, p.Save(ctx, "db-password", "hunter2"))
+	secret, err := p.Get(ctx, "db-password")
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", secret)
+
+	_, err = NewLocalKeyWrapper([]byte("short"))
+	assert.Error(t, err)
+}
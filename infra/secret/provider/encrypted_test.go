@@ -0,0 +1,35 @@
+package provider
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret/provider/encrypted"
+)
+
+func TestEncryptedProvider(t *testing.T) {
+	t.Setenv(encrypted.KeyEnvKey, base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+
+	pv, err := FromLocation("enc+kube://secrets/my-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "enc+kube://secrets/", pv.Prefix())
+
+	t.Setenv(SecretManagerEnvKey, "enc+file")
+	pv, err = FromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "enc+file://", pv.Prefix())
+
+	t.Setenv(SecretManagerEnvKey, "chain:enc+file,env")
+	_, err = FromEnv()
+	assert.NoError(t, err)
+
+	// development secrets are stored in their location, so there is nothing to encrypt
+	_, err = FromLocation("enc+dev://bXktc2VjcmV0")
+	assert.Error(t, err)
+	t.Setenv(SecretManagerEnvKey, "enc+enc+file")
+	_, err = FromEnv()
+	assert.Error(t, err)
+}
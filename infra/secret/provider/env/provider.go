@@ -4,12 +4,17 @@ import (
 	"context"
 	"os"
 	"regexp"
+	"strings"
 
 	"userclouds.com/infra/ucerr"
 )
 
 const (
 	Prefix = "env://"
+
+	// DefaultSeparator separates the variable name from the value to use when the
+	// variable is unset or empty, e.g. env://LOG_LEVEL?default=info
+	DefaultSeparator = "?default="
 )
 
 var specialCharsRegex = regexp.MustCompile(`[^a-zA-Z0-9]+`)
@@ -33,9 +38,23 @@ func (p *Provider) IsDev() bool {
 	return false
 }
 
-// Get returns a secret from an environment variable.
+// Get returns a secret from an environment variable.  Paths that aren't valid variable
+// names, such as userclouds/onprem/plex/client_secret, fall back to the variable named by
+// VariableName, e.g. USERCLOUDS_ONPREM_PLEX_CLIENT_SECRET.  Paths ending in
+// ?default=<value> return the value instead of an error when the variable is unset or
+// empty, for optional configuration.
 func (p *Provider) Get(ctx context.Context, path string) (string, error) {
+	path, defaultValue, hasDefault := strings.Cut(path, DefaultSeparator)
+
 	secret, defined := os.LookupEnv(path)
+	if !defined && specialCharsRegex.MatchString(path) {
+		path = VariableName(path)
+		secret, defined = os.LookupEnv(path)
+	}
+	if hasDefault && secret == "" {
+		return defaultValue, nil
+	}
+
 	if !defined {
 		return "", ucerr.Errorf("Can't load secret from environment variable %s", path)
 	}
@@ -56,3 +75,9 @@ func (p *Provider) Save(ctx context.Context, path, secret string) error {
 func (p *Provider) Delete(ctx context.Context, path string) error {
 	return nil
 }
+
+// VariableName converts a secret path into an environment variable name, by upper casing
+// it and replacing runs of other characters than letters and digits with underscores.
+func VariableName(path string) string {
+	return strings.ToUpper(strings.Trim(specialCharsRegex.ReplaceAllString(path, "_"), "_"))
+}
@@ -20,4 +20,40 @@ func TestProvider_Get(t *testing.T) {
 	v, err = provider.Get(ctx, "MISSING")
 	assert.Error(t, err)
 	assert.Empty(t, v)
+
+	// paths fall back to the variable of the same name
+	t.Setenv("USERCLOUDS_ONPREM_PLEX_CLIENT_SECRET", "bar")
+	v, err = provider.Get(ctx, "userclouds/onprem/plex/client_secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", v)
+}
+
+func TestProvider_GetDefault(t *testing.T) {
+	ctx := context.Background()
+
+	t.Setenv("MY_VAR", "foo")
+	t.Setenv("EMPTY_VAR", "")
+
+	provider := New()
+	v, err := provider.Get(ctx, "MY_VAR?default=bar")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", v)
+
+	v, err = provider.Get(ctx, "MISSING?default=bar")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", v)
+
+	v, err = provider.Get(ctx, "EMPTY_VAR?default=bar")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", v)
+
+	// an empty default makes the variable optional
+	v, err = provider.Get(ctx, "MISSING?default=")
+	assert.NoError(t, err)
+	assert.Empty(t, v)
+
+	t.Setenv("USERCLOUDS_ONPREM_LOG_LEVEL", "debug")
+	v, err = provider.Get(ctx, "userclouds/onprem/log_level?default=info")
+	assert.NoError(t, err)
+	assert.Equal(t, "debug", v)
 }
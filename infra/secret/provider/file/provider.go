@@ -0,0 +1,258 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+const (
+	Prefix = "file://"
+
+	// RootEnvKey overrides the directory that secrets are saved under.
+	RootEnvKey = "UC_SECRET_FILE_ROOT"
+
+	// DefaultRoot is where Docker mounts secrets, and a common mount path for
+	// Kubernetes projected volumes.
+	DefaultRoot = "/run/secrets"
+)
+
+// Provider reads secrets from files, such as Kubernetes projected volumes or Docker
+// secrets.  Relative paths are resolved under the root directory, and absolute paths,
+// e.g. file:///run/secrets/db-password, must be under it too, so that secret locations
+// can't be used to read arbitrary files such as /etc/shadow.
+type Provider struct {
+	root string
+}
+
+// New returns a new file based secrets provider, rooted at UC_SECRET_FILE_ROOT or
+// /run/secrets if it isn't set.
+func New() *Provider {
+	root := os.Getenv(RootEnvKey)
+	if root == "" {
+		root = DefaultRoot
+	}
+
+	return &Provider{root: filepath.Clean(root)}
+}
+
+// WithRoot sets the directory that secrets are saved under, overriding UC_SECRET_FILE_ROOT.
+func (p *Provider) WithRoot(root string) *Provider {
+	p.root = filepath.Clean(root)
+	return p
+}
+
+// Prefix returns the URI prefix for a file based secret.
+func (p *Provider) Prefix() string {
+	return Prefix
+}
+
+// IsDev returns false, file secrets are stored like any other.
+func (p *Provider) IsDev() bool {
+	return false
+}
+
+// Get returns the contents of a secret file under the root.  A single trailing newline is
+// removed, since files created with echo or editors usually end with one.
+func (p *Provider) Get(ctx context.Context, path string) (string, error) {
+	name, err := p.file(path)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return "", ucerr.Errorf("Can't load secret from file %s: %w", name, err)
+	}
+	uclog.Debugf(ctx, "Loaded secret from file %s", name)
+
+	secret := strings.TrimSuffix(strings.TrimSuffix(string(bs), "\n"), "\r")
+	if secret == "" {
+		return "", ucerr.Errorf("Secret from file %s is empty", name)
+	}
+
+	return secret, nil
+}
+
+// Save writes the secret to a file under the root that is only readable by the owner.
+// The file is replaced atomically, so that readers never see a partial secret.
+func (p *Provider) Save(ctx context.Context, path, secret string) error {
+	name, err := p.file(path)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp")
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	// CreateTemp creates the file with 0600 permissions
+	if _, err := tmp.WriteString(secret); err != nil {
+		tmp.Close()
+		return ucerr.Wrap(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	uclog.Infof(ctx, "saving secret to file %s", name)
+	return ucerr.Wrap(os.Rename(tmp.Name(), name))
+}
+
+// Delete removes a secret file under the root.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	name, err := p.file(path)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	uclog.Infof(ctx, "Delete secret file %s", name)
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return ucerr.Wrap(err)
+	}
+
+	return nil
+}
+
+// Location returns the absolute path that a secret saved with path can be retrieved
+// from, so that the secret still resolves if the root changes.
+func (p *Provider) Location(path string) string {
+	return p.resolve(path)
+}
+
+// List returns the absolute paths of the secret files under the root whose path relative
+// to the root starts with pathPrefix, which must be relative to the root.
+func (p *Provider) List(ctx context.Context, pathPrefix string) ([]string, error) {
+	if pathPrefix != "" && !filepath.IsLocal(pathPrefix) {
+		return nil, ucerr.Errorf("secret path prefix '%s' is not under the secret root %s", pathPrefix, p.root)
+	}
+
+	var paths []string
+	err := filepath.WalkDir(p.root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// skip temporary files and the hidden directories Kubernetes uses for atomic
+		// updates of projected volumes
+		if strings.HasPrefix(d.Name(), ".") && name != p.root {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(p.root, name)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(filepath.ToSlash(rel), pathPrefix) {
+			paths = append(paths, name)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return paths, nil
+}
+
+// ValidatePath checks that a secret can be saved at the path, which must resolve to a
+// file under the root.
+func (p *Provider) ValidatePath(path string) error {
+	_, err := p.file(path)
+	return ucerr.Wrap(err)
+}
+
+// GetTimestamps returns when the secret file was last modified, for both its creation
+// and last update, since file systems don't reliably record when files were created.
+func (p *Provider) GetTimestamps(ctx context.Context, path string) (time.Time, time.Time, error) {
+	name, err := p.file(path)
+	if err != nil {
+		return time.Time{}, time.Time{}, ucerr.Wrap(err)
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		return time.Time{}, time.Time{}, ucerr.Errorf("Can't stat secret file %s: %w", name, err)
+	}
+
+	return info.ModTime(), info.ModTime(), nil
+}
+
+// Exists returns whether the secret file exists under the root.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	name, err := p.file(path)
+	if err != nil {
+		return false, ucerr.Wrap(err)
+	}
+
+	_, err = os.Stat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, ucerr.Errorf("Can't stat secret file %s: %w", name, err)
+	}
+
+	return true, nil
+}
+
+// Ping checks that the root directory exists, e.g. that the secrets volume is mounted.
+func (p *Provider) Ping(ctx context.Context) error {
+	info, err := os.Stat(p.root)
+	if err != nil {
+		return ucerr.Errorf("secret root %s is unavailable: %w", p.root, err)
+	}
+	if !info.IsDir() {
+		return ucerr.Errorf("secret root %s is not a directory", p.root)
+	}
+
+	return nil
+}
+
+// file returns the file of a path, which must resolve to a file under the root.  Paths
+// are checked lexically rather than by following symlinks, since Kubernetes projected
+// volumes link each secret to a hidden directory under the root.
+func (p *Provider) file(path string) (string, error) {
+	if path == "" {
+		return "", ucerr.Errorf("secret path must not be empty")
+	}
+
+	name := p.resolve(path)
+	rel, err := filepath.Rel(p.root, name)
+	if err != nil || rel == "." || !filepath.IsLocal(rel) {
+		return "", ucerr.Errorf("secret path '%s' is not a file under the secret root %s", path, p.root)
+	}
+
+	return name, nil
+}
+
+// resolve returns the file of a path, resolving relative paths under the root.
+func (p *Provider) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+
+	return filepath.Join(p.root, filepath.FromSlash(path))
+}
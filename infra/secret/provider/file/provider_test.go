@@ -0,0 +1,103 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFile_GetAndSave(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	p := New().WithRoot(root)
+
+	// mounted secrets are read by absolute path, without their trailing newline
+	mounted := filepath.Join(root, "db-password")
+	assert.NoError(t, os.WriteFile(mounted, []byte("hunter2\n"), 0600))
+	secret, err := p.Get(ctx, mounted)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", secret)
+
+	_, err = p.Get(ctx, "missing")
+	assert.Error(t, err)
+
+	path := "userclouds/onprem/plex/client_secret"
+	assert.NoError(t, p.Save(ctx, path, "s3cret"))
+	info, err := os.Stat(filepath.Join(root, path))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	secret, err = p.Get(ctx, p.Location(path))
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", secret)
+
+	paths, err := p.List(ctx, "userclouds/onprem/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, path)}, paths)
+	_, err = p.List(ctx, "../")
+	assert.Error(t, err)
+
+	assert.NoError(t, p.Delete(ctx, path))
+	_, err = os.Stat(filepath.Join(root, path))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFile_ValidatePath(t *testing.T) {
+	p := New().WithRoot("/run/secrets")
+
+	assert.NoError(t, p.ValidatePath("userclouds/onprem/plex/client_secret"))
+	assert.NoError(t, p.ValidatePath("/run/secrets/db-password"))
+	assert.Error(t, p.ValidatePath(""))
+	assert.Error(t, p.ValidatePath("../etc/passwd"))
+	assert.Error(t, p.ValidatePath("/etc/passwd"))
+	assert.Error(t, p.ValidatePath("/run/secrets"))
+}
+
+func TestFile_OutsideRoot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	p := New().WithRoot(filepath.Join(dir, "secrets"))
+
+	outside := filepath.Join(dir, "shadow")
+	assert.NoError(t, os.WriteFile(outside, []byte("root:x"), 0600))
+
+	for _, path := range []string{outside, "../shadow", "userclouds/../../shadow"} {
+		_, err := p.Get(ctx, path)
+		assert.ErrorContains(t, err, "not a file under the secret root")
+
+		_, err = p.Exists(ctx, path)
+		assert.Error(t, err)
+
+		_, _, err = p.GetTimestamps(ctx, path)
+		assert.Error(t, err)
+	}
+}
+
+func TestFile_Ping(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+
+	assert.NoError(t, New().WithRoot(root).Ping(ctx))
+	assert.Error(t, New().WithRoot(filepath.Join(root, "missing")).Ping(ctx))
+
+	name := filepath.Join(root, "file")
+	assert.NoError(t, os.WriteFile(name, []byte("x"), 0600))
+	assert.Error(t, New().WithRoot(name).Ping(ctx))
+}
+
+func TestFile_Exists(t *testing.T) {
+	ctx := context.Background()
+	p := New().WithRoot(t.TempDir())
+
+	exists, err := p.Exists(ctx, "db-password")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.NoError(t, p.Save(ctx, "db-password", "hunter2"))
+	exists, err = p.Exists(ctx, "db-password")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
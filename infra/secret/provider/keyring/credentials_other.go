@@ -0,0 +1,8 @@
+//go:build !windows
+
+package keyring
+
+// systemCredentials returns nil, since the Credential Manager only exists on Windows.
+func systemCredentials() credentials {
+	return nil
+}
@@ -0,0 +1,108 @@
+package keyring
+
+import (
+	"errors"
+	"syscall"
+	"unsafe"
+
+	"userclouds.com/infra/ucerr"
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+
+	errNotFound syscall.Errno = 1168 // ERROR_NOT_FOUND
+)
+
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredRead   = advapi32.NewProc("CredReadW")
+	procCredWrite  = advapi32.NewProc("CredWriteW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+// credential is the CREDENTIALW struct of the Credential Manager API.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// credManager stores secrets as generic credentials of the current user in the Windows
+// Credential Manager, with the secret as the credential's blob.
+type credManager struct{}
+
+func systemCredentials() credentials {
+	return credManager{}
+}
+
+func (credManager) read(target string) (string, error) {
+	name, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	var cred *credential
+	if ret, _, err := procCredRead.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred))); ret == 0 {
+		if errors.Is(err, errNotFound) {
+			return "", nil
+		}
+		return "", ucerr.Wrap(err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+func (credManager) write(target, account, secret string) error {
+	name, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	user, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	blob := []byte(secret)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         name,
+		UserName:           user,
+		Persist:            credPersistLocalMachine,
+		CredentialBlobSize: uint32(len(blob)),
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+
+	if ret, _, err := procCredWrite.Call(uintptr(unsafe.Pointer(&cred)), 0); ret == 0 {
+		return ucerr.Wrap(err)
+	}
+
+	return nil
+}
+
+func (credManager) delete(target string) error {
+	name, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if ret, _, err := procCredDelete.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0); ret == 0 {
+		return ucerr.Wrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package keyring
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"userclouds.com/infra/secret/provider"
+	"userclouds.com/infra/ucerr"
+)
+
+const (
+	Prefix = "keyring://"
+
+	// DefaultService is the keyring service used for paths without a service.
+	DefaultService = "userclouds"
+)
+
+// The provider is registered by importing this package rather than being built in, so that
+// only command line tools like ucctl can resolve keyring:// locations; services never run
+// the keyring tools.
+func init() {
+	provider.Register(Prefix, func() provider.Interface {
+		return New()
+	})
+}
+
+// runner runs a command with the given stdin and returns its stdout.
+type runner func(ctx context.Context, stdin string, name string, args ...string) (string, error)
+
+// credentials reads and writes generic credentials in the Windows Credential Manager.  read
+// returns an empty string for credentials that don't exist.
+type credentials interface {
+	read(target string) (string, error)
+	write(target, account, secret string) error
+	delete(target string) error
+}
+
+// Provider stores secrets in the operating system keyring: the login keychain on macOS
+// (via the security tool), the Secret Service on Linux (via secret-tool) and the Windows
+// Credential Manager.  Paths are <service>/<account>.  It is intended for secrets on
+// operator machines, such as ucctl context credentials, rather than for services.
+type Provider struct {
+	goos  string
+	run   runner
+	creds credentials
+}
+
+// New returns a new keyring based secrets provider.
+func New() *Provider {
+	return &Provider{goos: runtime.GOOS, run: runCommand, creds: systemCredentials()}
+}
+
+// Prefix returns the URI prefix for a keyring secret.
+func (p *Provider) Prefix() string {
+	return Prefix
+}
+
+// IsDev returns false, keyring secrets are stored like any other.
+func (p *Provider) IsDev() bool {
+	return false
+}
+
+// Get returns a secret from the keyring.
+func (p *Provider) Get(ctx context.Context, path string) (string, error) {
+	service, account := splitPath(path)
+
+	var out string
+	var err error
+	switch p.goos {
+	case "darwin":
+		out, err = p.run(ctx, "", "security", "find-generic-password", "-s", service, "-a", account, "-w")
+	case "linux":
+		out, err = p.run(ctx, "", "secret-tool", "lookup", "service", service, "account", account)
+	case "windows":
+		if p.creds == nil {
+			return "", p.unsupported()
+		}
+		out, err = p.creds.read(target(service, account))
+	default:
+		return "", p.unsupported()
+	}
+	if err != nil {
+		return "", ucerr.Errorf("failed to read keyring secret %s: %w", path, err)
+	}
+
+	secret := strings.TrimSuffix(out, "\n")
+	if secret == "" {
+		return "", ucerr.Errorf("keyring secret %s not found", path)
+	}
+
+	return secret, nil
+}
+
+// Save stores a secret in the keyring, replacing any existing value.
+func (p *Provider) Save(ctx context.Context, path, secret string) error {
+	service, account := splitPath(path)
+
+	var err error
+	switch p.goos {
+	case "darwin":
+		// the command is passed to the interactive mode of security on stdin, with the secret
+		// hex encoded by -X, so that the secret isn't in its arguments where other processes
+		// can see it.  -U updates the item if it already exists.
+		command := fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", quote(service), quote(account), hex.EncodeToString([]byte(secret)))
+		_, err = p.run(ctx, command, "security", "-i")
+	case "linux":
+		_, err = p.run(ctx, secret, "secret-tool", "store", "--label", path, "service", service, "account", account)
+	case "windows":
+		if p.creds == nil {
+			return p.unsupported()
+		}
+		err = p.creds.write(target(service, account), account, secret)
+	default:
+		return p.unsupported()
+	}
+	if err != nil {
+		return ucerr.Errorf("failed to save keyring secret %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Delete removes a secret from the keyring.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	service, account := splitPath(path)
+
+	var err error
+	switch p.goos {
+	case "darwin":
+		_, err = p.run(ctx, "", "security", "delete-generic-password", "-s", service, "-a", account)
+	case "linux":
+		_, err = p.run(ctx, "", "secret-tool", "clear", "service", service, "account", account)
+	case "windows":
+		if p.creds == nil {
+			return p.unsupported()
+		}
+		err = p.creds.delete(target(service, account))
+	default:
+		return p.unsupported()
+	}
+	if err != nil {
+		return ucerr.Errorf("failed to delete keyring secret %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (p *Provider) unsupported() error {
+	return ucerr.Errorf("the keyring secret provider is not supported on %s", p.goos)
+}
+
+// splitPath splits a path into the keyring service and account.
+func splitPath(path string) (string, string) {
+	if service, account, found := strings.Cut(path, "/"); found {
+		return service, account
+	}
+
+	return DefaultService, path
+}
+
+// target returns the name of the Windows credential of a secret.
+func target(service, account string) string {
+	return service + "/" + account
+}
+
+// quote quotes s as a single argument of a command in the interactive mode of security.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+func runCommand(ctx context.Context, stdin string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", ucerr.Errorf("%s: %s", err, msg)
+		}
+		return "", ucerr.Wrap(err)
+	}
+
+	return stdout.String(), nil
+}
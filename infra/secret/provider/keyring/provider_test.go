@@ -0,0 +1,112 @@
+package keyring
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeKeyring records the commands run by the provider and answers lookups.
+type fakeKeyring struct {
+	commands []string
+	stdin    []string
+	value    string
+}
+
+func (f *fakeKeyring) run(ctx context.Context, stdin string, name string, args ...string) (string, error) {
+	f.commands = append(f.commands, strings.Join(append([]string{name}, args...), " "))
+	f.stdin = append(f.stdin, stdin)
+	return f.value, nil
+}
+
+func TestKeyring_Darwin(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeKeyring{value: "super_secret\n"}
+	p := &Provider{goos: "darwin", run: f.run}
+
+	assert.NoError(t, p.Save(ctx, "ucctl/prod", "super_secret"))
+	value, err := p.Get(ctx, "ucctl/prod")
+	assert.NoError(t, err)
+	assert.Equal(t, "super_secret", value)
+	assert.NoError(t, p.Delete(ctx, "prod"))
+
+	assert.Equal(t, []string{
+		"security -i",
+		"security find-generic-password -s ucctl -a prod -w",
+		"security delete-generic-password -s userclouds -a prod",
+	}, f.commands)
+
+	// the secret is passed hex encoded on stdin rather than as an argument
+	assert.Equal(t, "add-generic-password -U -s 'ucctl' -a 'prod' -X 73757065725f736563726574\n", f.stdin[0])
+	assert.NoError(t, p.Save(ctx, "ucctl/it's", "super_secret"))
+	assert.Equal(t, `add-generic-password -U -s 'ucctl' -a 'it'"'"'s' -X 73757065725f736563726574`+"\n", f.stdin[3])
+}
+
+func TestKeyring_Linux(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeKeyring{value: "super_secret"}
+	p := &Provider{goos: "linux", run: f.run}
+
+	assert.NoError(t, p.Save(ctx, "ucctl/prod", "super_secret"))
+	value, err := p.Get(ctx, "ucctl/prod")
+	assert.NoError(t, err)
+	assert.Equal(t, "super_secret", value)
+
+	// the secret is passed on stdin rather than as an argument
+	assert.Equal(t, "secret-tool store --label ucctl/prod service ucctl account prod", f.commands[0])
+	assert.Equal(t, "super_secret", f.stdin[0])
+
+	f.value = ""
+	_, err = p.Get(ctx, "ucctl/missing")
+	assert.Error(t, err)
+}
+
+// fakeCredentials is an in-memory Windows Credential Manager.
+type fakeCredentials struct {
+	secrets  map[string]string
+	accounts map[string]string
+}
+
+func (f *fakeCredentials) read(target string) (string, error) {
+	return f.secrets[target], nil
+}
+
+func (f *fakeCredentials) write(target, account, secret string) error {
+	f.secrets[target] = secret
+	f.accounts[target] = account
+	return nil
+}
+
+func (f *fakeCredentials) delete(target string) error {
+	delete(f.secrets, target)
+	return nil
+}
+
+func TestKeyring_Windows(t *testing.T) {
+	ctx := context.Background()
+	creds := &fakeCredentials{secrets: map[string]string{}, accounts: map[string]string{}}
+	p := &Provider{goos: "windows", run: (&fakeKeyring{}).run, creds: creds}
+
+	assert.NoError(t, p.Save(ctx, "ucctl/prod", "super_secret"))
+	assert.Equal(t, map[string]string{"ucctl/prod": "prod"}, creds.accounts)
+	value, err := p.Get(ctx, "ucctl/prod")
+	assert.NoError(t, err)
+	assert.Equal(t, "super_secret", value)
+
+	assert.NoError(t, p.Delete(ctx, "ucctl/prod"))
+	_, err = p.Get(ctx, "ucctl/prod")
+	assert.Error(t, err)
+}
+
+func TestKeyring_Unsupported(t *testing.T) {
+	p := &Provider{goos: "plan9", run: (&fakeKeyring{}).run}
+	_, err := p.Get(context.Background(), "ucctl/prod")
+	assert.Error(t, err)
+
+	// Windows without the Credential Manager
+	p = &Provider{goos: "windows", run: (&fakeKeyring{}).run}
+	_, err = p.Get(context.Background(), "ucctl/prod")
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package kubernetes
+
+import (
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"userclouds.com/infra/ucerr"
+)
+
+var invalidNameCharsRegex = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// ValidatePath checks that the secret name generated from the path is a valid
+// kubernetes object name (DNS-1123 subdomain), and that the namespace of a namespaced
+// path is a valid namespace.  The returned error includes a sanitized name that can be
+// used instead.
+func (p *Provider) ValidatePath(path string) error {
+	namespace, name := p.parsePath(path)
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return ucerr.Errorf("secret path '%s' has an invalid kubernetes namespace '%s': %s",
+			path, namespace, strings.Join(errs, "; "))
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return ucerr.Errorf("secret path '%s' is not a valid kubernetes secret name '%s': %s (try '%s')",
+			path, name, strings.Join(errs, "; "), SanitizeName(path))
+	}
+
+	return nil
+}
+
+// SanitizeName converts a path into a name that is valid for a kubernetes secret.
+func SanitizeName(path string) string {
+	name := strings.ToLower(pathToSecretName(path))
+	name = invalidNameCharsRegex.ReplaceAllString(name, "-")
+	if len(name) > validation.DNS1123SubdomainMaxLength {
+		name = name[:validation.DNS1123SubdomainMaxLength]
+	}
+
+	return strings.TrimFunc(name, func(r rune) bool {
+		return r == '-' || r == '.'
+	})
+}
@@ -0,0 +1,51 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestKubernetes_ValidatePath(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		valid     bool
+		sanitized string
+	}{
+		{"simple", "userclouds/test/plex/my_secret", true, ""},
+		{"uppercase", "userclouds/test/Plex/MySecret", false, "userclouds.test.plex.mysecret"},
+		{"invalid chars", "userclouds/test/plex/my secret!", false, "userclouds.test.plex.my-secret"},
+		{"leading dot", "/userclouds/test", false, "userclouds.test"},
+		{"too long", strings.Repeat("a", 300), false, strings.Repeat("a", 253)},
+	}
+
+	p := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.ValidatePath(tt.path)
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.sanitized)
+			assert.Equal(t, tt.sanitized, SanitizeName(tt.path))
+			assert.NoError(t, p.ValidatePath(SanitizeName(tt.path)))
+		})
+	}
+}
+
+func TestKubernetes_SaveInvalidName(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	provider := New().WithClient(client)
+
+	err := provider.Save(ctx, "Invalid/Name", "secret")
+	assert.Error(t, err)
+	assert.Empty(t, client.Actions())
+}
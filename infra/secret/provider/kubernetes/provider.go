@@ -2,9 +2,15 @@ package kubernetes
 
 import (
 	"context"
+	"encoding/json"
+	"os"
 	"strings"
+	"time"
 
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	listersv1 "k8s.io/client-go/listers/core/v1"
 
 	"userclouds.com/infra/ucerr"
 	"userclouds.com/infra/uckube"
@@ -13,18 +19,71 @@ import (
 
 const (
 	Prefix = "kube://secrets/"
-	// TODO: Make this configurable.
+	// DefaultNamespace is the namespace that secrets are stored in unless configured
+	// otherwise, and where secrets created before the namespace was configurable live.
 	DefaultNamespace = "userclouds"
+	// NamespaceEnvKey overrides the namespace that secrets without a namespaced path are
+	// stored in.
+	NamespaceEnvKey = "UC_KUBE_SECRET_NAMESPACE"
+	// NamespaceSeparator separates the namespace from the secret name in namespaced paths,
+	// e.g. kube://secrets/payments:db-password.  Other paths can't contain it, since it isn't
+	// valid in kubernetes secret names, so namespaced paths are never mistaken for them.
+	NamespaceSeparator = ":"
+	// DataKeyEnvKey overrides the key of the secret data that secrets are read from, e.g.
+	// for secrets synced by external-secrets-operator or created by helm charts.
+	DataKeyEnvKey = "UC_KUBE_SECRET_DATA_KEY"
+	// DefaultDataKey is the key of the secret data that the provider stores secrets in.
+	DefaultDataKey = "value"
+	// AllDataKeys is the data key that reads the whole secret data as a JSON object,
+	// which can be resolved with secret.Map, or field by field with a #<key> selector.
+	AllDataKeys = "*"
+	// ManagedBySelector selects the secrets that were created by the provider.
+	ManagedBySelector = "app.kubernetes.io/managed-by=userclouds"
+	// PathAnnotation records the path that a secret was saved with, which can't be
+	// recovered from the secret name it is mangled into, so that watches can report the
+	// location that readers of the secret cache it by.
+	PathAnnotation = "userclouds.com/secret-path"
+	// listPageSize is the number of secrets requested per page when listing.
+	listPageSize = 100
 )
 
 // Provider is the implementation for the kubernetes secrets provider
 type Provider struct {
-	client kubernetes.Interface
+	client    kubernetes.Interface
+	namespace string
+	dataKey   string
+	lister    listersv1.SecretLister // set by StartWatch
 }
 
-// New returns a new provider
+// New returns a new provider, storing secrets in UC_KUBE_SECRET_NAMESPACE or the
+// default namespace if it isn't set.
 func New() *Provider {
-	return &Provider{}
+	namespace := os.Getenv(NamespaceEnvKey)
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	dataKey := os.Getenv(DataKeyEnvKey)
+	if dataKey == "" {
+		dataKey = DefaultDataKey
+	}
+
+	return &Provider{namespace: namespace, dataKey: dataKey}
+}
+
+// WithDataKey sets the key of the secret data that secrets are read from, overriding
+// UC_KUBE_SECRET_DATA_KEY.  Secrets can only be saved with the default key, since
+// secrets stored under other keys are managed outside of userclouds.
+func (p *Provider) WithDataKey(dataKey string) *Provider {
+	p.dataKey = dataKey
+	return p
+}
+
+// WithNamespace sets the namespace that secrets without a namespaced path are stored
+// in, overriding UC_KUBE_SECRET_NAMESPACE.
+func (p *Provider) WithNamespace(namespace string) *Provider {
+	p.namespace = namespace
+	return p
 }
 
 // WithClient allows the kubernetes client interface to be set directly
@@ -44,27 +103,82 @@ func (p *Provider) IsDev() bool {
 	return false
 }
 
-// Get retrieves a secret and returns its value.
+// Get retrieves a secret and returns its value.  Paths in the namespaced form
+// <namespace>:<name> are read from the namespace, and other secrets that aren't found in
+// the configured namespace fall back to the default namespace.
 func (p *Provider) Get(ctx context.Context, path string) (string, error) {
 	if err := p.initClient(); err != nil {
 		return "", ucerr.Wrap(err)
 	}
 
-	secretPath := pathToSecretName(path)
-	uclog.Debugf(ctx, "Getting secret %s", secretPath)
-	secret, err := uckube.GetSecret(ctx, p.client, secretPath, DefaultNamespace)
-	return secret, ucerr.Wrap(err)
+	var data map[string][]byte
+	var namespace, name string
+	var err error
+	for _, c := range p.candidates(path) {
+		namespace, name = c.namespace, c.name
+		if watched, ok := p.getWatched(c.namespace, c.name); ok {
+			data, err = watched, nil
+			break
+		}
+
+		uclog.Debugf(ctx, "Getting secret %s/%s", c.namespace, c.name)
+		data, err = uckube.GetSecretData(ctx, p.client, c.name, c.namespace)
+		if !errors.IsNotFound(err) {
+			break
+		}
+	}
+	if err != nil {
+		return "", ucerr.Wrap(accessError(ctx, namespace, err))
+	}
+
+	return p.secretFromData(namespace, name, data)
+}
+
+// secretFromData returns the secret stored under the provider's data key.
+func (p *Provider) secretFromData(namespace, name string, data map[string][]byte) (string, error) {
+	if p.dataKey == AllDataKeys {
+		values := make(map[string]string, len(data))
+		for k, v := range data {
+			values[k] = string(v)
+		}
+
+		bs, err := json.Marshal(values)
+		if err != nil {
+			return "", ucerr.Wrap(err)
+		}
+		return string(bs), nil
+	}
+
+	value, ok := data[p.dataKey]
+	if !ok {
+		return "", ucerr.Errorf("kubernetes secret %s/%s has no data key '%s'", namespace, name, p.dataKey)
+	}
+
+	return string(value), nil
 }
 
 // Save stores a secret.  If the secret is new it will be created, otherwise the
 // secret value is updated.
 func (p *Provider) Save(ctx context.Context, path, secret string) error {
+	if err := p.ValidatePath(path); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if p.dataKey != DefaultDataKey {
+		return ucerr.Errorf("kubernetes secrets read from data key '%s' are managed outside of userclouds and can't be saved", p.dataKey)
+	}
+
 	if err := p.initClient(); err != nil {
 		return ucerr.Wrap(err)
 	}
 
-	err := uckube.CreateOrUpdateSecret(ctx, p.client, pathToSecretName(path), DefaultNamespace, secret)
-	return ucerr.Wrap(err)
+	namespace, name, err := p.resolvePath(ctx, path)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	err = uckube.CreateOrUpdateSecret(ctx, p.client, name, namespace, secret, map[string]string{PathAnnotation: path})
+	return ucerr.Wrap(accessError(ctx, namespace, err))
 }
 
 // Delete removes the secret from the provider.
@@ -73,8 +187,129 @@ func (p *Provider) Delete(ctx context.Context, path string) error {
 		return ucerr.Wrap(err)
 	}
 
-	err := uckube.DeleteSecret(ctx, p.client, pathToSecretName(path), DefaultNamespace)
-	return ucerr.Wrap(err)
+	namespace, name, err := p.resolvePath(ctx, path)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	err = uckube.DeleteSecret(ctx, p.client, name, namespace)
+	return ucerr.Wrap(accessError(ctx, namespace, err))
+}
+
+// Location returns the path that a secret saved with path can be retrieved from.  Secrets
+// stored outside of the default namespace are located by namespaced path, i.e.
+// <namespace>:<name>, so that they resolve regardless of the namespace that the reader is
+// configured with.
+func (p *Provider) Location(path string) string {
+	if _, _, found := strings.Cut(path, NamespaceSeparator); found || p.namespace == DefaultNamespace {
+		return path
+	}
+
+	return namespacedPath(p.namespace, pathToSecretName(path))
+}
+
+// List returns the userclouds managed secrets that match the path prefix.  Since paths
+// are mangled into k8s compatible names when stored, the returned values are namespaced
+// paths (<namespace>:<name>), which can be passed back into Get, Save, and Delete unchanged.
+func (p *Provider) List(ctx context.Context, pathPrefix string) ([]string, error) {
+	if err := p.initClient(); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	namePrefix := pathToSecretName(pathPrefix)
+	opts := metav1.ListOptions{
+		LabelSelector: ManagedBySelector,
+		Limit:         listPageSize,
+	}
+
+	var paths []string
+	for {
+		secrets, err := p.client.CoreV1().Secrets(p.namespace).List(ctx, opts)
+		if err != nil {
+			return nil, ucerr.Wrap(accessError(ctx, p.namespace, err))
+		}
+
+		for _, s := range secrets.Items {
+			if strings.HasPrefix(s.Name, namePrefix) {
+				paths = append(paths, namespacedPath(p.namespace, s.Name))
+			}
+		}
+
+		if secrets.Continue == "" {
+			break
+		}
+		opts.Continue = secrets.Continue
+	}
+
+	return paths, nil
+}
+
+// GetTimestamps returns when the secret was created and when its value was last saved by
+// the provider.  Secrets saved before it recorded updates, or by other tools, report
+// their creation time for both.
+func (p *Provider) GetTimestamps(ctx context.Context, path string) (time.Time, time.Time, error) {
+	if err := p.initClient(); err != nil {
+		return time.Time{}, time.Time{}, ucerr.Wrap(err)
+	}
+
+	namespace, name, err := p.resolvePath(ctx, path)
+	if err != nil {
+		return time.Time{}, time.Time{}, ucerr.Wrap(err)
+	}
+
+	s, err := p.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return time.Time{}, time.Time{}, ucerr.Wrap(accessError(ctx, namespace, err))
+	}
+
+	created := s.CreationTimestamp.Time
+	updated := created
+	if v, ok := s.Annotations[uckube.UpdatedAtAnnotation]; ok {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			updated = t
+		}
+	}
+
+	return created, updated, nil
+}
+
+// Exists returns whether the secret exists in its namespace, or in the fallback that Get
+// reads it from.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	if err := p.initClient(); err != nil {
+		return false, ucerr.Wrap(err)
+	}
+
+	for _, c := range p.candidates(path) {
+		if _, ok := p.getWatched(c.namespace, c.name); ok {
+			return true, nil
+		}
+
+		_, err := p.client.CoreV1().Secrets(c.namespace).Get(ctx, c.name, metav1.GetOptions{})
+		if err == nil {
+			return true, nil
+		}
+		if !errors.IsNotFound(err) {
+			return false, ucerr.Wrap(accessError(ctx, c.namespace, err))
+		}
+	}
+
+	return false, nil
+}
+
+// Ping checks that the kubernetes API server is reachable and that the service is
+// allowed to list the managed secrets in the provider's namespace.
+func (p *Provider) Ping(ctx context.Context) error {
+	if err := p.initClient(); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	opts := metav1.ListOptions{LabelSelector: ManagedBySelector, Limit: 1}
+	if _, err := p.client.CoreV1().Secrets(p.namespace).List(ctx, opts); err != nil {
+		return ucerr.Errorf("failed to list kubernetes secrets in namespace %s: %w", p.namespace, err)
+	}
+
+	return nil
 }
 
 // initClient initializes the kubernetes rest client if it has not been previously
@@ -93,6 +328,64 @@ func (p *Provider) initClient() error {
 	return nil
 }
 
+// resolvePath returns the namespace and name of an existing secret for the path.  Paths
+// which don't exist, but have a fallback secret, resolve to the fallback so that updates
+// and deletes are applied to the secret that Get would have returned.
+func (p *Provider) resolvePath(ctx context.Context, path string) (string, string, error) {
+	candidates := p.candidates(path)
+	if len(candidates) == 1 {
+		return candidates[0].namespace, candidates[0].name, nil
+	}
+
+	for _, c := range candidates {
+		_, err := p.client.CoreV1().Secrets(c.namespace).Get(ctx, c.name, metav1.GetOptions{})
+		if err == nil {
+			return c.namespace, c.name, nil
+		}
+
+		if !errors.IsNotFound(err) {
+			return "", "", ucerr.Wrap(accessError(ctx, c.namespace, err))
+		}
+	}
+
+	return candidates[0].namespace, candidates[0].name, nil
+}
+
+// candidate is a namespace and name that a path may be stored at.
+type candidate struct {
+	namespace string
+	name      string
+}
+
+// candidates returns where the secret of a path may be stored, in the order they are
+// tried: the parsed path, then the default namespace if another one is configured.
+// Namespaced paths are only stored in their namespace.
+func (p *Provider) candidates(path string) []candidate {
+	namespace, name := p.parsePath(path)
+	candidates := []candidate{{namespace, name}}
+
+	if _, _, found := strings.Cut(path, NamespaceSeparator); !found && namespace != DefaultNamespace {
+		candidates = append(candidates, candidate{DefaultNamespace, name})
+	}
+
+	return candidates
+}
+
+// parsePath returns the namespace and secret name for a path.  Namespaced paths are
+// split, and all other paths are converted to a name in the provider's namespace.
+func (p *Provider) parsePath(path string) (string, string) {
+	if namespace, name, found := strings.Cut(path, NamespaceSeparator); found {
+		return namespace, name
+	}
+
+	return p.namespace, pathToSecretName(path)
+}
+
+// namespacedPath returns the path of the secret with the name in the namespace.
+func namespacedPath(namespace, name string) string {
+	return namespace + NamespaceSeparator + name
+}
+
 // pathToSecretName turns a <service>/<name> userclouds secret path
 // to a k8s compatible name.
 func pathToSecretName(path string) string {
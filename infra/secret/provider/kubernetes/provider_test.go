@@ -3,12 +3,15 @@ package kubernetes
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestKubernetes_pathToSecretName(t *testing.T) {
@@ -105,3 +108,250 @@ func TestKubernetes_Save(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "really_super_secret", string(secret.Data["value"]))
 }
+
+func TestKubernetes_List(t *testing.T) {
+	ctx := context.Background()
+
+	managed := map[string]string{"app.kubernetes.io/managed-by": "userclouds"}
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "userclouds.test.service.one", Namespace: DefaultNamespace, Labels: managed}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "userclouds.test.service.two", Namespace: DefaultNamespace, Labels: managed}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "userclouds.test.unmanaged", Namespace: DefaultNamespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "userclouds.prod.service.one", Namespace: DefaultNamespace, Labels: managed}},
+	)
+
+	provider := New().WithClient(client)
+	paths, err := provider.List(ctx, "userclouds/test/")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"userclouds:userclouds.test.service.one", "userclouds:userclouds.test.service.two"}, paths)
+
+	// listed names can be used directly as paths
+	err = provider.Save(ctx, paths[0], "updated")
+	assert.NoError(t, err)
+	value, err := provider.Get(ctx, paths[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "updated", value)
+}
+
+func TestKubernetes_NamespacedPaths(t *testing.T) {
+	ctx := context.Background()
+
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-secret", Namespace: "other"},
+			Data:       map[string][]byte{"value": []byte("namespaced")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "other.my-secret", Namespace: DefaultNamespace},
+			Data:       map[string][]byte{"value": []byte("default")},
+		},
+	)
+	provider := New().WithClient(client)
+
+	// namespaced path
+	value, err := provider.Get(ctx, "other:my-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "namespaced", value)
+
+	// two segment paths are still stored in the provider's namespace
+	value, err = provider.Get(ctx, "other/my-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "default", value)
+	assert.NoError(t, provider.Save(ctx, "svc/name", "new"))
+	secret, err := client.CoreV1().Secrets(DefaultNamespace).Get(ctx, "svc.name", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(secret.Data["value"]))
+
+	// new namespaced secrets are created in the namespace, and never fall back
+	assert.NoError(t, provider.Save(ctx, "other:new-secret", "new"))
+	secret, err = client.CoreV1().Secrets("other").Get(ctx, "new-secret", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(secret.Data["value"]))
+	_, err = provider.Get(ctx, "third:my-secret")
+	assert.Error(t, err)
+
+	assert.NoError(t, provider.Delete(ctx, "other:my-secret"))
+	_, err = client.CoreV1().Secrets("other").Get(ctx, "my-secret", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+
+	assert.Error(t, provider.ValidatePath("Not_A_Namespace:my-secret"))
+}
+
+func TestKubernetes_Location(t *testing.T) {
+	tests := []struct {
+		path     string
+		location string
+	}{
+		{"userclouds/test/service/my_secret", "uc:userclouds.test.service.my-secret"},
+		{"simple", "uc:simple"},
+		{"other:my-secret", "other:my-secret"},
+	}
+
+	provider := New().WithNamespace("uc")
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			location := provider.Location(tt.path)
+			assert.Equal(t, tt.location, location)
+
+			// locations are stable
+			assert.Equal(t, location, provider.Location(location))
+		})
+	}
+
+	// secrets in the default namespace are located by their path, as they always were
+	assert.Equal(t, "userclouds/test/service/my_secret", New().Location("userclouds/test/service/my_secret"))
+}
+
+func TestKubernetes_Namespace(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(NamespaceEnvKey, "uc")
+
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "userclouds.test.old-secret", Namespace: DefaultNamespace},
+		Data:       map[string][]byte{"value": []byte("old")},
+	})
+	provider := New().WithClient(client)
+	assert.Equal(t, "uc:userclouds.test.new-secret", provider.Location("userclouds/test/new-secret"))
+
+	// new secrets are created in the configured namespace
+	assert.NoError(t, provider.Save(ctx, "userclouds/test/new-secret", "new"))
+	secret, err := client.CoreV1().Secrets("uc").Get(ctx, "userclouds.test.new-secret", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(secret.Data["value"]))
+
+	// secrets created in the default namespace still resolve
+	value, err := provider.Get(ctx, "userclouds/test/old-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", value)
+	assert.NoError(t, provider.Save(ctx, "userclouds/test/old-secret", "updated"))
+	secret, err = client.CoreV1().Secrets(DefaultNamespace).Get(ctx, "userclouds.test.old-secret", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "updated", string(secret.Data["value"]))
+
+	assert.Equal(t, "other", New().WithNamespace("other").namespace)
+}
+
+func TestKubernetes_DataKey(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-creds", Namespace: "external"},
+		Data: map[string][]byte{
+			"username": []byte("plex"),
+			"password": []byte("hunter2"),
+		},
+	})
+
+	_, err := New().WithClient(client).Get(ctx, "external:db-creds")
+	assert.ErrorContains(t, err, "no data key 'value'")
+
+	t.Setenv(DataKeyEnvKey, "password")
+	provider := New().WithClient(client)
+	value, err := provider.Get(ctx, "external:db-creds")
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+	assert.Error(t, provider.Save(ctx, "external:db-creds", "new"))
+
+	value, err = provider.WithDataKey(AllDataKeys).Get(ctx, "external:db-creds")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"username":"plex","password":"hunter2"}`, value)
+}
+
+func TestKubernetes_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	managed := map[string]string{"app.kubernetes.io/managed-by": "userclouds"}
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "userclouds.test.watched", Namespace: DefaultNamespace, Labels: managed},
+			Data:       map[string][]byte{"value": []byte("watched")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "userclouds.test.unmanaged", Namespace: DefaultNamespace},
+			Data:       map[string][]byte{"value": []byte("unmanaged")},
+		},
+	)
+
+	changed := make(chan string, 10)
+	provider := New().WithClient(client)
+	assert.NoError(t, provider.StartWatch(ctx, func(location string) { changed <- location }))
+
+	data, ok := provider.getWatched(DefaultNamespace, "userclouds.test.watched")
+	assert.True(t, ok)
+	assert.Equal(t, "watched", string(data["value"]))
+	_, ok = provider.getWatched(DefaultNamespace, "userclouds.test.unmanaged")
+	assert.False(t, ok)
+
+	// unwatched secrets are still resolved from the API server
+	value, err := provider.Get(ctx, "userclouds/test/unmanaged")
+	assert.NoError(t, err)
+	assert.Equal(t, "unmanaged", value)
+
+	assert.NoError(t, provider.Save(ctx, "userclouds/test/watched", "rotated"))
+	assert.Equal(t, Prefix+"userclouds:userclouds.test.watched", <-changed)
+	assert.Equal(t, Prefix+"userclouds/test/watched", <-changed)
+	assert.Eventually(t, func() bool {
+		value, err := provider.Get(ctx, "userclouds/test/watched")
+		return err == nil && value == "rotated"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestKubernetes_Ping(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+
+	provider := New().WithClient(client)
+	assert.NoError(t, provider.Ping(ctx))
+
+	client.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewForbidden(corev1.Resource("secrets"), "", nil)
+	})
+	assert.Error(t, provider.Ping(ctx))
+}
+
+func TestKubernetes_GetTimestamps(t *testing.T) {
+	ctx := context.Background()
+	created := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "userclouds.test.legacy", Namespace: DefaultNamespace, CreationTimestamp: created},
+		Data:       map[string][]byte{"value": []byte("legacy")},
+	})
+
+	provider := New().WithClient(client)
+	c, u, err := provider.GetTimestamps(ctx, "userclouds/test/legacy")
+	assert.NoError(t, err)
+	assert.True(t, created.Time.Equal(c))
+	assert.True(t, created.Time.Equal(u))
+
+	// saving records the update
+	assert.NoError(t, provider.Save(ctx, "userclouds/test/legacy", "updated"))
+	_, u, err = provider.GetTimestamps(ctx, "userclouds/test/legacy")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), u, 5*time.Second)
+
+	_, _, err = provider.GetTimestamps(ctx, "userclouds/test/missing")
+	assert.Error(t, err)
+}
+
+func TestKubernetes_Exists(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "userclouds.test.legacy", Namespace: DefaultNamespace},
+		Data:       map[string][]byte{"value": []byte("legacy")},
+	})
+
+	provider := New().WithClient(client)
+	exists, err := provider.Exists(ctx, "userclouds/test/legacy")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = provider.Exists(ctx, "userclouds/test/missing")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	client.PrependReactor("get", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewForbidden(corev1.Resource("secrets"), "", nil)
+	})
+	_, err = provider.Exists(ctx, "userclouds/test/legacy")
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package kubernetes
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"userclouds.com/infra/uclog"
+)
+
+const (
+	// RBACRoleName is the name used for the suggested secrets Role and RoleBinding.
+	RBACRoleName = "userclouds-secrets"
+)
+
+// forbiddenUserRegex extracts the user from a kubernetes forbidden error, e.g.
+// secrets "foo" is forbidden: User "system:serviceaccount:uc:plex" cannot get resource ...
+var forbiddenUserRegex = regexp.MustCompile(`User "([^"]+)"`)
+
+// accessError logs a suggested Role and RoleBinding when the error is due to missing
+// RBAC permissions for secrets in the namespace.  The error is returned unchanged.
+func accessError(ctx context.Context, namespace string, err error) error {
+	if err == nil || !errors.IsForbidden(err) {
+		return err
+	}
+
+	var user string
+	if m := forbiddenUserRegex.FindStringSubmatch(err.Error()); m != nil {
+		user = m[1]
+	}
+
+	hint, hintErr := RBACHint(namespace, user)
+	if hintErr != nil {
+		uclog.Warningf(ctx, "access to secrets in namespace %s is forbidden, and generating an RBAC hint failed: %v", namespace, hintErr)
+		return err
+	}
+
+	uclog.Warningf(ctx, "access to secrets in namespace %s is forbidden, the following Role and RoleBinding grant the required access:\n%s", namespace, hint)
+	return err
+}
+
+// RBACHint returns a YAML manifest for a Role and RoleBinding that grants the
+// subject the access to secrets in the namespace required by the provider.  The
+// subject is a kubernetes user name, where service accounts are in the form
+// system:serviceaccount:<namespace>:<name>.
+func RBACHint(namespace, subject string) (string, error) {
+	role := rbacv1.Role{
+		TypeMeta: metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RBACRoleName,
+			Namespace: namespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"secrets"},
+				Verbs:     []string{"get", "list", "create", "update", "delete"},
+			},
+		},
+	}
+
+	binding := rbacv1.RoleBinding{
+		TypeMeta: metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RBACRoleName,
+			Namespace: namespace,
+		},
+		Subjects: []rbacv1.Subject{rbacSubject(subject)},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "Role",
+			Name:     RBACRoleName,
+		},
+	}
+
+	var docs []string
+	for _, obj := range []any{role, binding} {
+		bs, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, string(bs))
+	}
+
+	return strings.Join(docs, "---\n"), nil
+}
+
+// rbacSubject turns a kubernetes user name into a RoleBinding subject.
+func rbacSubject(user string) rbacv1.Subject {
+	if sa, found := strings.CutPrefix(user, "system:serviceaccount:"); found {
+		if namespace, name, ok := strings.Cut(sa, ":"); ok {
+			return rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: name}
+		}
+	}
+
+	if user == "" {
+		user = "<service-account>"
+	}
+
+	return rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: user}
+}
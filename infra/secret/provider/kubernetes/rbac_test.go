@@ -0,0 +1,23 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKubernetes_RBACHint(t *testing.T) {
+	hint, err := RBACHint("userclouds", "system:serviceaccount:uc:plex")
+	assert.NoError(t, err)
+	assert.Contains(t, hint, "kind: Role\n")
+	assert.Contains(t, hint, "kind: RoleBinding\n")
+	assert.Contains(t, hint, "namespace: userclouds\n")
+	assert.Contains(t, hint, "kind: ServiceAccount")
+	assert.Contains(t, hint, "name: plex")
+	assert.Contains(t, hint, "- secrets")
+
+	hint, err = RBACHint("userclouds", "jane@example.com")
+	assert.NoError(t, err)
+	assert.Contains(t, hint, "kind: User")
+	assert.Contains(t, hint, "name: jane@example.com")
+}
@@ -0,0 +1,99 @@
+package kubernetes
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+// watchResync is how often the watched secrets are fully re-listed, in case a change
+// event was missed.
+const watchResync = 10 * time.Minute
+
+// StartWatch watches the secrets managed by the provider in its namespace until ctx is
+// done, and serves them from memory instead of requesting them from the API server on
+// every Get.  Other secrets are still requested from the API server.  onChange, if not
+// nil, is called with the locations of each watched secret that changes or is deleted,
+// e.g. to invalidate it in the secret cache so rotations are picked up immediately.  The
+// locations are its namespaced path, and the location of the path it was saved with
+// (see PathAnnotation) if that differs.
+// StartWatch returns once the watched secrets have been loaded, and must be called
+// before the provider is used concurrently.
+func (p *Provider) StartWatch(ctx context.Context, onChange func(location string)) error {
+	if err := p.initClient(); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(p.client, watchResync,
+		informers.WithNamespace(p.namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = ManagedBySelector
+		}))
+	secrets := factory.Core().V1().Secrets()
+
+	if onChange != nil {
+		changed := func(obj any) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if s, ok := obj.(*corev1.Secret); ok {
+				for _, location := range p.watchedLocations(s) {
+					onChange(location)
+				}
+			}
+		}
+		if _, err := secrets.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(_, obj any) { changed(obj) },
+			DeleteFunc: changed,
+		}); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+
+	lister := secrets.Lister()
+	factory.Start(ctx.Done())
+	for typ, synced := range factory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return ucerr.Errorf("failed to load kubernetes secrets in namespace %s to watch (%v)", p.namespace, typ)
+		}
+	}
+
+	uclog.Infof(ctx, "Watching kubernetes secrets in namespace %s", p.namespace)
+	p.lister = lister
+	return nil
+}
+
+// watchedLocations returns the locations that a watched secret can be cached by.
+func (p *Provider) watchedLocations(s *corev1.Secret) []string {
+	locations := []string{Prefix + namespacedPath(s.Namespace, s.Name)}
+	if path, ok := s.Annotations[PathAnnotation]; ok {
+		if location := Prefix + p.Location(path); location != locations[0] {
+			locations = append(locations, location)
+		}
+	}
+
+	return locations
+}
+
+// getWatched returns the data of a watched secret.  The second return value is false if
+// the secret isn't watched, and has to be requested from the API server.
+func (p *Provider) getWatched(namespace, name string) (map[string][]byte, bool) {
+	if p.lister == nil || namespace != p.namespace {
+		return nil, false
+	}
+
+	// secrets which aren't labeled as managed by the provider aren't watched
+	s, err := p.lister.Secrets(namespace).Get(name)
+	if err != nil {
+		return nil, false
+	}
+
+	return s.Data, true
+}
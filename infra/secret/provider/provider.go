@@ -4,11 +4,17 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"userclouds.com/infra/secret/prefix"
 	"userclouds.com/infra/secret/provider/aws"
+	"userclouds.com/infra/secret/provider/azure"
+	"userclouds.com/infra/secret/provider/chain"
 	"userclouds.com/infra/secret/provider/dev"
+	"userclouds.com/infra/secret/provider/encrypted"
 	"userclouds.com/infra/secret/provider/env"
+	"userclouds.com/infra/secret/provider/file"
 	"userclouds.com/infra/secret/provider/kubernetes"
 )
 
@@ -26,11 +32,196 @@ type Interface interface {
 	IsDev() bool
 }
 
-// FromEnv returns the discovered provider.  There are three that are supported
-// currently: 'aws', 'kube', and 'dev'.  This is not the best way to manage this.
-// I'd like to merge into the config at a later time, but this is the most straight
-// forward approach given how it is handled right now (based on universe env vars)
-// since there would need to be other changes to the callers.
+// Lister is an optional interface implemented by providers that are able to
+// enumerate the secrets that they store.
+type Lister interface {
+	List(ctx context.Context, pathPrefix string) ([]string, error)
+}
+
+// List returns the paths of the secrets stored by the provider which start with
+// pathPrefix.  An error is returned if the provider does not support listing.
+func List(ctx context.Context, pv Interface, pathPrefix string) ([]string, error) {
+	lister, ok := pv.(Lister)
+	if !ok {
+		return nil, fmt.Errorf("secret provider %s does not support listing", pv.Prefix())
+	}
+
+	return lister.List(ctx, pathPrefix)
+}
+
+// HealthChecker is an optional interface implemented by providers that depend on a
+// backend, such as a cloud secret manager, to check that it is reachable and that the
+// service is allowed to use it.
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks that the provider's backend is reachable.  Providers that don't implement
+// HealthChecker, such as env, are always healthy.
+func Ping(ctx context.Context, pv Interface) error {
+	if hc, ok := pv.(HealthChecker); ok {
+		return hc.Ping(ctx)
+	}
+
+	return nil
+}
+
+// NameValidator is an optional interface implemented by providers that restrict
+// the names of the secrets they store.
+type NameValidator interface {
+	ValidatePath(path string) error
+}
+
+// ValidatePath checks that the path can be stored by the provider.  Providers that
+// don't implement NameValidator accept any path.
+func ValidatePath(pv Interface, path string) error {
+	if v, ok := pv.(NameValidator); ok {
+		return v.ValidatePath(path)
+	}
+
+	return nil
+}
+
+// TimestampGetter is an optional interface implemented by providers that know when a
+// secret was created and last updated.
+type TimestampGetter interface {
+	GetTimestamps(ctx context.Context, path string) (created, updated time.Time, err error)
+}
+
+// GetTimestamps returns when the secret at path was created and last updated.  An error
+// is returned if the provider doesn't track them.
+func GetTimestamps(ctx context.Context, pv Interface, path string) (time.Time, time.Time, error) {
+	tg, ok := pv.(TimestampGetter)
+	if !ok {
+		return time.Time{}, time.Time{}, fmt.Errorf("secret provider %s does not track when secrets are updated", pv.Prefix())
+	}
+
+	return tg.GetTimestamps(ctx, path)
+}
+
+// ExistenceChecker is an optional interface implemented by providers that can check
+// whether a secret exists without resolving it, so that secrets which are missing can
+// be told apart from those the service isn't allowed to read.
+type ExistenceChecker interface {
+	Exists(ctx context.Context, path string) (bool, error)
+}
+
+// Exists returns whether the secret at path exists, ignoring a pinned version.  For
+// providers that don't implement ExistenceChecker the secret exists if it resolves, and
+// the error resolving it is returned otherwise, since it can't tell why it failed.
+func Exists(ctx context.Context, pv Interface, path string) (bool, error) {
+	path, _ = SplitVersion(pv, path)
+	if ec, ok := pv.(ExistenceChecker); ok {
+		return ec.Exists(ctx, path)
+	}
+
+	if _, err := pv.Get(ctx, path); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// VersionGetter is an optional interface implemented by providers that keep previous
+// versions of secrets.  Locations pin a version with a suffix, e.g.
+// aws://secrets/my-secret@AWSPREVIOUS, whose meaning is up to the provider.
+type VersionGetter interface {
+	GetVersion(ctx context.Context, path, version string) (string, error)
+}
+
+// SplitVersion returns the path and pinned version of a path for the provider.  Paths
+// are only split for providers that implement VersionGetter, at the last '@', so the
+// names of their secrets can only contain '@' if a version is pinned too.
+func SplitVersion(pv Interface, path string) (string, string) {
+	if _, ok := pv.(VersionGetter); !ok {
+		return path, ""
+	}
+
+	i := strings.LastIndex(path, "@")
+	if i <= 0 || i == len(path)-1 {
+		return path, ""
+	}
+
+	return path[:i], path[i+1:]
+}
+
+// Get returns the secret at path, fetching the pinned version if the path pins one.
+func Get(ctx context.Context, pv Interface, path string) (string, error) {
+	path, version := SplitVersion(pv, path)
+	if version == "" {
+		return pv.Get(ctx, path)
+	}
+
+	return pv.(VersionGetter).GetVersion(ctx, path, version)
+}
+
+// Rotator is an optional interface implemented by providers that keep the value replaced
+// by saving a secret as a version of it, such as AWS with its AWSPREVIOUS stage, so that
+// rotations don't need a secret of their own to keep the previous value readable.
+// Rotators must implement VersionGetter too.
+type Rotator interface {
+	// PreviousVersion returns the version that the value replaced by the last save can be
+	// retrieved at with GetVersion.
+	PreviousVersion() string
+	// FinalizeRotation stops the previous version of the secret from being retrievable.
+	FinalizeRotation(ctx context.Context, path string) error
+	// CancelRotation makes the previous version of the secret current again, and stops the
+	// version it replaces from being retrievable.
+	CancelRotation(ctx context.Context, path string) error
+}
+
+// BatchGetter is an optional interface implemented by providers that can retrieve many
+// secrets in fewer calls than getting them one at a time, e.g. to load the secrets of a
+// service at startup.
+type BatchGetter interface {
+	// GetBatch returns the values of the secrets at paths in the same order, and the
+	// errors of the secrets that couldn't be retrieved at their index.
+	GetBatch(ctx context.Context, paths []string) ([]string, []error)
+}
+
+// GetBatch returns the secrets at paths like Get, in batches if the provider implements
+// BatchGetter.  Paths that pin a version are always retrieved one at a time.
+func GetBatch(ctx context.Context, pv Interface, paths []string) ([]string, []error) {
+	values := make([]string, len(paths))
+	errs := make([]error, len(paths))
+
+	bg, ok := pv.(BatchGetter)
+	var batch []string
+	var indexes []int
+	for i, path := range paths {
+		if _, version := SplitVersion(pv, path); ok && version == "" {
+			batch = append(batch, path)
+			indexes = append(indexes, i)
+			continue
+		}
+
+		values[i], errs[i] = Get(ctx, pv, path)
+	}
+
+	if len(batch) > 0 {
+		batchValues, batchErrs := bg.GetBatch(ctx, batch)
+		for j, i := range indexes {
+			values[i], errs[i] = batchValues[j], batchErrs[j]
+		}
+	}
+
+	return values, errs
+}
+
+// Locator is an optional interface implemented by providers that store secrets at a
+// location which differs from the path they were saved with.
+type Locator interface {
+	Location(path string) string
+}
+
+// FromEnv returns the discovered provider.  There are six that are supported
+// currently: 'aws', 'azure', 'kubernetes', 'file', 'dev', and 'dev-file' (dev secrets
+// persisted in ~/.userclouds/secrets).  Providers can also be
+// chained, e.g. 'chain:kubernetes,env', to try each of them in order, and providers
+// added with Register are selected by their name.  Prefixing a name with 'enc+', e.g.
+// 'enc+kubernetes', encrypts secrets before that provider stores them.  Services that
+// set the provider in their config (see Config) only fall back to the environment when
+// they don't.
 func FromEnv() (Interface, error) {
 	// Supporting three stores at the moment.  If the store isn't defined we choose the
 	// expected AWS for cloud and on-prem universes.  I may get rid of `dev` later on since
@@ -40,18 +231,84 @@ func FromEnv() (Interface, error) {
 		return aws.New(), nil
 	}
 
-	storeMap := map[string]Interface{
+	if names, ok := strings.CutPrefix(value, chainPrefix); ok {
+		return chainFromNames(storeMap(), names)
+	}
+
+	provider, err := fromName(storeMap(), value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret provider in environment variable %s: %w", SecretManagerEnvKey, err)
+	}
+
+	return provider, nil
+}
+
+// fromName returns the provider in stores or registered with the name, wrapped to be
+// encrypted if the name starts with enc+.
+func fromName(stores map[string]Interface, name string) (Interface, error) {
+	if inner, ok := strings.CutPrefix(name, encrypted.Prefix); ok {
+		pv, err := fromName(stores, inner)
+		if err != nil {
+			return nil, err
+		}
+		return encryptedProvider(pv)
+	}
+
+	pv, found := stores[name]
+	if !found {
+		pv, found = registeredByName(name)
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown secret provider '%s'", name)
+	}
+
+	return pv, nil
+}
+
+// encryptedProvider wraps pv to encrypt the secrets it stores.  Development providers
+// that store secrets in their location can't be wrapped, nor can encrypted ones.
+func encryptedProvider(pv Interface) (Interface, error) {
+	if pv.IsDev() || strings.HasPrefix(pv.Prefix(), encrypted.Prefix) {
+		return nil, fmt.Errorf("secrets of provider %s can't be encrypted", pv.Prefix())
+	}
+
+	return encrypted.New(pv), nil
+}
+
+// chainPrefix starts a list of providers to chain in UC_SECRET_MANAGER.
+const chainPrefix = "chain:"
+
+func storeMap() map[string]Interface {
+	return map[string]Interface{
 		"aws":        aws.New(),
+		"azure":      azure.New(),
 		"kubernetes": kubernetes.New(),
+		"file":       file.New(),
 		"dev":        dev.New(),
+		"dev-file":   dev.NewStore(),
 	}
+}
 
-	provider, found := storeMap[value]
-	if !found {
-		return nil, fmt.Errorf("secret provider not found in environment variable %s", SecretManagerEnvKey)
+// chainFromNames returns a chain of the comma separated providers in stores.  Providers
+// that only read secrets, such as env, can be chained too.
+func chainFromNames(stores map[string]Interface, names string) (Interface, error) {
+	stores["env"] = env.New()
+
+	var providers []chain.Interface
+	for _, name := range strings.Split(names, ",") {
+		pv, err := fromName(stores, strings.TrimSpace(name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret provider in the chain in environment variable %s: %w", SecretManagerEnvKey, err)
+		}
+		providers = append(providers, pv)
 	}
 
-	return provider, nil
+	pv, err := chain.New(providers...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pv, nil
 }
 
 // FromLocation determines the appropriate provider to use based on the URI
@@ -63,17 +320,43 @@ func FromLocation(loc string) (Interface, error) {
 		return nil, err
 	}
 
+	if px.Encrypted() {
+		pv, err := FromLocation(strings.TrimPrefix(loc, prefix.EncryptedPrefix))
+		if err != nil {
+			return nil, err
+		}
+		return encryptedProvider(pv)
+	}
+
 	switch px {
 	case prefix.PrefixAWS:
 		return aws.New(), nil
+	case prefix.PrefixAzureKeyVault:
+		return azure.New(), nil
+	case prefix.PrefixChain:
+		// chained locations resolve with the chain of the environment, which is what lets
+		// the same location work across environments
+		names, ok := strings.CutPrefix(os.Getenv(SecretManagerEnvKey), chainPrefix)
+		if !ok {
+			return nil, fmt.Errorf("secret location %s requires %s to be set to a chain, e.g. %skubernetes,env", loc, SecretManagerEnvKey, chainPrefix)
+		}
+		return chainFromNames(storeMap(), names)
 	case prefix.PrefixEnv:
 		return env.New(), nil
+	case prefix.PrefixFile:
+		return file.New(), nil
 	case prefix.PrefixKubernetes:
 		return kubernetes.New(), nil
 	case prefix.PrefixDev:
 		return dev.New(), nil
 	case prefix.PrefixDevLiteral:
 		return dev.New().WithLiterals(), nil
+	case prefix.PrefixDevFile:
+		return dev.NewStore(), nil
+	}
+
+	if pv, found := fromRegistry(px.String()); found {
+		return pv, nil
 	}
 
 	return nil, fmt.Errorf("unknown secret provider for %s", loc)
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"userclouds.com/infra/secret/prefix"
+)
+
+// Factory returns a new instance of a provider.
+type Factory func() Interface
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register adds a custom provider for secret locations starting with pfx, which must be of
+// the form <name>://, e.g. "vault://".  FromLocation uses the provider for these locations,
+// and FromEnv selects it when UC_SECRET_MANAGER is set to its name, alone or in a chain.
+// Like database/sql drivers, providers are meant to be registered from an init function,
+// and Register panics if the prefix is invalid or already known.
+func Register(pfx string, factory Factory) {
+	if factory == nil {
+		panic(fmt.Sprintf("secret provider factory for %s is nil", pfx))
+	}
+
+	if err := prefix.Register(prefix.Prefix(pfx)); err != nil {
+		panic(err)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[pfx] = factory
+}
+
+// fromRegistry returns a new instance of the registered provider with the prefix.
+func fromRegistry(pfx string) (Interface, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	factory, found := registry[pfx]
+	if !found {
+		return nil, false
+	}
+
+	return factory(), true
+}
+
+// registeredByName returns a new instance of the registered provider named name, which
+// is its prefix without "://".
+func registeredByName(name string) (Interface, bool) {
+	if name == "" || strings.Contains(name, "://") {
+		return nil, false
+	}
+
+	return fromRegistry(name + "://")
+}
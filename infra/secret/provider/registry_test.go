@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret/provider/env"
+)
+
+// testProvider is a custom provider that resolves paths to themselves.
+type testProvider struct {
+	*env.Provider
+}
+
+func (p testProvider) Prefix() string {
+	return "test-registry://"
+}
+
+func (p testProvider) Get(ctx context.Context, path string) (string, error) {
+	return path, nil
+}
+
+func TestRegister(t *testing.T) {
+	ctx := context.Background()
+	Register("test-registry://", func() Interface { return testProvider{env.New()} })
+
+	pv, err := FromLocation("test-registry://my-secret")
+	assert.NoError(t, err)
+	secret, err := pv.Get(ctx, "my-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-secret", secret)
+
+	t.Setenv(SecretManagerEnvKey, "test-registry")
+	pv, err = FromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "test-registry://", pv.Prefix())
+
+	t.Setenv(SecretManagerEnvKey, "chain:env,test-registry")
+	_, err = FromEnv()
+	assert.NoError(t, err)
+
+	// known prefixes can't be taken over
+	assert.Panics(t, func() { Register("test-registry://", func() Interface { return testProvider{} }) })
+	assert.Panics(t, func() { Register("env://", func() Interface { return testProvider{} }) })
+	assert.Panics(t, func() { Register("no-scheme", func() Interface { return testProvider{} }) })
+}
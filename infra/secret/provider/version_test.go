@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret/provider/aws"
+	"userclouds.com/infra/secret/provider/env"
+)
+
+func TestSplitVersion(t *testing.T) {
+	pv := aws.New()
+	for path, want := range map[string][2]string{
+		"secrets/my-secret@v3":          {"secrets/my-secret", "v3"},
+		"secrets/my-secret@AWSPREVIOUS": {"secrets/my-secret", "AWSPREVIOUS"},
+		"user@example/secret@v1":        {"user@example/secret", "v1"},
+		"secrets/my-secret":             {"secrets/my-secret", ""},
+		"secrets/my-secret@":            {"secrets/my-secret@", ""},
+		"@v3":                           {"@v3", ""},
+	} {
+		p, v := SplitVersion(pv, path)
+		assert.Equal(t, want, [2]string{p, v}, path)
+	}
+
+	// providers without versions use the path as is
+	p, v := SplitVersion(env.New(), "MY_SECRET@v3")
+	assert.Equal(t, "MY_SECRET@v3", p)
+	assert.Empty(t, v)
+}
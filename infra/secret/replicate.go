@@ -0,0 +1,44 @@
+package secret
+
+import (
+	"context"
+
+	"userclouds.com/infra/secret/provider"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+// Replicate copies the current value of src into dstPath in the destination provider,
+// returning a String pointing at the replica.  The source is always read from its
+// provider (bypassing the cache) and the destination is only written when its value
+// differs, so Replicate can be called periodically to keep a mirror up to date.
+func Replicate(ctx context.Context, src *String, dst provider.Interface, dstPath string) (*String, error) {
+	if src.IsEmpty() {
+		return nil, ucerr.New("cannot replicate an empty secret")
+	}
+
+	if !src.HasPrefix() {
+		return nil, ucerr.New("cannot replicate a secret without a provider prefix")
+	}
+
+	value, err := src.fetch(ctx)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	if !dst.IsDev() {
+		current, err := dst.Get(ctx, dstPath)
+		if err == nil && current == value {
+			uclog.Debugf(ctx, "secret replica %s%s is up to date", dst.Prefix(), dstPath)
+			return FromLocation(locationForPath(dst, dstPath)).WithProvider(dst), nil
+		}
+	}
+
+	replica, err := saveWithProvider(ctx, dst, dstPath, value)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	uclog.Infof(ctx, "replicated secret to %s%s", dst.Prefix(), dstPath)
+	return replica, nil
+}
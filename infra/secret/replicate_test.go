@@ -0,0 +1,45 @@
+package secret
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"userclouds.com/infra/secret/provider/kubernetes"
+)
+
+func TestReplicate(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	dst := kubernetes.New().WithClient(client)
+
+	src := FromLocation("dev-literal://replicated")
+	replica, err := Replicate(ctx, src, dst, "userclouds/test/service/replica")
+	assert.NoError(t, err)
+	assert.Equal(t, "kube://secrets/userclouds/test/service/replica", replica.location)
+
+	value, err := replica.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "replicated", value)
+
+	// replicating an unchanged value doesn't write to the destination
+	client.ClearActions()
+	_, err = Replicate(ctx, src, dst, "userclouds/test/service/replica")
+	assert.NoError(t, err)
+	for _, action := range client.Actions() {
+		assert.Equal(t, "get", action.GetVerb())
+	}
+
+	// changed values are written
+	_, err = Replicate(ctx, FromLocation("dev-literal://changed"), dst, "userclouds/test/service/replica")
+	assert.NoError(t, err)
+	secret, err := client.CoreV1().Secrets(kubernetes.DefaultNamespace).Get(ctx, "userclouds.test.service.replica", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "changed", string(secret.Data["value"]))
+
+	_, err = Replicate(ctx, &EmptyString, dst, "userclouds/test/service/replica")
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"userclouds.com/infra/secret/prefix"
+	"userclouds.com/infra/secret/provider"
+	"userclouds.com/infra/ucerr"
+)
+
+// DefaultResolveConcurrency is how many secrets ResolveAll resolves at once by default.
+const DefaultResolveConcurrency = 8
+
+// ResolveAllOptions configures ResolveAll.
+type ResolveAllOptions struct {
+	// Concurrency is the maximum number of secrets resolved at once, which defaults to
+	// DefaultResolveConcurrency.
+	Concurrency int
+}
+
+// ResolveAll resolves secrets concurrently, e.g. to load the secrets of a service at
+// startup, and returns their values in the same order.  Every secret is resolved even
+// if some fail, and the error lists all the failures.  Uncached secrets of providers
+// that implement provider.BatchGetter, such as AWS, are fetched in batches first.
+func ResolveAll(ctx context.Context, secrets []*String, opts ResolveAllOptions) ([]string, error) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultResolveConcurrency
+	}
+
+	ttl := GetCacheTTL()
+	fetches := batchFetches(ctx, secrets, ttl)
+
+	values := make([]string, len(secrets))
+	errs := make([]error, len(secrets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, s := range secrets {
+		if s == nil {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fetch, ok := fetches[i]
+			if !ok {
+				fetch = s.fetch
+			}
+			values[i], errs[i] = s.resolve(ctx, ttl, fetch)
+		}()
+	}
+	wg.Wait()
+
+	var failures []string
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("secret %d (%s): %s", i, secrets[i].String(), ucerr.FirstLine(err)))
+		}
+	}
+	if len(failures) > 0 {
+		return values, ucerr.Errorf("failed to resolve %d of %d secrets: %s", len(failures), len(secrets), strings.Join(failures, "; "))
+	}
+
+	return values, nil
+}
+
+// batchFetches fetches the uncached secrets of providers that implement
+// provider.BatchGetter in one batch per provider, and returns the functions that return
+// their results, by index, for resolve to fetch them with.  Batches are per provider
+// rather than per prefix, since providers with the same prefix can differ in region or
+// credentials.
+func batchFetches(ctx context.Context, secrets []*String, ttl time.Duration) map[int]func(context.Context) (string, error) {
+	type batch struct {
+		pv      provider.Interface
+		indexes []int
+		paths   []string
+		fields  []string
+	}
+
+	var order []provider.Interface
+	batches := map[provider.Interface]*batch{}
+	defaults := map[prefix.Prefix]provider.Interface{}
+	for i, s := range secrets {
+		if s == nil || s.IsEmpty() || !s.HasPrefix() {
+			continue
+		}
+		s.shareDefaultProvider(defaults)
+
+		// secrets that are cached or backed off aren't fetched by resolve either
+		if _, found := s.getCache().Get(ctx, s.location, ttl); found {
+			continue
+		}
+		if ttl > 0 && failures.check(s.location) != nil {
+			continue
+		}
+
+		pv, path, field, err := s.providerPath()
+		if err != nil {
+			continue
+		}
+		if _, ok := pv.(provider.BatchGetter); !ok {
+			continue
+		}
+
+		b, ok := batches[pv]
+		if !ok {
+			b = &batch{pv: pv}
+			batches[pv] = b
+			order = append(order, pv)
+		}
+		b.indexes = append(b.indexes, i)
+		b.paths = append(b.paths, path)
+		b.fields = append(b.fields, field)
+	}
+
+	fetches := map[int]func(context.Context) (string, error){}
+	for _, pv := range order {
+		b := batches[pv]
+		values, errs := provider.GetBatch(ctx, b.pv, b.paths)
+		for j, i := range b.indexes {
+			value, err := values[j], errs[j]
+			if err == nil && b.fields[j] != "" {
+				value, err = selectField(value, b.fields[j])
+			}
+
+			fetches[i] = func(context.Context) (string, error) {
+				return value, ucerr.Wrap(err)
+			}
+		}
+	}
+
+	return fetches
+}
+
+// shareDefaultProvider sets the provider of a secret that doesn't have one yet to the
+// provider of the same prefix in defaults, adding it if there isn't one, so that the
+// secrets resolved with the default provider of their location are batched together.
+func (s *String) shareDefaultProvider(defaults map[prefix.Prefix]provider.Interface) {
+	if s.provider != nil {
+		return
+	}
+
+	px, err := prefix.PrefixFromString(s.location)
+	if err != nil {
+		return
+	}
+
+	pv, ok := defaults[px]
+	if !ok {
+		if pv, err = providerForLocation(s.location); err != nil {
+			return
+		}
+		defaults[px] = pv
+	}
+
+	s.provider = pv
+}
@@ -0,0 +1,112 @@
+package secret
+
+import (
+	"context"
+	"testing"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"userclouds.com/infra/secret/provider/aws"
+)
+
+func TestResolveAll(t *testing.T) {
+	ctx := context.Background()
+
+	secrets := []*String{
+		FromLocation("dev-literal://one"),
+		FromLocation("dev://dHdv"),
+		nil,
+		&EmptyString,
+		FromLocation("dev-literal://four"),
+	}
+	values, err := ResolveAll(ctx, secrets, ResolveAllOptions{Concurrency: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "", "", "four"}, values)
+
+	secrets = append(secrets, FromLocation("unknown://five"), FromLocation("unknown://six"))
+	values, err = ResolveAll(ctx, secrets, ResolveAllOptions{})
+	assert.ErrorContains(t, err, "failed to resolve 2 of 7 secrets")
+	assert.ErrorContains(t, err, "secret 5")
+	assert.ErrorContains(t, err, "secret 6")
+	assert.Equal(t, "four", values[4])
+}
+
+func TestResolveAll_batch(t *testing.T) {
+	ctx := context.Background()
+	InvalidateAll(ctx)
+	t.Cleanup(func() { InvalidateAll(ctx) })
+
+	sm := &aws.MockSecretsManagerClient{}
+	sm.On("BatchGetSecretValue", ctx, mock.MatchedBy(func(in *secretsmanager.BatchGetSecretValueInput) bool {
+		return len(in.SecretIdList) == 2
+	}), mock.Anything).Return(&secretsmanager.BatchGetSecretValueOutput{
+		SecretValues: []types.SecretValueEntry{
+			{Name: awsv2.String("userclouds/batch/one"), SecretString: awsv2.String(`{"string":"one"}`)},
+			{Name: awsv2.String("userclouds/batch/db"), SecretString: awsv2.String(`{"user":"admin"}`)},
+		},
+	}, nil).Once()
+	pv := aws.New().WithSecretsManagerClient(sm)
+
+	secrets := []*String{
+		FromLocation("aws://secrets/userclouds/batch/one").WithProvider(pv),
+		FromLocation("dev-literal://two"),
+		FromLocation("aws://secrets/userclouds/batch/db#user").WithProvider(pv),
+	}
+	values, err := ResolveAll(ctx, secrets, ResolveAllOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "admin"}, values)
+
+	// the batch is cached, so resolving the secrets again doesn't fetch them
+	values, err = ResolveAll(ctx, secrets, ResolveAllOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "admin"}, values)
+	sm.AssertExpectations(t)
+}
+
+func TestResolveAll_batchPerProvider(t *testing.T) {
+	ctx := context.Background()
+	InvalidateAll(ctx)
+	t.Cleanup(func() { InvalidateAll(ctx) })
+
+	// providers with the same prefix, e.g. in different accounts, are batched separately
+	providers := make([]*aws.Provider, 2)
+	clients := make([]*aws.MockSecretsManagerClient, 2)
+	for i, name := range []string{"west", "east"} {
+		clients[i] = &aws.MockSecretsManagerClient{}
+		clients[i].On("BatchGetSecretValue", ctx, mock.MatchedBy(func(in *secretsmanager.BatchGetSecretValueInput) bool {
+			return len(in.SecretIdList) == 2 && in.SecretIdList[0] == "userclouds/"+name+"/one"
+		}), mock.Anything).Return(&secretsmanager.BatchGetSecretValueOutput{
+			SecretValues: []types.SecretValueEntry{
+				{Name: awsv2.String("userclouds/" + name + "/one"), SecretString: awsv2.String(`{"string":"` + name + `-one"}`)},
+				{Name: awsv2.String("userclouds/" + name + "/two"), SecretString: awsv2.String(`{"string":"` + name + `-two"}`)},
+			},
+		}, nil).Once()
+		providers[i] = aws.New().WithSecretsManagerClient(clients[i])
+	}
+
+	secrets := []*String{
+		FromLocation("aws://secrets/userclouds/west/one").WithProvider(providers[0]),
+		FromLocation("aws://secrets/userclouds/east/one").WithProvider(providers[1]),
+		FromLocation("aws://secrets/userclouds/west/two").WithProvider(providers[0]),
+		FromLocation("aws://secrets/userclouds/east/two").WithProvider(providers[1]),
+	}
+	values, err := ResolveAll(ctx, secrets, ResolveAllOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"west-one", "east-one", "west-two", "east-two"}, values)
+	for _, c := range clients {
+		c.AssertExpectations(t)
+	}
+}
+
+func TestResolveAll_sharedDefaultProvider(t *testing.T) {
+	ctx := context.Background()
+
+	secrets := []*String{FromLocation("dev-literal://one"), FromLocation("dev-literal://two")}
+	_, err := ResolveAll(ctx, secrets, ResolveAllOptions{})
+	assert.NoError(t, err)
+	assert.Same(t, secrets[0].provider, secrets[1].provider)
+}
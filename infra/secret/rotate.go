@@ -0,0 +1,169 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+
+	"userclouds.com/infra/secret/provider"
+	"userclouds.com/infra/ucerr"
+	"userclouds.com/infra/uclog"
+)
+
+// PreviousSuffix is appended to the path of a secret that is being rotated to store the
+// value that the rotation replaced, for providers that don't keep it as a version of the
+// secret (see provider.Rotator).
+const PreviousSuffix = "-previous"
+
+// previousField is the field of the secret at PreviousSuffix that holds the value the
+// rotation replaced.
+const previousField = "value"
+
+// previousValue is stored at PreviousSuffix, recording the path it was rotated from so
+// that a secret which only happens to have the same name is never overwritten.
+type previousValue struct {
+	RotationOf string `json:"rotation_of"`
+	Value      string `json:"value"`
+}
+
+// Rotate replaces the value of the secret while keeping the value it replaced readable
+// through Previous, so that consumers can accept both values until every client has
+// picked up the new one.  The rotation is ended with FinalizeRotation, or undone with
+// CancelRotation.  Rotating again before then replaces the previous value.
+//
+// Providers that implement provider.Rotator keep the previous value as a version of the
+// secret, e.g. AWSPREVIOUS.  Other providers keep it in a secret at the path of the
+// secret with PreviousSuffix, and the rotation fails if a secret that wasn't created by
+// a rotation already exists there.
+func (s *String) Rotate(ctx context.Context, newValue string) (err error) {
+	defer func() { audit(ctx, AuditRotate, s.location, err) }()
+
+	if newValue == "" {
+		return ucerr.New("cannot rotate a secret to an empty value")
+	}
+
+	pv, path, err := s.storedPath("rotate")
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	old, err := s.fetch(ctx)
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	prev := s.Previous()
+	if _, ok := pv.(provider.Rotator); !ok {
+		// save the previous value first, so it stays readable if saving the new one fails
+		if err := savePrevious(ctx, pv, path, old); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
+
+	if err := pv.Save(ctx, path, newValue); err != nil {
+		return ucerr.Wrap(err)
+	}
+	s.getCache().Store(ctx, s.location, newValue)
+	prev.getCache().Store(ctx, prev.location, old)
+
+	uclog.Infof(ctx, "rotated secret %s", s.String())
+	return nil
+}
+
+// savePrevious saves the value replaced by rotating the secret at path next to it,
+// unless a secret that wasn't created by rotating it is already stored there.
+func savePrevious(ctx context.Context, pv provider.Interface, path, old string) error {
+	if err := provider.ValidatePath(pv, path+PreviousSuffix); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	// providers that can't check for secrets fail to get those that don't exist
+	exists, err := provider.Exists(ctx, pv, path+PreviousSuffix)
+	if _, ok := pv.(provider.ExistenceChecker); ok && err != nil {
+		return ucerr.Wrap(err)
+	}
+	if exists {
+		existing, err := pv.Get(ctx, path+PreviousSuffix)
+		if err != nil {
+			return ucerr.Wrap(err)
+		}
+
+		var prev previousValue
+		if err := json.Unmarshal([]byte(existing), &prev); err != nil || prev.RotationOf != path {
+			return ucerr.Errorf("cannot rotate %s, since %s%s already exists and wasn't created by rotating it", path, path, PreviousSuffix)
+		}
+	}
+
+	bs, err := json.Marshal(previousValue{RotationOf: path, Value: old})
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	return ucerr.Wrap(pv.Save(ctx, path+PreviousSuffix, string(bs)))
+}
+
+// Previous returns the secret holding the value replaced by a rotation in progress.
+// Resolving it fails if the secret isn't being rotated.
+func (s *String) Previous() *String {
+	location := s.location + PreviousSuffix + FieldSeparator + previousField
+	if pv, err := s.GetProvider(); err == nil {
+		if r, ok := pv.(provider.Rotator); ok {
+			location = s.location + "@" + r.PreviousVersion()
+		}
+	}
+
+	return FromLocation(location).WithProvider(s.provider).WithCache(s.cache)
+}
+
+// FinalizeRotation ends a rotation by deleting the previous value.
+func (s *String) FinalizeRotation(ctx context.Context) (err error) {
+	defer func() { audit(ctx, AuditFinalizeRotation, s.location, err) }()
+
+	pv, path, err := s.storedPath("rotate")
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if r, ok := pv.(provider.Rotator); ok {
+		err = r.FinalizeRotation(ctx, path)
+	} else {
+		err = pv.Delete(ctx, path+PreviousSuffix)
+	}
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	prev := s.Previous()
+	prev.getCache().Delete(ctx, prev.location)
+
+	uclog.Infof(ctx, "finalized rotation of secret %s", s.String())
+	return nil
+}
+
+// CancelRotation undoes a rotation by restoring the previous value and deleting it.
+func (s *String) CancelRotation(ctx context.Context) (err error) {
+	defer func() { audit(ctx, AuditCancelRotation, s.location, err) }()
+
+	pv, path, err := s.storedPath("rotate")
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	prev := s.Previous()
+	old, err := prev.fetch(ctx)
+	if err != nil {
+		return ucerr.Errorf("secret %s has no rotation to cancel: %w", s.String(), err)
+	}
+
+	if r, ok := pv.(provider.Rotator); ok {
+		err = r.CancelRotation(ctx, path)
+	} else if err = pv.Save(ctx, path, old); err == nil {
+		err = pv.Delete(ctx, path+PreviousSuffix)
+	}
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+	s.getCache().Store(ctx, s.location, old)
+	prev.getCache().Delete(ctx, prev.location)
+
+	uclog.Infof(ctx, "cancelled rotation of secret %s", s.String())
+	return nil
+}
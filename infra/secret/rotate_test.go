@@ -0,0 +1,110 @@
+package secret
+
+import (
+	"context"
+	"testing"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"userclouds.com/infra/secret/provider/aws"
+	"userclouds.com/infra/secret/provider/file"
+)
+
+func TestRotate(t *testing.T) {
+	ctx := context.Background()
+	InvalidateAll(ctx)
+	pv := file.New().WithRoot(t.TempDir())
+
+	s, err := NewStringWithProvider(ctx, "plex", "client-secret", "old", pv)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Rotate(ctx, "new"))
+	value, err := s.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", value)
+
+	// both values are readable from a fresh copy during the rotation
+	InvalidateAll(ctx)
+	reader := FromLocation(s.Location()).WithProvider(pv)
+	value, err = reader.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", value)
+	value, err = reader.Previous().Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", value)
+
+	assert.NoError(t, s.CancelRotation(ctx))
+	value, err = s.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", value)
+	_, err = s.Previous().Resolve(ctx)
+	assert.Error(t, err)
+
+	assert.NoError(t, s.Rotate(ctx, "newer"))
+	assert.NoError(t, s.FinalizeRotation(ctx))
+	value, err = s.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "newer", value)
+	_, err = s.Previous().Resolve(ctx)
+	assert.Error(t, err)
+	assert.Error(t, s.CancelRotation(ctx))
+
+	// secrets stored in their location can't be rotated
+	assert.Error(t, FromLocation("dev-literal://secret").Rotate(ctx, "new"))
+}
+
+func TestRotate_existingPrevious(t *testing.T) {
+	ctx := context.Background()
+	InvalidateAll(ctx)
+	pv := file.New().WithRoot(t.TempDir())
+
+	// a secret that only happens to be named like the previous value isn't overwritten
+	s, err := NewStringWithProvider(ctx, "plex", "client", "old", pv)
+	assert.NoError(t, err)
+	other, err := NewStringWithProvider(ctx, "plex", "client"+PreviousSuffix, "unrelated", pv)
+	assert.NoError(t, err)
+
+	assert.ErrorContains(t, s.Rotate(ctx, "new"), "wasn't created by rotating it")
+	value, err := other.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "unrelated", value)
+	value, err = s.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", value)
+}
+
+func TestRotate_providerVersions(t *testing.T) {
+	ctx := context.Background()
+	InvalidateAll(ctx)
+	t.Cleanup(func() { InvalidateAll(ctx) })
+
+	sm := &aws.MockSecretsManagerClient{}
+	sm.On("GetSecretValue", ctx, mock.MatchedBy(func(in *secretsmanager.GetSecretValueInput) bool {
+		return *in.VersionStage == "AWSCURRENT"
+	}), mock.Anything).Return(&secretsmanager.GetSecretValueOutput{SecretString: awsv2.String(`{"string":"old"}`)}, nil).Once()
+	sm.On("UpdateSecret", ctx, mock.MatchedBy(func(in *secretsmanager.UpdateSecretInput) bool {
+		return *in.SecretId == "userclouds/plex/client" && *in.SecretString == `{"string":"new"}`
+	}), mock.Anything).Return(&secretsmanager.UpdateSecretOutput{}, nil).Once()
+	sm.On("CreateSecret", ctx, mock.Anything, mock.Anything).Return(&secretsmanager.CreateSecretOutput{}, &types.ResourceExistsException{}).Once()
+	sm.On("DescribeSecret", ctx, mock.Anything, mock.Anything).Return(&secretsmanager.DescribeSecretOutput{
+		VersionIdsToStages: map[string][]string{"v2": {"AWSCURRENT"}, "v1": {"AWSPREVIOUS"}},
+	}, nil).Once()
+	sm.On("UpdateSecretVersionStage", ctx, mock.MatchedBy(func(in *secretsmanager.UpdateSecretVersionStageInput) bool {
+		return *in.VersionStage == "AWSPREVIOUS" && *in.RemoveFromVersionId == "v1"
+	}), mock.Anything).Return(&secretsmanager.UpdateSecretVersionStageOutput{}, nil).Once()
+
+	// the previous value is the AWSPREVIOUS version, rather than a secret of its own
+	s := FromLocation("aws://secrets/userclouds/plex/client").WithProvider(aws.New().WithSecretsManagerClient(sm))
+	assert.NoError(t, s.Rotate(ctx, "new"))
+	assert.Equal(t, "aws://secrets/userclouds/plex/client@AWSPREVIOUS", s.Previous().Location())
+	value, err := s.Previous().Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", value)
+
+	assert.NoError(t, s.FinalizeRotation(ctx))
+	sm.AssertExpectations(t)
+}
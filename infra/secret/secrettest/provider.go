@@ -0,0 +1,256 @@
+package secrettest
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"userclouds.com/infra/secret"
+	"userclouds.com/infra/secret/provider"
+	"userclouds.com/infra/ucerr"
+)
+
+// Prefix is the location prefix of secrets resolved by the installed test provider.
+const Prefix = "secrettest://"
+
+// ErrScripted is returned by scripted failures that weren't given an error.
+var ErrScripted = ucerr.New("scripted secret provider failure")
+
+var (
+	installedMu sync.RWMutex
+	installed   = New()
+)
+
+func init() {
+	provider.Register(Prefix, func() provider.Interface {
+		installedMu.RLock()
+		defer installedMu.RUnlock()
+		return installed
+	})
+}
+
+// Provider is an in-memory secret provider for tests, whose values, latency and failures
+// are scripted with a fluent API, e.g.
+//
+//	p := secrettest.New().WithValue("db/password", "hunter2").Fail("db/password", 2)
+//
+// so that packages using secrets can exercise retries and caching without mocking a
+// cloud provider's client.  It is safe for concurrent use.
+type Provider struct {
+	mu       sync.Mutex
+	values   map[string]string
+	latency  time.Duration
+	failures map[string]*failure
+	gets     map[string]int
+}
+
+// failure fails the operations on a path a number of times.
+type failure struct {
+	remaining int // negative to fail until cleared
+	err       error
+}
+
+// New returns an empty test provider.
+func New() *Provider {
+	return &Provider{
+		values:   map[string]string{},
+		failures: map[string]*failure{},
+		gets:     map[string]int{},
+	}
+}
+
+// WithValue preloads the secret at path.
+func (p *Provider) WithValue(path, value string) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.values[path] = value
+	return p
+}
+
+// WithLatency delays every operation by d, or until its context is done.
+func (p *Provider) WithLatency(d time.Duration) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latency = d
+	return p
+}
+
+// Fail makes the next times operations on path fail with ErrScripted.  A negative times
+// fails them until Succeed is called.
+func (p *Provider) Fail(path string, times int) *Provider {
+	return p.FailWith(path, times, ErrScripted)
+}
+
+// FailWith makes the next times operations on path fail with err, like Fail.
+func (p *Provider) FailWith(path string, times int, err error) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if times == 0 {
+		delete(p.failures, path)
+		return p
+	}
+	p.failures[path] = &failure{remaining: times, err: err}
+	return p
+}
+
+// Succeed clears the failures scripted for path.
+func (p *Provider) Succeed(path string) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.failures, path)
+	return p
+}
+
+// Install makes locations starting with Prefix resolve with p until the test ends, and
+// clears the secrets cached by other tests.
+func (p *Provider) Install(t testing.TB) *Provider {
+	t.Helper()
+
+	installedMu.Lock()
+	previous := installed
+	installed = p
+	installedMu.Unlock()
+	secret.InvalidateAll(context.Background())
+
+	t.Cleanup(func() {
+		installedMu.Lock()
+		installed = previous
+		installedMu.Unlock()
+		secret.InvalidateAll(context.Background())
+	})
+
+	return p
+}
+
+// String returns a secret at path, which resolves with p even if it isn't installed.
+func (p *Provider) String(path string) *secret.String {
+	return secret.FromLocation(Prefix + path).WithProvider(p)
+}
+
+// Value returns the secret stored at path, and whether there is one.
+func (p *Provider) Value(path string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	value, ok := p.values[path]
+	return value, ok
+}
+
+// Gets returns the number of times the secret at path was fetched, including failures,
+// e.g. to check that it was cached or retried.
+func (p *Provider) Gets(path string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.gets[path]
+}
+
+// Prefix returns the URI prefix of test secrets.
+func (p *Provider) Prefix() string {
+	return Prefix
+}
+
+// IsDev returns false, test secrets are stored by path.
+func (p *Provider) IsDev() bool {
+	return false
+}
+
+// Get returns the secret at path, unless it is scripted to fail.
+func (p *Provider) Get(ctx context.Context, path string) (string, error) {
+	p.mu.Lock()
+	p.gets[path]++
+	p.mu.Unlock()
+
+	if err := p.script(ctx, path); err != nil {
+		return "", ucerr.Wrap(err)
+	}
+
+	value, ok := p.Value(path)
+	if !ok {
+		return "", ucerr.Errorf("test secret '%s' not found", path)
+	}
+
+	return value, nil
+}
+
+// Save stores the secret at path, unless it is scripted to fail.
+func (p *Provider) Save(ctx context.Context, path, secret string) error {
+	if err := p.script(ctx, path); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	p.WithValue(path, secret)
+	return nil
+}
+
+// Delete deletes the secret at path, unless it is scripted to fail.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	if err := p.script(ctx, path); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.values[path]; !ok {
+		return ucerr.Errorf("test secret '%s' not found", path)
+	}
+	delete(p.values, path)
+	return nil
+}
+
+// Exists returns whether there is a secret at path, unless it is scripted to fail.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	if err := p.script(ctx, path); err != nil {
+		return false, ucerr.Wrap(err)
+	}
+
+	_, ok := p.Value(path)
+	return ok, nil
+}
+
+// List returns the paths of the stored secrets that start with pathPrefix.
+func (p *Provider) List(ctx context.Context, pathPrefix string) ([]string, error) {
+	if err := p.script(ctx, pathPrefix); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var paths []string
+	for path := range p.values {
+		if strings.HasPrefix(path, pathPrefix) {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
+
+// script waits for the scripted latency, and returns the scripted failure of path if
+// there is one left.
+func (p *Provider) script(ctx context.Context, path string) error {
+	p.mu.Lock()
+	latency := p.latency
+	var err error
+	if f, ok := p.failures[path]; ok {
+		err = f.err
+		if f.remaining > 0 {
+			f.remaining--
+			if f.remaining == 0 {
+				delete(p.failures, path)
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return ucerr.Wrap(ctx.Err())
+		}
+	}
+
+	return err
+}
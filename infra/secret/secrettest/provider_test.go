@@ -0,0 +1,69 @@
+package secrettest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"userclouds.com/infra/secret"
+)
+
+func TestProvider_Fail(t *testing.T) {
+	ctx := context.Background()
+	p := New().WithValue("db/password", "hunter2").Fail("db/password", 2)
+
+	for range 2 {
+		_, err := p.Get(ctx, "db/password")
+		assert.ErrorIs(t, err, ErrScripted)
+	}
+	value, err := p.Get(ctx, "db/password")
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+	assert.Equal(t, 3, p.Gets("db/password"))
+
+	p.Fail("db/password", -1)
+	for range 5 {
+		assert.ErrorIs(t, p.Save(ctx, "db/password", "new"), ErrScripted)
+	}
+	p.Succeed("db/password")
+	assert.NoError(t, p.Save(ctx, "db/password", "new"))
+	value, _ = p.Value("db/password")
+	assert.Equal(t, "new", value)
+}
+
+func TestProvider_Latency(t *testing.T) {
+	p := New().WithValue("slow", "value").WithLatency(time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := p.Get(ctx, "slow")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestProvider_Install(t *testing.T) {
+	ctx := context.Background()
+	p := New().WithValue("db/password", "hunter2").Fail("db/password", 1).Install(t)
+
+	// locations resolve with the installed provider, and failures back off
+	s := secret.FromLocation(Prefix + "db/password")
+	_, err := s.Resolve(ctx)
+	assert.Error(t, err)
+	_, err = s.Resolve(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 1, p.Gets("db/password"))
+
+	// until the cache is invalidated, after which the value is cached
+	secret.Invalidate(ctx, s.Location())
+	for range 3 {
+		value, err := s.Resolve(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "hunter2", value)
+	}
+	assert.Equal(t, 2, p.Gets("db/password"))
+
+	value, err := New().WithValue("other", "value").String("other").Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
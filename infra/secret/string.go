@@ -6,8 +6,8 @@ import (
 	"encoding/base64"
 	"fmt"
 	"strings"
+	"time"
 
-	"userclouds.com/infra/namespace/universe"
 	"userclouds.com/infra/secret/prefix"
 	"userclouds.com/infra/secret/provider"
 	"userclouds.com/infra/ucerr"
@@ -25,14 +25,15 @@ var (
 type String struct {
 	location string // the location, which may be the secret or a prefixed pointer
 	provider provider.Interface
+	cache    Cache // overrides the package-wide cache if set
 }
 
 // NewString returns a new secret.String that is stored "correctly" according to
 // the underlying provider.
 func NewString(ctx context.Context, serviceName, name, secret string) (*String, error) {
-	pv, err := provider.FromEnv()
+	pv, err := getProvider()
 	if err != nil {
-		uclog.Errorf(ctx, "Failed to get provider from env: %v", err)
+		uclog.Errorf(ctx, "Failed to get secret provider: %v", err)
 		return &EmptyString, err
 	}
 
@@ -47,11 +48,37 @@ func NewStringWithProvider(ctx context.Context, serviceName, name, secret string
 	if secret == "" {
 		return &EmptyString, nil
 	}
-	uv := universe.Current()
-	path := getSecretPath(uv, serviceName, name)
+	return saveWithProvider(ctx, pv, NewPath(serviceName, name).String(), secret)
+}
+
+// NewStringAtLocation stores the secret at an explicit location, such as
+// "aws://secrets/userclouds/prod/plex/client-secret", using the provider named by the
+// location's prefix.
+func NewStringAtLocation(ctx context.Context, location, secret string) (*String, error) {
+	px, err := prefix.PrefixFromString(location)
+	if err != nil {
+		return nil, ucerr.Errorf("invalid secret location %s: %w", location, err)
+	}
+
+	pv, err := providerForLocation(location)
+	if err != nil {
+		return nil, ucerr.Wrap(err)
+	}
+
+	return saveWithProvider(ctx, pv, px.Value(location), secret)
+}
+
+// saveWithProvider stores the secret at path in the provider and returns a String
+// pointing at it.
+func saveWithProvider(ctx context.Context, pv provider.Interface, path, secret string) (*String, error) {
+	// Fail fast with a helpful message rather than an opaque error from the backend.
+	if err := provider.ValidatePath(pv, path); err != nil {
+		return nil, ucerr.Wrap(err)
+	}
 
 	err := pv.Save(ctx, path, secret)
 	if err != nil {
+		audit(ctx, AuditSave, locationForPath(pv, path), err)
 		return nil, ucerr.Wrap(err)
 	}
 
@@ -64,51 +91,121 @@ func NewStringWithProvider(ctx context.Context, serviceName, name, secret string
 		encSecret := base64.StdEncoding.EncodeToString([]byte(secret))
 		loc = fmt.Sprintf("%s%s", pv.Prefix(), encSecret)
 	} else {
-		loc = fmt.Sprintf("%s%s", pv.Prefix(), path)
+		loc = locationForPath(pv, path)
 	}
+	audit(ctx, AuditSave, loc, nil)
 
-	return FromLocation(loc), nil
+	return FromLocation(loc).WithProvider(pv), nil
 }
 
 // Resolve decides if the string is a Secret Store path and resolves it, or returns
-// the string unchanged otherwise.
+// the string unchanged otherwise.  Resolved secrets are cached for the package-wide
+// TTL, see SetCacheTTL.
 func (s *String) Resolve(ctx context.Context) (string, error) {
+	return s.ResolveWithTTL(ctx, GetCacheTTL())
+}
+
+// ResolveWithTTL resolves the secret like Resolve, but only uses a cached value that
+// was resolved less than ttl ago.  A ttl of 0 always fetches the secret from its
+// provider and doesn't cache it, e.g. for secrets that are read rarely but rotated.
+// Secrets that failed to resolve aren't fetched again until their backoff has passed
+// (see SetFailureBackoff), except with a ttl of 0.
+func (s *String) ResolveWithTTL(ctx context.Context, ttl time.Duration) (string, error) {
+	return s.resolve(ctx, ttl, s.fetch)
+}
+
+// resolve resolves the secret like ResolveWithTTL, fetching it with fetch if it isn't
+// cached, e.g. from the results of a batch.
+func (s *String) resolve(ctx context.Context, ttl time.Duration, fetch func(context.Context) (string, error)) (string, error) {
 	// Handle the empty case
 	if s.IsEmpty() {
 		return "", nil
 	}
 
-	// If we don't have a prefix then just return the location.  I don't
-	// know if this is used, but the behavior was expected prior to the
-	// refactor.
+	// Legacy secrets without a prefix, e.g. in older tenant database rows, are the
+	// secret itself unless strict mode rejects them.
 	if !s.HasPrefix() {
-		return s.location, nil
+		value, err := s.resolveUnprefixed(ctx)
+		if err != nil {
+			audit(ctx, AuditResolve, s.location, err)
+		}
+		return value, ucerr.Wrap(err)
 	}
 
-	secret, found := c.Get(s.location)
+	value, err := s.resolveFromProvider(ctx, ttl, fetch)
+	audit(ctx, AuditResolve, s.location, err)
+	return value, ucerr.Wrap(err)
+}
+
+// resolveFromProvider resolves a prefixed secret through the cache.
+func (s *String) resolveFromProvider(ctx context.Context, ttl time.Duration, fetch func(context.Context) (string, error)) (string, error) {
+	start := time.Now()
+	cache := s.getCache()
+	secret, found := cache.Get(ctx, s.location, ttl)
 	if found {
+		observeResolve(ctx, s.location, start, ResolveCacheHit, nil)
 		return secret, nil
 	}
 
-	pv, err := s.GetProvider()
+	if ttl > 0 {
+		if err := failures.check(s.location); err != nil {
+			observeResolve(ctx, s.location, start, ResolveBackedOff, err)
+			return "", ucerr.Wrap(err)
+		}
+	}
+
+	value, err := fetch(ctx)
 	if err != nil {
+		failures.record(s.location, err)
+		observeResolve(ctx, s.location, start, ResolveFailed, err)
 		return "", ucerr.Wrap(err)
 	}
+	failures.clear(s.location)
+	observeResolve(ctx, s.location, start, ResolveFetched, nil)
 
-	px, err := prefix.PrefixFromString(pv.Prefix())
+	if ttl > 0 {
+		cache.Store(ctx, s.location, value)
+	}
+	return value, nil
+}
+
+// fetch retrieves the secret value from the provider, bypassing the cache.
+func (s *String) fetch(ctx context.Context) (string, error) {
+	pv, path, field, err := s.providerPath()
 	if err != nil {
 		return "", ucerr.Wrap(err)
 	}
 
-	value, err := pv.Get(ctx, px.Value(s.location))
+	value, err := provider.Get(ctx, pv, path)
 	if err != nil {
 		return "", ucerr.Wrap(err)
 	}
 
-	c.Store(s.location, value)
+	if field != "" {
+		value, err = selectField(value, field)
+		return value, ucerr.Wrap(err)
+	}
+
 	return value, nil
 }
 
+// providerPath returns the provider of the secret, the path that it retrieves the secret
+// at, and the field of the secret that the location selects, if any.
+func (s *String) providerPath() (provider.Interface, string, string, error) {
+	pv, err := s.GetProvider()
+	if err != nil {
+		return nil, "", "", ucerr.Wrap(err)
+	}
+
+	px, err := prefix.PrefixFromString(pv.Prefix())
+	if err != nil {
+		return nil, "", "", ucerr.Wrap(err)
+	}
+
+	path, field := splitField(pv, px.Value(s.location))
+	return pv, path, field, nil
+}
+
 // HasPrefix returns true if there is a prefix specifying the secrets
 // provider in the form of <name>://<path>.
 func (s *String) HasPrefix() bool {
@@ -158,7 +255,16 @@ func (s *String) Delete(ctx context.Context) error {
 		return ucerr.Wrap(err)
 	}
 
+	// deleting would remove the whole secret, not just the selected field or pinned version
+	if _, field := splitField(pv, px.Value(s.location)); field != "" {
+		return ucerr.Errorf("secret location selects field '%s', delete the secret through its location without the field", field)
+	}
+	if _, version := provider.SplitVersion(pv, px.Value(s.location)); version != "" {
+		return ucerr.Errorf("secret location pins version %s, delete the secret through its unpinned location", version)
+	}
+
 	err = pv.Delete(ctx, px.Value(s.location))
+	audit(ctx, AuditDelete, s.location, err)
 	if err != nil {
 		return ucerr.Wrap(err)
 	}
@@ -167,6 +273,31 @@ func (s *String) Delete(ctx context.Context) error {
 	return ucerr.Wrap(err)
 }
 
+// Update replaces the value of the secret in its provider, keeping its location so that
+// configs and database rows pointing at it don't have to change.  Use Rotate instead to
+// keep the previous value readable while clients pick up the new one.
+func (s *String) Update(ctx context.Context, newValue string) (err error) {
+	defer func() { audit(ctx, AuditSave, s.location, err) }()
+
+	if newValue == "" {
+		return ucerr.New("cannot update a secret to an empty value, delete it instead")
+	}
+
+	pv, path, err := s.storedPath("update")
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if err := pv.Save(ctx, path, newValue); err != nil {
+		return ucerr.Wrap(err)
+	}
+	s.getCache().Store(ctx, s.location, newValue)
+	failures.clear(s.location)
+
+	uclog.Infof(ctx, "updated secret %s", s.String())
+	return nil
+}
+
 // UnmarshalYAML implements yaml.Unmarshaler
 // Note like UnmarshalText we assume this is a location, and
 // we'll lazily resolve it later as needed
@@ -184,6 +315,10 @@ func (s *String) UnmarshalYAML(unmarshal func(any) error) error {
 // NB: we don't implement MarshalJSON because we intentionally *don't* want
 // to emit a rich object here (for backcompat, and no need)
 func (s String) MarshalText() ([]byte, error) {
+	if redactOnMarshal.Load() {
+		return []byte(mask(s.location)), nil
+	}
+
 	// we always save location since it's either the pointer we
 	// want to save, or it's a copy of .value anyway
 	return []byte(s.location), nil
@@ -198,9 +333,10 @@ func (s *String) UnmarshalText(b []byte) error {
 }
 
 // String implements Stringer, specifically to obscure secrets when logged
-// To actually use a secret, you need to explicitly use Resolve()
+// To actually use a secret, you need to explicitly use Resolve().  The rendering
+// is controlled by SetMaskMode.
 func (s *String) String() string {
-	return strings.Repeat("*", len(s.location))
+	return mask(s.location)
 }
 
 // Validate implements Validateable
@@ -210,9 +346,9 @@ func (s String) Validate() error {
 		return nil
 	}
 
-	// support inline secrets with no prefix
+	// support inline secrets with no prefix, unless strict mode rejects them
 	if !s.HasPrefix() {
-		return nil
+		return ucerr.Wrap(s.checkUnprefixed())
 	}
 
 	px, err := prefix.PrefixFromString(s.location)
@@ -220,7 +356,7 @@ func (s String) Validate() error {
 		return ucerr.Wrap(err)
 	}
 
-	if err := px.Validate(); err != nil {
+	if err := prefix.Validate(px); err != nil {
 		return ucerr.Wrap(err)
 	}
 
@@ -228,6 +364,54 @@ func (s String) Validate() error {
 	return nil
 }
 
+// ValidateDeep validates the secret like Validate, and also checks that it resolves, e.g.
+// for the secrets of a config before it is deployed.  Secrets that don't resolve are
+// reported as missing, or as unreadable if they exist or their provider can't tell, which
+// is usually because the service isn't allowed to read them.  The secret is always
+// fetched from its provider rather than the cache.
+func (s *String) ValidateDeep(ctx context.Context) error {
+	if err := s.Validate(); err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	if s.IsEmpty() || !s.HasPrefix() {
+		return nil
+	}
+
+	_, resolveErr := s.ResolveWithTTL(ctx, 0)
+	if resolveErr == nil {
+		return nil
+	}
+
+	pv, err := s.GetProvider()
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	px, err := prefix.PrefixFromString(pv.Prefix())
+	if err != nil {
+		return ucerr.Wrap(err)
+	}
+
+	location := auditLocation(s.location)
+	path, _ := splitField(pv, px.Value(s.location))
+	exists, err := provider.Exists(ctx, pv, path)
+	switch {
+	case err != nil:
+		return ucerr.Errorf("secret %s can't be read: %w", location, err)
+	case !exists:
+		return ucerr.Errorf("secret %s doesn't exist", location)
+	default:
+		return ucerr.Errorf("secret %s exists but can't be resolved: %w", location, resolveErr)
+	}
+}
+
+// Location returns the location of the secret, which is either a prefixed pointer
+// to the secret in a provider or (for legacy values) the secret itself.
+func (s String) Location() string {
+	return s.location
+}
+
 // IsEmpty checks if the secret.String location is empty
 func (s String) IsEmpty() bool {
 	return s.location == ""
@@ -260,7 +444,7 @@ func (s *String) GetProvider() (provider.Interface, error) {
 		return s.provider, nil
 	}
 
-	pv, err := provider.FromLocation(s.location)
+	pv, err := providerForLocation(s.location)
 	if err != nil {
 		return nil, err
 	}
@@ -280,3 +464,52 @@ func (s *String) WithProvider(provider provider.Interface) *String {
 	s.provider = provider
 	return s
 }
+
+// WithCache sets the cache that the secret is resolved through, instead of the
+// package-wide one, e.g. NoCache for a secret that must always be read fresh.
+func (s *String) WithCache(cache Cache) *String {
+	s.cache = cache
+	return s
+}
+
+// getCache returns the cache that the secret is resolved through.
+func (s *String) getCache() Cache {
+	if s.cache != nil {
+		return s.cache
+	}
+
+	return GetCache()
+}
+
+// storedPath returns the provider and path of a secret that can be changed in place,
+// which has to be stored by path rather than in its location.  action names the change
+// in errors, e.g. "rotate".
+func (s *String) storedPath(action string) (provider.Interface, string, error) {
+	if s.IsEmpty() || !s.HasPrefix() {
+		return nil, "", ucerr.Errorf("cannot %s a secret without a provider prefix", action)
+	}
+
+	pv, err := s.GetProvider()
+	if err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+
+	if pv.IsDev() {
+		return nil, "", ucerr.Errorf("cannot %s %s secrets, which are stored in their location", action, pv.Prefix())
+	}
+
+	px, err := prefix.PrefixFromString(pv.Prefix())
+	if err != nil {
+		return nil, "", ucerr.Wrap(err)
+	}
+
+	path := px.Value(s.location)
+	if _, field := splitField(pv, path); field != "" {
+		return nil, "", ucerr.Errorf("secret location selects field '%s', %s the secret through its location without the field", field, action)
+	}
+	if _, version := provider.SplitVersion(pv, path); version != "" {
+		return nil, "", ucerr.Errorf("secret location pins version %s, %s the secret through its unpinned location", version, action)
+	}
+
+	return pv, path, nil
+}
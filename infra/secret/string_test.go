@@ -3,7 +3,10 @@ package secret
 import (
 	"context"
 	"encoding/json"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
@@ -16,6 +19,7 @@ import (
 	"sigs.k8s.io/yaml"
 	"userclouds.com/infra/secret/provider/aws"
 	"userclouds.com/infra/secret/provider/dev"
+	"userclouds.com/infra/secret/provider/file"
 	"userclouds.com/infra/secret/provider/kubernetes"
 )
 
@@ -196,6 +200,17 @@ func TestString_NewString_Dev(t *testing.T) {
 	}
 }
 
+func TestString_NewStringAtLocation(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := NewStringAtLocation(ctx, "dev://ignored", "testsecret")
+	assert.NoError(t, err)
+	assert.Equal(t, "dev://dGVzdHNlY3JldA==", s.location)
+
+	_, err = NewStringAtLocation(ctx, "unknown://secrets/testsecret", "testsecret")
+	assert.Error(t, err)
+}
+
 func TestString_NewString_Kubernetes(t *testing.T) {
 	tests := []struct {
 		description string
@@ -223,11 +238,18 @@ func TestString_NewString_Kubernetes(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.description, func(t *testing.T) {
-			c := fake.NewSimpleClientset(tt.fixture)
+			c := fake.NewSimpleClientset()
+			if tt.fixture != nil {
+				c = fake.NewSimpleClientset(tt.fixture)
+			}
 			s, err := NewStringWithProvider(ctx, tt.service, tt.name, tt.value, kubernetes.New().WithClient(c))
 			assert.NoError(t, err)
 			assert.Equal(t, tt.location, s.location)
 
+			value, err := s.Resolve(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.value, value)
+
 			secret, err := c.CoreV1().Secrets(kubernetes.DefaultNamespace).Get(ctx, tt.secret, metav1.GetOptions{})
 			assert.NoError(t, err)
 			assert.Equal(t, tt.value, string(secret.Data["value"]))
@@ -235,6 +257,31 @@ func TestString_NewString_Kubernetes(t *testing.T) {
 	}
 }
 
+func TestString_KubernetesWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	t.Setenv("UC_UNIVERSE", "test")
+
+	c := fake.NewSimpleClientset()
+	pv := kubernetes.New().WithClient(c)
+	s, err := NewStringWithProvider(ctx, "plex", "watched", "original", pv)
+	assert.NoError(t, err)
+	assert.Equal(t, "kube://secrets/userclouds/test/plex/watched", s.location)
+	defer Invalidate(ctx, s.location)
+
+	assert.NoError(t, pv.StartWatch(ctx, func(location string) { Invalidate(ctx, location) }))
+	value, err := s.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "original", value)
+
+	// rotated by another process, so only the watch tells the cache about it
+	assert.NoError(t, kubernetes.New().WithClient(c).Save(ctx, "userclouds/test/plex/watched", "rotated"))
+	assert.Eventually(t, func() bool {
+		value, err := s.Resolve(ctx)
+		return err == nil && value == "rotated"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
 func TestString_Resolve(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -285,10 +332,88 @@ func TestString_Resolve(t *testing.T) {
 			assert.Equal(t, tt.output, secret)
 
 			if tt.cached {
-				cachedValue, found := c.Get(tt.input)
+				cachedValue, found := GetCache().Get(ctx, tt.input, GetCacheTTL())
 				assert.True(t, found)
 				assert.Equal(t, tt.output, cachedValue)
 			}
 		})
 	}
 }
+
+func TestString_ResolveWithTTL(t *testing.T) {
+	ctx := context.Background()
+	InvalidateAll(ctx)
+	pv := file.New().WithRoot(t.TempDir())
+
+	s, err := NewStringWithProvider(ctx, "plex", "ttl-secret", "old", pv)
+	assert.NoError(t, err)
+	value, err := s.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", value)
+
+	// change the secret behind the cache's back
+	path := strings.TrimPrefix(s.Location(), file.Prefix)
+	assert.NoError(t, pv.Save(ctx, path, "new"))
+	value, err = s.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", value)
+
+	value, err = s.ResolveWithTTL(ctx, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", value)
+
+	defer SetCacheTTL(GetCacheTTL())
+	SetCacheTTL(0)
+	assert.NoError(t, pv.Save(ctx, path, "newer"))
+	value, err = s.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "newer", value)
+}
+
+func TestString_Update(t *testing.T) {
+	ctx := context.Background()
+	InvalidateAll(ctx)
+	pv := file.New().WithRoot(t.TempDir())
+
+	s, err := NewStringWithProvider(ctx, "plex", "client-secret", "old", pv)
+	assert.NoError(t, err)
+	location := s.Location()
+
+	assert.NoError(t, s.Update(ctx, "new"))
+	assert.Equal(t, location, s.Location())
+	value, err := s.Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", value)
+
+	// the new value is stored, not just cached
+	InvalidateAll(ctx)
+	value, err = FromLocation(location).WithProvider(pv).Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", value)
+
+	assert.Error(t, s.Update(ctx, ""))
+	ts := NewTestString("inline")
+	assert.Error(t, ts.Update(ctx, "new"))
+	assert.Error(t, FromLocation("legacy-raw-value").Update(ctx, "new"))
+}
+
+func TestString_ValidateDeep(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	t.Setenv(file.RootEnvKey, root)
+	pv := file.New().WithRoot(root)
+
+	s, err := NewStringWithProvider(ctx, "plex", "deep-secret", `{"user":"plex"}`, pv)
+	assert.NoError(t, err)
+	assert.NoError(t, s.ValidateDeep(ctx))
+
+	missing := FromLocation("file://" + filepath.Join(root, "missing"))
+	assert.ErrorContains(t, missing.ValidateDeep(ctx), "doesn't exist")
+
+	field := FromLocation(s.Location() + "#password")
+	assert.ErrorContains(t, field.ValidateDeep(ctx), "exists but can't be resolved")
+
+	// validation errors are reported before resolving
+	assert.Error(t, FromLocation("unknown://secret").ValidateDeep(ctx))
+	assert.NoError(t, EmptyString.ValidateDeep(ctx))
+}
@@ -11,3 +11,15 @@ func IsContextCanceledError(err error) bool {
 	return err != nil && (errors.Is(err, context.Canceled) ||
 		(strings.Contains(err.Error(), "pq: query execution canceled")) || strings.Contains(err.Error(), "pq: canceling statement due to user request"))
 }
+
+// FirstLine returns the first line of an error's message.  The messages of wrapped ucerr
+// errors carry a line per error they wrap, so this is the message of the innermost error,
+// which is short enough to report alongside others, e.g. when listing many failures.
+func FirstLine(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	msg, _, _ := strings.Cut(err.Error(), "\n")
+	return msg
+}
@@ -26,3 +26,11 @@ func TestIsContextCanceledError(t *testing.T) {
 	assert.True(t, ucerr.IsContextCanceledError(err))
 	assert.True(t, ucerr.IsContextCanceledError(context.Canceled))
 }
+
+func TestFirstLine(t *testing.T) {
+	assert.Equal(t, ucerr.FirstLine(nil), "")
+	assert.Equal(t, ucerr.FirstLine(errors.New("plain")), "plain")
+
+	err := ucerr.Wrap(ucerr.Wrap(errors.New("inner")))
+	assert.Equal(t, ucerr.FirstLine(err), "inner")
+}
@@ -3,6 +3,8 @@ package uckube
 import (
 	"context"
 	"fmt"
+	"maps"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -12,23 +14,38 @@ import (
 	"userclouds.com/infra/uclog"
 )
 
+// UpdatedAtAnnotation records when CreateOrUpdateSecret last set the value of a secret, in
+// RFC 3339 format, since kubernetes only records when secrets were created.
+const UpdatedAtAnnotation = "userclouds.com/updated-at"
+
 // GetSecret retrieves a secret and returns the value.
 func GetSecret(ctx context.Context, client kubernetes.Interface, name string, namespace string) (string, error) {
-	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	data, err := GetSecretData(ctx, client, name, namespace)
 	if err != nil {
 		return "", err
 	}
 
-	if value, ok := secret.Data["value"]; ok {
+	if value, ok := data["value"]; ok {
 		return string(value), nil
 	}
 
 	return "", fmt.Errorf("secret does not contain value field")
 }
 
+// GetSecretData retrieves a secret and returns all of its data, e.g. for secrets that
+// weren't created by CreateOrUpdateSecret.
+func GetSecretData(ctx context.Context, client kubernetes.Interface, name string, namespace string) (map[string][]byte, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return secret.Data, nil
+}
+
 // CreateOrUpdateSecret checks for the existence of a secret and then creates or
-// updates the value.
-func CreateOrUpdateSecret(ctx context.Context, client kubernetes.Interface, name string, namespace string, value string) error {
+// updates the value.  annotations are added to those of the secret.
+func CreateOrUpdateSecret(ctx context.Context, client kubernetes.Interface, name string, namespace string, value string, annotations map[string]string) error {
 	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -40,6 +57,7 @@ func CreateOrUpdateSecret(ctx context.Context, client kubernetes.Interface, name
 					Labels: map[string]string{
 						"app.kubernetes.io/managed-by": "userclouds",
 					},
+					Annotations: secretAnnotations(nil, annotations),
 				},
 				Data: map[string][]byte{
 					"value": []byte(value),
@@ -54,6 +72,7 @@ func CreateOrUpdateSecret(ctx context.Context, client kubernetes.Interface, name
 	secret.Data = map[string][]byte{
 		"value": []byte(value),
 	}
+	secret.Annotations = secretAnnotations(secret.Annotations, annotations)
 	secret, err = client.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
 	if err != nil {
 		return err
@@ -66,6 +85,17 @@ func CreateOrUpdateSecret(ctx context.Context, client kubernetes.Interface, name
 	return nil
 }
 
+// secretAnnotations returns the existing annotations of a secret with annotations added,
+// and UpdatedAtAnnotation set to now.
+func secretAnnotations(existing, annotations map[string]string) map[string]string {
+	if existing == nil {
+		existing = map[string]string{}
+	}
+	maps.Copy(existing, annotations)
+	existing[UpdatedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	return existing
+}
+
 // DeleteSecret removes a secret if it exists.
 func DeleteSecret(ctx context.Context, client kubernetes.Interface, name string, namespace string) error {
 	err := client.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
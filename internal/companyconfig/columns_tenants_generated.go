@@ -7,6 +7,7 @@ func init() {
 		"company_id",
 		"created",
 		"deleted",
+		"ephemeral_expiry",
 		"id",
 		"name",
 		"state",
@@ -1657,6 +1657,13 @@ var Migrations = migrate.Migrations{
 		Down: `ALTER TABLE tenants_internal DROP COLUMN remote_user_region_db_configs;
 			ALTER TABLE tenants_internal DROP COLUMN primary_user_region;`,
 	},
+	{
+		Version: 113,
+		Table:   "tenants",
+		Desc:    "add ephemeral_expiry to tenants",
+		Up:      `ALTER TABLE tenants ADD COLUMN ephemeral_expiry TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00';`,
+		Down:    `ALTER TABLE tenants DROP COLUMN ephemeral_expiry;`,
+	},
 }
 
 // these should eventually be used nowhere (obviously, since they're checked in),
@@ -98,6 +98,10 @@ type Tenant struct {
 	// this can be eliminated when worker is doing enough stuff to have stable connections to
 	// enough tenant DBs that we don't care.
 	SyncUsers bool `db:"sync_users" json:"sync_users" yaml:"sync_users"`
+
+	// EphemeralExpiry is when a short-lived tenant, e.g. one created for a CI run, may be
+	// deleted by `ucctl tenant reap-expired`.  It is zero for all other tenants.
+	EphemeralExpiry time.Time `db:"ephemeral_expiry" json:"ephemeral_expiry" yaml:"ephemeral_expiry"`
 }
 
 //go:generate genvalidate Tenant
@@ -103,7 +103,8 @@ var schemas = []string{
     state character varying DEFAULT 'creating'::character varying NOT NULL,
     primary_region character varying DEFAULT ''::character varying NOT NULL,
     sync_users boolean DEFAULT false NOT NULL,
-    sqlshim_config jsonb
+    sqlshim_config jsonb,
+    ephemeral_expiry timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
 );`,
 	`CREATE TABLE public.tenants_internal (
     id uuid NOT NULL,
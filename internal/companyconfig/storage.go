@@ -157,7 +157,7 @@ func (s *Storage) GetTenantByHost(ctx context.Context, host string) (*Tenant, er
 	}
 
 	// NB: the primary URL is (at this point) always validated
-	const query = `SELECT id, created, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users FROM tenants WHERE LOWER(tenant_url) LIKE CONCAT('%://', LOWER($1)) AND deleted='0001-01-01 00:00:00';`
+	const query = `SELECT id, created, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users, ephemeral_expiry FROM tenants WHERE LOWER(tenant_url) LIKE CONCAT('%://', LOWER($1)) AND deleted='0001-01-01 00:00:00';`
 	var tenant Tenant
 	if err := s.db.GetContext(ctx, "GetTenantByHost", &tenant, query, host); err != nil {
 		// if we don't find it in primary, look it up in secondary storage
@@ -185,7 +185,7 @@ func (s *Storage) GetTenantByHost(ctx context.Context, host string) (*Tenant, er
 // This is command line only functon so it doesn't use cache
 func (s *Storage) ListTenantsByName(ctx context.Context, tenantName string) ([]Tenant, error) {
 	// NB: tenant name is case insensitive for the purpose of this query
-	const query = `SELECT id, created, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users FROM tenants WHERE name ILIKE $1 AND deleted='0001-01-01 00:00:00';`
+	const query = `SELECT id, created, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users, ephemeral_expiry FROM tenants WHERE name ILIKE $1 AND deleted='0001-01-01 00:00:00';`
 	var tenants []Tenant
 	if err := s.db.SelectContext(ctx, "ListTenantsByName", &tenants, query, tenantName); err != nil {
 		return nil, ucerr.Wrap(err)
@@ -239,7 +239,7 @@ func (s *Storage) ListTenantsForCompany(ctx context.Context, companyID uuid.UUID
 		defer cache.ReleasePerItemCollectionLock(ctx, *s.cm, []cache.Key{ckey}, obj, sentinel)
 	}
 
-	const query = `SELECT id, created, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users FROM tenants WHERE company_id=$1 AND deleted='0001-01-01 00:00:00';`
+	const query = `SELECT id, created, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users, ephemeral_expiry FROM tenants WHERE company_id=$1 AND deleted='0001-01-01 00:00:00';`
 
 	// TODO: validate that Company ID is actually a valid company?
 	var tenants []Tenant
@@ -56,7 +56,7 @@ func (s *Storage) IsTenantSoftDeleted(ctx context.Context, id uuid.UUID) (bool,
 func (s *Storage) GetTenant(ctx context.Context, id uuid.UUID) (*Tenant, error) {
 	return cache.ServerGetItem(ctx, s.cm, id, TenantKeyID, IsModifiedKeyID,
 		func(id uuid.UUID, conflict cache.Sentinel, obj *Tenant) error {
-			const q = "SELECT id, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users, created FROM tenants WHERE id=$1 AND deleted='0001-01-01 00:00:00';"
+			const q = "SELECT id, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users, ephemeral_expiry, created FROM tenants WHERE id=$1 AND deleted='0001-01-01 00:00:00';"
 
 			if err := s.db.GetContextWithDirty(ctx, "GetTenant", obj, q, cache.IsTombstoneSentinel(string(conflict)), id); err != nil {
 				if errors.Is(err, sql.ErrNoRows) {
@@ -86,7 +86,7 @@ func (s *Storage) GetTenantSoftDeleted(ctx context.Context, id uuid.UUID) (*Tena
 			return nil, ucerr.Friendlyf(err, "soft-deleted Tenant %v not found", id)
 		}
 	}
-	const q = "SELECT id, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users, created FROM tenants WHERE id=$1 AND deleted<>'0001-01-01 00:00:00';"
+	const q = "SELECT id, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users, ephemeral_expiry, created FROM tenants WHERE id=$1 AND deleted<>'0001-01-01 00:00:00';"
 
 	var obj Tenant
 	if err := s.db.GetContextWithDirty(ctx, "GetTenantSoftDeleted", &obj, q, cache.IsTombstoneSentinel(string(conflict)), id); err != nil {
@@ -160,7 +160,7 @@ func (s *Storage) GetTenantsForIDs(ctx context.Context, errorOnMissing bool, ids
 
 // getTenantsHelperForIDs loads multiple Tenant for a given list of IDs from the DB
 func (s *Storage) getTenantsHelperForIDs(ctx context.Context, dirty bool, errorOnMissing bool, ids ...uuid.UUID) ([]Tenant, error) {
-	const q = "SELECT id, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users, created FROM tenants WHERE id=ANY($1) AND deleted='0001-01-01 00:00:00';"
+	const q = "SELECT id, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users, ephemeral_expiry, created FROM tenants WHERE id=ANY($1) AND deleted='0001-01-01 00:00:00';"
 	var objects []Tenant
 	if err := s.db.SelectContextWithDirty(ctx, "GetTenantsForIDs", &objects, q, dirty, pq.Array(ids)); err != nil {
 		return nil, ucerr.Wrap(err)
@@ -210,7 +210,7 @@ func (s *Storage) listInnerTenantsPaginated(ctx context.Context, p pagination.Pa
 
 	// the inner query requires an alias for postgres, so we always call it tmp
 	// the outer query is just to reverse the order of the results in the case of paging backwards with forward sort
-	q := fmt.Sprintf("SELECT id, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users, created FROM (SELECT id, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users, created FROM tenants WHERE deleted='0001-01-01 00:00:00' %s ORDER BY %s LIMIT %d) tmp ORDER BY %s;", p.GetWhereClause(), p.GetInnerOrderByClause(), p.GetLimit()+1, p.GetOuterOrderByClause())
+	q := fmt.Sprintf("SELECT id, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users, ephemeral_expiry, created FROM (SELECT id, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users, ephemeral_expiry, created FROM tenants WHERE deleted='0001-01-01 00:00:00' %s ORDER BY %s LIMIT %d) tmp ORDER BY %s;", p.GetWhereClause(), p.GetInnerOrderByClause(), p.GetLimit()+1, p.GetOuterOrderByClause())
 
 	var objsDB []Tenant
 	if err := s.db.SelectContextWithDirty(ctx, "ListTenantsPaginated", &objsDB, q, cache.IsTombstoneSentinel(string(conflict)), queryFields...); err != nil {
@@ -247,8 +247,8 @@ func (s *Storage) SaveTenant(ctx context.Context, obj *Tenant) error {
 
 // SaveTenant saves a Tenant
 func (s *Storage) saveInnerTenant(ctx context.Context, obj *Tenant) error {
-	const q = "INSERT INTO tenants (id, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (id, deleted) DO UPDATE SET updated = CLOCK_TIMESTAMP(), deleted = $2, name = $3, company_id = $4, tenant_url = $5, use_organizations = $6, state = $7, sync_users = $8 WHERE (tenants.id = $1) RETURNING created, updated; /* allow-multiple-target-use no-match-cols-vals */"
-	if err := s.db.GetContext(ctx, "SaveTenant", obj, q, obj.ID, obj.Deleted, obj.Name, obj.CompanyID, obj.TenantURL, obj.UseOrganizations, obj.State, obj.SyncUsers); err != nil {
+	const q = "INSERT INTO tenants (id, updated, deleted, name, company_id, tenant_url, use_organizations, state, sync_users, ephemeral_expiry) VALUES ($1, CLOCK_TIMESTAMP(), $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (id, deleted) DO UPDATE SET updated = CLOCK_TIMESTAMP(), deleted = $2, name = $3, company_id = $4, tenant_url = $5, use_organizations = $6, state = $7, sync_users = $8, ephemeral_expiry = $9 WHERE (tenants.id = $1) RETURNING created, updated; /* allow-multiple-target-use no-match-cols-vals */"
+	if err := s.db.GetContext(ctx, "SaveTenant", obj, q, obj.ID, obj.Deleted, obj.Name, obj.CompanyID, obj.TenantURL, obj.UseOrganizations, obj.State, obj.SyncUsers, obj.EphemeralExpiry); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ucerr.Friendlyf(err, "Tenant %v not found", obj.ID)
 		}
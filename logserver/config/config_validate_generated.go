@@ -43,6 +43,11 @@ func (o Config) Validate() error {
 			return ucerr.Wrap(err)
 		}
 	}
+	if o.Secrets != nil {
+		if err := o.Secrets.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
 	if o.FeatureFlagConfig != nil {
 		if err := o.FeatureFlagConfig.Validate(); err != nil {
 			return ucerr.Wrap(err)
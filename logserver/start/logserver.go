@@ -7,6 +7,7 @@ import (
 	"userclouds.com/infra/logtransports"
 	"userclouds.com/infra/migrate"
 	serviceNamespace "userclouds.com/infra/namespace/service"
+	"userclouds.com/infra/secret"
 	"userclouds.com/infra/service"
 	"userclouds.com/infra/uclog"
 	"userclouds.com/internal/companyconfig"
@@ -28,6 +29,9 @@ func RunLogServer() {
 	if err != nil {
 		uclog.Fatalf(ctx, "failed to load config: %v", err)
 	}
+	if err := secret.Configure(cfg.Secrets); err != nil {
+		uclog.Fatalf(ctx, "failed to configure the secret provider: %v", err)
+	}
 
 	if err := logtransports.InitLoggerAndTransportsForService(&cfg.Log, nil, serviceNamespace.LogServer, service.GetMachineName()); err != nil {
 		uclog.Fatalf(ctx, "failed to initialize logger and transports: %v", err)
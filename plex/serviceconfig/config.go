@@ -12,6 +12,7 @@ import (
 	"userclouds.com/infra/featureflags"
 	"userclouds.com/infra/logtransports"
 	"userclouds.com/infra/namespace/universe"
+	"userclouds.com/infra/secret/provider"
 	"userclouds.com/infra/service"
 	"userclouds.com/infra/ucdb"
 	"userclouds.com/infra/ucerr"
@@ -50,6 +51,7 @@ type ServiceConfig struct {
 	FeatureFlagConfig *featureflags.Config `yaml:"featureflags,omitempty" json:"featureflags" validate:"allownil"`
 	Sentry            *ucsentry.Config     `yaml:"sentry,omitempty" json:"sentry" validate:"allownil"`
 	Tracing           *uctrace.Config      `yaml:"tracing,omitempty" json:"tracing" validate:"allownil"`
+	Secrets           *provider.Config     `yaml:"secrets,omitempty" json:"secrets" validate:"allownil"`
 }
 
 //go:generate gendbjson ServiceConfig
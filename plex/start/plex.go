@@ -7,6 +7,7 @@ import (
 	"userclouds.com/infra/logtransports"
 	serviceNamespace "userclouds.com/infra/namespace/service"
 	"userclouds.com/infra/namespace/universe"
+	"userclouds.com/infra/secret"
 	"userclouds.com/infra/service"
 	"userclouds.com/infra/uclog"
 	"userclouds.com/infra/uctypes/messaging/email"
@@ -28,6 +29,9 @@ func RunPlex() {
 	if err := yamlconfig.LoadServiceConfig(ctx, serviceNamespace.Plex, &cfg); err != nil {
 		uclog.Fatalf(ctx, "failed to load config: %v", err)
 	}
+	if err := secret.Configure(cfg.Secrets); err != nil {
+		uclog.Fatalf(ctx, "failed to configure the secret provider: %v", err)
+	}
 
 	ts, err := m2m.GetM2MTokenSource(ctx, cfg.ConsoleTenantID)
 	if err != nil {
@@ -59,6 +59,11 @@ func (o Config) Validate() error {
 			return ucerr.Wrap(err)
 		}
 	}
+	if o.Secrets != nil {
+		if err := o.Secrets.Validate(); err != nil {
+			return ucerr.Wrap(err)
+		}
+	}
 	if o.OpenSearchConfig != nil {
 		if err := o.OpenSearchConfig.Validate(); err != nil {
 			return ucerr.Wrap(err)